@@ -0,0 +1,76 @@
+package slogproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// AdminState is the runtime state of a [Handler] exposed by [AdminHandler].
+type AdminState struct {
+	// Level is the minimum level of records written by the handler.
+	Level slog.Level `json:"level"`
+
+	// Paused reports whether the handler is paused.
+	Paused bool `json:"paused"`
+}
+
+// adminUpdate is the body of a request changing the state of a handler,
+// where omitted fields are left unchanged.
+type adminUpdate struct {
+	Level  *slog.Level `json:"level"`
+	Paused *bool       `json:"paused"`
+}
+
+// AdminHandler returns an HTTP handler that exposes the runtime state of
+// the handler, so that its level can be changed, or it can be paused and
+// resumed, without restarting the service.
+//
+// A GET request returns the state as JSON, such as:
+//
+//	{"level":"INFO","paused":false}
+//
+// A PUT or POST request changes the fields given in its JSON body, and
+// returns the new state:
+//
+//	curl -X PUT -d '{"level":"DEBUG"}' http://localhost:6060/debug/slogproto
+//	curl -X PUT -d '{"paused":true}' http://localhost:6060/debug/slogproto
+//
+// The HTTP handler doesn't authenticate requests, so it should only be
+// served on an internal address.
+func AdminHandler(h *Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			var update adminUpdate
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&update); err != nil {
+				http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			if update.Level != nil {
+				h.SetLevel(*update.Level)
+			}
+
+			if update.Paused != nil {
+				if *update.Paused {
+					h.Pause()
+				} else {
+					h.Resume()
+				}
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AdminState{
+			Level:  h.Level(),
+			Paused: h.Paused(),
+		})
+	})
+}
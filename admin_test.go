@@ -0,0 +1,94 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestHandler_PauseAndSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := slogproto.NewHandler(&buf, nil)
+	logger := slog.New(h).With("derived", true)
+
+	h.Pause()
+	logger.Info("dropped while paused")
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no records while paused")
+	}
+
+	h.Resume()
+	h.SetLevel(slog.LevelError)
+	logger.Warn("dropped below level")
+	logger.Error("kept")
+
+	var messages []string
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		messages = append(messages, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error reading records: %v", err)
+	}
+
+	if len(messages) != 1 || messages[0] != "kept" {
+		t.Fatalf("expected only the error, got %v", messages)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	h := slogproto.NewHandler(&bytes.Buffer{}, nil)
+	srv := httptest.NewServer(slogproto.AdminHandler(h))
+	defer srv.Close()
+
+	state := func(resp *http.Response, err error) slogproto.AdminState {
+		t.Helper()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %s", resp.Status)
+		}
+
+		var s slogproto.AdminState
+		if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	if s := state(http.Get(srv.URL)); s.Level != slog.LevelInfo || s.Paused {
+		t.Fatalf("unexpected initial state: %+v", s)
+	}
+
+	s := state(http.Post(srv.URL, "application/json", strings.NewReader(`{"level":"DEBUG","paused":true}`)))
+	if s.Level != slog.LevelDebug || !s.Paused {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+
+	if h.Level() != slog.LevelDebug || !h.Paused() {
+		t.Fatalf("handler state was not changed")
+	}
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"level":"LOUD"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request, got %s", resp.Status)
+	}
+}
@@ -0,0 +1,184 @@
+// Package aggregate computes streaming statistics over slog records, such
+// as the number of records per level and numeric summaries of attributes.
+//
+// It is used by the slp stats command, but can be used by any program
+// that consumes records, such as those read with
+// [github.com/picatz/slogproto.Read].
+package aggregate
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+// Aggregator maintains statistics over the records added to it. It is safe
+// for concurrent use.
+type Aggregator struct {
+	mu      sync.Mutex
+	attrs   []string
+	records int64
+	levels  map[slog.Level]int64
+	numeric map[string]*NumericStats
+	start   time.Time
+	end     time.Time
+}
+
+// New returns an Aggregator which, in addition to counting records by
+// level, computes numeric statistics for the given attribute keys.
+//
+// Keys of attributes nested in groups are given as a dotted path, such
+// as "http.duration_ms".
+func New(attrs ...string) *Aggregator {
+	a := &Aggregator{
+		attrs:   attrs,
+		levels:  make(map[slog.Level]int64),
+		numeric: make(map[string]*NumericStats, len(attrs)),
+	}
+
+	for _, attr := range attrs {
+		a.numeric[attr] = &NumericStats{
+			Sketch: NewSketch(DefaultRelativeAccuracy),
+		}
+	}
+
+	return a
+}
+
+// Add updates the statistics with the given record.
+//
+// Attributes which are missing from the record, or which are not numeric,
+// are ignored.
+func (a *Aggregator) Add(r *slog.Record) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.records++
+	a.levels[r.Level]++
+
+	if !r.Time.IsZero() {
+		if a.start.IsZero() || r.Time.Before(a.start) {
+			a.start = r.Time
+		}
+		if r.Time.After(a.end) {
+			a.end = r.Time
+		}
+	}
+
+	for _, attr := range a.attrs {
+		v, ok := slogproto.GetAttr(r, attr)
+		if !ok {
+			continue
+		}
+
+		f, ok := Float64(v)
+		if !ok {
+			continue
+		}
+
+		a.numeric[attr].add(f)
+	}
+}
+
+// Snapshot returns a copy of the current statistics.
+func (a *Aggregator) Snapshot() Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := Snapshot{
+		Records: a.records,
+		Levels:  make(map[slog.Level]int64, len(a.levels)),
+		Attrs:   make(map[string]NumericStats, len(a.numeric)),
+		Start:   a.start,
+		End:     a.end,
+	}
+
+	for level, count := range a.levels {
+		s.Levels[level] = count
+	}
+
+	for attr, stats := range a.numeric {
+		c := *stats
+		c.Sketch = stats.Sketch.Clone()
+		s.Attrs[attr] = c
+	}
+
+	return s
+}
+
+// Snapshot is a point in time copy of the statistics of an [Aggregator].
+type Snapshot struct {
+	// Records is the total number of records.
+	Records int64
+
+	// Levels is the number of records for each level.
+	Levels map[slog.Level]int64
+
+	// Attrs contains the numeric statistics for each attribute key
+	// given to [New].
+	Attrs map[string]NumericStats
+
+	// Start and End are the earliest and latest non-zero record
+	// times, if any.
+	Start, End time.Time
+}
+
+// NumericStats summarizes the numeric values of an attribute.
+type NumericStats struct {
+	Count int64
+	Min   float64
+	Max   float64
+	Sum   float64
+
+	// Sketch estimates the quantiles of the values.
+	Sketch *Sketch
+}
+
+// add updates the statistics with the given value.
+func (s *NumericStats) add(f float64) {
+	s.Sketch.Add(f)
+
+	if s.Count == 0 || f < s.Min {
+		s.Min = f
+	}
+	if s.Count == 0 || f > s.Max {
+		s.Max = f
+	}
+	s.Count++
+	s.Sum += f
+}
+
+// Quantile returns an estimate of the value at quantile q, such as 0.99 for
+// the 99th percentile, within [DefaultRelativeAccuracy].
+func (s NumericStats) Quantile(q float64) float64 {
+	if s.Sketch == nil {
+		return 0
+	}
+	return s.Sketch.Quantile(q)
+}
+
+// Mean returns the arithmetic mean of the values, or zero if there are
+// none.
+func (s NumericStats) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Float64 returns the value as a float64 if it is numeric: an int64,
+// uint64 or float64. Otherwise, it returns false.
+func Float64(v slog.Value) (float64, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return float64(v.Int64()), true
+	case slog.KindUint64:
+		return float64(v.Uint64()), true
+	case slog.KindFloat64:
+		return v.Float64(), true
+	default:
+		return 0, false
+	}
+}
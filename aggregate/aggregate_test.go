@@ -0,0 +1,58 @@
+package aggregate_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto/aggregate"
+)
+
+func TestAggregator(t *testing.T) {
+	a := aggregate.New("duration_ms", "http.status")
+
+	start := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 10; i++ {
+		level := slog.LevelInfo
+		if i%5 == 0 {
+			level = slog.LevelError
+		}
+
+		r := slog.NewRecord(start.Add(time.Duration(i)*time.Second), level, "request", 0)
+		r.AddAttrs(
+			slog.Int("duration_ms", i+1),
+			slog.Group("http", slog.Int("status", 200)),
+		)
+
+		a.Add(&r)
+	}
+
+	// A record without the attributes.
+	r := slog.NewRecord(time.Time{}, slog.LevelDebug, "no attributes", 0)
+	a.Add(&r)
+
+	s := a.Snapshot()
+
+	if s.Records != 11 {
+		t.Fatalf("expected 11 records, got %d", s.Records)
+	}
+
+	if s.Levels[slog.LevelError] != 2 || s.Levels[slog.LevelInfo] != 8 || s.Levels[slog.LevelDebug] != 1 {
+		t.Fatalf("unexpected level counts: %v", s.Levels)
+	}
+
+	if !s.Start.Equal(start) || !s.End.Equal(start.Add(9*time.Second)) {
+		t.Fatalf("unexpected time range: %v - %v", s.Start, s.End)
+	}
+
+	d := s.Attrs["duration_ms"]
+	if d.Count != 10 || d.Min != 1 || d.Max != 10 || d.Sum != 55 || d.Mean() != 5.5 {
+		t.Fatalf("unexpected duration_ms stats: %+v", d)
+	}
+
+	status := s.Attrs["http.status"]
+	if status.Count != 10 || status.Min != 200 || status.Max != 200 {
+		t.Fatalf("unexpected http.status stats: %+v", status)
+	}
+}
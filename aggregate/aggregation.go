@@ -0,0 +1,164 @@
+package aggregate
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Aggregation is a function computed from a [Snapshot], such as the number
+// of records or the 99th percentile of an attribute.
+//
+// Aggregations are written as function calls, such as "count()",
+// "sum(duration_ms)" or "p99(duration_ms)". The supported functions are:
+//
+//   - count(): the number of records
+//   - count(level): the number of records with the given level, such as count(ERROR)
+//   - min(attr), max(attr), sum(attr), avg(attr) and mean(attr)
+//   - pNN(attr): the NNth percentile of the attribute, such as p50 or p99.9
+type Aggregation struct {
+	// Func is the name of the function, such as "count" or "p99".
+	Func string
+
+	// Arg is the argument of the function, such as an attribute key, if any.
+	Arg string
+}
+
+// String returns the aggregation in the form it is parsed from.
+func (a Aggregation) String() string {
+	return a.Func + "(" + a.Arg + ")"
+}
+
+// Attr returns the attribute key the aggregation computes statistics for,
+// if any, which must be given to [New] or [NewGroupBy].
+func (a Aggregation) Attr() (string, bool) {
+	if a.Func == "count" {
+		return "", false
+	}
+	return a.Arg, true
+}
+
+// Value returns the value of the aggregation for the snapshot.
+func (a Aggregation) Value(s Snapshot) float64 {
+	switch a.Func {
+	case "count":
+		if a.Arg == "" {
+			return float64(s.Records)
+		}
+
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(a.Arg)); err != nil {
+			return 0
+		}
+		return float64(s.Levels[level])
+	case "min":
+		return s.Attrs[a.Arg].Min
+	case "max":
+		return s.Attrs[a.Arg].Max
+	case "sum":
+		return s.Attrs[a.Arg].Sum
+	case "avg", "mean":
+		return s.Attrs[a.Arg].Mean()
+	default:
+		p, _ := percentile(a.Func)
+		return s.Attrs[a.Arg].Quantile(p / 100)
+	}
+}
+
+// ParseAggregation parses a single aggregation, such as "p99(duration_ms)".
+func ParseAggregation(s string) (Aggregation, error) {
+	s = strings.TrimSpace(s)
+
+	name, rest, ok := strings.Cut(s, "(")
+	if !ok || !strings.HasSuffix(rest, ")") {
+		return Aggregation{}, fmt.Errorf("invalid aggregation %q: expected a function call such as count()", s)
+	}
+
+	a := Aggregation{
+		Func: strings.TrimSpace(name),
+		Arg:  strings.TrimSpace(strings.TrimSuffix(rest, ")")),
+	}
+
+	switch a.Func {
+	case "count":
+		if a.Arg != "" {
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(a.Arg)); err != nil {
+				return Aggregation{}, fmt.Errorf("invalid aggregation %q: %w", s, err)
+			}
+		}
+		return a, nil
+	case "min", "max", "sum", "avg", "mean":
+	default:
+		if _, ok := percentile(a.Func); !ok {
+			return Aggregation{}, fmt.Errorf("invalid aggregation %q: unknown function %q", s, a.Func)
+		}
+	}
+
+	if a.Arg == "" {
+		return Aggregation{}, fmt.Errorf("invalid aggregation %q: %s requires an attribute", s, a.Func)
+	}
+
+	return a, nil
+}
+
+// ParseAggregations parses a comma separated list of aggregations, such as
+// "count(),p99(duration_ms)".
+func ParseAggregations(s string) ([]Aggregation, error) {
+	var aggs []Aggregation
+
+	for _, part := range splitAggregations(s) {
+		a, err := ParseAggregation(part)
+		if err != nil {
+			return nil, err
+		}
+		aggs = append(aggs, a)
+	}
+
+	return aggs, nil
+}
+
+// splitAggregations splits a list of aggregations on the commas that are
+// not within parentheses.
+func splitAggregations(s string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+
+	return parts
+}
+
+// percentile returns the percentile of a function name of the form pNN.
+func percentile(name string) (float64, bool) {
+	if !strings.HasPrefix(name, "p") {
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(name[1:], 64)
+	if err != nil || p < 0 || p > 100 {
+		return 0, false
+	}
+
+	return p, true
+}
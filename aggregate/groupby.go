@@ -0,0 +1,100 @@
+package aggregate
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/picatz/slogproto"
+)
+
+// GroupBy maintains separate statistics for each distinct combination of
+// values of a set of attributes, such as per endpoint or per status code.
+// It is safe for concurrent use.
+type GroupBy struct {
+	mu     sync.Mutex
+	by     []string
+	attrs  []string
+	groups map[string]*group
+}
+
+// group is the aggregator for a single combination of attribute values.
+type group struct {
+	key []string
+	agg *Aggregator
+}
+
+// NewGroupBy returns a GroupBy which groups records by the values of the
+// given attribute keys and, within each group, computes numeric statistics
+// for the given attrs, as with [New].
+//
+// Records missing one of the attributes to group by are grouped under an
+// empty string for that attribute.
+func NewGroupBy(by []string, attrs ...string) *GroupBy {
+	return &GroupBy{
+		by:     by,
+		attrs:  attrs,
+		groups: make(map[string]*group),
+	}
+}
+
+// Add updates the statistics of the record's group with the given record.
+func (g *GroupBy) Add(r *slog.Record) {
+	key := make([]string, len(g.by))
+	for i, attr := range g.by {
+		if v, ok := slogproto.GetAttr(r, attr); ok {
+			key[i] = v.String()
+		}
+	}
+
+	id := strings.Join(key, "\x00")
+
+	g.mu.Lock()
+	grp, ok := g.groups[id]
+	if !ok {
+		grp = &group{
+			key: key,
+			agg: New(g.attrs...),
+		}
+		g.groups[id] = grp
+	}
+	g.mu.Unlock()
+
+	grp.agg.Add(r)
+}
+
+// GroupSnapshot is a point in time copy of the statistics of one group of
+// a [GroupBy].
+type GroupSnapshot struct {
+	// Key contains the values of the attributes grouped by, in the order
+	// given to [NewGroupBy].
+	Key []string
+
+	Snapshot
+}
+
+// Snapshot returns a copy of the current statistics of every group, sorted
+// by their keys.
+func (g *GroupBy) Snapshot() []GroupSnapshot {
+	g.mu.Lock()
+	groups := make([]*group, 0, len(g.groups))
+	for _, grp := range g.groups {
+		groups = append(groups, grp)
+	}
+	g.mu.Unlock()
+
+	snapshots := make([]GroupSnapshot, 0, len(groups))
+	for _, grp := range groups {
+		snapshots = append(snapshots, GroupSnapshot{
+			Key:      grp.key,
+			Snapshot: grp.agg.Snapshot(),
+		})
+	}
+
+	slices.SortFunc(snapshots, func(a, b GroupSnapshot) int {
+		return slices.Compare(a.Key, b.Key)
+	})
+
+	return snapshots
+}
@@ -0,0 +1,87 @@
+package aggregate_test
+
+import (
+	"log/slog"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto/aggregate"
+)
+
+func TestGroupBy(t *testing.T) {
+	aggs, err := aggregate.ParseAggregations("count(), count(ERROR), max(duration_ms), p50(duration_ms)")
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	var attrs []string
+	for _, agg := range aggs {
+		if attr, ok := agg.Attr(); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	g := aggregate.NewGroupBy([]string{"endpoint"}, attrs...)
+
+	for i := 0; i < 9; i++ {
+		level := slog.LevelInfo
+		if i%3 == 0 {
+			level = slog.LevelError
+		}
+
+		r := slog.NewRecord(time.Now(), level, "request", 0)
+		r.AddAttrs(
+			slog.String("endpoint", []string{"/a", "/b", "/c"}[i%3]),
+			slog.Int("duration_ms", i),
+		)
+		g.Add(&r)
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "no endpoint", 0)
+	g.Add(&r)
+
+	groups := g.Snapshot()
+
+	var keys [][]string
+	for _, grp := range groups {
+		keys = append(keys, grp.Key)
+	}
+
+	if !slices.EqualFunc(keys, [][]string{{""}, {"/a"}, {"/b"}, {"/c"}}, slices.Equal) {
+		t.Fatalf("unexpected group keys: %v", keys)
+	}
+
+	a := groups[1]
+
+	want := []float64{3, 3, 6, 3}
+	for i, agg := range aggs {
+		// Percentiles are estimated within the sketch's relative accuracy.
+		if got := agg.Value(a.Snapshot); math.Abs(got-want[i]) > want[i]*aggregate.DefaultRelativeAccuracy {
+			t.Errorf("%s: expected %v, got %v", agg, want[i], got)
+		}
+	}
+}
+
+func TestParseAggregations(t *testing.T) {
+	aggs, err := aggregate.ParseAggregations("count(),p99.9(duration_ms),avg(size)")
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	var got []string
+	for _, agg := range aggs {
+		got = append(got, agg.String())
+	}
+
+	if !slices.Equal(got, []string{"count()", "p99.9(duration_ms)", "avg(size)"}) {
+		t.Fatalf("unexpected aggregations: %v", got)
+	}
+
+	for _, invalid := range []string{"count", "median(x)", "sum()", "p101(x)", "count(LOUD)"} {
+		if _, err := aggregate.ParseAggregations(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package aggregate
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+// DefaultRelativeAccuracy is the relative accuracy of the quantiles
+// estimated by the sketches of an [Aggregator].
+const DefaultRelativeAccuracy = 0.01
+
+// Sketch estimates quantiles of a stream of values using a bounded amount
+// of memory, independent of the number of values added.
+//
+// Values are counted in logarithmically sized buckets, so that any quantile
+// is estimated within the relative accuracy given to [NewSketch] (in the
+// manner of DDSketch).
+type Sketch struct {
+	gamma    float64
+	logGamma float64
+
+	positive map[int]int64
+	negative map[int]int64
+	zero     int64
+
+	count int64
+	min   float64
+	max   float64
+}
+
+// NewSketch returns an empty sketch with the given relative accuracy, which
+// must be between 0 and 1, such as 0.01 for 1%.
+func NewSketch(relativeAccuracy float64) *Sketch {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+
+	return &Sketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		positive: make(map[int]int64),
+		negative: make(map[int]int64),
+	}
+}
+
+// Add adds a value to the sketch.
+func (s *Sketch) Add(f float64) {
+	switch {
+	case math.IsNaN(f):
+		return
+	case f > 0:
+		s.positive[s.index(f)]++
+	case f < 0:
+		s.negative[s.index(-f)]++
+	default:
+		s.zero++
+	}
+
+	if s.count == 0 || f < s.min {
+		s.min = f
+	}
+	if s.count == 0 || f > s.max {
+		s.max = f
+	}
+	s.count++
+}
+
+// Count returns the number of values added to the sketch.
+func (s *Sketch) Count() int64 {
+	return s.count
+}
+
+// Quantile returns an estimate of the value at quantile q, which must be
+// between 0 and 1, such as 0.99 for the 99th percentile. It returns zero if
+// the sketch is empty.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+
+	switch {
+	case q <= 0:
+		return s.min
+	case q >= 1:
+		return s.max
+	}
+
+	rank := int64(q * float64(s.count-1))
+
+	var seen int64
+
+	// Negative values, from the largest magnitude to the smallest.
+	negative := sortedKeys(s.negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		k := negative[i]
+		seen += s.negative[k]
+		if seen > rank {
+			return s.clamp(-s.value(k))
+		}
+	}
+
+	seen += s.zero
+	if seen > rank {
+		return 0
+	}
+
+	// Positive values, from the smallest to the largest.
+	for _, k := range sortedKeys(s.positive) {
+		seen += s.positive[k]
+		if seen > rank {
+			return s.clamp(s.value(k))
+		}
+	}
+
+	return s.max
+}
+
+// Clone returns a copy of the sketch.
+func (s *Sketch) Clone() *Sketch {
+	c := *s
+	c.positive = maps.Clone(s.positive)
+	c.negative = maps.Clone(s.negative)
+	return &c
+}
+
+// index returns the bucket index for a positive value.
+func (s *Sketch) index(f float64) int {
+	return int(math.Ceil(math.Log(f) / s.logGamma))
+}
+
+// value returns the representative value for a bucket index, which is
+// within the relative accuracy of every value in the bucket.
+func (s *Sketch) value(k int) float64 {
+	return 2 * math.Pow(s.gamma, float64(k)) / (s.gamma + 1)
+}
+
+// clamp limits an estimate to the range of values added to the sketch.
+func (s *Sketch) clamp(f float64) float64 {
+	return max(s.min, min(s.max, f))
+}
+
+// sortedKeys returns the bucket indexes of the map in ascending order.
+func sortedKeys(buckets map[int]int64) []int {
+	keys := make([]int, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
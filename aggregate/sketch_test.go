@@ -0,0 +1,59 @@
+package aggregate_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/picatz/slogproto/aggregate"
+)
+
+func TestSketch(t *testing.T) {
+	s := aggregate.NewSketch(aggregate.DefaultRelativeAccuracy)
+
+	if s.Quantile(0.5) != 0 {
+		t.Fatalf("expected zero for an empty sketch")
+	}
+
+	// Add the values 1 to 10000 in a random order.
+	for _, v := range rand.Perm(10000) {
+		s.Add(float64(v + 1))
+	}
+
+	if s.Count() != 10000 {
+		t.Fatalf("expected 10000 values, got %d", s.Count())
+	}
+
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		want := q * 10000
+		got := s.Quantile(q)
+
+		if math.Abs(got-want)/want > aggregate.DefaultRelativeAccuracy*1.5 {
+			t.Errorf("quantile %v: expected about %v, got %v", q, want, got)
+		}
+	}
+
+	if s.Quantile(0) != 1 || s.Quantile(1) != 10000 {
+		t.Errorf("expected min and max for quantiles 0 and 1, got %v and %v", s.Quantile(0), s.Quantile(1))
+	}
+}
+
+func TestSketch_negative(t *testing.T) {
+	s := aggregate.NewSketch(aggregate.DefaultRelativeAccuracy)
+
+	for _, v := range []float64{-100, -10, 0, 10, 100} {
+		s.Add(v)
+	}
+
+	if got := s.Quantile(0.25); math.Abs(got+10) > 0.2 {
+		t.Errorf("expected about -10, got %v", got)
+	}
+
+	if got := s.Quantile(0.5); got != 0 {
+		t.Errorf("expected 0, got %v", got)
+	}
+
+	if got := s.Quantile(0.75); math.Abs(got-10) > 0.2 {
+		t.Errorf("expected about 10, got %v", got)
+	}
+}
@@ -0,0 +1,101 @@
+package aggregate
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Windows maintains grouped statistics, as with [GroupBy], for each
+// consecutive, non-overlapping (tumbling) window of time, such as each
+// minute. It is safe for concurrent use.
+type Windows struct {
+	mu      sync.Mutex
+	size    time.Duration
+	by      []string
+	attrs   []string
+	windows map[time.Time]*GroupBy
+}
+
+// NewWindows returns a Windows which assigns records to windows of the
+// given size, aligned to the Unix epoch (e.g. on the minute), and groups
+// them within each window by the given attribute keys, computing numeric
+// statistics for the given attrs.
+//
+// Records with a zero time are assigned to a window starting at the zero
+// time. If size is zero or negative, all records are assigned to a single
+// window starting at the zero time.
+func NewWindows(size time.Duration, by []string, attrs ...string) *Windows {
+	return &Windows{
+		size:    size,
+		by:      by,
+		attrs:   attrs,
+		windows: make(map[time.Time]*GroupBy),
+	}
+}
+
+// Add updates the statistics of the record's window with the given record.
+func (w *Windows) Add(r *slog.Record) {
+	var start time.Time
+	if !r.Time.IsZero() && w.size > 0 {
+		start = r.Time.Truncate(w.size)
+	}
+
+	w.mu.Lock()
+	g, ok := w.windows[start]
+	if !ok {
+		g = NewGroupBy(w.by, w.attrs...)
+		w.windows[start] = g
+	}
+	w.mu.Unlock()
+
+	g.Add(r)
+}
+
+// WindowSnapshot is a point in time copy of the statistics of one window
+// of a [Windows].
+type WindowSnapshot struct {
+	// Start is the start of the window, inclusive.
+	Start time.Time
+
+	// End is the end of the window, exclusive, or the zero time if there
+	// is a single window.
+	End time.Time
+
+	// Groups contains the statistics of each group in the window.
+	Groups []GroupSnapshot
+}
+
+// Snapshot returns a copy of the current statistics of every window,
+// ordered by their start time.
+func (w *Windows) Snapshot() []WindowSnapshot {
+	w.mu.Lock()
+	starts := make([]time.Time, 0, len(w.windows))
+	for start := range w.windows {
+		starts = append(starts, start)
+	}
+	groups := make(map[time.Time]*GroupBy, len(w.windows))
+	for start, g := range w.windows {
+		groups[start] = g
+	}
+	w.mu.Unlock()
+
+	slices.SortFunc(starts, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+
+	snapshots := make([]WindowSnapshot, 0, len(starts))
+	for _, start := range starts {
+		snapshot := WindowSnapshot{
+			Start:  start,
+			Groups: groups[start].Snapshot(),
+		}
+		if w.size > 0 {
+			snapshot.End = start.Add(w.size)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots
+}
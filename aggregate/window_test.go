@@ -0,0 +1,44 @@
+package aggregate_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto/aggregate"
+)
+
+func TestWindows(t *testing.T) {
+	w := aggregate.NewWindows(time.Minute, []string{"endpoint"})
+
+	start := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	// Two records per endpoint in each of three minutes.
+	for i := 0; i < 12; i++ {
+		r := slog.NewRecord(start.Add(time.Duration(i)*15*time.Second), slog.LevelInfo, "request", 0)
+		r.AddAttrs(slog.String("endpoint", []string{"/a", "/b"}[i%2]))
+		w.Add(&r)
+	}
+
+	windows := w.Snapshot()
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(windows))
+	}
+
+	for i, window := range windows {
+		wantStart := start.Add(time.Duration(i) * time.Minute)
+		if !window.Start.Equal(wantStart) || !window.End.Equal(wantStart.Add(time.Minute)) {
+			t.Errorf("window %d: unexpected range %v - %v", i, window.Start, window.End)
+		}
+
+		if len(window.Groups) != 2 {
+			t.Fatalf("window %d: expected 2 groups, got %d", i, len(window.Groups))
+		}
+
+		for _, g := range window.Groups {
+			if g.Records != 2 {
+				t.Errorf("window %d, group %v: expected 2 records, got %d", i, g.Key, g.Records)
+			}
+		}
+	}
+}
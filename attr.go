@@ -0,0 +1,57 @@
+package slogproto
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// GetAttr returns the resolved value of the attribute of the record at the
+// given dotted path, such as "http.request.method", descending into
+// groups. An attribute whose key is the whole path, such as one that has
+// been flattened, also matches. The attributes of groups with an empty
+// key are looked up as if they were attributes of the enclosing group.
+//
+// If more than one attribute matches, the first is returned.
+func GetAttr(r *slog.Record, path string) (slog.Value, bool) {
+	var (
+		value slog.Value
+		found bool
+	)
+
+	r.Attrs(func(a slog.Attr) bool {
+		value, found = lookupAttr(a, path)
+		return !found
+	})
+
+	return value, found
+}
+
+// lookupAttr returns the value at the given dotted path relative to the
+// attribute's parent.
+func lookupAttr(a slog.Attr, path string) (slog.Value, bool) {
+	if a.Key == path {
+		return a.Value.Resolve(), true
+	}
+
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		return slog.Value{}, false
+	}
+
+	rest := path
+	if a.Key != "" {
+		var ok bool
+		rest, ok = strings.CutPrefix(path, a.Key+".")
+		if !ok {
+			return slog.Value{}, false
+		}
+	}
+
+	for _, ga := range a.Value.Group() {
+		if v, ok := lookupAttr(ga, rest); ok {
+			return v, true
+		}
+	}
+
+	return slog.Value{}, false
+}
@@ -0,0 +1,57 @@
+package slogproto_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestGetAttr(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+	r.AddAttrs(
+		slog.String("user", "alice"),
+		slog.Group("http",
+			slog.Group("request", slog.String("method", "GET")),
+			slog.Int("status", 200),
+		),
+		slog.String("db.statement", "SELECT 1"),
+		slog.Group("", slog.Bool("inlined", true)),
+	)
+
+	tests := []struct {
+		path  string
+		want  slog.Value
+		found bool
+	}{
+		{path: "user", want: slog.StringValue("alice"), found: true},
+		{path: "http.request.method", want: slog.StringValue("GET"), found: true},
+		{path: "http.status", want: slog.IntValue(200), found: true},
+		{path: "db.statement", want: slog.StringValue("SELECT 1"), found: true},
+		{path: "inlined", want: slog.BoolValue(true), found: true},
+		{path: "http.missing"},
+		{path: "user.name"},
+		{path: "missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			v, ok := slogproto.GetAttr(&r, test.path)
+			if ok != test.found {
+				t.Fatalf("expected found to be %v, got %v", test.found, ok)
+			}
+
+			if ok && !v.Equal(test.want) {
+				t.Fatalf("expected %v, got %v", test.want, v)
+			}
+		})
+	}
+
+	t.Run("group", func(t *testing.T) {
+		v, ok := slogproto.GetAttr(&r, "http.request")
+		if !ok || v.Kind() != slog.KindGroup {
+			t.Fatalf("expected a group, got %v", v)
+		}
+	})
+}
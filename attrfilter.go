@@ -0,0 +1,186 @@
+package slogproto
+
+import (
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// WithAllowAttrs configures the handler to only write the attributes whose
+// dotted paths, such as "http.method", match one of the patterns. A
+// pattern matching a group keeps all of its attributes, and each segment
+// of a pattern may contain the wildcards supported by [path.Match], such
+// as "http.*" or "user_*".
+//
+// The paths of attributes include the groups opened with WithGroup. The
+// time, level, message and source of records are always written.
+func WithAllowAttrs(patterns ...string) HandlerOption {
+	return func(h *Handler) {
+		if h.attrFilter == nil {
+			h.attrFilter = &attrFilter{}
+		}
+		h.attrFilter.allow = append(h.attrFilter.allow, splitPatterns(patterns)...)
+	}
+}
+
+// WithDenyAttrs configures the handler to drop the attributes whose dotted
+// paths match one of the patterns, as described by [WithAllowAttrs]. A
+// pattern matching a group drops all of its attributes. Attributes that
+// are both allowed and denied are dropped.
+func WithDenyAttrs(patterns ...string) HandlerOption {
+	return func(h *Handler) {
+		if h.attrFilter == nil {
+			h.attrFilter = &attrFilter{}
+		}
+		h.attrFilter.deny = append(h.attrFilter.deny, splitPatterns(patterns)...)
+	}
+}
+
+// WithRenameAttrs configures the handler to rename attributes, so that
+// the naming conventions of a service can be normalized without changing
+// how it logs. Each key of the mapping is the dotted path of an attribute,
+// such as "user_id" or "http.status_code", and its value is the new key of
+// the attribute within the same group. Attributes renamed to an empty key
+// are dropped:
+//
+//	slogproto.WithRenameAttrs(map[string]string{
+//		"user_id": "user.id",
+//		"lvl":     "",
+//	})
+//
+// Renamed attributes are still matched by their original paths with
+// [WithAllowAttrs] and [WithDenyAttrs].
+func WithRenameAttrs(mapping map[string]string) HandlerOption {
+	return func(h *Handler) {
+		if h.attrFilter == nil {
+			h.attrFilter = &attrFilter{}
+		}
+		if h.attrFilter.rename == nil {
+			h.attrFilter.rename = make(map[string]string, len(mapping))
+		}
+		for from, to := range mapping {
+			h.attrFilter.rename[from] = to
+		}
+	}
+}
+
+// splitPatterns splits the dotted patterns into their segments.
+func splitPatterns(patterns []string) [][]string {
+	split := make([][]string, len(patterns))
+	for i, p := range patterns {
+		split[i] = strings.Split(p, ".")
+	}
+	return split
+}
+
+// attrFilter selects and renames the attributes written by a handler by
+// their paths.
+type attrFilter struct {
+	allow  [][]string
+	deny   [][]string
+	rename map[string]string
+}
+
+// matchPrefix reports whether the pattern matches the first segments of
+// the attribute path, or all of them if the pattern is as long as it.
+func matchPrefix(pattern, attrPath []string) bool {
+	if len(pattern) > len(attrPath) {
+		return false
+	}
+	for i := range pattern {
+		if ok, _ := path.Match(pattern[i], attrPath[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// filter returns the attribute, found within the groups of the given
+// path, with the attributes it holds filtered, and false if it should be
+// dropped entirely.
+func (f *attrFilter) filter(groups []string, a slog.Attr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+
+	// Attributes of groups with an empty key are inlined.
+	p := groups
+	if a.Key != "" {
+		p = append(groups[:len(groups):len(groups)], a.Key)
+	}
+
+	for _, pattern := range f.deny {
+		if matchPrefix(pattern, p) {
+			return a, false
+		}
+	}
+
+	allowed := len(f.allow) == 0
+	for _, pattern := range f.allow {
+		if matchPrefix(pattern, p) {
+			allowed = true
+			break
+		}
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return f.renameAttr(p, a, allowed)
+	}
+
+	// A group is kept if it has attributes left, which are all kept if it
+	// is allowed, or else only if a pattern selects them.
+	if !allowed && !f.allowsWithin(p) {
+		return a, false
+	}
+
+	group := a.Value.Group()
+	attrs := make([]slog.Attr, 0, len(group))
+	for _, ga := range group {
+		if allowed && len(f.deny) == 0 && len(f.rename) == 0 {
+			attrs = append(attrs, ga)
+			continue
+		}
+
+		sub := *f
+		if allowed {
+			sub.allow = nil
+		}
+		if ga, ok := sub.filter(p, ga); ok {
+			attrs = append(attrs, ga)
+		}
+	}
+	if len(attrs) == 0 {
+		return a, false
+	}
+
+	a.Value = slog.GroupValue(attrs...)
+	return f.renameAttr(p, a, true)
+}
+
+// renameAttr returns the attribute at the path with its key renamed, and
+// false if it is renamed to an empty key, or isn't kept.
+func (f *attrFilter) renameAttr(attrPath []string, a slog.Attr, keep bool) (slog.Attr, bool) {
+	if !keep || len(f.rename) == 0 || a.Key == "" {
+		return a, keep
+	}
+
+	key, ok := f.rename[strings.Join(attrPath, ".")]
+	if !ok {
+		return a, true
+	}
+	if key == "" {
+		return a, false
+	}
+
+	a.Key = key
+	return a, true
+}
+
+// allowsWithin reports whether an allow pattern may select attributes
+// within the group at the path.
+func (f *attrFilter) allowsWithin(groupPath []string) bool {
+	for _, pattern := range f.allow {
+		if len(pattern) > len(groupPath) && matchPrefix(pattern[:len(groupPath)], groupPath) {
+			return true
+		}
+	}
+	return false
+}
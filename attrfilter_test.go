@@ -0,0 +1,94 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestHandler_attrFilter(t *testing.T) {
+	log := func(l *slog.Logger) {
+		l.With("service", "api", slog.Group("user", "id", 1, "email", "a@example.com")).
+			WithGroup("req").
+			Info("hello",
+				"id", 7,
+				"body", "large payload",
+				slog.Group("http", "method", "GET", "status", 200, "headers", slog.GroupValue(slog.String("cookie", "secret"))),
+				slog.Group("", "inline", true),
+			)
+	}
+
+	tests := map[string]struct {
+		options []slogproto.HandlerOption
+		want    string
+	}{
+		"none": {
+			want: `level=INFO msg=hello req.body="large payload" req.http.headers.cookie=secret req.http.method=GET req.http.status=200 req.id=7 req.inline=true service=api user.email=a@example.com user.id=1`,
+		},
+		"deny": {
+			options: []slogproto.HandlerOption{slogproto.WithDenyAttrs("req.body", "*.http.headers", "user.email")},
+			want:    `level=INFO msg=hello req.http.method=GET req.http.status=200 req.id=7 req.inline=true service=api user.id=1`,
+		},
+		"allow": {
+			options: []slogproto.HandlerOption{slogproto.WithAllowAttrs("service", "req.http.*", "req.id")},
+			want:    `level=INFO msg=hello req.http.headers.cookie=secret req.http.method=GET req.http.status=200 req.id=7 service=api`,
+		},
+		"allow and deny": {
+			options: []slogproto.HandlerOption{
+				slogproto.WithAllowAttrs("user", "req.http"),
+				slogproto.WithDenyAttrs("req.http.headers", "user.e*"),
+			},
+			want: `level=INFO msg=hello req.http.method=GET req.http.status=200 user.id=1`,
+		},
+		"rename": {
+			options: []slogproto.HandlerOption{
+				slogproto.WithRenameAttrs(map[string]string{
+					"service":          "svc",
+					"user":             "account",
+					"req.id":           "request_id",
+					"req.http.headers": "",
+				}),
+				slogproto.WithDenyAttrs("user.email"),
+			},
+			want: `level=INFO msg=hello account.id=1 req.body="large payload" req.http.method=GET req.http.status=200 req.inline=true req.request_id=7 svc=api`,
+		},
+		"wildcard": {
+			options: []slogproto.HandlerOption{slogproto.WithAllowAttrs("*.in*")},
+			want:    `level=INFO msg=hello req.inline=true`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log(slog.New(slogproto.NewHandler(&buf, nil, test.options...)))
+
+			var got bytes.Buffer
+			text := slog.NewTextHandler(&got, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+
+			err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+				if err := text.Handle(context.Background(), *r); err != nil {
+					t.Fatal(err)
+				}
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := bytes.TrimSpace(got.Bytes()); string(got) != test.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
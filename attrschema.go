@@ -0,0 +1,241 @@
+package slogproto
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSchemaViolation is returned by [Handler.Handle] for records that
+// don't match the schema configured with [WithSchema].
+var ErrSchemaViolation = errors.New("slogproto: schema violation")
+
+// Schema maps the dotted paths of attributes, such as "http.status", to
+// what is expected of them. As with [WithAllowAttrs], the paths include
+// the groups opened with WithGroup.
+type Schema map[string]AttrSchema
+
+// AttrSchema describes the expected attribute at a path of a [Schema].
+type AttrSchema struct {
+	// Kind is the expected kind of the attribute's value. The zero value,
+	// slog.KindAny, accepts values of any kind.
+	Kind slog.Kind
+
+	// Required is set if every record must have the attribute.
+	Required bool
+}
+
+// SchemaAction is what a handler does with attributes of the wrong kind.
+type SchemaAction int
+
+const (
+	// SchemaReport rejects records with attributes of the wrong kind.
+	SchemaReport SchemaAction = iota
+
+	// SchemaCoerce converts attributes of the wrong kind to the expected
+	// kind, such as the string "200" to an int, rejecting records with
+	// attributes that can't be converted.
+	SchemaCoerce
+
+	// SchemaDrop drops attributes of the wrong kind from records.
+	SchemaDrop
+)
+
+// WithSchema configures the handler to validate the attributes of records
+// against the schema, to catch an attribute being logged with different
+// kinds in different places, such as a status logged as a string in one
+// place and as an int in another.
+//
+// Attributes of the wrong kind are handled according to the action.
+// Records that are rejected, including those missing a required attribute,
+// fail with ErrSchemaViolation, and are sent to the dead-letter writer if
+// one is configured. Attributes that aren't in the schema are written
+// unchanged.
+//
+// # Example
+//
+//	h := slogproto.NewHandler(f, nil, slogproto.WithSchema(slogproto.Schema{
+//		"http.status": {Kind: slog.KindInt64, Required: true},
+//		"user_id":     {Kind: slog.KindString},
+//	}, slogproto.SchemaCoerce))
+func WithSchema(schema Schema, action SchemaAction) HandlerOption {
+	return func(h *Handler) {
+		s := &attrSchema{attrs: schema, action: action}
+		for _, as := range schema {
+			if as.Required {
+				s.required++
+			}
+		}
+		h.schema = s
+	}
+}
+
+// attrSchema validates the attributes written by a handler.
+type attrSchema struct {
+	attrs    Schema
+	action   SchemaAction
+	required int
+}
+
+// schemaCheck tracks the required attributes found in a record.
+type schemaCheck struct {
+	*attrSchema
+	found map[string]bool
+}
+
+// newCheck returns a check of a single record.
+func (s *attrSchema) newCheck() *schemaCheck {
+	c := &schemaCheck{attrSchema: s}
+	if s.required > 0 {
+		c.found = make(map[string]bool, s.required)
+	}
+	return c
+}
+
+// check returns the attribute, found within the groups of the given path,
+// with the attributes it holds checked, and false if it should be dropped.
+func (c *schemaCheck) check(groups []string, a slog.Attr) (slog.Attr, bool, error) {
+	a.Value = a.Value.Resolve()
+
+	// Attributes of groups with an empty key are inlined.
+	p := groups
+	if a.Key != "" {
+		p = append(groups[:len(groups):len(groups)], a.Key)
+	}
+	key := strings.Join(p, ".")
+
+	if as, ok := c.attrs[key]; ok && a.Key != "" {
+		if as.Kind != slog.KindAny && a.Value.Kind() != as.Kind {
+			switch c.action {
+			case SchemaDrop:
+				return a, false, nil
+			case SchemaCoerce:
+				v, ok := coerceValue(a.Value, as.Kind)
+				if !ok {
+					return a, false, fmt.Errorf("%w: attribute %q of kind %s can't be converted to %s", ErrSchemaViolation, key, a.Value.Kind(), as.Kind)
+				}
+				a.Value = v
+			default:
+				return a, false, fmt.Errorf("%w: attribute %q is of kind %s, expected %s", ErrSchemaViolation, key, a.Value.Kind(), as.Kind)
+			}
+		}
+
+		if as.Required {
+			c.found[key] = true
+		}
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return a, true, nil
+	}
+
+	group := a.Value.Group()
+	attrs := make([]slog.Attr, 0, len(group))
+	for _, ga := range group {
+		ga, ok, err := c.check(p, ga)
+		if err != nil {
+			return a, false, err
+		}
+		if ok {
+			attrs = append(attrs, ga)
+		}
+	}
+
+	a.Value = slog.GroupValue(attrs...)
+	return a, true, nil
+}
+
+// missing returns an error naming the required attributes that weren't
+// found, if any.
+func (c *schemaCheck) missing() error {
+	if len(c.found) == c.required {
+		return nil
+	}
+
+	var keys []string
+	for key, as := range c.attrs {
+		if as.Required && !c.found[key] {
+			keys = append(keys, strconv.Quote(key))
+		}
+	}
+	slices.Sort(keys)
+
+	return fmt.Errorf("%w: missing required attributes %s", ErrSchemaViolation, strings.Join(keys, ", "))
+}
+
+// coerceValue converts the value to the given kind, and returns false if
+// it can't be converted without losing information.
+func coerceValue(v slog.Value, kind slog.Kind) (slog.Value, bool) {
+	switch kind {
+	case slog.KindString:
+		if v.Kind() == slog.KindGroup {
+			return v, false
+		}
+		return slog.StringValue(v.String()), true
+	case slog.KindInt64:
+		switch v.Kind() {
+		case slog.KindUint64:
+			if u := v.Uint64(); u <= math.MaxInt64 {
+				return slog.Int64Value(int64(u)), true
+			}
+		case slog.KindFloat64:
+			if f := v.Float64(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+				return slog.Int64Value(int64(f)), true
+			}
+		case slog.KindString:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v.String()), 10, 64); err == nil {
+				return slog.Int64Value(i), true
+			}
+		}
+	case slog.KindUint64:
+		switch v.Kind() {
+		case slog.KindInt64:
+			if i := v.Int64(); i >= 0 {
+				return slog.Uint64Value(uint64(i)), true
+			}
+		case slog.KindFloat64:
+			if f := v.Float64(); f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 {
+				return slog.Uint64Value(uint64(f)), true
+			}
+		case slog.KindString:
+			if u, err := strconv.ParseUint(strings.TrimSpace(v.String()), 10, 64); err == nil {
+				return slog.Uint64Value(u), true
+			}
+		}
+	case slog.KindFloat64:
+		switch v.Kind() {
+		case slog.KindInt64:
+			return slog.Float64Value(float64(v.Int64())), true
+		case slog.KindUint64:
+			return slog.Float64Value(float64(v.Uint64())), true
+		case slog.KindString:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err == nil {
+				return slog.Float64Value(f), true
+			}
+		}
+	case slog.KindBool:
+		if v.Kind() == slog.KindString {
+			if b, err := strconv.ParseBool(strings.TrimSpace(v.String())); err == nil {
+				return slog.BoolValue(b), true
+			}
+		}
+	case slog.KindDuration:
+		if v.Kind() == slog.KindString {
+			if d, err := time.ParseDuration(strings.TrimSpace(v.String())); err == nil {
+				return slog.DurationValue(d), true
+			}
+		}
+	case slog.KindTime:
+		if v.Kind() == slog.KindString {
+			if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v.String())); err == nil {
+				return slog.TimeValue(t), true
+			}
+		}
+	}
+	return v, false
+}
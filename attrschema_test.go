@@ -0,0 +1,102 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestWithSchema(t *testing.T) {
+	schema := slogproto.Schema{
+		"service":     {Required: true},
+		"req.status":  {Kind: slog.KindInt64, Required: true},
+		"req.latency": {Kind: slog.KindDuration},
+	}
+
+	tests := map[string]struct {
+		action  slogproto.SchemaAction
+		attrs   []any
+		want    string
+		wantErr string
+	}{
+		"valid": {
+			action: slogproto.SchemaReport,
+			attrs:  []any{"status", 200, "latency", time.Second},
+			want:   `level=INFO msg=hello req.latency=1s req.status=200 service=api`,
+		},
+		"report": {
+			action:  slogproto.SchemaReport,
+			attrs:   []any{"status", "200"},
+			wantErr: `attribute "req.status" is of kind String, expected Int64`,
+		},
+		"coerce": {
+			action: slogproto.SchemaCoerce,
+			attrs:  []any{"status", "200", "latency", "250ms"},
+			want:   `level=INFO msg=hello req.latency=250ms req.status=200 service=api`,
+		},
+		"coerce invalid": {
+			action:  slogproto.SchemaCoerce,
+			attrs:   []any{"status", "ok"},
+			wantErr: `attribute "req.status" of kind String can't be converted to Int64`,
+		},
+		"drop": {
+			action: slogproto.SchemaDrop,
+			attrs:  []any{"status", 200, "latency", 250},
+			want:   `level=INFO msg=hello req.status=200 service=api`,
+		},
+		"drop required": {
+			action:  slogproto.SchemaDrop,
+			attrs:   []any{"status", "200"},
+			wantErr: `missing required attributes "req.status"`,
+		},
+		"missing": {
+			action:  slogproto.SchemaReport,
+			attrs:   []any{"latency", time.Second},
+			wantErr: `missing required attributes "req.status"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := slogproto.NewHandler(&buf, nil, slogproto.WithSchema(schema, test.action))
+
+			r := slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)
+			r.Add(test.attrs...)
+
+			err := h.WithAttrs([]slog.Attr{slog.String("service", "api")}).WithGroup("req").Handle(context.Background(), r)
+			if test.wantErr != "" {
+				if !errors.Is(err, slogproto.ErrSchemaViolation) || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected schema violation %q, got %v", test.wantErr, err)
+				}
+				if buf.Len() != 0 {
+					t.Fatal("expected the record to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+			text := slog.NewTextHandler(&got, nil)
+
+			err = slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+				err = text.Handle(context.Background(), *r)
+				return err == nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(got.String()); got != test.want {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
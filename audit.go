@@ -0,0 +1,291 @@
+package slogproto
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Keys and message of the attributes and records written by a handler
+// configured with [WithAuditChain].
+const (
+	// AuditPrevKey is the key of the attribute of each record holding the
+	// hexadecimal SHA-256 hash of the previous record, or zeros for the
+	// first record.
+	AuditPrevKey = "audit_prev"
+
+	// AuditCheckpointKey is the key of the group of checkpoint records,
+	// holding the number of records preceding the checkpoint, "records",
+	// and the hexadecimal Ed25519 signature of the checkpoint,
+	// "signature".
+	AuditCheckpointKey = "audit_checkpoint"
+
+	// AuditCheckpointMessage is the message of checkpoint records.
+	AuditCheckpointMessage = "audit checkpoint"
+)
+
+// ErrAuditChain is returned by [VerifyAuditLog] when records of an audit
+// log were removed, reordered or modified.
+var ErrAuditChain = errors.New("slogproto: audit chain broken")
+
+// ErrAuditUnsigned is returned by [VerifyAuditLog], given a public key,
+// when an audit log has no signed checkpoint, or records after its last
+// checkpoint, which could have been forged or removed without the private
+// key.
+var ErrAuditUnsigned = errors.New("slogproto: audit log not signed")
+
+// auditSignaturePrefix prefixes the messages signed by checkpoints, so that
+// their signatures can't be used for anything else.
+const auditSignaturePrefix = "slogproto audit checkpoint\x00"
+
+// auditChain holds the state of the chain of records of a handler.
+type auditChain struct {
+	key      ed25519.PrivateKey
+	interval int
+
+	// prev is the hash of the previous record.
+	prev [sha256.Size]byte
+
+	records         uint64
+	sinceCheckpoint int
+}
+
+// WithAuditChain configures the handler to write an append-only audit log,
+// where each record includes the hash of the previous record as the
+// [AuditPrevKey] attribute, so that removing, reordering or modifying a
+// record breaks the chain of every record after it. The chain starts
+// with the first record the handler writes.
+//
+// If key is set, a checkpoint record signed with it is written after
+// every interval records, and when the handler is shut down, so that the
+// chain can't be rewritten without the key, and records removed from the
+// end of the log are detected up to the last checkpoint. Otherwise, or if
+// interval is not positive, checkpoints are only written at shutdown.
+//
+// Audit logs are verified with [VerifyAuditLog]. Records are encoded
+// while holding the handler's lock, since each depends on the last.
+func WithAuditChain(key ed25519.PrivateKey, interval int) HandlerOption {
+	return func(h *Handler) {
+		h.audit = &auditChain{key: key, interval: interval}
+	}
+}
+
+// writeChained adds the hash of the previous record to the record, and
+// writes it, followed by a checkpoint if one is due. It must be called
+// with mu held.
+func (h *Handler) writeChained(pbr *Record, t time.Time, checkpoint bool) error {
+	_, overwrite := pbr.Attrs[AuditPrevKey]
+	pbr.Attrs[AuditPrevKey] = &Value{
+		Kind: &Value_String_{
+			String_: hex.EncodeToString(h.audit.prev[:]),
+		},
+	}
+	if h.enc.keyOrder && !overwrite {
+		pbr.Keys = append(pbr.Keys, AuditPrevKey)
+	}
+
+	b, err := h.encodeFrame(pbr)
+	if err != nil {
+		return err
+	}
+	if err := h.writeFrameLocked(b, t); err != nil {
+		return err
+	}
+
+	h.audit.prev = sha256.Sum256(b[frameSizeLen(h.framing):])
+	h.audit.records++
+	if checkpoint {
+		h.audit.sinceCheckpoint = 0
+		return nil
+	}
+
+	h.audit.sinceCheckpoint++
+	if h.audit.key != nil && h.audit.interval > 0 && h.audit.sinceCheckpoint >= h.audit.interval {
+		return h.writeCheckpoint()
+	}
+	return nil
+}
+
+// writeCheckpoint writes a checkpoint record, signing the hash of the
+// previous record and the number of records. It must be called with mu
+// held.
+func (h *Handler) writeCheckpoint() error {
+	sig := ed25519.Sign(h.audit.key, auditSignedMessage(h.audit.prev[:], h.audit.records))
+
+	now := time.Now()
+	pbr := &Record{
+		Time:    timestamppb.New(now),
+		Level:   Level_LEVEL_INFO,
+		Message: AuditCheckpointMessage,
+		Stream:  h.stream,
+		Attrs: map[string]*Value{
+			AuditCheckpointKey: {
+				Kind: &Value_Group_{
+					Group: &Value_Group{
+						Attrs: map[string]*Value{
+							"records":   {Kind: &Value_Uint{Uint: h.audit.records}},
+							"signature": {Kind: &Value_String_{String_: hex.EncodeToString(sig)}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if h.enc.keyOrder {
+		pbr.Keys = []string{AuditCheckpointKey}
+	}
+
+	if err := h.writeChained(pbr, now, true); err != nil {
+		return fmt.Errorf("slogproto: error writing audit checkpoint: %w", err)
+	}
+	return nil
+}
+
+// auditSignedMessage returns the message signed by a checkpoint following
+// the record with the hash, after the number of records.
+func auditSignedMessage(prev []byte, records uint64) []byte {
+	msg := append([]byte(auditSignaturePrefix), prev...)
+	return binary.BigEndian.AppendUint64(msg, records)
+}
+
+// AuditReport describes an audit log verified by [VerifyAuditLog].
+type AuditReport struct {
+	// Records is the number of records of the log, including checkpoints.
+	Records int64
+
+	// Checkpoints is the number of checkpoints whose signatures were
+	// verified.
+	Checkpoints int64
+
+	// Unsigned is the number of records following the last verified
+	// checkpoint, which could have been removed from the end of the log,
+	// or rewritten, without breaking the chain.
+	Unsigned int64
+}
+
+// AuditVerifyOption configures [VerifyAuditLog].
+type AuditVerifyOption func(*auditVerifyConfig)
+
+// auditVerifyConfig holds the options of [VerifyAuditLog].
+type auditVerifyConfig struct {
+	allowUnsigned bool
+}
+
+// AllowUnsignedTail accepts records after the last checkpoint of an audit
+// log, such as those of a log still being written, which are reported in
+// [AuditReport.Unsigned]. The log must still have a signed checkpoint.
+func AllowUnsignedTail() AuditVerifyOption {
+	return func(c *auditVerifyConfig) {
+		c.allowUnsigned = true
+	}
+}
+
+// VerifyAuditLog reads an audit log written by a handler configured with
+// [WithAuditChain], checking that each record follows the previous one,
+// and that checkpoints are signed by the private key of pub, if given. It
+// returns ErrAuditChain if a record was removed, reordered or modified,
+// or a checkpoint is invalid.
+//
+// Without a signed checkpoint, anyone can rewrite the chain, so given a
+// public key, it returns ErrAuditUnsigned if the log has no checkpoint,
+// or records after the last checkpoint, unless [AllowUnsignedTail] is
+// given.
+func VerifyAuditLog(ctx context.Context, r io.Reader, pub ed25519.PublicKey, opts ...AuditVerifyOption) (AuditReport, error) {
+	var config auditVerifyConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var (
+		report AuditReport
+		prev   [sha256.Size]byte
+		fnErr  error
+	)
+
+	err := readFrames(ctx, r, func(frame []byte, r *slog.Record) bool {
+		var (
+			chained    string
+			checkpoint []slog.Attr
+		)
+		r.Attrs(func(a slog.Attr) bool {
+			switch a.Key {
+			case AuditPrevKey:
+				chained = a.Value.String()
+			case AuditCheckpointKey:
+				if r.Message == AuditCheckpointMessage && a.Value.Kind() == slog.KindGroup {
+					checkpoint = a.Value.Group()
+				}
+			}
+			return true
+		})
+
+		if chained != hex.EncodeToString(prev[:]) {
+			fnErr = fmt.Errorf("%w: record %d doesn't follow the previous record", ErrAuditChain, report.Records)
+			return false
+		}
+
+		if checkpoint != nil && pub != nil {
+			if err := verifyCheckpoint(checkpoint, prev[:], uint64(report.Records), pub); err != nil {
+				fnErr = fmt.Errorf("%w: record %d: %w", ErrAuditChain, report.Records, err)
+				return false
+			}
+			report.Checkpoints++
+			report.Unsigned = -1
+		}
+
+		prev = sha256.Sum256(frame)
+		report.Records++
+		report.Unsigned++
+		return true
+	})
+	if err == nil {
+		err = fnErr
+	}
+	if err != nil || pub == nil {
+		return report, err
+	}
+
+	switch {
+	case report.Checkpoints == 0:
+		err = fmt.Errorf("%w: no signed checkpoint", ErrAuditUnsigned)
+	case report.Unsigned > 0 && !config.allowUnsigned:
+		err = fmt.Errorf("%w: %d records after the last checkpoint", ErrAuditUnsigned, report.Unsigned)
+	}
+	return report, err
+}
+
+// verifyCheckpoint verifies the attributes of a checkpoint following the
+// record with the hash, after the number of records.
+func verifyCheckpoint(attrs []slog.Attr, prev []byte, records uint64, pub ed25519.PublicKey) error {
+	var (
+		count uint64
+		sig   []byte
+	)
+	for _, a := range attrs {
+		switch a.Key {
+		case "records":
+			if a.Value.Kind() == slog.KindUint64 {
+				count = a.Value.Uint64()
+			}
+		case "signature":
+			sig, _ = hex.DecodeString(a.Value.String())
+		}
+	}
+
+	if count != records {
+		return fmt.Errorf("checkpoint of %d records follows %d records", count, records)
+	}
+	if !ed25519.Verify(pub, auditSignedMessage(prev, records), sig) {
+		return errors.New("invalid checkpoint signature")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+// splitFrames returns the size prefixed frames of a stream of records.
+func splitFrames(t *testing.T, b []byte) [][]byte {
+	t.Helper()
+
+	var frames [][]byte
+	for len(b) > 0 {
+		size := 4 + int(binary.LittleEndian.Uint32(b))
+		if size > len(b) {
+			t.Fatalf("truncated frame of %d bytes", size)
+		}
+		frames = append(frames, b[:size])
+		b = b[size:]
+	}
+	return frames
+}
+
+func TestWithAuditChain(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithAuditChain(key, 3), slogproto.WithKeyOrder())
+	logger := slog.New(h).With("actor", "admin")
+	for _, action := range []string{"login", "read", "update", "delete", "grant", "revoke", "logout"} {
+		logger.Info(action)
+	}
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := readMessages(t, bytes.NewBuffer(buf.Bytes())), "login,read,update,audit checkpoint,delete,grant,revoke,audit checkpoint,logout,audit checkpoint"; got != want {
+		t.Fatalf("expected records %q, got %q", want, got)
+	}
+
+	report, err := slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(buf.Bytes()), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report != (slogproto.AuditReport{Records: 10, Checkpoints: 3, Unsigned: 0}) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	frames := splitFrames(t, buf.Bytes())
+
+	t.Run("truncated", func(t *testing.T) {
+		truncated := slices.Concat(frames[:6]...)
+		report, err := slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(truncated), pub)
+		if !errors.Is(err, slogproto.ErrAuditUnsigned) {
+			t.Fatalf("expected ErrAuditUnsigned, got %v", err)
+		}
+		if report != (slogproto.AuditReport{Records: 6, Checkpoints: 1, Unsigned: 2}) {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		// Records after the last checkpoint can be allowed, as those of a
+		// log still being written.
+		_, err = slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(truncated), pub, slogproto.AllowUnsignedTail())
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		// Remove a record and every checkpoint, and chain the rest again
+		// without the key.
+		var forged bytes.Buffer
+		fh := slogproto.NewHandler(&forged, nil, slogproto.WithAuditChain(nil, 0))
+		err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+			if r.Message != "read" && r.Message != slogproto.AuditCheckpointMessage {
+				if err := fh.Handle(context.Background(), *r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The chain is intact, but nothing is signed.
+		report, err := slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(forged.Bytes()), nil)
+		if err != nil || report.Records != 6 {
+			t.Fatalf("expected a valid chain of 6 records, got %+v, %v", report, err)
+		}
+
+		for _, opts := range [][]slogproto.AuditVerifyOption{nil, {slogproto.AllowUnsignedTail()}} {
+			report, err := slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(forged.Bytes()), pub, opts...)
+			if !errors.Is(err, slogproto.ErrAuditUnsigned) {
+				t.Fatalf("expected ErrAuditUnsigned, got %+v, %v", report, err)
+			}
+		}
+	})
+
+	for name, tampered := range map[string][][]byte{
+		"removed":   slices.Delete(slices.Clone(frames), 1, 2),
+		"reordered": append([][]byte{frames[1], frames[0]}, frames[2:]...),
+		"modified": func() [][]byte {
+			modified := slices.Clone(frames)
+			modified[4] = bytes.Replace(frames[4], []byte("delete"), []byte("create"), 1)
+			return modified
+		}(),
+		"first removed": frames[1:],
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(slices.Concat(tampered...)), pub)
+			if !errors.Is(err, slogproto.ErrAuditChain) {
+				t.Fatalf("expected ErrAuditChain, got %v", err)
+			}
+		})
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, _, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(buf.Bytes()), other)
+		if !errors.Is(err, slogproto.ErrAuditChain) {
+			t.Fatalf("expected ErrAuditChain, got %v", err)
+		}
+	})
+}
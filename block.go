@@ -0,0 +1,324 @@
+//go:build !slogproto_nozstd && !tinygo
+
+package slogproto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultBlockSize is the default size, in bytes, of the records
+// compressed together in each block by a [BlockWriter].
+const DefaultBlockSize = 64 << 10
+
+// BlockWriter compresses the records written to it with zstd in blocks,
+// each an independent zstd frame holding whole records, so that any block
+// can be decompressed on its own. Unlike a stream compressed as a whole,
+// which must be decompressed from its start, a [BlockReader] can then
+// read from any block, such as the one holding the records of a given
+// time.
+//
+// The output is still a regular zstd stream, which can be read with
+// [WithZstdDict] and the same dictionary, or by slp.
+//
+// Each call to Write must hold whole records, as written by the [Handler]
+// and [WriteRecord]. A BlockWriter is not safe for concurrent use, but the
+// Handler serializes its writes.
+type BlockWriter struct {
+	w         io.Writer
+	enc       *zstd.Encoder
+	blockSize int
+	buf       []byte
+	out       []byte
+}
+
+// NewBlockWriter returns a writer which compresses records written to it
+// in blocks of about blockSize bytes of records, or DefaultBlockSize if
+// blockSize is zero or less, using the dictionary if it isn't empty, and
+// writes them to w.
+func NewBlockWriter(w io.Writer, blockSize int, dict []byte) (*BlockWriter, error) {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+
+	enc, err := zstd.NewWriter(nil, append(zstdEncoderOptions(dict), zstd.WithEncoderConcurrency(1))...)
+	if err != nil {
+		return nil, fmt.Errorf("slogproto: error creating zstd encoder: %w", err)
+	}
+
+	return &BlockWriter{
+		w:         w,
+		enc:       enc,
+		blockSize: blockSize,
+	}, nil
+}
+
+// Write buffers the records, writing the block once it holds at least
+// the block size.
+func (bw *BlockWriter) Write(p []byte) (int, error) {
+	bw.buf = append(bw.buf, p...)
+	if len(bw.buf) >= bw.blockSize {
+		if err := bw.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered records as a block, even if it is smaller
+// than the block size.
+func (bw *BlockWriter) Flush() error {
+	if len(bw.buf) == 0 {
+		return nil
+	}
+
+	bw.out = bw.enc.EncodeAll(bw.buf, bw.out[:0])
+	bw.buf = bw.buf[:0]
+
+	_, err := bw.w.Write(bw.out)
+	return err
+}
+
+// Close writes the buffered records, and releases the encoder. It doesn't
+// close the underlying writer.
+func (bw *BlockWriter) Close() error {
+	err := bw.Flush()
+	if cerr := bw.enc.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WithBlockCompression configures the handler to compress the records it
+// writes with a [BlockWriter] of the given block size and dictionary. As
+// with WithGzip, the last block is written by [Handler.Shutdown], which
+// must be called to write every record.
+//
+// WithBlockCompression panics if the dictionary is larger than zstd
+// supports, or if the handler already compresses its records.
+func WithBlockCompression(blockSize int, dict []byte) HandlerOption {
+	return func(h *Handler) {
+		err := h.compress(func(w io.Writer) (io.WriteCloser, error) {
+			return NewBlockWriter(w, blockSize, dict)
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// BlockReader reads the records of a stream written by a [BlockWriter]
+// starting from any of its blocks.
+//
+// Blocks are located by reading the zstd frame headers, and the headers of
+// their compressed blocks, without decompressing them, so opening a
+// BlockReader only reads a few bytes per block.
+//
+// # Example
+//
+//	br, err := slogproto.NewBlockReader(f, fi.Size(), nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer br.Close()
+//
+//	i, err := br.Search(since)
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = slogproto.Read(ctx, br.NewReader(i), func(r *slog.Record) bool {
+//		...
+//	}, slogproto.WithFilter(prog))
+type BlockReader struct {
+	r      io.ReaderAt
+	blocks []blockSpan
+	dec    *zstd.Decoder
+}
+
+// blockSpan is the location of a block in its stream.
+type blockSpan struct {
+	offset int64
+	size   int64
+}
+
+// NewBlockReader returns a reader of the blocks of the size bytes read
+// from r, which were compressed with the dictionary, if it isn't empty.
+// Close must be called to release it.
+func NewBlockReader(r io.ReaderAt, size int64, dict []byte) (*BlockReader, error) {
+	blocks, err := scanBlocks(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := zstd.NewReader(nil, append(zstdDecoderOptions(dict), zstd.WithDecoderConcurrency(1))...)
+	if err != nil {
+		return nil, fmt.Errorf("slogproto: error creating zstd decoder: %w", err)
+	}
+
+	return &BlockReader{r: r, blocks: blocks, dec: dec}, nil
+}
+
+// scanBlocks returns the location of each zstd frame of the size bytes
+// read from r, skipping skippable frames.
+func scanBlocks(r io.ReaderAt, size int64) ([]blockSpan, error) {
+	var (
+		blocks []blockSpan
+		header [zstd.HeaderMaxSize]byte
+	)
+	for offset := int64(0); offset < size; {
+		n, err := r.ReadAt(header[:min(int64(len(header)), size-offset)], offset)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		var h zstd.Header
+		if err := h.Decode(header[:n]); err != nil {
+			return nil, fmt.Errorf("slogproto: invalid zstd frame at offset %d: %w", offset, err)
+		}
+		if h.Skippable {
+			offset += int64(h.HeaderSize) + int64(h.SkippableSize)
+			continue
+		}
+
+		// Walk the headers of the frame's compressed blocks.
+		end := offset + int64(h.HeaderSize)
+		for last := false; !last; {
+			var bh [3]byte
+			if _, err := r.ReadAt(bh[:], end); err != nil {
+				return nil, fmt.Errorf("slogproto: truncated zstd frame at offset %d: %w", offset, err)
+			}
+			v := uint32(bh[0]) | uint32(bh[1])<<8 | uint32(bh[2])<<16
+			last = v&1 != 0
+
+			blockSize := int64(v >> 3)
+			if (v>>1)&3 == 1 {
+				// An RLE block holds a single byte.
+				blockSize = 1
+			}
+			end += int64(len(bh)) + blockSize
+		}
+		if h.HasCheckSum {
+			end += 4
+		}
+		if end > size {
+			return nil, fmt.Errorf("slogproto: truncated zstd frame at offset %d", offset)
+		}
+
+		blocks = append(blocks, blockSpan{offset: offset, size: end - offset})
+		offset = end
+	}
+	return blocks, nil
+}
+
+// Len returns the number of blocks.
+func (br *BlockReader) Len() int {
+	return len(br.blocks)
+}
+
+// Search returns the index of the block from which records at or after t
+// can be read, assuming the records are ordered by time, as written by a
+// single logger. It decompresses the blocks it compares with t.
+func (br *BlockReader) Search(t time.Time) (int, error) {
+	var err error
+	i := sort.Search(len(br.blocks), func(i int) bool {
+		if err != nil {
+			return true
+		}
+		var first time.Time
+		first, err = br.firstTime(i)
+		return err == nil && !first.Before(t)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The previous block may end with records at or after t.
+	return max(i-1, 0), nil
+}
+
+// firstTime returns the time of the first record of the block.
+func (br *BlockReader) firstTime(i int) (time.Time, error) {
+	data, err := br.block(i, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var t time.Time
+	err = readFrames(context.Background(), bytes.NewReader(data), func(_ []byte, r *slog.Record) bool {
+		t = r.Time
+		return false
+	})
+	return t, err
+}
+
+// block returns the decompressed records of the block, appended to dst.
+func (br *BlockReader) block(i int, dst []byte) ([]byte, error) {
+	b := br.blocks[i]
+	compressed := make([]byte, b.size)
+	if _, err := br.r.ReadAt(compressed, b.offset); err != nil {
+		return nil, fmt.Errorf("slogproto: error reading block %d: %w", i, err)
+	}
+
+	data, err := br.dec.DecodeAll(compressed, dst)
+	if err != nil {
+		return nil, fmt.Errorf("slogproto: error decompressing block %d: %w", i, err)
+	}
+	return data, nil
+}
+
+// NewReader returns a reader of the decompressed records of the blocks,
+// starting from the block with the given index, which can be read with
+// [Read]. Blocks are decompressed one at a time, as they are read.
+func (br *BlockReader) NewReader(from int) io.Reader {
+	return &blocksReader{br: br, next: from, end: len(br.blocks)}
+}
+
+// Block returns a reader of the decompressed records of the block with the
+// given index alone.
+func (br *BlockReader) Block(i int) io.Reader {
+	return &blocksReader{br: br, next: i, end: i + 1}
+}
+
+// Close releases the decoder of the reader. It doesn't close the
+// underlying reader.
+func (br *BlockReader) Close() error {
+	br.dec.Close()
+	return nil
+}
+
+// blocksReader reads the decompressed records of consecutive blocks, up
+// to the block with the index end.
+type blocksReader struct {
+	br   *BlockReader
+	next int
+	end  int
+	buf  []byte
+	data []byte
+}
+
+func (r *blocksReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.next >= r.end {
+			return 0, io.EOF
+		}
+
+		var err error
+		if r.buf, err = r.br.block(r.next, r.buf[:0]); err != nil {
+			return 0, err
+		}
+		r.data = r.buf
+		r.next++
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
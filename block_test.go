@@ -0,0 +1,101 @@
+//go:build !slogproto_nozstd && !tinygo
+
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestBlockReader(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader(), slogproto.WithBlockCompression(1024, nil))
+	for i := 0; i < 1000; i++ {
+		r := slog.NewRecord(start.Add(time.Duration(i)*time.Second), slog.LevelInfo, "tick", 0)
+		r.AddAttrs(slog.Int("i", i))
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The blocks can be read as a single zstd stream.
+	var n int
+	err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+		n++
+		return true
+	}, slogproto.WithZstdDict(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1000 {
+		t.Fatalf("expected 1000 records, got %d", n)
+	}
+
+	br, err := slogproto.NewBlockReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+
+	if br.Len() < 2 {
+		t.Fatalf("expected several blocks, got %d", br.Len())
+	}
+
+	since := start.Add(500 * time.Second)
+	i, err := br.Search(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i == 0 {
+		t.Fatal("expected to skip the first blocks")
+	}
+
+	var (
+		first, records int
+		found          bool
+	)
+	err = slogproto.Read(context.Background(), br.NewReader(i), func(r *slog.Record) bool {
+		v, _ := slogproto.GetAttr(r, "i")
+		if records == 0 {
+			first = int(v.Int64())
+		}
+		found = found || r.Time.Equal(since)
+		records++
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first > 500 || !found {
+		t.Fatalf("expected the records from the block of record 500, got records from %d", first)
+	}
+	if records+first != 1000 || records == 1000 {
+		t.Fatalf("expected the records from %d to the end, got %d", first, records)
+	}
+
+	var inFirst int
+	err = slogproto.Read(context.Background(), br.Block(0), func(r *slog.Record) bool {
+		inFirst++
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inFirst == 0 || inFirst >= first+records {
+		t.Fatalf("expected only the records of the first block, got %d", inFirst)
+	}
+
+	if i, err := br.Search(start.Add(-time.Hour)); err != nil || i != 0 {
+		t.Fatalf("expected the first block, got %d (%v)", i, err)
+	}
+}
@@ -0,0 +1,246 @@
+package slogproto
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RecordBuilder constructs a [Record] directly, for programs producing
+// records from sources other than slog, such as agents converting foreign
+// log formats, which don't have a program counter or a [slog.Record] to
+// convert. The order of the attributes is recorded, and an attribute
+// added with the key of an earlier one replaces it.
+//
+// # Example
+//
+//	pbr, err := slogproto.NewRecordBuilder().
+//		Time(ts).
+//		Level(slog.LevelWarn).
+//		Msg("disk almost full").
+//		Str("host", "db-1").
+//		Group("disk", func(g *slogproto.GroupBuilder) {
+//			g.Str("device", "/dev/sda1").Float("used", 0.93)
+//		}).
+//		Build()
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = slogproto.WriteRecord(w, pbr)
+type RecordBuilder struct {
+	r     *Record
+	attrs GroupBuilder
+}
+
+// NewRecordBuilder returns a builder of a record with the zero time, the
+// info level, and no message or attributes.
+func NewRecordBuilder() *RecordBuilder {
+	r := &Record{
+		Level: LevelInfo,
+		Attrs: make(map[string]*Value),
+	}
+	return &RecordBuilder{
+		r:     r,
+		attrs: GroupBuilder{attrs: r.Attrs, keys: &r.Keys, err: new(error)},
+	}
+}
+
+// Time sets the time of the record. The zero time leaves it unset.
+func (b *RecordBuilder) Time(t time.Time) *RecordBuilder {
+	b.r.Time = nil
+	if !t.IsZero() {
+		b.r.Time = timestamppb.New(t)
+	}
+	return b
+}
+
+// Level sets the level of the record, which may be a custom level.
+func (b *RecordBuilder) Level(level slog.Level) *RecordBuilder {
+	b.r.Level = convertLevel(level)
+	b.r.SlogLevel = 0
+	if !isStandardLevel(level) {
+		b.r.SlogLevel = int64(level)
+	}
+	return b
+}
+
+// Severity sets the level of the record to that of a severity of a
+// foreign scale, such as [SyslogLevels] or [OTelLevels].
+func (b *RecordBuilder) Severity(m *LevelMapper, severity int) *RecordBuilder {
+	return b.Level(m.Level(severity))
+}
+
+// Msg sets the message of the record.
+func (b *RecordBuilder) Msg(msg string) *RecordBuilder {
+	b.r.Message = msg
+	return b
+}
+
+// Str adds a string attribute.
+func (b *RecordBuilder) Str(key, v string) *RecordBuilder {
+	b.attrs.Str(key, v)
+	return b
+}
+
+// Int adds an integer attribute.
+func (b *RecordBuilder) Int(key string, v int64) *RecordBuilder {
+	b.attrs.Int(key, v)
+	return b
+}
+
+// Uint adds an unsigned integer attribute.
+func (b *RecordBuilder) Uint(key string, v uint64) *RecordBuilder {
+	b.attrs.Uint(key, v)
+	return b
+}
+
+// Float adds a floating-point attribute.
+func (b *RecordBuilder) Float(key string, v float64) *RecordBuilder {
+	b.attrs.Float(key, v)
+	return b
+}
+
+// Bool adds a boolean attribute.
+func (b *RecordBuilder) Bool(key string, v bool) *RecordBuilder {
+	b.attrs.Bool(key, v)
+	return b
+}
+
+// Timestamp adds a time attribute.
+func (b *RecordBuilder) Timestamp(key string, v time.Time) *RecordBuilder {
+	b.attrs.Timestamp(key, v)
+	return b
+}
+
+// Dur adds a duration attribute.
+func (b *RecordBuilder) Dur(key string, v time.Duration) *RecordBuilder {
+	b.attrs.Dur(key, v)
+	return b
+}
+
+// Any adds an attribute holding a protocol buffer message.
+func (b *RecordBuilder) Any(key string, v *anypb.Any) *RecordBuilder {
+	b.attrs.Any(key, v)
+	return b
+}
+
+// Attr adds a slog attribute, converted as by [AttrToProto].
+func (b *RecordBuilder) Attr(a slog.Attr) *RecordBuilder {
+	b.attrs.Attr(a)
+	return b
+}
+
+// Group adds a group attribute, whose attributes are added by fn. Groups
+// without attributes are omitted, as they are by the [Handler].
+func (b *RecordBuilder) Group(key string, fn func(g *GroupBuilder)) *RecordBuilder {
+	b.attrs.Group(key, fn)
+	return b
+}
+
+// Build returns the record, or the first error converting an attribute
+// added with Attr. The builder must not be used afterwards.
+func (b *RecordBuilder) Build() (*Record, error) {
+	if err := *b.attrs.err; err != nil {
+		return nil, err
+	}
+	return b.r, nil
+}
+
+// GroupBuilder adds the attributes of a group built with a
+// [RecordBuilder].
+type GroupBuilder struct {
+	attrs map[string]*Value
+	keys  *[]string
+	err   *error
+}
+
+// add adds the value with the key, replacing any value with the same
+// key while keeping its position.
+func (g *GroupBuilder) add(key string, v *Value) *GroupBuilder {
+	if _, ok := g.attrs[key]; !ok {
+		*g.keys = append(*g.keys, key)
+	}
+	g.attrs[key] = v
+	return g
+}
+
+// Str adds a string attribute.
+func (g *GroupBuilder) Str(key, v string) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_String_{String_: v}})
+}
+
+// Int adds an integer attribute.
+func (g *GroupBuilder) Int(key string, v int64) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Int{Int: v}})
+}
+
+// Uint adds an unsigned integer attribute.
+func (g *GroupBuilder) Uint(key string, v uint64) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Uint{Uint: v}})
+}
+
+// Float adds a floating-point attribute.
+func (g *GroupBuilder) Float(key string, v float64) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Float{Float: v}})
+}
+
+// Bool adds a boolean attribute.
+func (g *GroupBuilder) Bool(key string, v bool) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Bool{Bool: v}})
+}
+
+// Timestamp adds a time attribute.
+func (g *GroupBuilder) Timestamp(key string, v time.Time) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Time{Time: timestamppb.New(v)}})
+}
+
+// Dur adds a duration attribute.
+func (g *GroupBuilder) Dur(key string, v time.Duration) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Duration{Duration: durationpb.New(v)}})
+}
+
+// Any adds an attribute holding a protocol buffer message.
+func (g *GroupBuilder) Any(key string, v *anypb.Any) *GroupBuilder {
+	return g.add(key, &Value{Kind: &Value_Any{Any: v}})
+}
+
+// Attr adds a slog attribute, converted as by [AttrToProto]. As with the
+// [Handler], empty groups are omitted, and the attributes of groups with
+// an empty key are inlined.
+func (g *GroupBuilder) Attr(a slog.Attr) *GroupBuilder {
+	err := addAttr(g.attrs, g.keys, a, encoding{keyOrder: true, embedAny: true})
+	if err != nil && *g.err == nil {
+		*g.err = fmt.Errorf("slogproto: error converting attribute %q: %w", a.Key, err)
+	}
+	return g
+}
+
+// Group adds a group attribute, whose attributes are added by fn. Groups
+// without attributes are omitted.
+func (g *GroupBuilder) Group(key string, fn func(g *GroupBuilder)) *GroupBuilder {
+	group := &Value_Group{Attrs: make(map[string]*Value)}
+	fn(&GroupBuilder{attrs: group.Attrs, keys: &group.Keys, err: g.err})
+	if len(group.Attrs) == 0 {
+		return g
+	}
+	return g.add(key, &Value{Kind: &Value_Group_{Group: group}})
+}
+
+// WriteRecord writes the record to w, framed like the records written by
+// the [Handler] with the default framing, with a single call to its Write
+// method, so it must not be used to append to a stream whose header
+// declares another framing.
+func WriteRecord(w io.Writer, pbr *Record) error {
+	b, err := proto.Marshal(pbr)
+	if err != nil {
+		return err
+	}
+	return writeFrame(w, Framing_FRAMING_UINT32_LE, b)
+}
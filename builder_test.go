@@ -0,0 +1,66 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRecordBuilder(t *testing.T) {
+	ts := time.Date(2023, 8, 1, 3, 13, 0, 0, time.UTC)
+
+	pbr, err := slogproto.NewRecordBuilder().
+		Time(ts).
+		Severity(slogproto.OTelLevels, 15).
+		Msg("disk almost full").
+		Str("host", "db-1").
+		Int("host", 1).
+		Group("disk", func(g *slogproto.GroupBuilder) {
+			g.Str("device", "/dev/sda1").
+				Float("used", 0.5).
+				Group("empty", func(g *slogproto.GroupBuilder) {})
+		}).
+		Attr(slog.Group("", slog.Duration("uptime", time.Hour))).
+		Bool("alert", true).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := slogproto.WriteRecord(&buf, pbr); err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	text := slog.NewTextHandler(&got, nil)
+
+	err = slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		err = text.Handle(context.Background(), *r)
+		return err == nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `time=2023-08-01T03:13:00.000Z level=WARN+2 msg="disk almost full" host=1 disk.device=/dev/sda1 disk.used=0.5 uptime=1h0m0s alert=true`
+	if got := strings.TrimSpace(got.String()); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRecordBuilder_error(t *testing.T) {
+	_, err := slogproto.NewRecordBuilder().
+		Group("g", func(g *slogproto.GroupBuilder) {
+			g.Attr(slog.Any("ch", make(chan int)))
+		}).
+		Build()
+	if err == nil || !strings.Contains(err.Error(), `"ch"`) {
+		t.Fatalf("expected an error converting the attribute, got %v", err)
+	}
+}
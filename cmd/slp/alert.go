@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	alertExecFlag       string
+	alertWebhookFlag    string
+	alertTimeoutFlag    time.Duration
+	alertCooldownFlag   time.Duration
+	alertDedupFlag      []string
+	alertFollowFlag     bool
+	alertCheckpointFlag string
+)
+
+func init() {
+	alertCmd.Flags().StringVar(&alertExecFlag, "exec", "", "command to run for each alert, receiving the record as JSON on STDIN")
+	alertCmd.Flags().StringVar(&alertWebhookFlag, "webhook", "", "URL to POST each alert to, with the record as a JSON body")
+	alertCmd.Flags().DurationVar(&alertTimeoutFlag, "webhook-timeout", 10*time.Second, "maximum time to wait for each webhook request, or 0 to wait indefinitely")
+	alertCmd.Flags().DurationVar(&alertCooldownFlag, "cooldown", 0, "minimum time between alerts with the same dedup key, such as 5m")
+	alertCmd.Flags().StringSliceVar(&alertDedupFlag, "dedup", nil, "attribute used to deduplicate alerts during the cooldown, such as attrs.code (repeatable, default msg)")
+	alertCmd.Flags().BoolVar(&alertFollowFlag, "follow", false, "follow the file, alerting on records appended to it until interrupted, across rotations")
+	alertCmd.Flags().StringVar(&alertCheckpointFlag, "checkpoint", "", "file in which to save the position of the last record read when following, to resume from it")
+	addFilterFlag(alertCmd)
+
+	rootCmd.AddCommand(alertCmd)
+}
+
+var alertCmd = &cobra.Command{
+	Use:   "alert [file]",
+	Short: "Run a command or webhook when records match a filter",
+	Long: `Reads protobuf messages from STDIN or a file and, for each record matching the filter expression, runs a command and/or sends a webhook. Alerts with the same dedup key are suppressed for the cooldown duration.
+
+With --follow, records appended to the file are read as they are written, starting from its end, or from the checkpoint if one was saved, until interrupted:
+
+  $ slp alert app.log --follow --checkpoint app.alert --filter 'level == "ERROR"' --exec ./notify.sh --cooldown 5m`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if filterFlag == "" {
+			return fmt.Errorf("a filter expression is required")
+		}
+
+		if alertExecFlag == "" && alertWebhookFlag == "" {
+			return fmt.Errorf("at least one of --exec or --webhook is required")
+		}
+
+		if (alertFollowFlag || alertCheckpointFlag != "") && len(args) == 0 {
+			return fmt.Errorf("a file is required to follow")
+		}
+
+		filterProg, err := compileFilter(filterFlag)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		a := newAlerter(alertCooldownFlag, alertDedupFlag)
+
+		alert := func(r *slog.Record) bool {
+			if !a.due(r, time.Now()) {
+				return true
+			}
+			if err := sendAlert(cmd.Context(), cmd.ErrOrStderr(), r); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "error sending alert: %v\n", err)
+			}
+			return true
+		}
+
+		if alertFollowFlag {
+			var filterErr error
+			err := slogproto.Tail(cmd.Context(), args[0], func(r *slog.Record) bool {
+				var ok bool
+				if ok, filterErr = slogproto.EvalFilter(filterProg, r); filterErr != nil {
+					return false
+				}
+				return !ok || alert(r)
+			}, &slogproto.TailOptions{
+				FromEnd:    true,
+				Checkpoint: alertCheckpointFlag,
+			})
+			if filterErr != nil {
+				return filterErr
+			}
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		return slogproto.Read(cmd.Context(), input, alert, append(readOptions(cmd), slogproto.WithFilter(filterProg))...)
+	},
+}
+
+// alerter decides which of the records matching the filter are alerted
+// on, suppressing alerts with the same dedup key during the cooldown.
+type alerter struct {
+	cooldown time.Duration
+	dedup    []string
+
+	// last is the time of the last alert for each dedup key.
+	last map[string]time.Time
+}
+
+// newAlerter returns an alerter with the cooldown, deduplicating alerts
+// by the given attributes, which may be dotted paths into groups.
+func newAlerter(cooldown time.Duration, dedup []string) *alerter {
+	a := &alerter{
+		cooldown: cooldown,
+		dedup:    make([]string, 0, len(dedup)),
+		last:     map[string]time.Time{},
+	}
+	for _, attr := range dedup {
+		a.dedup = append(a.dedup, attrPath(attr))
+	}
+	return a
+}
+
+// due reports whether to alert on the record, and if so records the
+// alert. The cooldown is measured from the time of the records, or now
+// for records without one.
+func (a *alerter) due(r *slog.Record, now time.Time) bool {
+	if !r.Time.IsZero() {
+		now = r.Time
+	}
+
+	key := dedupKey(r, a.dedup)
+	if last, ok := a.last[key]; ok && now.Sub(last) < a.cooldown {
+		return false
+	}
+	a.last[key] = now
+	return true
+}
+
+// dedupKey returns the key used to deduplicate alerts for the record: the
+// values of the given attributes, which may be dotted paths into groups,
+// or the message if none are given.
+func dedupKey(r *slog.Record, attrs []string) string {
+	if len(attrs) == 0 {
+		return r.Message
+	}
+
+	values := make([]string, len(attrs))
+	for i, attr := range attrs {
+		if v, ok := slogproto.GetAttr(r, attr); ok {
+			values[i] = v.String()
+		}
+	}
+
+	return strings.Join(values, "\x00")
+}
+
+// sendAlert runs the alert command and sends the alert webhook for the
+// record, as configured by the flags. The command's output is written to
+// stderr.
+func sendAlert(ctx context.Context, stderr io.Writer, r *slog.Record) error {
+	var body bytes.Buffer
+	if err := slog.NewJSONHandler(&body, nil).Handle(ctx, *r); err != nil {
+		return fmt.Errorf("error encoding record as JSON: %w", err)
+	}
+
+	if alertExecFlag != "" {
+		c := exec.CommandContext(ctx, alertExecFlag)
+		c.Stdin = bytes.NewReader(body.Bytes())
+		c.Stdout = stderr
+		c.Stderr = stderr
+		c.Env = append(os.Environ(),
+			"SLP_LEVEL="+r.Level.String(),
+			"SLP_MSG="+r.Message,
+			"SLP_TIME="+r.Time.Format(time.RFC3339Nano),
+		)
+
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("error running %q: %w", alertExecFlag, err)
+		}
+	}
+
+	if alertWebhookFlag != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, alertWebhookFlag, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return fmt.Errorf("error creating webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		// A webhook that hangs would otherwise block every later alert.
+		client := &http.Client{Timeout: alertTimeoutFlag}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending webhook: %w", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with status %s", resp.Status)
+		}
+	}
+
+	return nil
+}
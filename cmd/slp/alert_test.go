@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlerter(t *testing.T) {
+	base := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	now := base.Add(time.Hour)
+
+	record := func(offset time.Duration, msg string, attrs ...any) *slog.Record {
+		var tm time.Time
+		if offset >= 0 {
+			tm = base.Add(offset)
+		}
+		r := slog.NewRecord(tm, slog.LevelError, msg, 0)
+		r.Add(attrs...)
+		return &r
+	}
+
+	type alert struct {
+		r    *slog.Record
+		want bool
+	}
+
+	tests := map[string]struct {
+		cooldown time.Duration
+		dedup    []string
+		alerts   []alert
+	}{
+		"no cooldown": {
+			alerts: []alert{
+				{record(0, "failure"), true},
+				{record(0, "failure"), true},
+			},
+		},
+		"cooldown by message": {
+			cooldown: time.Minute,
+			alerts: []alert{
+				{record(0, "failure"), true},
+				{record(30*time.Second, "failure"), false},
+				{record(30*time.Second, "timeout"), true},
+				// The window is measured from the last alert, not the
+				// last suppressed record.
+				{record(time.Minute-time.Nanosecond, "failure"), false},
+				{record(time.Minute, "failure"), true},
+			},
+		},
+		"cooldown by attributes": {
+			cooldown: time.Minute,
+			dedup:    []string{"attrs.code", "attrs.http.path"},
+			alerts: []alert{
+				{record(0, "failure", "code", 500, slog.Group("http", "path", "/a")), true},
+				{record(time.Second, "other", "code", 500, slog.Group("http", "path", "/a")), false},
+				{record(time.Second, "failure", "code", 500, slog.Group("http", "path", "/b")), true},
+				{record(time.Second, "failure", "code", 502), true},
+				{record(2*time.Second, "failure", "code", 502), false},
+			},
+		},
+		"records without a time": {
+			cooldown: time.Minute,
+			alerts: []alert{
+				{record(-1, "failure"), true},
+				{record(-1, "failure"), false},
+				// Records with a time are compared with the time the
+				// record without one was alerted at.
+				{record(time.Hour+time.Minute, "failure"), true},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := newAlerter(test.cooldown, test.dedup)
+			for i, alert := range test.alerts {
+				if got := a.due(alert.r, now); got != alert.want {
+					t.Fatalf("record %d: expected alert %v, got %v", i, alert.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSendAlert_webhookTimeout(t *testing.T) {
+	hung := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer srv.Close()
+	defer close(hung)
+
+	defer func(webhook string, timeout time.Duration) {
+		alertWebhookFlag, alertTimeoutFlag = webhook, timeout
+	}(alertWebhookFlag, alertTimeoutFlag)
+	alertWebhookFlag, alertTimeoutFlag = srv.URL, 50*time.Millisecond
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sendAlert(context.Background(), io.Discard, &r)
+	}()
+
+	select {
+	case err := <-done:
+		var netErr interface{ Timeout() bool }
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("expected a timeout error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook request didn't time out")
+	}
+}
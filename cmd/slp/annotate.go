@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	annotateOffsetFlag int64
+	annotateNoteFlag   string
+)
+
+func init() {
+	annotateCmd.Flags().Int64Var(&annotateOffsetFlag, "at-offset", -1, "offset of the annotated record, as printed with slp --offsets")
+	annotateCmd.Flags().StringVar(&annotateNoteFlag, "note", "", "note to add to the record")
+
+	rootCmd.AddCommand(annotateCmd)
+}
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate file",
+	Short: "Add notes to the records of a log file, or list them",
+	Long: `Adds a note to the record at an offset of a log file, such as during an incident review, without changing the file: notes are appended to a sidecar file next to it, named after it with a .notes suffix. Without --note, the notes of the file are listed.
+
+The offsets of records are printed with slp --offsets, which also prints the notes of each record as its annotations attribute:
+
+  $ slp --offsets app.log
+  $ slp annotate app.log --at-offset 1520 --note "root cause"
+  $ slp app.log`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if annotateNoteFlag == "" {
+			if cmd.Flags().Changed("at-offset") {
+				return fmt.Errorf("a note is required")
+			}
+			return listAnnotations(cmd.OutOrStdout(), args[0])
+		}
+		if annotateOffsetFlag < 0 {
+			return fmt.Errorf("the offset of the annotated record is required")
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		// Find the record at the offset, to check that there is one, and
+		// identify it should the file be rewritten.
+		id, err := recordIDAt(cmd.Context(), input, annotateOffsetFlag, readOptions(cmd)...)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return fmt.Errorf("no record at offset %d of %s", annotateOffsetFlag, args[0])
+		}
+
+		return appendAnnotation(args[0], annotation{
+			Offset:   annotateOffsetFlag,
+			RecordID: id,
+			Time:     time.Now().UTC(),
+			Note:     annotateNoteFlag,
+		})
+	},
+}
+
+// recordIDAt returns the RecordID of the record at the offset of the
+// input, or an empty string if no record starts at the offset.
+func recordIDAt(ctx context.Context, input io.Reader, at int64, opts ...slogproto.ReadOption) (string, error) {
+	var (
+		offset int64
+		id     string
+		idErr  error
+	)
+	err := slogproto.Read(ctx, input, func(r *slog.Record) bool {
+		if offset == at {
+			id, idErr = slogproto.RecordID(*r)
+		}
+		return offset < at
+	}, append(opts, slogproto.WithRecordOffsets(func(o int64) {
+		offset = o
+	}))...)
+	if err = errors.Join(err, idErr); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// annotation is a note added to a record of a log file, as written to its
+// sidecar file, one per line.
+type annotation struct {
+	Offset   int64     `json:"offset"`
+	RecordID string    `json:"record_id"`
+	Time     time.Time `json:"time"`
+	Note     string    `json:"note"`
+}
+
+// annotationsPath returns the path of the sidecar file holding the
+// annotations of the named log file.
+func annotationsPath(name string) string {
+	return name + ".notes"
+}
+
+// appendAnnotation appends the annotation to the sidecar file of the named
+// log file, creating it if needed.
+func appendAnnotation(name string, a annotation) error {
+	f, err := os.OpenFile(annotationsPath(name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open annotations file: %w", err)
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write annotation: %w", err)
+	}
+	return f.Close()
+}
+
+// readAnnotations returns the annotations of the named log file, in the
+// order they were added, or none if it has no sidecar file.
+func readAnnotations(name string) ([]annotation, error) {
+	f, err := os.Open(annotationsPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open annotations file: %w", err)
+	}
+	defer f.Close()
+
+	var annotations []annotation
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var a annotation
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			return nil, fmt.Errorf("error reading annotations file %q: line %d: %w", annotationsPath(name), n, err)
+		}
+		annotations = append(annotations, a)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading annotations file %q: %w", annotationsPath(name), err)
+	}
+	return annotations, nil
+}
+
+// notesByOffset returns the notes of the annotations by the offsets of
+// their records.
+func notesByOffset(annotations []annotation) map[int64][]string {
+	notes := make(map[int64][]string, len(annotations))
+	for _, a := range annotations {
+		notes[a.Offset] = append(notes[a.Offset], a.Note)
+	}
+	return notes
+}
+
+// listAnnotations prints the annotations of the named log file, ordered by
+// the offsets of their records.
+func listAnnotations(w io.Writer, name string) error {
+	annotations, err := readAnnotations(name)
+	if err != nil {
+		return err
+	}
+	if len(annotations) == 0 {
+		return fmt.Errorf("no annotations of %s", name)
+	}
+
+	slices.SortStableFunc(annotations, func(a, b annotation) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "offset\tannotated\tnote")
+	for _, a := range annotations {
+		fmt.Fprintf(tw, "%d\t%v\t%s\n", a.Offset, renderTime(a.Time, time.RFC3339), a.Note)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRecordIDAt(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader())
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		r := slog.NewRecord(base.Add(time.Duration(i)*time.Second), slog.LevelInfo, fmt.Sprint("request ", i), 0)
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+		id, err := slogproto.RecordID(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	var offsets []int64
+	err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(*slog.Record) bool {
+		return true
+	}, slogproto.WithRecordOffsets(func(o int64) {
+		offsets = append(offsets, o)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first record follows the stream header.
+	if offsets[0] == 0 {
+		t.Fatal("expected the first record after the stream header")
+	}
+
+	for i, offset := range offsets {
+		id, err := recordIDAt(context.Background(), bytes.NewReader(buf.Bytes()), offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != ids[i] {
+			t.Errorf("offset %d: expected record %q, got %q", offset, ids[i], id)
+		}
+	}
+
+	// No record starts within a record, or past the last one.
+	for _, offset := range []int64{0, offsets[1] + 1, int64(buf.Len())} {
+		id, err := recordIDAt(context.Background(), bytes.NewReader(buf.Bytes()), offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			t.Errorf("offset %d: expected no record, got %q", offset, id)
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+
+	// A log file without annotations has none.
+	annotations, err := readAnnotations(name)
+	if err != nil || annotations != nil {
+		t.Fatalf("expected no annotations, got %v, %v", annotations, err)
+	}
+	if err := listAnnotations(&bytes.Buffer{}, name); err == nil {
+		t.Fatal("expected an error listing no annotations")
+	}
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, a := range []annotation{
+		{Offset: 120, RecordID: "b", Time: at, Note: "retry"},
+		{Offset: 30, RecordID: "a", Time: at.Add(time.Minute), Note: "first error"},
+		{Offset: 120, RecordID: "b", Time: at.Add(2 * time.Minute), Note: "root cause"},
+	} {
+		if err := appendAnnotation(name, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	annotations, err = readAnnotations(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 3 || annotations[0].Note != "retry" || !annotations[2].Time.Equal(at.Add(2*time.Minute)) {
+		t.Fatalf("unexpected annotations %+v", annotations)
+	}
+
+	// Notes of the same record are kept in the order they were added.
+	if got, want := fmt.Sprint(notesByOffset(annotations)), "map[30:[first error] 120:[retry root cause]]"; got != want {
+		t.Fatalf("expected notes %s, got %s", want, got)
+	}
+
+	var buf bytes.Buffer
+	if err := listAnnotations(&buf, name); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"offset  annotated             note",
+		"30      2024-01-02T03:05:05Z  first error",
+		"120     2024-01-02T03:04:05Z  retry",
+		"120     2024-01-02T03:06:05Z  root cause",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	// Blank lines are skipped, and invalid lines reported.
+	f, err := os.OpenFile(annotationsPath(name), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("\n{\"offset\":\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := readAnnotations(name); err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Fatalf("expected an error at line 5, got %v", err)
+	}
+}
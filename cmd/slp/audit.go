@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditPublicKeyFlag     string
+	auditAllowUnsignedFlag bool
+)
+
+func init() {
+	auditVerifyCmd.Flags().StringVarP(&auditPublicKeyFlag, "public-key", "k", "", "file containing the 32 byte Ed25519 public key of checkpoints, raw or hex encoded")
+	auditVerifyCmd.Flags().BoolVar(&auditAllowUnsignedFlag, "allow-unsigned", false, "accept records after the last checkpoint, such as those of a log still being written")
+
+	rootCmd.AddCommand(auditVerifyCmd)
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "audit-verify [file]",
+	Short: "Verify the hash chain of an audit log",
+	Long:  `Reads an audit log (or STDIN) written by a handler configured with WithAuditChain, checking that each record includes the hash of the previous one, which proves that no record was removed, reordered or modified, and that its checkpoints are signed by the private key of the public key. With a public key, a log without a signed checkpoint is rejected, since anyone could have rewritten its chain, and so are records after the last checkpoint, which could have been forged or removed from the end of the log without breaking the chain, unless --allow-unsigned is given.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var pub ed25519.PublicKey
+		if auditPublicKeyFlag != "" {
+			var err error
+			pub, err = readPublicKeyFile(auditPublicKeyFlag)
+			if err != nil {
+				return err
+			}
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		var opts []slogproto.AuditVerifyOption
+		if auditAllowUnsignedFlag {
+			opts = append(opts, slogproto.AllowUnsignedTail())
+		}
+
+		report, err := slogproto.VerifyAuditLog(cmd.Context(), input, pub, opts...)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "valid: %d records", report.Records)
+		if pub != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), ", %d signed checkpoints, %d records after the last checkpoint", report.Checkpoints, report.Unsigned)
+		}
+		fmt.Fprintln(cmd.OutOrStdout())
+		return nil
+	},
+}
+
+// readPublicKeyFile returns the Ed25519 public key in the named file,
+// which is either raw or hex encoded.
+func readPublicKeyFile(name string) (ed25519.PublicKey, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key file: %w", err)
+	}
+
+	if len(b) != ed25519.PublicKeySize {
+		b, err = hex.DecodeString(string(bytes.TrimSpace(b)))
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key: expected %d bytes, raw or hex encoded", ed25519.PublicKeySize)
+		}
+	}
+
+	return ed25519.PublicKey(b), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compactOutputFlag string
+	compactCodecFlag  string
+	compactBlockFlag  int
+)
+
+func init() {
+	compactCmd.Flags().StringVarP(&compactOutputFlag, "output", "o", "", "file to write the compacted log to")
+	compactCmd.Flags().StringVar(&compactCodecFlag, "codec", "zstd", "compression codec: none, gzip, snappy or zstd")
+
+	compactCmd.Flags().IntVar(&compactBlockFlag, "block-size", 0, "compress zstd output in independently readable blocks of this many bytes of records")
+
+	rootCmd.AddCommand(compactCmd)
+}
+
+var compactCmd = &cobra.Command{
+	Use:   "compact [file]",
+	Short: "Recompress a log file for archival",
+	Long:  `Rewrites a log file (or STDIN), which may already be compressed, using the given compression codec, optionally in independently readable zstd blocks, validating every record and reporting the size reduction. Compressed files can be read directly by slp.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if compactOutputFlag == "" {
+			return fmt.Errorf("an output file is required")
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		f, err := os.OpenFile(compactOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer f.Close()
+
+		output := &countingWriter{w: f}
+
+		var w io.WriteCloser
+		if compactBlockFlag > 0 {
+			if compactCodecFlag != "zstd" {
+				return fmt.Errorf("--block-size requires the zstd codec")
+			}
+			w, err = slogproto.NewBlockWriter(output, compactBlockFlag, zstdDict)
+		} else {
+			w, err = newCompressor(compactCodecFlag, output)
+		}
+		if err != nil {
+			return err
+		}
+
+		records, err := slogproto.CopyFiltered(cmd.Context(), w, input, nil, readOptions(cmd)...)
+		if err != nil {
+			return err
+		}
+
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("error flushing compressed output: %w", err)
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		var inputSize int64
+		if len(args) > 0 {
+			if fi, err := os.Stat(args[0]); err == nil {
+				inputSize = fi.Size()
+			}
+		}
+
+		if inputSize > 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "compacted %d records: %s -> %s (%.1f%%)\n", records, humanSize(inputSize), humanSize(output.n), float64(output.n)/float64(inputSize)*100)
+		} else {
+			fmt.Fprintf(cmd.ErrOrStderr(), "compacted %d records: %s\n", records, humanSize(output.n))
+		}
+
+		return nil
+	},
+}
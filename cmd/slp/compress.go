@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/picatz/slogproto"
+	_ "github.com/picatz/slogproto/codec/snappy"
+)
+
+// zstdDict is the dictionary used to compress and decompress zstd data,
+// loaded from the file given with --dict, if any.
+var zstdDict []byte
+
+// loadDict loads the zstd dictionary from the file at path, if any.
+func loadDict(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	dict, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	zstdDict = dict
+	return nil
+}
+
+// codecOptions returns the options of the codecs compressing and
+// decompressing data, with the dictionary loaded from --dict, if any.
+func codecOptions() *slogproto.CodecOptions {
+	return &slogproto.CodecOptions{Dict: zstdDict}
+}
+
+// newCompressor returns a writer which compresses data written to it with
+// the named codec and writes it to w. Close must be called to flush it.
+func newCompressor(codec string, w io.Writer) (io.WriteCloser, error) {
+	if codec == "" {
+		codec = "none"
+	}
+	return slogproto.NewCodecWriter(codec, w, codecOptions())
+}
+
+// decompress returns a reader which transparently decompresses r if it
+// starts with the magic number of a registered codec, and otherwise
+// returns the data as is. It also returns the name of the codec.
+func decompress(r io.Reader) (io.Reader, string, error) {
+	codec, br, err := slogproto.DetectCodec(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dr, err := codec.NewReader(br, codecOptions())
+	return dr, codec.Name, err
+}
+
+// decompressReader is a reader which decompresses its input like
+// decompress, but only detects the compression format once it is first
+// read, so that opening a pipe doesn't wait for its first bytes.
+//
+// Uncompressed files keep their Stat method, so that read progress can
+// report the total size of the input.
+type decompressReader struct {
+	r   io.Reader
+	dr  io.Reader
+	err error
+}
+
+// detect detects the compression format of the input, once.
+func (d *decompressReader) detect() error {
+	if d.dr == nil && d.err == nil {
+		d.dr, _, d.err = decompress(d.r)
+		if d.err != nil {
+			d.err = fmt.Errorf("failed to decompress input: %w", d.err)
+		}
+	}
+	return d.err
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if err := d.detect(); err != nil {
+		return 0, err
+	}
+	return d.dr.Read(p)
+}
+
+func (d *decompressReader) Stat() (fs.FileInfo, error) {
+	f, ok := d.r.(*os.File)
+	if !ok {
+		return nil, errors.New("input is not a file")
+	}
+
+	// Only regular files are read to detect their compression here, since
+	// reading from them doesn't block.
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return fi, err
+	}
+
+	if err := d.detect(); err != nil {
+		return nil, err
+	}
+	if _, ok := d.dr.(*bufio.Reader); !ok {
+		return nil, errors.New("input is compressed")
+	}
+	return fi, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configPath returns the path of the configuration file, which is named by
+// the SLP_CONFIG environment variable, or else is slp/config.yaml in the
+// XDG configuration directory (~/.config by default).
+func configPath() (string, error) {
+	if path := os.Getenv("SLP_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "slp", "config.yaml"), nil
+}
+
+// applyConfig sets the flags of the command that weren't given on the
+// command line from the environment and the configuration file, in that
+// order of precedence.
+//
+// A flag is set by the SLP_<COMMAND>_<FLAG> environment variable, such as
+// SLP_STATS_OUTPUT, or by the flag's name in a section of the
+// configuration file named after the command. The global flags of every
+// command, and the flags of slp itself, are also set by the SLP_<FLAG>
+// environment variable, such as SLP_PROGRESS, or by the flag's name at the
+// top level of the configuration file. Flags of a single command, such as
+// --output, can't be set for every command this way:
+//
+//	progress: true
+//
+//	stats:
+//	  output: csv
+//	  percentiles: attrs.duration_ms
+func applyConfig(cmd *cobra.Command) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	config := map[string]string{}
+
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		config, err = parseConfig(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("error reading configuration file %q: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("error opening configuration file: %w", err)
+	}
+
+	var section string
+	if cmd.HasParent() {
+		section = cmd.Name()
+	}
+
+	var errs []error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			return
+		}
+
+		global := section == "" || cmd.Root().PersistentFlags().Lookup(flag.Name) != nil
+		value, source, ok := configValue(config, section, flag.Name, global)
+		if !ok {
+			return
+		}
+
+		if err := cmd.Flags().Set(flag.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for --%s from %s: %w", value, flag.Name, source, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// configValue returns the value of the named flag of the command in the
+// given section from the environment or the configuration, along with a
+// description of where it came from. Only global flags are set from the
+// top level.
+func configValue(config map[string]string, section, name string, global bool) (value, source string, ok bool) {
+	var keys []string
+	if section != "" {
+		keys = append(keys, section+"_"+name)
+	}
+	if global {
+		keys = append(keys, name)
+	}
+
+	for _, key := range keys {
+		env := "SLP_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+		if value, ok := os.LookupEnv(env); ok {
+			return value, env, true
+		}
+	}
+
+	if section != "" {
+		if value, ok := config[section+"."+name]; ok {
+			return value, "the configuration file", true
+		}
+	}
+
+	if value, ok := config[name]; ok && global {
+		return value, "the configuration file", true
+	}
+
+	return "", "", false
+}
+
+// parseConfig parses a configuration file, which is the subset of YAML
+// made of "key: value" pairs, optionally nested one level deep in
+// sections, and comments on lines of their own. Nested keys are returned
+// as "section.key".
+func parseConfig(r io.Reader) (map[string]string, error) {
+	config := map[string]string{}
+
+	var section string
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", n)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		// A key without a value, or followed by a comment, opens a section.
+		opens := value == "" || strings.HasPrefix(value, "#")
+		value = unquote(value)
+
+		indented := line[0] == ' ' || line[0] == '\t'
+		switch {
+		case indented && section == "":
+			return nil, fmt.Errorf("line %d: unexpected indentation", n)
+		case indented:
+			key = section + "." + key
+		case opens:
+			section = key
+			continue
+		default:
+			section = ""
+		}
+
+		config[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// unquote removes the quotes around a value that is a single quoted
+// string, where a quote is written twice, or a double quoted string, with
+// escapes. A value starting with # is a comment, and is empty. Other
+// values are kept as they are, even if they contain a # or start and end
+// with quotes, so that filter expressions such as "a" == "b", and strings
+// containing a #, aren't changed.
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		inner := value[1 : len(value)-1]
+		if !strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+			return strings.ReplaceAll(inner, "''", "'")
+		}
+	}
+
+	if strings.HasPrefix(value, `"`) {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+
+	if strings.HasPrefix(value, "#") {
+		return ""
+	}
+
+	return value
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  map[string]string
+		err   string
+	}{
+		"top level": {
+			input: "filter: level == \"ERROR\"\nprogress: true\n",
+			want:  map[string]string{"filter": `level == "ERROR"`, "progress": "true"},
+		},
+		"sections": {
+			input: "progress: true\nstats:\n  output: csv\n\tpercentiles: attrs.duration_ms\nfilter: msg != \"\"\n",
+			want: map[string]string{
+				"progress":          "true",
+				"stats.output":      "csv",
+				"stats.percentiles": "attrs.duration_ms",
+				"filter":            `msg != ""`,
+			},
+		},
+		"comments": {
+			input: "# slp configuration\n\nstats: # per command\n  # the output format\n  output: csv\n",
+			want:  map[string]string{"stats.output": "csv"},
+		},
+		"hash in values": {
+			input: "filter: msg.contains(\" #1\")\nlabel: a #b\n",
+			want:  map[string]string{"filter": `msg.contains(" #1")`, "label": "a #b"},
+		},
+		"quoted": {
+			input: "filter: 'attrs.tag == \"#x\"'\nname: \"a: b\"\nempty: ''\n",
+			want:  map[string]string{"filter": `attrs.tag == "#x"`, "name": "a: b", "empty": ""},
+		},
+		"quotes within values": {
+			input: "filter: \"a\" == \"b\"\nname: 'it''s'\nother: 'a' + 'b'\nescaped: \"say \\\"hi\\\"\"\n",
+			want: map[string]string{
+				"filter":  `"a" == "b"`,
+				"name":    "it's",
+				"other":   "'a' + 'b'",
+				"escaped": `say "hi"`,
+			},
+		},
+		"missing colon": {
+			input: "progress true\n",
+			err:   "line 1",
+		},
+		"indented without section": {
+			input: "  output: csv\n",
+			err:   "line 1: unexpected indentation",
+		},
+		"indented after value": {
+			input: "progress: true\n  output: csv\n",
+			err:   "line 2: unexpected indentation",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseConfig(strings.NewReader(test.input))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	t.Setenv("SLP_CONFIG", writeConfig(t, "progress: true\noutput: table\n\nexport:\n  format: json\n"))
+
+	// newCommands returns a root command with a global --progress flag and
+	// a local --output flag, and an export command with its own --output
+	// and --format flags.
+	newCommands := func() (root, export *cobra.Command) {
+		root = &cobra.Command{Use: "slp"}
+		root.PersistentFlags().Bool("progress", false, "")
+		root.Flags().String("output", "", "")
+
+		export = &cobra.Command{Use: "export"}
+		export.Flags().StringP("output", "o", "", "")
+		export.Flags().String("format", "", "")
+		root.AddCommand(export)
+		return root, export
+	}
+
+	tests := map[string]struct {
+		env  map[string]string
+		want string
+	}{
+		// Top-level keys only set global flags of commands.
+		"config": {
+			want: "output=table progress=true export: format=json output= progress=true",
+		},
+		"environment": {
+			env:  map[string]string{"SLP_OUTPUT": "csv", "SLP_PROGRESS": "false", "SLP_EXPORT_FORMAT": "text"},
+			want: "output=csv progress=false export: format=text output= progress=false",
+		},
+		"command environment": {
+			env:  map[string]string{"SLP_EXPORT_OUTPUT": "out.json", "SLP_EXPORT_PROGRESS": "false"},
+			want: "output=table progress=true export: format=json output=out.json progress=false",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			var got []string
+			for i := 0; i < 2; i++ {
+				cmd, export := newCommands()
+				if i == 1 {
+					cmd = export
+					got = append(got, "export:")
+				}
+				// Parsing merges the global flags into those of the command.
+				if err := cmd.ParseFlags(nil); err != nil {
+					t.Fatal(err)
+				}
+				if err := applyConfig(cmd); err != nil {
+					t.Fatal(err)
+				}
+				cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+					got = append(got, flag.Name+"="+flag.Value.String())
+				})
+			}
+			if got := strings.Join(got, " "); got != test.want {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
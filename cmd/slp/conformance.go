@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/picatz/slogproto/conformance"
+	"github.com/spf13/cobra"
+)
+
+var conformanceGenerateFlag bool
+
+func init() {
+	conformanceCmd.Flags().BoolVar(&conformanceGenerateFlag, "generate", false, "write the corpus to the directory instead of verifying it")
+
+	rootCmd.AddCommand(conformanceCmd)
+}
+
+var conformanceCmd = &cobra.Command{
+	Use:   "conformance dir",
+	Short: "Generate or verify the conformance corpus",
+	Long: `Verifies the streams of a conformance corpus in a directory, such as streams written from the records of the corpus by an implementation of the format in another language, checking that each holds the records of its case and that invalid streams are rejected.
+
+With --generate, writes the corpus to the directory instead: a stream (.slp) and its records in the protobuf text format (.txtpb) for each case, listed in index.json, which implementations in other languages can read to prove that they are wire compatible.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if conformanceGenerateFlag {
+			return conformance.Generate(args[0])
+		}
+
+		results, err := conformance.Verify(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+				fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s: %v\n", result.Name, result.Err)
+				continue
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "ok   %s\n", result.Name)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d cases failed", failed, len(results))
+		}
+		return nil
+	},
+}
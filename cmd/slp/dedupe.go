@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var dedupeOutputFlag string
+
+func init() {
+	dedupeCmd.Flags().StringVarP(&dedupeOutputFlag, "output", "o", "", "file to write the deduplicated log to")
+
+	rootCmd.AddCommand(dedupeCmd)
+}
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe [file...]",
+	Short: "Merge log files, dropping identical records",
+	Long:  `Reads each log file (or STDIN) in turn and writes their records to a single file, dropping records identical to one already written, such as when merging overlapping copies of a log. Records are identified by their time, level, message and attributes, however they were encoded.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dedupeOutputFlag == "" {
+			return fmt.Errorf("an output file is required")
+		}
+
+		names := args
+		if len(names) == 0 {
+			names = []string{""}
+		}
+
+		readers := make([]io.Reader, 0, len(names))
+		for _, name := range names {
+			var inputArgs []string
+			if name != "" {
+				inputArgs = []string{name}
+			}
+
+			input, closeInput, err := openInput(cmd, inputArgs)
+			if err != nil {
+				return err
+			}
+			defer closeInput()
+
+			readers = append(readers, input)
+		}
+
+		f, err := os.OpenFile(dedupeOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer f.Close()
+
+		n, duplicates, err := slogproto.Dedupe(cmd.Context(), f, readers...)
+		if err != nil {
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "wrote %d records, dropped %d duplicates\n", n, duplicates)
+		return nil
+	},
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/picatz/slogproto/encryption"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyFileFlag     string
+	cryptOutputFlag string
+	generateKeyFlag bool
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{encryptCmd, decryptCmd} {
+		cmd.Flags().StringVarP(&keyFileFlag, "key-file", "k", "", "file containing a 32 byte key, raw or hex encoded")
+		cmd.Flags().StringVarP(&cryptOutputFlag, "output", "o", "", "file to write to (default STDOUT)")
+		rootCmd.AddCommand(cmd)
+	}
+
+	encryptCmd.Flags().BoolVar(&generateKeyFlag, "generate-key", false, "generate a new hex encoded key, write it to the key file, and exit")
+}
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt [file]",
+	Short: "Encrypt a log file",
+	Long:  `Encrypts a file (or STDIN) with AES-256-GCM using the key in the key file, so archives can be protected at rest after they were written.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if generateKeyFlag {
+			return generateKeyFile(keyFileFlag)
+		}
+
+		key, err := readKeyFile(keyFileFlag)
+		if err != nil {
+			return err
+		}
+
+		return transform(cmd, args, func(dst io.Writer, src io.Reader) error {
+			w, err := encryption.NewWriter(dst, key)
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(w, src); err != nil {
+				return err
+			}
+
+			return w.Close()
+		})
+	},
+}
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt [file]",
+	Short: "Decrypt a log file",
+	Long:  `Decrypts a file (or STDIN) encrypted with the encrypt command using the key in the key file. The output can be piped to slp for viewing.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := readKeyFile(keyFileFlag)
+		if err != nil {
+			return err
+		}
+
+		return transform(cmd, args, func(dst io.Writer, src io.Reader) error {
+			r, err := encryption.NewReader(src, key)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(dst, r)
+			return err
+		})
+	},
+}
+
+// transform opens the input given by the arguments and the output given by
+// the --output flag, and calls fn to copy from one to the other.
+func transform(cmd *cobra.Command, args []string, fn func(dst io.Writer, src io.Reader) error) error {
+	input, closeInput, err := openRawInput(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	if cryptOutputFlag == "" {
+		return fn(cmd.OutOrStdout(), input)
+	}
+
+	f, err := os.OpenFile(cryptOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	if err := fn(f, input); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readKeyFile reads and parses the key in the named file.
+func readKeyFile(name string) ([]byte, error) {
+	if name == "" {
+		return nil, fmt.Errorf("a key file is required")
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	return encryption.ParseKey(b)
+}
+
+// generateKeyFile writes a new hex encoded key to the named file, which
+// must not already exist.
+func generateKeyFile(name string) error {
+	if name == "" {
+		return fmt.Errorf("a key file is required")
+	}
+
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(f, "%x\n", key); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
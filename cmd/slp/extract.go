@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	extractSinceFlag  string
+	extractUntilFlag  string
+	extractOutputFlag string
+)
+
+func init() {
+	extractCmd.Flags().StringVar(&extractSinceFlag, "since", "", "only include records at or after this time (RFC 3339, or a duration ago such as 2h)")
+	extractCmd.Flags().StringVar(&extractUntilFlag, "until", "", "only include records before this time (RFC 3339, or a duration ago such as 1h)")
+	extractCmd.Flags().StringVarP(&extractOutputFlag, "output", "o", "", "file to write the extracted records to")
+	addFilterFlag(extractCmd)
+
+	rootCmd.AddCommand(extractCmd)
+}
+
+var extractCmd = &cobra.Command{
+	Use:   "extract [file]",
+	Short: "Copy the records in a time range to a new file",
+	Long:  `Copies the records of a log file (or STDIN) within a time range, and matching the filter expression if given, unchanged to a new slogproto file.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if extractOutputFlag == "" {
+			return fmt.Errorf("an output file is required")
+		}
+
+		if extractSinceFlag == "" && extractUntilFlag == "" {
+			return fmt.Errorf("at least one of --since or --until is required")
+		}
+
+		expr, since, err := extractFilter(extractSinceFlag, extractUntilFlag, filterFlag, time.Now())
+		if err != nil {
+			return err
+		}
+
+		filterProg, err := compileFilter(expr)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		// Skip to the block holding the first records in the range of
+		// files written with block compression.
+		if !since.IsZero() && len(args) > 0 {
+			br, closeBlocks, err := openBlocks(args[0])
+			if err != nil {
+				return err
+			}
+			if br != nil {
+				defer closeBlocks()
+
+				i, err := br.Search(since)
+				if err != nil {
+					return err
+				}
+				input = br.NewReader(i)
+			}
+		}
+
+		f, err := os.OpenFile(extractOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer f.Close()
+
+		records, err := slogproto.CopyFiltered(cmd.Context(), f, input, filterProg, readOptions(cmd)...)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "extracted %d records\n", records)
+
+		return f.Close()
+	},
+}
+
+// extractFilter returns the filter expression of the extract command,
+// selecting the records from the --since time and before the --until
+// time, either of which may be empty, that match the filter expression,
+// if any. It also returns the since time, or the zero time if not given.
+func extractFilter(sinceFlag, untilFlag, filter string, now time.Time) (string, time.Time, error) {
+	var (
+		conditions []string
+		since      time.Time
+		err        error
+	)
+
+	if sinceFlag != "" {
+		since, err = parseTime(sinceFlag, now)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid --since time %q: %w", sinceFlag, err)
+		}
+		conditions = append(conditions, "time >= timestamp("+strconv.Quote(since.Format(time.RFC3339Nano))+")")
+	}
+
+	if untilFlag != "" {
+		until, err := parseTime(untilFlag, now)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid --until time %q: %w", untilFlag, err)
+		}
+		conditions = append(conditions, "time < timestamp("+strconv.Quote(until.Format(time.RFC3339Nano))+")")
+	}
+
+	if filter != "" {
+		conditions = append(conditions, "("+filter+")")
+	}
+
+	return strings.Join(conditions, " && "), since, nil
+}
+
+// openBlocks returns a block reader of the named file, along with a
+// function to close it, if it is a zstd stream of several independent
+// blocks, or nil otherwise.
+func openBlocks(name string) (*slogproto.BlockReader, func() error, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	if codec, _, err := slogproto.DetectCodec(io.NewSectionReader(f, 0, fi.Size())); err != nil || codec.Name != "zstd" {
+		f.Close()
+		return nil, nil, nil
+	}
+
+	br, err := slogproto.NewBlockReader(f, fi.Size(), zstdDict)
+	if err != nil {
+		f.Close()
+		return nil, nil, nil
+	}
+	if br.Len() < 2 {
+		br.Close()
+		f.Close()
+		return nil, nil, nil
+	}
+
+	return br, func() error {
+		br.Close()
+		return f.Close()
+	}, nil
+}
+
+// parseTime parses a time given either in RFC 3339 format, or as a duration
+// before now, such as "2h" or "7d".
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	d, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a duration")
+	}
+
+	return now.Add(-d), nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		want time.Time
+		err  bool
+	}{
+		"2024-01-01T10:30:00Z":        {want: time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)},
+		"2024-01-01T10:30:00.5+02:00": {want: time.Date(2024, 1, 1, 8, 30, 0, 5e8, time.UTC)},
+		"2h":                          {want: now.Add(-2 * time.Hour)},
+		"90s":                         {want: now.Add(-90 * time.Second)},
+		"7d":                          {want: now.Add(-7 * 24 * time.Hour)},
+		"1w":                          {want: now.Add(-7 * 24 * time.Hour)},
+		"2024-01-01":                  {err: true},
+		"yesterday":                   {err: true},
+	}
+
+	for s, test := range tests {
+		got, err := parseTime(s, now)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %v", s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", s, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%q: expected %v, got %v", s, test.want, got)
+		}
+	}
+}
+
+func TestExtractFilter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		since, until, filter string
+		// want is the hours, before now, of the records selected among
+		// those at each of the last 4 hours, alternating between info
+		// and error records.
+		want string
+		err  string
+	}{
+		"since": {
+			since: "2h",
+			want:  "[2 1 0]",
+		},
+		"until": {
+			until: now.Add(-2 * time.Hour).Format(time.RFC3339),
+			want:  "[4 3]",
+		},
+		"since and until": {
+			since: "3h",
+			until: "1h",
+			want:  "[3 2]",
+		},
+		"filter": {
+			since:  "3h",
+			filter: `level == "ERROR" || msg == "other"`,
+			want:   "[3 1]",
+		},
+		"invalid since": {
+			since: "soon",
+			err:   `invalid --since time "soon": expected an RFC 3339 time or a duration`,
+		},
+		"invalid until": {
+			since: "1h",
+			until: "later",
+			err:   `invalid --until time "later": expected an RFC 3339 time or a duration`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, since, err := extractFilter(test.since, test.until, test.filter, now)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (test.since == "") != since.IsZero() {
+				t.Fatalf("unexpected since time %v", since)
+			}
+
+			prog, err := slogproto.CompileFilter(expr)
+			if err != nil {
+				t.Fatalf("%s: %v", expr, err)
+			}
+
+			var got []int
+			for hours := 4; hours >= 0; hours-- {
+				level := slog.LevelInfo
+				if hours%2 == 1 {
+					level = slog.LevelError
+				}
+				r := slog.NewRecord(now.Add(-time.Duration(hours)*time.Hour), level, "request", 0)
+				ok, err := slogproto.EvalFilter(prog, &r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok {
+					got = append(got, hours)
+				}
+			}
+			if fmt.Sprint(got) != test.want {
+				t.Fatalf("%s: expected records %s, got %v", expr, test.want, got)
+			}
+		})
+	}
+}
+
+func TestOpenBlocks(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// writeLog writes records, an hour apart, to the named file, through
+	// a block writer if the block size is positive.
+	writeLog := func(name string, blockSize int) {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var w io.Writer = f
+		var bw *slogproto.BlockWriter
+		if blockSize > 0 {
+			if bw, err = slogproto.NewBlockWriter(f, blockSize, nil); err != nil {
+				t.Fatal(err)
+			}
+			w = bw
+		}
+
+		h := slogproto.NewHandler(w, nil)
+		for i := 0; i < 10; i++ {
+			r := slog.NewRecord(base.Add(time.Duration(i)*time.Hour), slog.LevelInfo, fmt.Sprint(i), 0)
+			if err := h.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if bw != nil {
+			if err := bw.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	writeLog("plain.log", 0)
+	writeLog("single.log.zst", 1<<20)
+	writeLog("blocks.log.zst", 1)
+
+	for _, name := range []string{"plain.log", "single.log.zst"} {
+		br, _, err := openBlocks(filepath.Join(dir, name))
+		if err != nil || br != nil {
+			t.Fatalf("%s: expected no block reader, got %v, %v", name, br, err)
+		}
+	}
+
+	br, closeBlocks, err := openBlocks(filepath.Join(dir, "blocks.log.zst"))
+	if err != nil || br == nil {
+		t.Fatalf("expected a block reader, got %v, %v", br, err)
+	}
+	defer closeBlocks()
+
+	i, err := br.Search(base.Add(7 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs []string
+	err = slogproto.Read(context.Background(), br.NewReader(i), func(r *slog.Record) bool {
+		msgs = append(msgs, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reading starts from the previous block, which may end with records
+	// in the range, and the filter skips the records before it.
+	if got, want := fmt.Sprint(msgs), "[6 7 8 9]"; got != want {
+		t.Fatalf("expected records %s, got %s", want, got)
+	}
+
+	if _, _, err := openBlocks(filepath.Join(dir, "missing.log")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
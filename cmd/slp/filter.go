@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var filterOutputFlag string
+
+func init() {
+	filterCmd.Flags().StringVarP(&filterOutputFlag, "output", "o", "", "file to append matching records to (default STDOUT)")
+	addFilterFlag(filterCmd)
+
+	rootCmd.AddCommand(filterCmd)
+}
+
+var filterCmd = &cobra.Command{
+	Use:   "filter [file]",
+	Short: "Copy matching records to another slogproto stream",
+	Long:  `Reads protobuf messages from STDIN or a file and appends the records matching the filter expression, unchanged, to a slogproto output file or STDOUT. When reading from a stream, matching records are written as they arrive, maintaining a filtered view of the stream (e.g. an errors-only file).`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if filterFlag == "" {
+			return fmt.Errorf("a filter expression is required")
+		}
+
+		filterProg, err := compileFilter(filterFlag)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		var output io.Writer = cmd.OutOrStdout()
+		if filterOutputFlag != "" {
+			f, err := os.OpenFile(filterOutputFlag, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open output file: %w", err)
+			}
+			defer f.Close()
+
+			output = f
+		}
+
+		_, err = slogproto.CopyFiltered(cmd.Context(), output, input, filterProg, readOptions(cmd)...)
+		return err
+	},
+}
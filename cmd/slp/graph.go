@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/aggregate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphMetricFlag string
+	graphByFlag     string
+	graphWindowFlag time.Duration
+	graphOutputFlag string
+	graphHeightFlag int
+)
+
+func init() {
+	graphCmd.Flags().StringVar(&graphMetricFlag, "metric", "count()", "aggregation to graph for each window, such as count() or p99(attrs.duration_ms)")
+	graphCmd.Flags().StringVar(&graphByFlag, "by", "", "level, msg or an attribute, such as attrs.endpoint, to graph a series for each value of")
+	graphCmd.Flags().DurationVar(&graphWindowFlag, "window", time.Minute, "duration of the windows of time the metric is computed for")
+	graphCmd.Flags().StringVarP(&graphOutputFlag, "output", "o", "ascii", "output format: ascii or png, written to STDOUT")
+	graphCmd.Flags().IntVar(&graphHeightFlag, "height", 12, "height of ascii charts, in lines")
+	addFilterFlag(graphCmd)
+
+	rootCmd.AddCommand(graphCmd)
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [file]",
+	Short: "Chart a metric of records over time",
+	Long:  `Reads protobuf messages from STDIN or a file and charts an aggregation of the records, such as their number, in consecutive windows of time, with a series for each value of the --by field, so that incidents can be investigated when only their logs were retained. Windows without records are charted as zero for count() and left out of other series.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if graphWindowFlag <= 0 {
+			return fmt.Errorf("the window must be positive")
+		}
+		if graphOutputFlag != "ascii" && graphOutputFlag != "png" {
+			return fmt.Errorf("unknown output format %q: expected ascii or png", graphOutputFlag)
+		}
+
+		metric, err := aggregate.ParseAggregation(graphMetricFlag)
+		if err != nil {
+			return err
+		}
+		var attrs []string
+		if attr, ok := metric.Attr(); ok {
+			metric.Arg = attrPath(attr)
+			attrs = append(attrs, metric.Arg)
+		}
+
+		filterProg, err := compileFilter(filterFlag)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		windows := make(map[time.Time]map[string]*aggregate.Aggregator)
+		err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+			if r.Time.IsZero() {
+				return true
+			}
+			start := r.Time.Truncate(graphWindowFlag)
+			series, ok := windows[start]
+			if !ok {
+				series = make(map[string]*aggregate.Aggregator)
+				windows[start] = series
+			}
+			key := seriesKey(r, graphByFlag)
+			agg, ok := series[key]
+			if !ok {
+				agg = aggregate.New(attrs...)
+				series[key] = agg
+			}
+			agg.Add(r)
+			return true
+		}, append(readOptions(cmd), slogproto.WithFilter(filterProg))...)
+		if err != nil {
+			return err
+		}
+		if len(windows) == 0 {
+			return fmt.Errorf("no records with a time to graph")
+		}
+
+		chart := newChart(metric, windows, graphWindowFlag)
+		if graphOutputFlag == "png" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s from 0 to %s, %v to %v\n", chart.title, formatFloat(chart.max), renderTime(chart.starts[0], time.RFC3339), renderTime(chart.starts[len(chart.starts)-1], time.RFC3339))
+			for i, s := range chart.series {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s\t%s\n", chartColorNames[i%len(chartColors)], s.name)
+			}
+			return chart.writePNG(cmd.OutOrStdout())
+		}
+		chart.writeASCII(cmd.OutOrStdout(), graphHeightFlag)
+		return nil
+	},
+}
+
+// seriesKey returns the value of the record's field or attribute given
+// by the --by flag that its series is named after.
+func seriesKey(r *slog.Record, by string) string {
+	switch by {
+	case "":
+		return ""
+	case "level":
+		return r.Level.String()
+	case "msg":
+		return r.Message
+	}
+	if v, ok := slogproto.GetAttr(r, attrPath(by)); ok {
+		return v.String()
+	}
+	return ""
+}
+
+// chart is a series of values for each window of time, from the first
+// window with records to the last.
+type chart struct {
+	title  string
+	starts []time.Time
+	series []chartSeries
+	max    float64
+}
+
+// chartSeries is a named series of values, which are NaN for windows
+// without any.
+type chartSeries struct {
+	name   string
+	values []float64
+}
+
+// newChart returns the chart of the metric for each window and series.
+func newChart(metric aggregate.Aggregation, windows map[time.Time]map[string]*aggregate.Aggregator, window time.Duration) *chart {
+	var first, last time.Time
+	names := make(map[string]bool)
+	for start, series := range windows {
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+		for name := range series {
+			names[name] = true
+		}
+	}
+
+	c := &chart{title: fmt.Sprintf("%s per %s", metric, window)}
+	for start := first; !start.After(last); start = start.Add(window) {
+		c.starts = append(c.starts, start)
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	slices.Sort(sorted)
+
+	for _, name := range sorted {
+		s := chartSeries{name: name, values: make([]float64, len(c.starts))}
+		for i, start := range c.starts {
+			agg, ok := windows[start][name]
+			switch {
+			case ok:
+				s.values[i] = metric.Value(agg.Snapshot())
+			case metric.Func == "count":
+				s.values[i] = 0
+			default:
+				s.values[i] = math.NaN()
+			}
+			if !math.IsNaN(s.values[i]) {
+				c.max = max(c.max, s.values[i])
+			}
+		}
+		c.series = append(c.series, s)
+	}
+	return c
+}
+
+// chartGlyphs are the characters the series of ascii charts are drawn
+// with, in turn.
+var chartGlyphs = []byte("*+ox#@%&")
+
+// writeASCII writes the chart drawn with characters, with a column for
+// each window and the given number of lines for values.
+func (c *chart) writeASCII(w io.Writer, height int) {
+	height = max(height, 2)
+
+	grid := make([][]byte, height)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(" ", len(c.starts)))
+	}
+	for i, s := range c.series {
+		for col, v := range s.values {
+			if math.IsNaN(v) {
+				continue
+			}
+			row := 0
+			if c.max > 0 {
+				row = int(math.Round(v / c.max * float64(height-1)))
+			}
+			grid[height-1-row][col] = chartGlyphs[i%len(chartGlyphs)]
+		}
+	}
+
+	top, bottom := formatFloat(c.max), "0"
+	label := max(len(top), len(bottom))
+
+	fmt.Fprintln(w, c.title)
+	for i, line := range grid {
+		var y string
+		switch i {
+		case 0:
+			y = top
+		case height - 1:
+			y = bottom
+		}
+		fmt.Fprintf(w, "%*s |%s\n", label, y, line)
+	}
+	fmt.Fprintf(w, "%*s +%s\n", label, "", strings.Repeat("-", len(c.starts)))
+
+	first := renderTime(c.starts[0], time.RFC3339)
+	last := renderTime(c.starts[len(c.starts)-1], time.RFC3339)
+	fmt.Fprintf(w, "%*s  %v to %v\n", label, "", first, last)
+
+	if len(c.series) > 1 || c.series[0].name != "" {
+		legend := make([]string, 0, len(c.series))
+		for i, s := range c.series {
+			legend = append(legend, fmt.Sprintf("%c %s", chartGlyphs[i%len(chartGlyphs)], s.name))
+		}
+		fmt.Fprintln(w, strings.Join(legend, "  "))
+	}
+}
+
+// chartColors are the colors the series of png charts are drawn with, in
+// turn, and chartColorNames their names for the legend.
+var (
+	chartColors = []color.RGBA{
+		{0x1f, 0x77, 0xb4, 0xff},
+		{0xff, 0x7f, 0x0e, 0xff},
+		{0x2c, 0xa0, 0x2c, 0xff},
+		{0xd6, 0x27, 0x28, 0xff},
+		{0x94, 0x67, 0xbd, 0xff},
+		{0x8c, 0x56, 0x4b, 0xff},
+		{0xe3, 0x77, 0xc2, 0xff},
+		{0x7f, 0x7f, 0x7f, 0xff},
+	}
+	chartColorNames = []string{"blue", "orange", "green", "red", "purple", "brown", "pink", "gray"}
+)
+
+// writePNG writes the chart as a PNG image of lines, one for each series,
+// over gridlines at every quarter of the maximum value.
+func (c *chart) writePNG(w io.Writer) error {
+	const (
+		width, height = 800, 400
+		margin        = 20
+	)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	plotW, plotH := width-2*margin, height-2*margin
+	point := func(i int, v float64) (int, int) {
+		x := margin
+		if len(c.starts) > 1 {
+			x += i * plotW / (len(c.starts) - 1)
+		}
+		y := height - margin
+		if c.max > 0 {
+			y -= int(v / c.max * float64(plotH))
+		}
+		return x, y
+	}
+
+	grid := color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	for q := 1; q <= 4; q++ {
+		y := height - margin - q*plotH/4
+		drawLine(img, margin, y, width-margin, y, grid)
+	}
+	axis := color.RGBA{0x33, 0x33, 0x33, 0xff}
+	drawLine(img, margin, margin, margin, height-margin, axis)
+	drawLine(img, margin, height-margin, width-margin, height-margin, axis)
+
+	for i, s := range c.series {
+		col := chartColors[i%len(chartColors)]
+		prev := -1
+		for j, v := range s.values {
+			if math.IsNaN(v) {
+				prev = -1
+				continue
+			}
+			x, y := point(j, v)
+			if prev < 0 {
+				drawLine(img, x, y, x, y, col)
+			} else {
+				px, py := point(prev, s.values[prev])
+				drawLine(img, px, py, x, y, col)
+			}
+			prev = j
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// drawLine draws a line two pixels thick between the points.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	for e := dx + dy; ; {
+		img.SetRGBA(x0, y0, c)
+		img.SetRGBA(x0+1, y0, c)
+		img.SetRGBA(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto/aggregate"
+)
+
+func TestSeriesKey(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "slow request", 0)
+	r.Add("endpoint", "/users", slog.Group("http", "status", 503))
+
+	tests := map[string]string{
+		"":                  "",
+		"level":             "WARN",
+		"msg":               "slow request",
+		"attrs.endpoint":    "/users",
+		"endpoint":          "/users",
+		"attrs.http.status": "503",
+		"attrs.missing":     "",
+	}
+
+	for by, want := range tests {
+		if got := seriesKey(&r, by); got != want {
+			t.Errorf("%q: expected %q, got %q", by, want, got)
+		}
+	}
+}
+
+// graphWindows returns the windows of the records aggregated for a chart
+// of the metric by the field, as the graph command does.
+func graphWindows(t *testing.T, metric string, by string, window time.Duration, records ...slog.Record) (aggregate.Aggregation, map[time.Time]map[string]*aggregate.Aggregator) {
+	t.Helper()
+
+	m, err := aggregate.ParseAggregation(metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs []string
+	if attr, ok := m.Attr(); ok {
+		m.Arg = attrPath(attr)
+		attrs = append(attrs, m.Arg)
+	}
+
+	windows := make(map[time.Time]map[string]*aggregate.Aggregator)
+	for _, r := range records {
+		start := r.Time.Truncate(window)
+		if windows[start] == nil {
+			windows[start] = make(map[string]*aggregate.Aggregator)
+		}
+		key := seriesKey(&r, by)
+		if windows[start][key] == nil {
+			windows[start][key] = aggregate.New(attrs...)
+		}
+		windows[start][key].Add(&r)
+	}
+	return m, windows
+}
+
+func TestNewChart(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	record := func(minute int, level slog.Level, duration float64) slog.Record {
+		r := slog.NewRecord(base.Add(time.Duration(minute)*time.Minute), level, "request", 0)
+		r.Add("duration_ms", duration)
+		return r
+	}
+	records := []slog.Record{
+		record(0, slog.LevelInfo, 10),
+		record(0, slog.LevelInfo, 30),
+		record(0, slog.LevelError, 100),
+		record(3, slog.LevelInfo, 50),
+	}
+
+	tests := map[string]struct {
+		metric string
+		by     string
+		want   string
+	}{
+		// Windows without records count as zero.
+		"count": {
+			metric: "count()",
+			want:   "count() per 1m0s max 3: [: 3 0 0 1]",
+		},
+		"count by level": {
+			metric: "count()",
+			by:     "level",
+			want:   "count() per 1m0s max 2: [ERROR: 1 0 0 0] [INFO: 2 0 0 1]",
+		},
+		// And are left out of other series.
+		"avg by level": {
+			metric: "avg(attrs.duration_ms)",
+			by:     "level",
+			want:   "avg(duration_ms) per 1m0s max 100: [ERROR: 100 NaN NaN NaN] [INFO: 20 NaN NaN 50]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			metric, windows := graphWindows(t, test.metric, test.by, time.Minute, records...)
+			c := newChart(metric, windows, time.Minute)
+
+			if len(c.starts) != 4 || !c.starts[0].Equal(base) || !c.starts[3].Equal(base.Add(3*time.Minute)) {
+				t.Fatalf("unexpected windows %v", c.starts)
+			}
+
+			got := fmt.Sprintf("%s max %s:", c.title, formatFloat(c.max))
+			for _, s := range c.series {
+				values := make([]string, len(s.values))
+				for i, v := range s.values {
+					values[i] = formatFloat(v)
+				}
+				got += fmt.Sprintf(" [%s: %s]", s.name, strings.Join(values, " "))
+			}
+			if got != test.want {
+				t.Fatalf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestChart_writeASCII(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := &chart{
+		title:  "count() per 1m0s",
+		starts: []time.Time{base, base.Add(time.Minute), base.Add(2 * time.Minute), base.Add(3 * time.Minute)},
+		series: []chartSeries{
+			{name: "ERROR", values: []float64{4, 0, 2, math.NaN()}},
+			{name: "INFO", values: []float64{0, 1, 4, 3}},
+		},
+		max: 4,
+	}
+
+	var buf bytes.Buffer
+	c.writeASCII(&buf, 3)
+
+	want := strings.Join([]string{
+		"count() per 1m0s",
+		"4 |* ++",
+		"  | +* ",
+		"0 |+*  ",
+		"  +----",
+		"   2024-01-01T12:00:00Z to 2024-01-01T12:03:00Z",
+		"* ERROR  + INFO",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	// A single unnamed series has no legend.
+	c.series = []chartSeries{{values: c.series[1].values}}
+	buf.Reset()
+	c.writeASCII(&buf, 3)
+	if lines := strings.Count(buf.String(), "\n"); lines != 6 {
+		t.Fatalf("unexpected legend:\n%s", buf.String())
+	}
+}
+
+func TestChart_writePNG(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := &chart{
+		title:  "count() per 1m0s",
+		starts: []time.Time{base, base.Add(time.Minute), base.Add(2 * time.Minute)},
+		series: []chartSeries{{values: []float64{1, math.NaN(), 2}}},
+		max:    2,
+	}
+
+	var buf bytes.Buffer
+	if err := c.writePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 800 || size.Y != 400 {
+		t.Fatalf("unexpected image size %v", size)
+	}
+
+	// The last value is the maximum, at the top right of the plot.
+	if got, want := img.At(780, 20), chartColors[0]; got != want {
+		t.Fatalf("expected the series color %v at the last value, got %v", want, got)
+	}
+}
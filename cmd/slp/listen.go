@@ -0,0 +1,83 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listenOutputFlag string
+	listenSyslogFlag bool
+	listenModeFlag   string
+)
+
+func init() {
+	listenCmd.Flags().StringVarP(&listenOutputFlag, "output", "o", "", "slogproto file to append received records to (default STDOUT, as JSON)")
+	listenCmd.Flags().BoolVar(&listenSyslogFlag, "syslog", false, "also accept syslog messages, converting them to records")
+	listenCmd.Flags().StringVar(&listenModeFlag, "mode", "", "octal permissions of the socket, such as 0666 to accept records from every user")
+
+	rootCmd.AddCommand(listenCmd)
+}
+
+var listenCmd = &cobra.Command{
+	Use:   "listen socket",
+	Short: "Collect records sent by local processes to a Unix datagram socket",
+	Long: `Creates a Unix datagram socket and, until interrupted, appends the records local processes send to it, as slogproto frames or, with --syslog, syslog messages, to a slogproto file or STDOUT. Each record is attributed to its sender with a sender group, holding its pid, uid and gid on Linux.
+
+  $ slp listen /run/app/log.sock --syslog --mode 0666 -o collected.log`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var mode os.FileMode
+		if listenModeFlag != "" {
+			m, err := strconv.ParseUint(listenModeFlag, 8, 32)
+			if err != nil || m > 0o777 {
+				return fmt.Errorf("invalid socket mode %q", listenModeFlag)
+			}
+			mode = os.FileMode(m)
+		}
+
+		// Every record received is kept, whatever its level.
+		handlerOpts := &slog.HandlerOptions{Level: slog.Level(math.MinInt)}
+
+		var h slog.Handler
+		if listenOutputFlag != "" {
+			f, err := slogproto.OpenFile(listenOutputFlag)
+			if err != nil {
+				return fmt.Errorf("failed to open output file: %w", err)
+			}
+			defer f.Close()
+
+			h = slogproto.NewHandler(f, handlerOpts)
+		} else {
+			handlerOpts.ReplaceAttr = renderOptions().ReplaceAttr(nil)
+			h = slogproto.NewJSONHandler(cmd.OutOrStdout(), handlerOpts)
+		}
+
+		// Progress and idle time aren't reported for datagrams.
+		readOpts := []slogproto.ReadOption{slogproto.WithMaxRecordSize(maxRecordSizeFlag)}
+
+		errOut := cmd.ErrOrStderr()
+		err := slogproto.ListenUnixgram(cmd.Context(), args[0], h, &slogproto.ListenOptions{
+			Syslog:      listenSyslogFlag,
+			Mode:        mode,
+			ReadOptions: readOpts,
+			OnError: func(err error) {
+				fmt.Fprintf(errOut, "dropped datagram: %v\n", err)
+			},
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	},
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lsPatternFlag string
+	lsSampleFlag  int
+	lsOutputFlag  string
+)
+
+func init() {
+	lsCmd.Flags().StringVar(&lsPatternFlag, "pattern", "*", "glob pattern selecting the log files in the directory")
+	lsCmd.Flags().IntVar(&lsSampleFlag, "sample", 1000, "number of records to read from files without a trailer or blocks")
+	lsCmd.Flags().StringVarP(&lsOutputFlag, "output", "o", "table", "output format: table, csv or json")
+
+	rootCmd.AddCommand(lsCmd)
+}
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [dir]",
+	Short: "List the log files of a directory",
+	Long: `Lists the log files in a directory (or the current directory) with their time range, record count, size and compression, as a quick inventory.
+
+Files written with a stream trailer are summarized from their trailer, and zstd files written in blocks from their first and last blocks. Other files are summarized from their first records, and their record count is estimated from the bytes read, unless they are small enough to be read whole. Estimated counts are prefixed with "~".`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		header := []string{"name", "from", "to", "records", "size", "codec", "source"}
+		var rows [][]any
+		for _, entry := range entries {
+			if ok, err := filepath.Match(lsPatternFlag, entry.Name()); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", lsPatternFlag, err)
+			} else if !ok || !entry.Type().IsRegular() {
+				continue
+			}
+
+			s, err := summarizeFile(cmd.Context(), filepath.Join(dir, entry.Name()), lsSampleFlag)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", entry.Name(), err)
+				continue
+			}
+
+			rows = append(rows, []any{
+				entry.Name(),
+				formatTime(s.from),
+				formatTime(s.to),
+				s.formatRecords(),
+				humanSize(s.size),
+				s.codec,
+				s.source,
+			})
+		}
+
+		return writeRows(cmd.OutOrStdout(), lsOutputFlag, header, rows)
+	},
+}
+
+// fileSummary summarizes a log file listed by ls.
+type fileSummary struct {
+	size  int64
+	codec string
+
+	// from and to are the times of the first and last records, if known.
+	from, to time.Time
+
+	// records is the number of records, or -1 if unknown, which is
+	// estimated if estimated is set.
+	records   int64
+	estimated bool
+
+	// source is how the file was summarized: "trailer", "blocks",
+	// "scan" or "sample".
+	source string
+}
+
+// formatRecords returns the record count for display.
+func (s *fileSummary) formatRecords() string {
+	switch {
+	case s.records < 0:
+		return "?"
+	case s.estimated:
+		return "~" + strconv.FormatInt(s.records, 10)
+	default:
+		return strconv.FormatInt(s.records, 10)
+	}
+}
+
+// formatTime formats a time for ls, or returns an empty string if it is
+// unknown.
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return ""
+	}
+	return renderTime(t, time.RFC3339)
+}
+
+// summarizeFile summarizes the named log file, using its trailer or its
+// blocks if it has them, and otherwise reading up to sample records.
+func summarizeFile(ctx context.Context, name string, sample int) (*fileSummary, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	codec, _, err := slogproto.DetectCodec(io.NewSectionReader(f, 0, fi.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &fileSummary{size: fi.Size(), codec: codec.Name, records: -1}
+
+	switch s.codec {
+	case "none":
+		trailer, err := slogproto.ReadStreamTrailer(f, s.size)
+		if err == nil {
+			s.source = "trailer"
+			s.records = int64(trailer.Records)
+			if trailer.MinTime != nil {
+				s.from, s.to = trailer.MinTime.AsTime(), trailer.MaxTime.AsTime()
+			}
+			return s, nil
+		}
+		if !errors.Is(err, slogproto.ErrNoTrailer) && !errors.Is(err, slogproto.ErrTrailerMismatch) {
+			return nil, err
+		}
+	case "zstd":
+		br, err := slogproto.NewBlockReader(f, s.size, zstdDict)
+		if err == nil {
+			defer br.Close()
+			if br.Len() > 1 {
+				return s, summarizeBlocks(ctx, s, br)
+			}
+		}
+	}
+
+	return s, sampleFile(ctx, s, f, sample)
+}
+
+// summarizeBlocks summarizes a file written in blocks from its first and
+// last blocks, estimating its record count from theirs.
+func summarizeBlocks(ctx context.Context, s *fileSummary, br *slogproto.BlockReader) error {
+	s.source = "blocks"
+
+	var first, last int64
+	err := slogproto.Read(ctx, br.Block(0), func(r *slog.Record) bool {
+		if first == 0 {
+			s.from = r.Time
+		}
+		first++
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	err = slogproto.Read(ctx, br.Block(br.Len()-1), func(r *slog.Record) bool {
+		s.to = r.Time
+		last++
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// Every block but the last is about as full as the first.
+	s.records = first*int64(br.Len()-1) + last
+	s.estimated = true
+	return nil
+}
+
+// sampleFile summarizes a file from up to sample of its first records,
+// estimating its record count from the bytes they took if there are more.
+func sampleFile(ctx context.Context, s *fileSummary, f *os.File, sample int) error {
+	raw := &countingReader{r: f}
+	input, _, err := decompress(raw)
+	if err != nil {
+		return err
+	}
+
+	var (
+		records   int64
+		bytesRead int64
+		last      time.Time
+		stopped   bool
+	)
+	progress := slogproto.WithProgress(time.Hour, func(p slogproto.Progress) {
+		bytesRead = p.BytesRead
+	})
+	err = slogproto.Read(ctx, input, func(r *slog.Record) bool {
+		if records == 0 {
+			s.from = r.Time
+		}
+		last = r.Time
+		records++
+		if records >= int64(sample) {
+			stopped = true
+			return false
+		}
+		return true
+	}, progress)
+	if err != nil {
+		return err
+	}
+
+	if !stopped {
+		s.source = "scan"
+		s.records = records
+		s.to = last
+		return nil
+	}
+
+	s.source = "sample"
+	switch {
+	case s.codec == "none" && bytesRead > 0:
+		s.records = records * s.size / bytesRead
+		s.estimated = true
+	case s.codec != "none" && raw.n > 0:
+		s.records = records * s.size / raw.n
+		s.estimated = true
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
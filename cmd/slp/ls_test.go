@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestSummarizeFile(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// writeLog writes 10 records, a minute apart, to the named file with
+	// the handler options, through a block writer if the block size is
+	// positive.
+	writeLog := func(name string, blockSize int, options ...slogproto.HandlerOption) {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var (
+			w  io.Writer = f
+			bw *slogproto.BlockWriter
+		)
+		if blockSize > 0 {
+			if bw, err = slogproto.NewBlockWriter(f, blockSize, nil); err != nil {
+				t.Fatal(err)
+			}
+			w = bw
+		}
+
+		h := slogproto.NewHandler(w, nil, options...)
+		for i := 0; i < 10; i++ {
+			r := slog.NewRecord(base.Add(time.Duration(i)*time.Minute), slog.LevelInfo, "request", 0)
+			if err := h.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := h.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if bw != nil {
+			if err := bw.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	writeLog("trailer.log", 0, slogproto.WithStreamTrailer())
+	writeLog("blocks.log.zst", 1)
+	writeLog("plain.log", 0)
+	writeLog("app.log.gz", 0, slogproto.WithGzip())
+
+	tests := []struct {
+		name   string
+		sample int
+		want   string
+	}{
+		{"trailer.log", 1000, "trailer none 10 00:00 to 00:09"},
+		{"blocks.log.zst", 1000, "blocks zstd ~10 00:00 to 00:09"},
+		{"plain.log", 1000, "scan none 10 00:00 to 00:09"},
+		{"app.log.gz", 1000, "scan gzip 10 00:00 to 00:09"},
+		// Sampled files have no end time, and their record count is
+		// estimated from the bytes read.
+		{"plain.log", 5, "sample none ~10 00:00 to ?"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s/%d", test.name, test.sample), func(t *testing.T) {
+			s, err := summarizeFile(context.Background(), filepath.Join(dir, test.name), test.sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock := func(t time.Time) string {
+				if t.IsZero() {
+					return "?"
+				}
+				return t.Format("15:04")
+			}
+			got := fmt.Sprintf("%s %s %s %s to %s", s.source, s.codec, s.formatRecords(), clock(s.from), clock(s.to))
+			if got != test.want {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFileSummary_formatRecords(t *testing.T) {
+	tests := []struct {
+		s    fileSummary
+		want string
+	}{
+		{fileSummary{records: -1}, "?"},
+		{fileSummary{records: 0}, "0"},
+		{fileSummary{records: 42}, "42"},
+		{fileSummary{records: 42, estimated: true}, "~42"},
+	}
+
+	for _, test := range tests {
+		if got := test.s.formatRecords(); got != test.want {
+			t.Errorf("expected %q for %+v, got %q", test.want, test.s, got)
+		}
+	}
+}
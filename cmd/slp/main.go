@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/google/cel-go/cel"
 	"github.com/picatz/slogproto"
@@ -19,11 +20,43 @@ import (
 var (
 	filterFlag   string
 	logLevelFlag string
+	progressFlag bool
+
+	flattenFlag   bool
+	unflattenFlag bool
+
+	maxRecordSizeFlag int
+
+	idleTimeoutFlag time.Duration
+	idleExitFlag    bool
+
+	streamsFlag []string
+
+	dictFlag string
+
+	timeFormatFlag       string
+	durationFormatFlag   string
+	largeUintStringsFlag bool
+
+	offsetsFlag bool
 )
 
 func init() {
-	rootCmd.Flags().StringVarP(&filterFlag, "filter", "f", "", "filter expression")
+	addFilterFlag(rootCmd)
 	rootCmd.Flags().StringVarP(&logLevelFlag, "log-level", "l", "info", "log level")
+	rootCmd.PersistentFlags().BoolVar(&progressFlag, "progress", false, "report read progress to STDERR")
+	rootCmd.Flags().BoolVar(&flattenFlag, "flatten", false, "replace groups with dotted keys, such as http.request.method")
+	rootCmd.Flags().BoolVar(&unflattenFlag, "unflatten", false, "replace dotted keys with nested groups")
+	rootCmd.MarkFlagsMutuallyExclusive("flatten", "unflatten")
+	rootCmd.Flags().BoolVar(&offsetsFlag, "offsets", false, "add the offset of each record in the file, which slp annotate refers to records by, as its record_offset attribute")
+	rootCmd.Flags().StringSliceVar(&streamsFlag, "streams", nil, "only print the records of these streams of a shared file")
+	rootCmd.PersistentFlags().IntVar(&maxRecordSizeFlag, "max-record-size", slogproto.DefaultMaxRecordSize, "maximum size of a single record in bytes")
+	rootCmd.PersistentFlags().DurationVar(&idleTimeoutFlag, "idle-timeout", 0, "report to STDERR when no record has been read for the duration")
+	rootCmd.PersistentFlags().StringVar(&dictFlag, "dict", "", "zstd dictionary file, trained with train-dict, to compress and decompress with")
+	rootCmd.PersistentFlags().StringVar(&timeFormatFlag, "time-format", "", "format of exported times: rfc3339, rfc3339nano, unix, unixmilli, unixmicro, unixnano or a Go time layout")
+	rootCmd.Flags().BoolVar(&largeUintStringsFlag, "large-uint-strings", true, "write unsigned integers above the maximum int64 as strings, which JSON consumers can't lose precision of")
+	rootCmd.PersistentFlags().StringVar(&durationFormatFlag, "duration-format", "", "format of exported durations: ns, us, ms, s or human")
+	rootCmd.PersistentFlags().BoolVar(&idleExitFlag, "idle-exit", false, "exit with an error, instead of reporting, once the idle timeout is reached")
 }
 
 var rootCmd = &cobra.Command{
@@ -31,6 +64,15 @@ var rootCmd = &cobra.Command{
 	Short: "Slogproto Log Parser",
 	Long:  `SLP (Slogproto Log Parser) is a simple CLI that reads protobuf messages from STDIN or a file and prints them to STDOUT in JSON format.`,
 	Args:  cobra.MaximumNArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		if err := renderOptions().Validate(); err != nil {
+			return err
+		}
+		return loadDict(dictFlag)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logLevel, err := cmd.Flags().GetString("log-level")
 		if err != nil {
@@ -44,58 +86,178 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("error parsing log leve %q: %w", logLevel, err)
 		}
 
-		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: level,
+		logger := slog.New(slogproto.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: renderOptions().ReplaceAttr(nil),
 		}))
 
-		expr, err := cmd.Flags().GetString("filter")
+		filterProg, err := compileFilter(filterFlag)
 		if err != nil {
-			return fmt.Errorf("error getting filter flag: %w", err)
+			return fmt.Errorf("error compiling filter expression: %w", err)
 		}
 
-		filterProg, err := compileFilter(expr)
+		pipeline, err := parseTransforms(transformFlag)
 		if err != nil {
-			return fmt.Errorf("error compiling filter expression: %w", err)
+			return err
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
 		}
+		defer closeInput()
 
-		var input io.Reader = cmd.InOrStdin()
+		readOpts := append(readOptions(cmd), slogproto.WithFilter(filterProg))
+		if cmd.Flags().Changed("streams") {
+			readOpts = append(readOpts, slogproto.WithStreams(streamsFlag...))
+		}
 
-		// Check if STDIN is a pipe or not to determine if we should read from a file
-		// or from STDIN.
+		// Add the notes of annotated records, added with slp annotate, as
+		// their annotations attribute.
+		var notes map[int64][]string
 		if len(args) > 0 {
-			file := args[0]
-
-			// Open the file for reading.
-			f, err := os.Open(file)
+			annotations, err := readAnnotations(args[0])
 			if err != nil {
-				return fmt.Errorf("failed to open file: %w", err)
+				return err
 			}
-			defer f.Close()
+			notes = notesByOffset(annotations)
+		}
 
-			input = f
+		var offset int64
+		if offsetsFlag || len(notes) > 0 {
+			readOpts = append(readOpts, slogproto.WithRecordOffsets(func(o int64) {
+				offset = o
+			}))
 		}
 
 		// Read the protobuf messages from the reader and write them to
 		// STDOUT in JSON format. Only include records that match the filter
 		// expression, if one was provided.
-		err = slogproto.Read(context.Background(), input, func(r *slog.Record) bool {
-			include, err := slogproto.EvalFilter(filterProg, r)
+		var transformErr error
+		err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+			keep, err := pipeline.Apply(r)
 			if err != nil {
-				logger.Error("error evaluating filter expression", "error", err)
+				transformErr = err
 				return false
 			}
 
-			if include {
-				logger.Handler().Handle(context.Background(), *r)
+			if keep && logger.Handler().Enabled(cmd.Context(), r.Level) {
+				switch {
+				case flattenFlag:
+					*r = transformAttrs(r, slogproto.Flatten)
+				case unflattenFlag:
+					*r = transformAttrs(r, slogproto.Unflatten)
+				}
+
+				if offsetsFlag {
+					r.AddAttrs(slog.Int64("record_offset", offset))
+				}
+				if n := notes[offset]; len(n) > 0 {
+					r.AddAttrs(slog.Any("annotations", n))
+				}
+
+				logger.Handler().Handle(cmd.Context(), *r)
 			}
 
 			return true
-		})
+		}, readOpts...)
+		if err != nil {
+			return err
+		}
 
-		return err
+		return transformErr
 	},
 }
 
+// addFilterFlag adds the --filter flag to a command that only reads the
+// records matching it. Other commands don't accept it, so that it can't
+// be silently ignored by those copying or rewriting every record.
+func addFilterFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&filterFlag, "filter", "f", "", "filter expression")
+}
+
+// renderOptions returns the options for rendering exported times,
+// durations and unsigned integers given by the flags.
+func renderOptions() slogproto.RenderOptions {
+	return slogproto.RenderOptions{
+		TimeFormat:       timeFormatFlag,
+		DurationFormat:   durationFormatFlag,
+		LargeUintStrings: largeUintStringsFlag,
+	}
+}
+
+// renderTime returns the time rendered as given by the --time-format flag,
+// or else in the layout, for tabular output.
+func renderTime(t time.Time, layout string) any {
+	if timeFormatFlag == "" {
+		return t.Format(layout)
+	}
+	return renderOptions().TimeValue(t).Any()
+}
+
+// transformAttrs returns a copy of the record with its attributes replaced
+// by the result of fn.
+func transformAttrs(r *slog.Record, fn func([]slog.Attr) []slog.Attr) slog.Record {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	record.AddAttrs(fn(attrs)...)
+	return record
+}
+
+// openInput returns the file named by the first argument, or STDIN if no
+// arguments were given, along with a function to close it. Compressed
+// input is transparently decompressed once it is read.
+func openInput(cmd *cobra.Command, args []string) (io.Reader, func() error, error) {
+	input, closeInput, err := openRawInput(cmd, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &decompressReader{r: input}, closeInput, nil
+}
+
+// openRawInput returns the file named by the first argument, or STDIN if no
+// arguments were given, along with a function to close it.
+func openRawInput(cmd *cobra.Command, args []string) (io.Reader, func() error, error) {
+	if len(args) == 0 {
+		return cmd.InOrStdin(), func() error { return nil }, nil
+	}
+
+	// Open the file for reading.
+	f, err := os.Open(args[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return f, f.Close, nil
+}
+
+// readOptions returns the read options configured by the persistent flags.
+func readOptions(cmd *cobra.Command) []slogproto.ReadOption {
+	readOpts := []slogproto.ReadOption{
+		slogproto.WithMaxRecordSize(maxRecordSizeFlag),
+	}
+	if progressFlag {
+		readOpts = append(readOpts, slogproto.WithProgress(progressInterval, newProgressReporter(cmd.ErrOrStderr())))
+	}
+	if idleTimeoutFlag > 0 {
+		var report func(time.Duration) error
+		if !idleExitFlag {
+			report = func(idle time.Duration) error {
+				fmt.Fprintf(cmd.ErrOrStderr(), "no records read for %s\n", idle.Round(time.Second))
+				return nil
+			}
+		}
+		readOpts = append(readOpts, slogproto.WithIdleTimeout(idleTimeoutFlag, report))
+	}
+	return readOpts
+}
+
 func compileFilter(expr string) (cel.Program, error) {
 	if expr == "" {
 		return nil, nil
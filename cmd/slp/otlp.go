@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/picatz/slogproto/otlp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	otlpOutputFlag string
+	otlpFormatFlag string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{exportOTLPCmd, importOTLPCmd} {
+		cmd.Flags().StringVarP(&otlpOutputFlag, "output", "o", "", "file to write to (default STDOUT)")
+		cmd.Flags().StringVar(&otlpFormatFlag, "format", "json", "OTLP file format: json or proto")
+		rootCmd.AddCommand(cmd)
+	}
+}
+
+var exportOTLPCmd = &cobra.Command{
+	Use:   "export-otlp [file]",
+	Short: "Convert a log file to OpenTelemetry OTLP logs",
+	Long:  `Converts a log file (or STDIN), which may be compressed, to the OTLP JSON lines (or binary protobuf) file format of the OpenTelemetry Collector's file exporter, which its file receiver and other OpenTelemetry tooling can read.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := otlp.ParseFormat(otlpFormatFlag)
+		if err != nil {
+			return err
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		return convertOTLP(cmd, func(ctx context.Context, w io.Writer) (int64, error) {
+			return otlp.Export(ctx, w, input, format, readOptions(cmd)...)
+		})
+	},
+}
+
+var importOTLPCmd = &cobra.Command{
+	Use:   "import-otlp [file]",
+	Short: "Convert OpenTelemetry OTLP logs to a log file",
+	Long:  `Converts OTLP logs in the JSON lines (or binary protobuf) file format of the OpenTelemetry Collector's file exporter, read from a file (or STDIN), to a log file which can be read by slp. Resource attributes are recorded in a "resource" group.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := otlp.ParseFormat(otlpFormatFlag)
+		if err != nil {
+			return err
+		}
+
+		input, closeInput, err := openRawInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		return convertOTLP(cmd, func(ctx context.Context, w io.Writer) (int64, error) {
+			return otlp.Import(ctx, w, input, format)
+		})
+	},
+}
+
+// convertOTLP calls fn to write to the output given by the --output flag,
+// and reports the number of records it converted.
+func convertOTLP(cmd *cobra.Command, fn func(ctx context.Context, w io.Writer) (int64, error)) error {
+	if otlpOutputFlag == "" {
+		_, err := fn(cmd.Context(), cmd.OutOrStdout())
+		return err
+	}
+
+	f, err := os.OpenFile(otlpOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	n, err := fn(cmd.Context(), f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "converted %d records\n", n)
+	return nil
+}
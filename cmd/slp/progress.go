@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+// progressInterval is how often progress is reported while reading.
+const progressInterval = 250 * time.Millisecond
+
+// progressBarWidth is the number of characters used to render the bar.
+const progressBarWidth = 30
+
+// newProgressReporter returns a function that reports read progress to w.
+//
+// If w is a terminal, a progress bar is redrawn in place. Otherwise, a
+// line is written for each report.
+func newProgressReporter(w io.Writer) func(slogproto.Progress) {
+	if isTerminal(w) {
+		return func(p slogproto.Progress) {
+			fmt.Fprintf(w, "\r\033[K%s", formatProgress(p, true))
+			if p.TotalBytes > 0 && p.BytesRead >= p.TotalBytes {
+				fmt.Fprintln(w)
+			}
+		}
+	}
+
+	return func(p slogproto.Progress) {
+		fmt.Fprintln(w, formatProgress(p, false))
+	}
+}
+
+// formatProgress renders the progress as a single line, optionally with a
+// progress bar when the total size is known.
+func formatProgress(p slogproto.Progress, bar bool) string {
+	var b strings.Builder
+
+	if p.TotalBytes > 0 {
+		ratio := float64(p.BytesRead) / float64(p.TotalBytes)
+		if ratio > 1 {
+			ratio = 1
+		}
+
+		if bar {
+			filled := int(ratio * progressBarWidth)
+			b.WriteString("[")
+			b.WriteString(strings.Repeat("=", filled))
+			b.WriteString(strings.Repeat(" ", progressBarWidth-filled))
+			b.WriteString("] ")
+		}
+
+		fmt.Fprintf(&b, "%5.1f%% %s/%s", ratio*100, humanSize(p.BytesRead), humanSize(p.TotalBytes))
+	} else {
+		b.WriteString(humanSize(p.BytesRead))
+	}
+
+	fmt.Fprintf(&b, " %d records", p.Records)
+
+	return b.String()
+}
+
+// isTerminal reports whether w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// humanSize returns a human readable string of the given size.
+//
+// e.g. 1.2MB
+func humanSize(v int64) string {
+	var unit string
+	var size float64
+
+	switch {
+	case v >= 1<<30:
+		unit = "GB"
+		size = float64(v) / (1 << 30)
+	case v >= 1<<20:
+		unit = "MB"
+		size = float64(v) / (1 << 20)
+	case v >= 1<<10:
+		unit = "KB"
+		size = float64(v) / (1 << 10)
+	default:
+		unit = "B"
+		size = float64(v)
+	}
+
+	return fmt.Sprintf("%.2f%s", size, unit)
+}
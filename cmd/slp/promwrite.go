@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/promwrite"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promWriteConfigFlag   string
+	promWriteURLFlag      string
+	promWriteIntervalFlag time.Duration
+	promWriteLabelsFlag   map[string]string
+)
+
+func init() {
+	promWriteCmd.Flags().StringVarP(&promWriteConfigFlag, "config", "c", "", "file declaring the metric rules")
+	promWriteCmd.Flags().StringVar(&promWriteURLFlag, "url", "", "URL of the remote write endpoint, such as http://prometheus:9090/api/v1/write")
+	promWriteCmd.Flags().DurationVar(&promWriteIntervalFlag, "interval", promwrite.DefaultInterval, "interval at which metrics are pushed")
+	promWriteCmd.Flags().StringToStringVar(&promWriteLabelsFlag, "label", nil, "label added to every series, such as job=api (repeatable)")
+
+	rootCmd.AddCommand(promWriteCmd)
+}
+
+var promWriteCmd = &cobra.Command{
+	Use:   "prom-write [file]",
+	Short: "Push metrics derived from records with Prometheus remote write",
+	Long: `Reads protobuf messages from STDIN or a file, such as the live output of a program, and derives metrics from the records matching rules declared by a configuration file with a section per metric:
+
+  http_requests_total:
+    match: has(attrs.status)
+    labels: status=attrs.status, level=level
+
+  http_request_duration_seconds:
+    type: histogram
+    value: attrs.duration
+    buckets: 0.05, 0.1, 0.5, 1
+
+A rule's type is counter (the default), counting matching records or summing their value attribute, or histogram, observing their value attribute, with durations in seconds. Label values are the level, msg or an attribute of the records.
+
+The metrics are pushed to the remote write endpoint at every interval, and once more when the input ends.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if promWriteConfigFlag == "" {
+			return fmt.Errorf("a metric rules configuration file is required")
+		}
+		if promWriteURLFlag == "" {
+			return fmt.Errorf("a remote write URL is required")
+		}
+
+		rules, err := readMetricRules(promWriteConfigFlag)
+		if err != nil {
+			return err
+		}
+
+		exporter, err := promwrite.NewExporter(promwrite.Config{
+			URL:      promWriteURLFlag,
+			Rules:    rules,
+			Labels:   promWriteLabelsFlag,
+			Interval: promWriteIntervalFlag,
+			OnError: func(err error) {
+				fmt.Fprintln(cmd.ErrOrStderr(), err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		pushed := make(chan error, 1)
+		go func() {
+			pushed <- exporter.Run(ctx)
+		}()
+
+		err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+			if err := exporter.Observe(r); err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err)
+			}
+			return true
+		}, readOptions(cmd)...)
+
+		cancel()
+		return errors.Join(err, <-pushed)
+	},
+}
+
+// readMetricRules reads the metric rules declared by the named
+// configuration file, in the order of their names.
+func readMetricRules(name string) ([]promwrite.Rule, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening metric rules configuration file: %w", err)
+	}
+	defer f.Close()
+
+	config, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metric rules configuration file %q: %w", name, err)
+	}
+
+	byName := map[string]*promwrite.Rule{}
+	for key, value := range config {
+		name, field, ok := strings.Cut(key, ".")
+		if !ok {
+			return nil, fmt.Errorf("unexpected key %q outside of a metric", key)
+		}
+
+		rule := byName[name]
+		if rule == nil {
+			rule = &promwrite.Rule{Name: name}
+			byName[name] = rule
+		}
+
+		switch field {
+		case "type":
+			rule.Type = promwrite.RuleType(value)
+		case "match":
+			rule.Match = value
+		case "labels":
+			rule.Labels = map[string]string{}
+			for _, pair := range strings.Split(value, ",") {
+				label, field, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					return nil, fmt.Errorf("metric %q: invalid label %q: expected name=field", name, pair)
+				}
+				rule.Labels[strings.TrimSpace(label)] = attrPath(strings.TrimSpace(field))
+			}
+		case "value":
+			rule.Value = attrPath(value)
+		case "buckets":
+			for _, s := range strings.Split(value, ",") {
+				bound, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				if err != nil {
+					return nil, fmt.Errorf("metric %q: invalid bucket %q", name, s)
+				}
+				rule.Buckets = append(rule.Buckets, bound)
+			}
+		default:
+			return nil, fmt.Errorf("metric %q: unknown key %q: expected type, match, labels, value or buckets", name, field)
+		}
+	}
+
+	if len(byName) == 0 {
+		return nil, fmt.Errorf("no metrics declared by %q", name)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	rules := make([]promwrite.Rule, 0, len(names))
+	for _, name := range names {
+		rules = append(rules, *byName[name])
+	}
+	return rules, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto/promwrite"
+)
+
+func TestReadMetricRules(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		want   string
+		err    string
+	}{
+		"rules": {
+			config: "http_requests_total:\n  match: has(attrs.status)\n  labels: status=attrs.status, level=level\n\nhttp_request_duration_seconds:\n  type: histogram\n  value: attrs.duration\n  buckets: 0.05, 0.1,0.5 , 1\n",
+			// Rules are ordered by name, and attributes given as their
+			// path.
+			want: "[{http_request_duration_seconds histogram  map[] duration [0.05 0.1 0.5 1]} {http_requests_total  has(attrs.status) map[level:level status:status]  []}]",
+		},
+		"invalid label": {
+			config: "errors_total:\n  labels: level\n",
+			err:    `metric "errors_total": invalid label "level": expected name=field`,
+		},
+		"invalid bucket": {
+			config: "latency:\n  type: histogram\n  buckets: 1, fast\n",
+			err:    `metric "latency": invalid bucket " fast"`,
+		},
+		"unknown key": {
+			config: "errors_total:\n  filter: level == \"ERROR\"\n",
+			err:    `metric "errors_total": unknown key "filter"`,
+		},
+		"key outside of a metric": {
+			config: "type: counter\n",
+			err:    `unexpected key "type" outside of a metric`,
+		},
+		"no metrics": {
+			config: "\n",
+			err:    "no metrics declared",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules, err := readMetricRules(writeConfig(t, test.config))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(rules); got != test.want {
+				t.Fatalf("expected %s, got %s", test.want, got)
+			}
+
+			// The rules are accepted by the exporter.
+			_, err = promwrite.NewExporter(promwrite.Config{URL: "http://localhost:9090/api/v1/write", Rules: rules})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	if _, err := readMetricRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
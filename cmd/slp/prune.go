@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDirFlag          string
+	pruneMaxAgeFlag       string
+	pruneMaxTotalSizeFlag string
+	prunePatternFlag      string
+	pruneDryRunFlag       bool
+	pruneRecordsFlag      bool
+	pruneTierFlag         []string
+	pruneTierKeepFlag     string
+)
+
+func init() {
+	pruneCmd.Flags().StringVar(&pruneDirFlag, "dir", "", "directory containing log files")
+	pruneCmd.Flags().StringVar(&pruneMaxAgeFlag, "max-age", "", "remove files older than this, such as 30d or 12h")
+	pruneCmd.Flags().StringVar(&pruneMaxTotalSizeFlag, "max-total-size", "", "remove the oldest files until the total size is within this, such as 50GB")
+	pruneCmd.Flags().StringVar(&prunePatternFlag, "pattern", "*", "glob pattern selecting the log files in the directory")
+	pruneCmd.Flags().BoolVar(&pruneDryRunFlag, "dry-run", false, "only print the files that would be removed")
+	pruneCmd.Flags().BoolVar(&pruneRecordsFlag, "records", false, "also remove the records older than their retention class from the remaining files")
+	pruneCmd.Flags().StringArrayVar(&pruneTierFlag, "tier", nil, "only keep the records at or above a level in files older than an age, such as 30d:WARN (repeatable)")
+	pruneCmd.Flags().StringVar(&pruneTierKeepFlag, "tier-keep", "", "filter expression selecting records kept by every tier whatever their level")
+
+	rootCmd.AddCommand(pruneCmd)
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old log files from a directory",
+	Long: `Removes the log files in a directory which are older than the maximum age, and then the oldest files until their total size is within the maximum total size.
+
+With --records, the records of the remaining files which are older than their retention class, given by their slogproto.retention attribute such as "30d" or "audit-7y", are removed too, by rewriting the files.
+
+With --tier, the remaining files are downsampled as they age, by rewriting them with only the records at or above the level of the tier for their age, and those matching --tier-keep. For example, --tier 30d:WARN --tier 90d:ERROR drops debug and info records from files older than 30 days, and warnings from files older than 90 days.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pruneDirFlag == "" {
+			return fmt.Errorf("a directory is required")
+		}
+
+		policy, err := retentionPolicy(pruneMaxAgeFlag, pruneMaxTotalSizeFlag, prunePatternFlag)
+		if err != nil {
+			return err
+		}
+
+		policy.Tiers, err = retentionTiers(pruneTierFlag, pruneTierKeepFlag)
+		if err != nil {
+			return err
+		}
+
+		if policy.MaxAge == 0 && policy.MaxTotalSize == 0 && !pruneRecordsFlag && len(policy.Tiers) == 0 {
+			return fmt.Errorf("at least one of --max-age, --max-total-size, --records or --tier is required")
+		}
+
+		var paths []string
+		if pruneDryRunFlag {
+			paths, err = policy.Expired(pruneDirFlag, time.Now())
+		} else {
+			paths, err = policy.Prune(pruneDirFlag)
+		}
+
+		for _, path := range paths {
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+		}
+		if err != nil || (!pruneRecordsFlag && len(policy.Tiers) == 0) {
+			return err
+		}
+
+		// Files that are removed, or would be, don't need their records
+		// pruned.
+		expired := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			expired[path] = true
+		}
+
+		names, err := filepath.Glob(filepath.Join(pruneDirFlag, prunePatternFlag))
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", prunePatternFlag, err)
+		}
+
+		now := time.Now()
+		for _, name := range names {
+			if expired[name] {
+				continue
+			}
+			fi, err := os.Stat(name)
+			if err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+
+			if pruneRecordsFlag {
+				removed, err := rewriteFile(name, now, pruneDryRunFlag, func(w io.Writer, r io.Reader) (int64, error) {
+					_, removed, err := slogproto.PruneRecords(cmd.Context(), w, r, now, readOptions(cmd)...)
+					return removed, err
+				})
+				if err != nil {
+					return fmt.Errorf("error pruning records of %s: %w", name, err)
+				}
+				if removed > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %d expired records\n", name, removed)
+				}
+			}
+
+			if tier, ok := policy.Tier(fi.ModTime(), now); ok {
+				removed, err := rewriteFile(name, now, pruneDryRunFlag, func(w io.Writer, r io.Reader) (int64, error) {
+					_, removed, err := slogproto.DownsampleRecords(cmd.Context(), w, r, tier, readOptions(cmd)...)
+					return removed, err
+				})
+				if err != nil {
+					return fmt.Errorf("error downsampling records of %s: %w", name, err)
+				}
+				if removed > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %d records below %s\n", name, removed, tier.Level)
+				}
+			}
+		}
+
+		return nil
+	},
+}
+
+// rewriteFile removes records of the named file, unless dryRun is set, by
+// writing the records that are kept by rewrite to a new file with the same
+// compression and modification time, which replaces it. It returns the
+// number of records removed by rewrite.
+func rewriteFile(name string, now time.Time, dryRun bool, rewrite func(w io.Writer, r io.Reader) (int64, error)) (int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r, codec, err := decompress(f)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decompress input: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := newCompressor(codec, tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := rewrite(w, r)
+	if err != nil {
+		return 0, err
+	}
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	// Keep the modification time, which the retention policy is based on.
+	if err := os.Chtimes(tmp.Name(), now, fi.ModTime()); err != nil {
+		return 0, err
+	}
+
+	return removed, os.Rename(tmp.Name(), name)
+}
+
+// retentionPolicy returns the retention policy for the given flag values.
+func retentionPolicy(maxAge, maxTotalSize, pattern string) (slogproto.RetentionPolicy, error) {
+	policy := slogproto.RetentionPolicy{
+		Pattern: pattern,
+	}
+
+	if maxAge != "" {
+		d, err := parseAge(maxAge)
+		if err != nil {
+			return policy, fmt.Errorf("invalid max age %q: %w", maxAge, err)
+		}
+		policy.MaxAge = d
+	}
+
+	if maxTotalSize != "" {
+		n, err := parseSize(maxTotalSize)
+		if err != nil {
+			return policy, fmt.Errorf("invalid max total size %q: %w", maxTotalSize, err)
+		}
+		policy.MaxTotalSize = n
+	}
+
+	return policy, nil
+}
+
+// retentionTiers returns the retention tiers for the given flag values,
+// each an age and a level separated by a colon, such as 30d:WARN.
+func retentionTiers(tiers []string, keep string) ([]slogproto.RetentionTier, error) {
+	if len(tiers) == 0 {
+		return nil, nil
+	}
+
+	prog, err := compileFilter(keep)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tier filter: %w", err)
+	}
+
+	var filter func(r *slog.Record) (bool, error)
+	if prog != nil {
+		filter = func(r *slog.Record) (bool, error) {
+			return slogproto.EvalFilter(prog, r)
+		}
+	}
+
+	result := make([]slogproto.RetentionTier, 0, len(tiers))
+	for _, tier := range tiers {
+		age, level, ok := strings.Cut(tier, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid tier %q: expected an age and a level, such as 30d:WARN", tier)
+		}
+
+		d, err := parseAge(age)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier %q: %w", tier, err)
+		}
+
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid tier %q: %w", tier, err)
+		}
+
+		result = append(result, slogproto.RetentionTier{MinAge: d, Level: l, Filter: filter})
+	}
+	return result, nil
+}
+
+// parseAge parses a duration, which in addition to the units supported by
+// time.ParseDuration may be given in days ("d") or weeks ("w").
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(f * float64(unit)), nil
+		}
+	}
+
+	return time.ParseDuration(s)
+}
+
+// parseSize parses a size in bytes with an optional unit: B, KB, MB, GB or
+// TB, using powers of 1024 as humanSize does.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	for _, unit := range units {
+		if n, ok := strings.CutSuffix(s, unit.suffix); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil {
+				return 0, err
+			}
+			return int64(f * float64(unit.size)), nil
+		}
+	}
+
+	return strconv.ParseInt(s, 10, 64)
+}
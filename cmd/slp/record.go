@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/slogprototest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordOutputFlag string
+	recordStreamFlag string
+
+	verifyStreamFlag     string
+	verifyIgnoreTimeFlag bool
+	verifyIgnoreAttrFlag []string
+)
+
+func init() {
+	recordCmd.Flags().StringVarP(&recordOutputFlag, "output", "o", "", "file to write the fixture to")
+	recordCmd.Flags().StringVar(&recordStreamFlag, "stream", "stdout", "output stream of the command to capture: stdout or stderr")
+
+	verifyCmd.Flags().StringVar(&verifyStreamFlag, "stream", "stdout", "output stream of the command to capture: stdout or stderr")
+	verifyCmd.Flags().BoolVar(&verifyIgnoreTimeFlag, "ignore-time", false, "ignore the time of records and time attributes")
+	verifyCmd.Flags().StringSliceVar(&verifyIgnoreAttrFlag, "ignore-attr", nil, "attribute to ignore, such as request_id or http.latency (repeatable)")
+
+	rootCmd.AddCommand(recordCmd)
+	rootCmd.AddCommand(verifyCmd)
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record -o fixture.slp -- command [args...]",
+	Short: "Capture the records written by a command into a fixture",
+	Long:  `Runs a command, capturing the slogproto records it writes to STDOUT (or STDERR) into a fixture file, which can be compared with later runs of the command using the verify command. The other output stream is passed through.`,
+	Args:  commandArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if recordOutputFlag == "" {
+			return fmt.Errorf("an output file is required")
+		}
+
+		var buf bytes.Buffer
+		if err := runCapture(cmd, args, recordStreamFlag, &buf); err != nil {
+			return err
+		}
+
+		// Validate the captured records before writing the fixture.
+		var records int
+		err := slogproto.Read(cmd.Context(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+			records++
+			return true
+		}, readOptions(cmd)...)
+		if err != nil {
+			return fmt.Errorf("error reading records from the command: %w", err)
+		}
+
+		if err := os.WriteFile(recordOutputFlag, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write fixture: %w", err)
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "recorded %d records to %s\n", records, recordOutputFlag)
+		return nil
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify fixture.slp -- command [args...]",
+	Short: "Compare the records written by a command with a fixture",
+	Long:  `Runs a command, capturing the slogproto records it writes to STDOUT (or STDERR), and compares them with the records of a fixture written by the record command. Attributes are compared regardless of their order. Differences are reported, and the command fails if there are any.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 1 {
+			return fmt.Errorf("expected a fixture, followed by -- and a command")
+		}
+		return commandArgs(cmd, args[1:])
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fixture, err := readRecords(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := runCapture(cmd, args[1:], verifyStreamFlag, &buf); err != nil {
+			return err
+		}
+
+		var got []slog.Record
+		err = slogproto.Read(cmd.Context(), &buf, func(r *slog.Record) bool {
+			got = append(got, *r)
+			return true
+		}, readOptions(cmd)...)
+		if err != nil {
+			return fmt.Errorf("error reading records from the command: %w", err)
+		}
+
+		var opts []slogprototest.Option
+		if verifyIgnoreTimeFlag {
+			opts = append(opts, slogprototest.IgnoreTime())
+		}
+		if len(verifyIgnoreAttrFlag) > 0 {
+			attrs := make([]string, len(verifyIgnoreAttrFlag))
+			for i, attr := range verifyIgnoreAttrFlag {
+				attrs[i] = attrPath(attr)
+			}
+			opts = append(opts, slogprototest.IgnoreAttrs(attrs...))
+		}
+
+		if diff := slogprototest.Diff(fixture, got, opts...); diff != "" {
+			fmt.Fprint(cmd.OutOrStdout(), diff)
+			return fmt.Errorf("records differ from the fixture %s", args[0])
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "%d records match %s\n", len(got), args[0])
+		return nil
+	},
+}
+
+// commandArgs validates that the arguments, after --, name a command.
+func commandArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected -- followed by a command")
+	}
+	return nil
+}
+
+// runCapture runs the command, writing the named output stream to w, and
+// passing the other through along with STDIN.
+func runCapture(cmd *cobra.Command, args []string, stream string, w io.Writer) error {
+	c := exec.CommandContext(cmd.Context(), args[0], args[1:]...)
+	c.Stdin = cmd.InOrStdin()
+
+	switch stream {
+	case "stdout":
+		c.Stdout, c.Stderr = w, cmd.ErrOrStderr()
+	case "stderr":
+		c.Stdout, c.Stderr = cmd.OutOrStdout(), w
+	default:
+		return fmt.Errorf("unknown stream %q: must be stdout or stderr", stream)
+	}
+
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("error running %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// readRecords returns the records of the named file, which may be
+// compressed.
+func readRecords(cmd *cobra.Command, name string) ([]slog.Record, error) {
+	input, closeInput, err := openInput(cmd, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput()
+
+	var records []slog.Record
+	err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+		records = append(records, *r)
+		return true
+	}, readOptions(cmd)...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading records from %s: %w", name, err)
+	}
+
+	return records, nil
+}
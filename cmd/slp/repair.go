@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var repairOutputFlag string
+
+func init() {
+	repairCmd.Flags().StringVarP(&repairOutputFlag, "output", "o", "", "file to write the recovered records to")
+
+	rootCmd.AddCommand(repairCmd)
+}
+
+var repairCmd = &cobra.Command{
+	Use:   "repair [file]",
+	Short: "Recover records from a corrupted log file",
+	Long:  `Scans a possibly corrupted log file (or STDIN), writes every record that can be decoded to a new file, and reports the byte ranges that were skipped.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if repairOutputFlag == "" {
+			return fmt.Errorf("an output file is required")
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		f, err := os.OpenFile(repairOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output file: %w", err)
+		}
+		defer f.Close()
+
+		report, err := slogproto.Repair(cmd.Context(), f, input, slogproto.WithMaxRecordSize(maxRecordSizeFlag))
+		if err != nil {
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		stderr := cmd.ErrOrStderr()
+
+		fmt.Fprintf(stderr, "recovered %d records, skipped %d bytes in %d ranges\n", report.Records, report.SkippedBytes(), len(report.Skipped))
+		for _, s := range report.Skipped {
+			fmt.Fprintf(stderr, "  skipped bytes %d-%d (%d bytes)\n", s.Offset, s.Offset+s.Length-1, s.Length)
+		}
+
+		return nil
+	},
+}
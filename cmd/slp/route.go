@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var routeConfigFlag string
+
+func init() {
+	routeCmd.Flags().StringVarP(&routeConfigFlag, "config", "c", "", "file declaring the routes")
+
+	rootCmd.AddCommand(routeCmd)
+}
+
+var routeCmd = &cobra.Command{
+	Use:   "route [file]",
+	Short: "Write records to sinks chosen by their level and filter expressions",
+	Long: `Reads protobuf messages from STDIN or a file and writes each record to the sink of every route it matches, as declared by a configuration file with a section per route:
+
+  errors:
+    min-level: WARN
+    sink: /var/log/app/errors.log
+
+  audit:
+    match: has(attrs.audit)
+    sink: tcp://collector:9000
+
+  console:
+    max-level: INFO
+    sink: stderr
+    format: json
+
+A route's sink is stderr, stdout, a file or a tcp://, udp:// or unix:// address, and its format is proto, json or text (the default for stderr and stdout).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if routeConfigFlag == "" {
+			return fmt.Errorf("a routes configuration file is required")
+		}
+
+		routes, err := readRoutes(routeConfigFlag)
+		if err != nil {
+			return err
+		}
+
+		router, err := slogproto.NewRouter(slogproto.RouterConfig{Routes: routes})
+		if err != nil {
+			return err
+		}
+		defer router.Close()
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		var handleErr error
+		err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+			handleErr = router.Handle(cmd.Context(), *r)
+			return handleErr == nil
+		}, readOptions(cmd)...)
+		if err == nil {
+			err = handleErr
+		}
+
+		return errors.Join(err, router.Close())
+	},
+}
+
+// readRoutes reads the routes declared by the named configuration file, in
+// the order of their names.
+func readRoutes(name string) ([]slogproto.Route, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening routes configuration file: %w", err)
+	}
+	defer f.Close()
+
+	config, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading routes configuration file %q: %w", name, err)
+	}
+
+	byName := map[string]*slogproto.Route{}
+	for key, value := range config {
+		name, field, ok := strings.Cut(key, ".")
+		if !ok {
+			return nil, fmt.Errorf("unexpected key %q outside of a route", key)
+		}
+
+		route := byName[name]
+		if route == nil {
+			route = &slogproto.Route{}
+			byName[name] = route
+		}
+
+		switch field {
+		case "min-level", "max-level":
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(value)); err != nil {
+				return nil, fmt.Errorf("route %q: invalid %s %q: %w", name, field, value, err)
+			}
+			if field == "min-level" {
+				route.MinLevel = level
+			} else {
+				route.MaxLevel = level
+			}
+		case "match":
+			route.Match = value
+		case "sink":
+			route.Sink = value
+		case "format":
+			route.Format = value
+		default:
+			return nil, fmt.Errorf("route %q: unknown key %q: expected min-level, max-level, match, sink or format", name, field)
+		}
+	}
+
+	if len(byName) == 0 {
+		return nil, fmt.Errorf("no routes declared by %q", name)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	routes := make([]slogproto.Route, 0, len(names))
+	for _, name := range names {
+		routes = append(routes, *byName[name])
+	}
+	return routes, nil
+}
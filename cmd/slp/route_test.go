@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+// writeConfig writes the configuration file to a temporary directory,
+// returning its path.
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(name, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadRoutes(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		want   string
+		err    string
+	}{
+		"routes": {
+			config: "errors:\n  min-level: WARN\n  sink: errors.log\n\naudit:\n  match: has(attrs.audit)\n  sink: tcp://collector:9000\n  format: json\n\nconsole:\n  max-level: INFO+2\n  sink: stderr\n",
+			// Routes are ordered by name.
+			want: "[{<nil> <nil> has(attrs.audit) tcp://collector:9000 json} {<nil> INFO+2  stderr } {WARN <nil>  errors.log }]",
+		},
+		"invalid level": {
+			config: "errors:\n  min-level: SEVERE\n",
+			err:    `route "errors": invalid min-level "SEVERE"`,
+		},
+		"unknown key": {
+			config: "errors:\n  sinks: errors.log\n",
+			err:    `route "errors": unknown key "sinks"`,
+		},
+		"key outside of a route": {
+			config: "sink: errors.log\n",
+			err:    `unexpected key "sink" outside of a route`,
+		},
+		"no routes": {
+			config: "# nothing yet\n",
+			err:    "no routes declared",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			routes, err := readRoutes(writeConfig(t, test.config))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(routes); got != test.want {
+				t.Fatalf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+
+	if _, err := readRoutes(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestReadRoutes_router(t *testing.T) {
+	dir := t.TempDir()
+	errorsLog := filepath.Join(dir, "errors.log")
+	auditLog := filepath.Join(dir, "audit.log")
+
+	routes, err := readRoutes(writeConfig(t, fmt.Sprintf("errors:\n  min-level: ERROR\n  sink: %s\naudit:\n  match: has(attrs.audit)\n  sink: %s\n", errorsLog, auditLog)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := slogproto.NewRouter(slogproto.RouterConfig{Routes: routes})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(router)
+	logger.Info("login", "audit", true)
+	logger.Error("timeout")
+	logger.Error("denied", "audit", true)
+	logger.Info("ignored")
+
+	if err := router.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		errorsLog: "timeout,denied",
+		auditLog:  "login,denied",
+	} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var msgs []string
+		err = slogproto.Read(context.Background(), f, func(r *slog.Record) bool {
+			msgs = append(msgs, r.Message)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(msgs, ","); got != want {
+			t.Errorf("%s: expected records %q, got %q", filepath.Base(name), want, got)
+		}
+	}
+}
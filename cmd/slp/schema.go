@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var schemaFormatFlag string
+
+func init() {
+	schemaCmd.Flags().StringVar(&schemaFormatFlag, "format", "jsonschema", "schema format: jsonschema")
+
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a schema of the JSON output of slp",
+	Long:  `Prints a JSON Schema describing the JSON written by slp for each record, including how attribute values of each kind and groups are written, so that consumers in other languages can validate it and generate code for it.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if schemaFormatFlag != "jsonschema" {
+			return fmt.Errorf("unknown schema format %q: expected jsonschema", schemaFormatFlag)
+		}
+
+		b, err := slogproto.JSONSchema(nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", b)
+		return err
+	},
+}
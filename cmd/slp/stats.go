@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/aggregate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsAttrsFlag       []string
+	statsPercentilesFlag []string
+	statsByFlag          []string
+	statsAggFlag         string
+	statsWindowFlag      time.Duration
+	statsOutputFlag      string
+)
+
+// statsPercentiles are the percentiles reported for the attributes given
+// with the --percentiles flag.
+var statsPercentiles = []float64{50, 90, 99}
+
+func init() {
+	statsCmd.Flags().StringSliceVarP(&statsAttrsFlag, "attr", "a", nil, "numeric attribute to summarize, such as attrs.duration_ms (repeatable)")
+	statsCmd.Flags().StringSliceVarP(&statsPercentilesFlag, "percentiles", "p", nil, "numeric attribute to report p50/p90/p99 for, such as attrs.duration_ms (repeatable)")
+
+	statsCmd.Flags().StringSliceVar(&statsByFlag, "by", nil, "attribute to group records by, such as attrs.endpoint (repeatable)")
+	statsCmd.Flags().StringVar(&statsAggFlag, "agg", "", "comma separated aggregations for each group, such as 'count(),p99(attrs.duration_ms)' (default \"count()\" with --by)")
+
+	statsCmd.Flags().DurationVar(&statsWindowFlag, "window", 0, "aggregate over consecutive windows of time, such as 1m")
+	statsCmd.Flags().StringVarP(&statsOutputFlag, "output", "o", "table", "output format for grouped statistics: table, csv or json")
+	addFilterFlag(statsCmd)
+
+	rootCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [file]",
+	Short: "Summarize records",
+	Long:  `Reads protobuf messages from STDIN or a file and prints the number of records per level, the time range, and numeric summaries of the given attributes.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filterProg, err := compileFilter(filterFlag)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		attrs := make([]string, 0, len(statsAttrsFlag)+len(statsPercentilesFlag))
+		for _, attr := range statsAttrsFlag {
+			attrs = append(attrs, attrPath(attr))
+		}
+
+		percentileAttrs := make([]string, 0, len(statsPercentilesFlag))
+		for _, attr := range statsPercentilesFlag {
+			percentileAttrs = append(percentileAttrs, attrPath(attr))
+			if !slices.Contains(attrs, attrPath(attr)) {
+				attrs = append(attrs, attrPath(attr))
+			}
+		}
+
+		if len(statsByFlag) > 0 || statsAggFlag != "" || statsWindowFlag > 0 {
+			return runGroupedStats(cmd, input, filterProg)
+		}
+
+		agg := aggregate.New(attrs...)
+
+		err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+			agg.Add(r)
+			return true
+		}, append(readOptions(cmd), slogproto.WithFilter(filterProg))...)
+		if err != nil {
+			return err
+		}
+
+		return writeStats(cmd.OutOrStdout(), agg.Snapshot(), attrs, percentileAttrs)
+	},
+}
+
+// runGroupedStats reads records from the input and writes a row of
+// aggregations for each group of records given by the --by flag, and for
+// each window of time given by the --window flag.
+func runGroupedStats(cmd *cobra.Command, input io.Reader, filterProg cel.Program) error {
+	spec := statsAggFlag
+	if spec == "" {
+		spec = "count()"
+	}
+
+	aggs, err := aggregate.ParseAggregations(spec)
+	if err != nil {
+		return err
+	}
+
+	var attrs []string
+	for i := range aggs {
+		if attr, ok := aggs[i].Attr(); ok {
+			aggs[i].Arg = attrPath(attr)
+			attrs = append(attrs, aggs[i].Arg)
+		}
+	}
+
+	by := make([]string, 0, len(statsByFlag))
+	for _, attr := range statsByFlag {
+		by = append(by, attrPath(attr))
+	}
+
+	// Without a window, all records are aggregated in a single window.
+	windows := aggregate.NewWindows(statsWindowFlag, by, attrs...)
+
+	err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+		windows.Add(r)
+		return true
+	}, append(readOptions(cmd), slogproto.WithFilter(filterProg))...)
+	if err != nil {
+		return err
+	}
+
+	var header []string
+	if statsWindowFlag > 0 {
+		header = append(header, "window")
+	}
+	header = append(header, by...)
+	for _, agg := range aggs {
+		header = append(header, agg.String())
+	}
+
+	var rows [][]any
+	for _, window := range windows.Snapshot() {
+		for _, grp := range window.Groups {
+			row := make([]any, 0, len(header))
+			if statsWindowFlag > 0 {
+				row = append(row, renderTime(window.Start, time.RFC3339Nano))
+			}
+			for _, key := range grp.Key {
+				row = append(row, key)
+			}
+			for _, agg := range aggs {
+				row = append(row, agg.Value(grp.Snapshot))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return writeRows(cmd.OutOrStdout(), statsOutputFlag, header, rows)
+}
+
+// writeRows writes rows of strings and numbers with the given header in the
+// given format: "table", "csv" or "json".
+func writeRows(w io.Writer, format string, header []string, rows [][]any) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fields := make([]string, 0, len(row))
+			for _, v := range row {
+				fields = append(fields, formatField(v, "-"))
+			}
+			fmt.Fprintln(tw, strings.Join(fields, "\t"))
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			fields := make([]string, 0, len(row))
+			for _, v := range row {
+				fields = append(fields, formatField(v, ""))
+			}
+			if err := cw.Write(fields); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		enc := json.NewEncoder(w)
+		for _, row := range rows {
+			obj := make(map[string]any, len(row))
+			for i, v := range row {
+				obj[header[i]] = v
+			}
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q: expected table, csv or json", format)
+	}
+}
+
+// formatField formats a string or number for tabular output, using empty
+// for empty strings.
+func formatField(v any, empty string) string {
+	switch v := v.(type) {
+	case float64:
+		return formatFloat(v)
+	case string:
+		if v == "" {
+			return empty
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// attrPath returns the attribute path for a field name given on the
+// command line, which may use the "attrs." prefix of filter expressions.
+func attrPath(field string) string {
+	return strings.TrimPrefix(field, "attrs.")
+}
+
+// writeStats writes the snapshot as a human readable table, including the
+// percentiles of the given percentile attributes.
+func writeStats(w io.Writer, s aggregate.Snapshot, attrs, percentileAttrs []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "records\t%d\n", s.Records)
+	if !s.Start.IsZero() {
+		fmt.Fprintf(tw, "start\t%v\n", renderTime(s.Start, time.RFC3339Nano))
+		fmt.Fprintf(tw, "end\t%v\n", renderTime(s.End, time.RFC3339Nano))
+	}
+
+	levels := make([]slog.Level, 0, len(s.Levels))
+	for level := range s.Levels {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "level\tcount")
+	for _, level := range levels {
+		fmt.Fprintf(tw, "%s\t%d\n", level, s.Levels[level])
+	}
+
+	if len(attrs) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "attr\tcount\tmin\tmax\tmean\tsum")
+		for _, attr := range attrs {
+			n := s.Attrs[attr]
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", attr, n.Count, formatFloat(n.Min), formatFloat(n.Max), formatFloat(n.Mean()), formatFloat(n.Sum))
+		}
+	}
+
+	if len(percentileAttrs) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprint(tw, "attr")
+		for _, p := range statsPercentiles {
+			fmt.Fprintf(tw, "\tp%g", p)
+		}
+		fmt.Fprintln(tw)
+
+		for _, attr := range percentileAttrs {
+			fmt.Fprint(tw, attr)
+			for _, p := range statsPercentiles {
+				fmt.Fprintf(tw, "\t%s", formatFloat(s.Attrs[attr].Quantile(p/100)))
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// formatFloat formats a float rounded to two decimal places, without
+// trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trainDictOutputFlag string
+	trainDictSizeFlag   int
+)
+
+func init() {
+	trainDictCmd.Flags().StringVarP(&trainDictOutputFlag, "output", "o", "", "file to write the dictionary to")
+	trainDictCmd.Flags().IntVar(&trainDictSizeFlag, "size", slogproto.DefaultDictSize, "maximum size of the dictionary in bytes")
+
+	rootCmd.AddCommand(trainDictCmd)
+}
+
+var trainDictCmd = &cobra.Command{
+	Use:   "train-dict [files...]",
+	Short: "Train a zstd dictionary from existing log files",
+	Long:  `Samples the records of existing log files (or STDIN) to build a zstd dictionary, which gives much better compression of short records than generic zstd. Use it with --dict when compacting and reading files, or with slogproto.WithZstd in Go programs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if trainDictOutputFlag == "" {
+			return fmt.Errorf("an output file is required")
+		}
+
+		var inputs []io.Reader
+		if len(args) == 0 {
+			inputs = append(inputs, &decompressReader{r: cmd.InOrStdin()})
+		}
+		for _, name := range args {
+			f, err := os.Open(name)
+			if err != nil {
+				return fmt.Errorf("failed to open file: %w", err)
+			}
+			defer f.Close()
+			inputs = append(inputs, &decompressReader{r: f})
+		}
+
+		dict, err := slogproto.TrainDict(cmd.Context(), trainDictSizeFlag, inputs...)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(trainDictOutputFlag, dict, 0644); err != nil {
+			return fmt.Errorf("failed to write dictionary: %w", err)
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "trained %s dictionary (id %d)\n", humanSize(int64(len(dict))), slogproto.DictID(dict))
+		return nil
+	},
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	transformFlag     []string
+	convertOutputFlag string
+)
+
+func init() {
+	addTransformFlag(rootCmd)
+
+	addTransformFlag(convertCmd)
+	convertCmd.Flags().StringVarP(&convertOutputFlag, "output", "o", "", "file to write the converted log to (default STDOUT)")
+	addFilterFlag(convertCmd)
+
+	rootCmd.AddCommand(convertCmd)
+}
+
+// addTransformFlag adds the --transform flag to a command that applies the
+// transform stages to the records it reads.
+func addTransformFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&transformFlag, "transform", "t", nil, "transform stage applied to records in order, such as 'redact:user.password' (repeatable, see slp convert --help)")
+}
+
+var convertCmd = &cobra.Command{
+	Use:   "convert [file]",
+	Short: "Rewrite a log file through transform stages",
+	Long: `Reads protobuf messages from STDIN or a file, applies the stages given with --transform to each record in order, and writes the records that are kept to a new log file, leaving the original untouched. The stages are:
+
+  filter:EXPR         keep the records matching the filter expression
+  attrs:EXPR          replace the attributes with the map returned by the
+                      expression, such as attrs:attrs.with("slow", attrs.duration_ms > 1000)
+  redact:PATTERN,...  replace the values of matching attributes with [REDACTED]
+  drop:PATTERN,...    drop matching attributes
+  level:FROM=TO,...   change the level of records, such as level:ERROR=WARN
+  relevel:FILE        change the level of records to that of the first rule
+                      they match, as declared by a configuration file
+  flatten             replace groups with dotted keys
+  unflatten           replace dotted keys with nested groups
+
+Level rules are declared with a section per rule, applied in the order of their names:
+
+  noisy-timeouts:
+    match: 'level == "ERROR" && msg == "upstream timeout"'
+    level: WARN
+
+Patterns are dotted attribute paths, whose segments may contain wildcards, such as user.password or *.token. The same stages can be applied to the records printed by slp with --transform.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline, err := parseTransforms(transformFlag)
+		if err != nil {
+			return err
+		}
+
+		filterProg, err := compileFilter(filterFlag)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		output := cmd.OutOrStdout()
+		if convertOutputFlag != "" {
+			f, err := os.OpenFile(convertOutputFlag, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open output file: %w", err)
+			}
+			defer f.Close()
+			output = f
+		}
+
+		n, err := pipeline.Copy(cmd.Context(), output, input, append(readOptions(cmd), slogproto.WithFilter(filterProg))...)
+		if err != nil {
+			return err
+		}
+
+		if f, ok := output.(*os.File); ok && convertOutputFlag != "" {
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "wrote %d records\n", n)
+		return nil
+	},
+}
+
+// parseTransforms returns the pipeline of the transform stages given with
+// the --transform flag, or nil if there are none.
+func parseTransforms(specs []string) (*slogproto.Pipeline, error) {
+	stages := make([]slogproto.Stage, 0, len(specs))
+	for _, spec := range specs {
+		stage, err := parseTransform(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform %q: %w", spec, err)
+		}
+		stages = append(stages, stage)
+	}
+	return slogproto.NewPipeline(stages...), nil
+}
+
+// parseTransform returns the stage given by a transform, which is the name
+// of a stage and its argument, separated by a colon.
+func parseTransform(spec string) (slogproto.Stage, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "filter":
+		prog, err := compileFilter(arg)
+		if err != nil {
+			return nil, err
+		}
+		if prog == nil {
+			return nil, fmt.Errorf("no filter expression")
+		}
+		return slogproto.FilterExprStage(prog), nil
+	case "attrs":
+		prog, err := slogproto.CompileTransform(arg)
+		if err != nil {
+			return nil, err
+		}
+		return slogproto.TransformExprStage(prog), nil
+	case "redact", "drop":
+		patterns := splitList(arg)
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("no attribute patterns")
+		}
+		if name == "redact" {
+			return slogproto.RedactStage(patterns...), nil
+		}
+		return slogproto.DropStage(patterns...), nil
+	case "level":
+		levels := map[slog.Level]slog.Level{}
+		for _, pair := range splitList(arg) {
+			from, to, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("expected FROM=TO levels, got %q", pair)
+			}
+			var fromLevel, toLevel slog.Level
+			if err := fromLevel.UnmarshalText([]byte(from)); err != nil {
+				return nil, err
+			}
+			if err := toLevel.UnmarshalText([]byte(to)); err != nil {
+				return nil, err
+			}
+			levels[fromLevel] = toLevel
+		}
+		if len(levels) == 0 {
+			return nil, fmt.Errorf("no levels")
+		}
+		return slogproto.RelevelStage(func(r *slog.Record) slog.Level {
+			if level, ok := levels[r.Level]; ok {
+				return level
+			}
+			return r.Level
+		}), nil
+	case "relevel":
+		rules, err := readLevelRules(arg)
+		if err != nil {
+			return nil, err
+		}
+		return slogproto.LevelRulesStage(rules...)
+	case "flatten":
+		return slogproto.TransformStage(slogproto.Flatten), nil
+	case "unflatten":
+		return slogproto.TransformStage(slogproto.Unflatten), nil
+	default:
+		return nil, fmt.Errorf("unknown stage %q: expected filter, attrs, redact, drop, level, relevel, flatten or unflatten", name)
+	}
+}
+
+// readLevelRules reads the level rules declared by the named
+// configuration file, in the order of their names.
+func readLevelRules(name string) ([]slogproto.LevelRule, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening level rules configuration file: %w", err)
+	}
+	defer f.Close()
+
+	config, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading level rules configuration file %q: %w", name, err)
+	}
+
+	byName := map[string]*slogproto.LevelRule{}
+	levels := map[string]bool{}
+	for key, value := range config {
+		name, field, ok := strings.Cut(key, ".")
+		if !ok {
+			return nil, fmt.Errorf("unexpected key %q outside of a rule", key)
+		}
+
+		rule := byName[name]
+		if rule == nil {
+			rule = &slogproto.LevelRule{}
+			byName[name] = rule
+		}
+
+		switch field {
+		case "match":
+			rule.Match = value
+		case "level":
+			if err := rule.Level.UnmarshalText([]byte(value)); err != nil {
+				return nil, fmt.Errorf("rule %q: invalid level %q: %w", name, value, err)
+			}
+			levels[name] = true
+		default:
+			return nil, fmt.Errorf("rule %q: unknown key %q: expected match or level", name, field)
+		}
+	}
+
+	if len(byName) == 0 {
+		return nil, fmt.Errorf("no level rules declared by %q", name)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		if byName[name].Match == "" || !levels[name] {
+			return nil, fmt.Errorf("rule %q: a match and level are required", name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	rules := make([]slogproto.LevelRule, 0, len(names))
+	for _, name := range names {
+		rules = append(rules, *byName[name])
+	}
+	return rules, nil
+}
+
+// splitList splits a comma separated list, dropping empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// transformRecord applies the transform stages to a record, returning it
+// as a string, or "dropped" if it isn't kept.
+func transformRecord(specs []string) (string, error) {
+	pipeline, err := parseTransforms(specs)
+	if err != nil {
+		return "", err
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "login", 0)
+	r.AddAttrs(
+		slog.Group("user", "name", "ann", "password", "secret"),
+		slog.String("token", "abc"),
+		slog.Int("duration_ms", 1500),
+	)
+
+	keep, err := pipeline.Apply(&r)
+	if err != nil {
+		return "", err
+	}
+	if !keep {
+		return "dropped", nil
+	}
+
+	attrs := []string{r.Level.String(), r.Message}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a.String())
+		return true
+	})
+	return strings.Join(attrs, " "), nil
+}
+
+func TestParseTransforms(t *testing.T) {
+	tests := map[string]struct {
+		specs []string
+		want  string
+		err   string
+	}{
+		"none": {
+			want: "ERROR login user=[name=ann password=secret] token=abc duration_ms=1500",
+		},
+		"filter": {
+			specs: []string{`filter:level == "ERROR" && attrs.duration_ms > 1000`},
+			want:  "ERROR login user=[name=ann password=secret] token=abc duration_ms=1500",
+		},
+		"filter dropping": {
+			specs: []string{`filter:msg == "logout"`},
+			want:  "dropped",
+		},
+		"redact": {
+			specs: []string{"redact:user.password, token"},
+			want:  "ERROR login user=[name=ann password=[REDACTED]] token=[REDACTED] duration_ms=1500",
+		},
+		"drop": {
+			specs: []string{"drop:*.password,duration_ms"},
+			want:  "ERROR login user=[name=ann] token=abc",
+		},
+		"level": {
+			specs: []string{"level:INFO=DEBUG, ERROR=WARN"},
+			want:  "WARN login user=[name=ann password=secret] token=abc duration_ms=1500",
+		},
+		"flatten": {
+			specs: []string{"flatten"},
+			want:  "ERROR login user.name=ann user.password=secret token=abc duration_ms=1500",
+		},
+		"unflatten": {
+			specs: []string{"flatten", "unflatten"},
+			want:  "ERROR login user=[name=ann password=secret] token=abc duration_ms=1500",
+		},
+		"attrs": {
+			specs: []string{`attrs:attrs.with("slow", attrs.duration_ms > 1000).without(["token", "user"])`},
+			want:  "ERROR login duration_ms=1500 slow=true",
+		},
+		"attrs of groups": {
+			specs: []string{`attrs:{"user": attrs.user.without("password")}`},
+			want:  "ERROR login user=[name=ann]",
+		},
+		// Stages are applied in order.
+		"stages": {
+			specs: []string{"level:ERROR=WARN", `filter:level == "ERROR"`},
+			want:  "dropped",
+		},
+		"empty filter": {
+			specs: []string{"filter:"},
+			err:   `invalid transform "filter:": no filter expression`,
+		},
+		"invalid filter": {
+			specs: []string{"filter:level =="},
+			err:   `invalid transform "filter:level ==": parse error`,
+		},
+		"attrs not returning a map": {
+			specs: []string{"attrs:attrs.token"},
+			err:   `invalid transform "attrs:attrs.token": invalid transform expression output type`,
+		},
+		"no patterns": {
+			specs: []string{"redact: ,"},
+			err:   `invalid transform "redact: ,": no attribute patterns`,
+		},
+		"invalid level pair": {
+			specs: []string{"level:ERROR"},
+			err:   `invalid transform "level:ERROR": expected FROM=TO levels, got "ERROR"`,
+		},
+		"invalid level": {
+			specs: []string{"level:ERROR=LOUD"},
+			err:   `invalid transform "level:ERROR=LOUD": slog: level string "LOUD": unknown name`,
+		},
+		"no levels": {
+			specs: []string{"level:"},
+			err:   `invalid transform "level:": no levels`,
+		},
+		"unknown stage": {
+			specs: []string{"flatten", "uppercase:msg"},
+			err:   `invalid transform "uppercase:msg": unknown stage "uppercase"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := transformRecord(test.specs)
+			if test.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	for s, want := range map[string]string{
+		"":           "[]",
+		"a":          "[a]",
+		" a, b ,c":   "[a b c]",
+		"a,, ,b,":    "[a b]",
+		"*.token,id": "[*.token id]",
+	} {
+		if got := fmt.Sprint(splitList(s)); got != want {
+			t.Errorf("%q: expected %s, got %s", s, want, got)
+		}
+	}
+}
+
+func TestReadLevelRules(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		want   string
+		err    string
+	}{
+		"rules": {
+			config: "noisy-timeouts:\n  match: 'level == \"ERROR\" && msg == \"upstream timeout\"'\n  level: WARN\n\naudit:\n  match: has(attrs.audit)\n  level: ERROR+2\n",
+			// Rules are ordered by name.
+			want: `[{has(attrs.audit) ERROR+2} {level == "ERROR" && msg == "upstream timeout" WARN}]`,
+		},
+		"invalid level": {
+			config: "audit:\n  match: has(attrs.audit)\n  level: LOUD\n",
+			err:    `rule "audit": invalid level "LOUD"`,
+		},
+		"no level": {
+			config: "audit:\n  match: has(attrs.audit)\n",
+			err:    `rule "audit": a match and level are required`,
+		},
+		"no match": {
+			config: "audit:\n  level: WARN\n",
+			err:    `rule "audit": a match and level are required`,
+		},
+		"unknown key": {
+			config: "audit:\n  filter: has(attrs.audit)\n",
+			err:    `rule "audit": unknown key "filter"`,
+		},
+		"key outside of a rule": {
+			config: "level: WARN\n",
+			err:    `unexpected key "level" outside of a rule`,
+		},
+		"no rules": {
+			config: "\n",
+			err:    "no level rules declared",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules, err := readLevelRules(writeConfig(t, test.config))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(rules); got != test.want {
+				t.Fatalf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTransforms_relevel(t *testing.T) {
+	name := writeConfig(t, "a-slow:\n  match: attrs.duration_ms > 1000\n  level: WARN\nb-login:\n  match: msg == \"login\"\n  level: DEBUG\n")
+
+	// The first rule matching the record applies.
+	got, err := transformRecord([]string{"relevel:" + name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "WARN login user=[name=ann password=secret] token=abc duration_ms=1500"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if _, err := transformRecord([]string{"relevel:" + name + ".missing"}); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+
+	// Invalid matches are reported when the stage is created.
+	name = writeConfig(t, "bad:\n  match: msg ==\n  level: WARN\n")
+	if _, err := transformRecord([]string{"relevel:" + name}); err == nil {
+		t.Fatal("expected an error for an invalid match")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addFilterFlag(treeCmd)
+
+	rootCmd.AddCommand(treeCmd)
+}
+
+var treeCmd = &cobra.Command{
+	Use:   "tree [file]",
+	Short: "Print records grouped by the operations they belong to",
+	Long:  `Reads protobuf messages from STDIN or a file and prints the records logged by operations started with StartOperation under their operation, nested within the operations they were started in, in the order they were read. Records outside of any operation are printed at the top level.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filterProg, err := compileFilter(filterFlag)
+		if err != nil {
+			return fmt.Errorf("error compiling filter expression: %w", err)
+		}
+
+		input, closeInput, err := openInput(cmd, args)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+
+		tree := newOpTree()
+		err = slogproto.Read(cmd.Context(), input, func(r *slog.Record) bool {
+			tree.add(r)
+			return true
+		}, append(readOptions(cmd), slogproto.WithFilter(filterProg))...)
+		if err != nil {
+			return err
+		}
+
+		tree.write(cmd.OutOrStdout())
+		return nil
+	},
+}
+
+// opTree is a tree of operations and their records.
+type opTree struct {
+	root  opNode
+	nodes map[string]*opNode
+	order []*opNode
+}
+
+// opNode is an operation, and its records and child operations, in the
+// order they were first read.
+type opNode struct {
+	id, name string
+	attached bool
+	items    []opItem
+}
+
+// opItem is either a record or an operation.
+type opItem struct {
+	record string
+	node   *opNode
+}
+
+func newOpTree() *opTree {
+	return &opTree{nodes: make(map[string]*opNode)}
+}
+
+// node returns the operation with the ID, creating it if needed.
+func (t *opTree) node(id string) *opNode {
+	n, ok := t.nodes[id]
+	if !ok {
+		n = &opNode{id: id}
+		t.nodes[id] = n
+		t.order = append(t.order, n)
+	}
+	return n
+}
+
+// add adds the record to its operation.
+func (t *opTree) add(r *slog.Record) {
+	var (
+		op    slogproto.Operation
+		attrs []slog.Attr
+	)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == slogproto.OperationKey && a.Value.Kind() == slog.KindGroup {
+			for _, ga := range a.Value.Group() {
+				switch ga.Key {
+				case slogproto.OperationIDKey:
+					op.ID = ga.Value.String()
+				case slogproto.OperationParentKey:
+					op.ParentID = ga.Value.String()
+				case slogproto.OperationNameKey:
+					op.Name = ga.Value.String()
+				}
+			}
+			return true
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+
+	line := formatTreeRecord(r, attrs)
+	if op.ID == "" {
+		t.root.items = append(t.root.items, opItem{record: line})
+		return
+	}
+
+	n := t.node(op.ID)
+	n.name = op.Name
+	if !n.attached {
+		parent := &t.root
+		if op.ParentID != "" {
+			parent = t.node(op.ParentID)
+		}
+		parent.items = append(parent.items, opItem{node: n})
+		n.attached = true
+	}
+	n.items = append(n.items, opItem{record: line})
+}
+
+// write writes the tree, with the operations whose records were never
+// read, but whose child operations' were, at the top level.
+func (t *opTree) write(w io.Writer) {
+	for _, n := range t.order {
+		if !n.attached {
+			t.root.items = append(t.root.items, opItem{node: n})
+			n.attached = true
+		}
+	}
+	writeOpItems(w, t.root.items, 0)
+}
+
+// writeOpItems writes the records and operations indented to the depth.
+func writeOpItems(w io.Writer, items []opItem, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, item := range items {
+		if item.node == nil {
+			fmt.Fprintf(w, "%s%s\n", indent, item.record)
+			continue
+		}
+
+		name := item.node.name
+		if name == "" {
+			name = "?"
+		}
+		fmt.Fprintf(w, "%s%s [%s]\n", indent, name, item.node.id)
+		writeOpItems(w, item.node.items, depth+1)
+	}
+}
+
+// formatTreeRecord formats a record on a single line, with its attributes
+// flattened.
+func formatTreeRecord(r *slog.Record, attrs []slog.Attr) string {
+	var sb strings.Builder
+	if !r.Time.IsZero() {
+		sb.WriteString(r.Time.Format(time.TimeOnly + ".000"))
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(r.Level.String())
+	sb.WriteByte(' ')
+	sb.WriteString(r.Message)
+	for _, a := range slogproto.Flatten(attrs) {
+		fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value)
+	}
+	return sb.String()
+}
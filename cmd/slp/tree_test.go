@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestOpTree(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// record returns a record logged by the operation with the ID, if any,
+	// started in the parent operation, if any, a second after the last.
+	var n int
+	record := func(msg, id, parent, name string, args ...any) *slog.Record {
+		n++
+		r := slog.NewRecord(base.Add(time.Duration(n)*time.Second), slog.LevelInfo, msg, 0)
+		if id != "" {
+			op := []any{slog.String(slogproto.OperationIDKey, id)}
+			if parent != "" {
+				op = append(op, slog.String(slogproto.OperationParentKey, parent))
+			}
+			if name != "" {
+				op = append(op, slog.String(slogproto.OperationNameKey, name))
+			}
+			r.AddAttrs(slog.Group(slogproto.OperationKey, op...))
+		}
+		r.Add(args...)
+		return &r
+	}
+
+	tree := newOpTree()
+	for _, r := range []*slog.Record{
+		record("starting", "", "", ""),
+		record("checkout", "a", "", "checkout", slog.Group("user", "name", "ann")),
+		record("charging", "b", "a", "payment", "amount", 42),
+		// An operation whose parent's records weren't read.
+		record("orphan", "c", "x", "refund"),
+		record("charged", "b", "a", "payment"),
+		record("checked out", "a", "", "checkout"),
+		record("done", "", "", ""),
+		// An operation without a name.
+		record("unnamed", "d", "", ""),
+	} {
+		tree.add(r)
+	}
+
+	var buf bytes.Buffer
+	tree.write(&buf)
+
+	want := strings.Join([]string{
+		"12:00:01.000 INFO starting",
+		"checkout [a]",
+		"  12:00:02.000 INFO checkout user.name=ann",
+		"  payment [b]",
+		"    12:00:03.000 INFO charging amount=42",
+		"    12:00:05.000 INFO charged",
+		"  12:00:06.000 INFO checked out",
+		"12:00:07.000 INFO done",
+		"? [d]",
+		"  12:00:08.000 INFO unnamed",
+		"? [x]",
+		"  refund [c]",
+		"    12:00:04.000 INFO orphan",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestOpTree_startOperation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, nil))
+
+	ctx, opLogger := slogproto.StartOperation(context.Background(), logger, "import")
+	opLogger.Info("reading")
+	_, childLogger := slogproto.StartOperation(ctx, opLogger, "parse")
+	childLogger.Warn("skipped line", "line", 3)
+	logger.Info("idle")
+
+	tree := newOpTree()
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		tree.add(r)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	tree.write(&out)
+
+	// Drop the times and the random operation IDs.
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		fields := strings.Fields(line)
+		if strings.HasPrefix(fields[len(fields)-1], "[") {
+			fields = fields[:len(fields)-1]
+		} else {
+			fields = fields[1:]
+		}
+		lines = append(lines, line[:indent]+strings.Join(fields, " "))
+	}
+
+	want := strings.Join([]string{
+		"import",
+		"  INFO reading",
+		"  parse",
+		"    WARN skipped line line=3",
+		"INFO idle",
+	}, "\n")
+	if got := strings.Join(lines, "\n"); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/spf13/cobra"
+)
+
+var validateQuickFlag bool
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateQuickFlag, "quick", false, "only check that the stream trailer matches the size of the file, without reading the records")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a log file against its stream trailer",
+	Long:  `Reads a log file (or STDIN) written with a stream trailer, checking that every record can be read and that the file matches the record count, byte count, time range and checksum of the trailer. With --quick, only the trailer of an uncompressed file is read, to check that the file is complete.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			trailer *slogproto.StreamTrailer
+			err     error
+		)
+
+		if validateQuickFlag {
+			if len(args) == 0 {
+				return fmt.Errorf("--quick requires a file")
+			}
+			trailer, err = readTrailer(args[0])
+		} else {
+			input, closeInput, openErr := openInput(cmd, args)
+			if openErr != nil {
+				return openErr
+			}
+			defer closeInput()
+
+			trailer, err = slogproto.VerifyStream(cmd.Context(), input)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "valid: %d records, %s", trailer.Records, humanSize(int64(trailer.Bytes)))
+		if trailer.MinTime != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), ", from %s to %s", trailer.MinTime.AsTime().Format(time.RFC3339), trailer.MaxTime.AsTime().Format(time.RFC3339))
+		}
+		fmt.Fprintln(cmd.OutOrStdout())
+		return nil
+	},
+}
+
+// readTrailer returns the stream trailer of the named file, checking that
+// it accounts for the whole file.
+func readTrailer(name string) (*slogproto.StreamTrailer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	trailer, err := slogproto.ReadStreamTrailer(f, fi.Size())
+	if errors.Is(err, slogproto.ErrNoTrailer) {
+		return nil, fmt.Errorf("%w: the file is incomplete, compressed, or was written without one", err)
+	}
+	return trailer, err
+}
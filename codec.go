@@ -0,0 +1,165 @@
+package slogproto
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Codec is a format that whole streams, such as log files, are compressed
+// or encrypted with. Codecs register themselves with [RegisterCodec],
+// usually in the init function of their package, so that programs opt
+// into the dependencies of a codec by importing its package, if only for
+// its side effects:
+//
+//	import _ "github.com/picatz/slogproto/codec/snappy"
+//
+// The "none" and "gzip" codecs are always registered, and "zstd" unless
+// built with the slogproto_nozstd tag.
+type Codec struct {
+	// Name is the name of the codec, such as "gzip".
+	Name string
+
+	// Magic is the prefix of the streams written by the codec, which
+	// [DetectCodec] detects them by. Streams of codecs without one can't
+	// be detected.
+	Magic []byte
+
+	// NewWriter returns a writer encoding the data written to it to w.
+	// Close must be called to flush it, which doesn't close w.
+	NewWriter func(w io.Writer, opts *CodecOptions) (io.WriteCloser, error)
+
+	// NewReader returns a reader decoding the data read from r.
+	NewReader func(r io.Reader, opts *CodecOptions) (io.Reader, error)
+}
+
+// CodecOptions are options for the writers and readers of codecs, which
+// each codec uses as applicable. A nil *CodecOptions is equivalent to the
+// zero value.
+type CodecOptions struct {
+	// Dict is a compression dictionary, such as one trained with
+	// TrainDict for zstd.
+	Dict []byte
+
+	// Key is an encryption key.
+	Key []byte
+}
+
+// codecs holds the registered codecs by name.
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: make(map[string]Codec)}
+
+// RegisterCodec makes the codec available by its name. It panics if a
+// codec with the same name is already registered, or if the codec has no
+// name, writer or reader.
+func RegisterCodec(c Codec) {
+	if c.Name == "" || c.NewWriter == nil || c.NewReader == nil {
+		panic("slogproto: RegisterCodec needs a name, writer and reader")
+	}
+
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	if _, dup := codecs.m[c.Name]; dup {
+		panic("slogproto: RegisterCodec called twice for codec " + c.Name)
+	}
+	codecs.m[c.Name] = c
+}
+
+// LookupCodec returns the codec registered with the name.
+func LookupCodec(name string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	c, ok := codecs.m[name]
+	return c, ok
+}
+
+// Codecs returns the sorted names of the registered codecs.
+func Codecs() []string {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	names := make([]string, 0, len(codecs.m))
+	for name := range codecs.m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// DetectCodec returns the codec whose magic number starts the stream read
+// from r, or the "none" codec if there is none, along with a reader
+// returning all of the stream, including the bytes read to detect it.
+func DetectCodec(r io.Reader) (Codec, io.Reader, error) {
+	codecs.RLock()
+	var size int
+	for _, c := range codecs.m {
+		size = max(size, len(c.Magic))
+	}
+	codecs.RUnlock()
+
+	br := bufio.NewReader(r)
+	header, err := br.Peek(size)
+	if err != nil && err != io.EOF {
+		return Codec{}, nil, err
+	}
+
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	// Prefer the longest magic number matching the stream.
+	detected := codecs.m["none"]
+	for _, c := range codecs.m {
+		if len(c.Magic) > len(detected.Magic) && bytes.HasPrefix(header, c.Magic) {
+			detected = c
+		}
+	}
+	return detected, br, nil
+}
+
+// NewCodecWriter returns a writer encoding the data written to it to w
+// with the named codec. Close must be called to flush it.
+func NewCodecWriter(name string, w io.Writer, opts *CodecOptions) (io.WriteCloser, error) {
+	c, ok := LookupCodec(name)
+	if !ok {
+		return nil, fmt.Errorf("slogproto: unknown codec %q: expected one of %v", name, Codecs())
+	}
+	return c.NewWriter(w, opts)
+}
+
+func init() {
+	RegisterCodec(Codec{
+		Name: "none",
+		NewWriter: func(w io.Writer, _ *CodecOptions) (io.WriteCloser, error) {
+			return nopWriteCloser{w}, nil
+		},
+		NewReader: func(r io.Reader, _ *CodecOptions) (io.Reader, error) {
+			return r, nil
+		},
+	})
+
+	RegisterCodec(Codec{
+		Name:  "gzip",
+		Magic: []byte{0x1f, 0x8b},
+		NewWriter: func(w io.Writer, _ *CodecOptions) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, gzip.BestCompression)
+		},
+		NewReader: func(r io.Reader, _ *CodecOptions) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	})
+}
+
+// nopWriteCloser adds a no-op Close method to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,26 @@
+// Package snappy registers the "snappy" codec, which compresses streams in
+// the framing format of Snappy, with [slogproto.RegisterCodec]. Import it
+// for its side effects to make the codec available:
+//
+//	import _ "github.com/picatz/slogproto/codec/snappy"
+package snappy
+
+import (
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/picatz/slogproto"
+)
+
+func init() {
+	slogproto.RegisterCodec(slogproto.Codec{
+		Name:  "snappy",
+		Magic: []byte("\xff\x06\x00\x00sNaPpY"),
+		NewWriter: func(w io.Writer, _ *slogproto.CodecOptions) (io.WriteCloser, error) {
+			return snappy.NewBufferedWriter(w), nil
+		},
+		NewReader: func(r io.Reader, _ *slogproto.CodecOptions) (io.Reader, error) {
+			return snappy.NewReader(r), nil
+		},
+	})
+}
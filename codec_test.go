@@ -0,0 +1,76 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/picatz/slogproto"
+	_ "github.com/picatz/slogproto/codec/snappy"
+)
+
+func TestCodecs(t *testing.T) {
+	names := slogproto.Codecs()
+	for _, name := range []string{"none", "gzip", "snappy"} {
+		if !slices.Contains(names, name) {
+			t.Fatalf("expected codec %q to be registered, got %v", name, names)
+		}
+	}
+
+	data := bytes.Repeat([]byte("slogproto "), 1000)
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := slogproto.NewCodecWriter(name, &buf, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			codec, r, err := slogproto.DetectCodec(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if codec.Name != name {
+				t.Fatalf("expected codec %q to be detected, got %q", name, codec.Name)
+			}
+
+			dr, err := codec.NewReader(r, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(dr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("expected %d bytes to be decoded, got %d", len(data), len(got))
+			}
+		})
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	if _, err := slogproto.NewCodecWriter("unknown", io.Discard, nil); err == nil {
+		t.Fatal("expected an error for an unknown codec")
+	}
+
+	codec, ok := slogproto.LookupCodec("gzip")
+	if !ok {
+		t.Fatal("expected the gzip codec to be registered")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering a codec twice to panic")
+		}
+	}()
+	slogproto.RegisterCodec(codec)
+}
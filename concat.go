@@ -0,0 +1,167 @@
+package slogproto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Concat reads the streams of protobuf encoded slog records from each of
+// the readers in turn, as with Read, and writes their records to w as a
+// single stream, returning the number of records written.
+//
+// Each stream may have its own stream headers, which are checked and
+// replaced by a stream header at the start of w, so the result is
+// readable even if some of the streams didn't declare their schema
+// version. Records are copied exactly as they were encoded, in the
+// framing of their stream, with another stream header wherever it
+// differs from the framing of the records before them.
+func Concat(ctx context.Context, w io.Writer, readers ...io.Reader) (int64, error) {
+	fw := newFrameWriter(w)
+	if err := fw.writeHeader(); err != nil {
+		return 0, fmt.Errorf("error writing stream header: %w", err)
+	}
+
+	var n int64
+	for i, r := range readers {
+		fw.setFraming(Framing_FRAMING_UINT32_LE)
+
+		var writeErr error
+		err := readFrames(ctx, r, func(frame []byte, _ *slog.Record) bool {
+			if writeErr = fw.write(frame); writeErr != nil {
+				return false
+			}
+			n++
+			return true
+		}, withFramingChanges(fw.setFraming))
+		if err == nil && writeErr != nil {
+			err = fmt.Errorf("error writing record: %w", writeErr)
+		}
+		if err != nil {
+			return n, fmt.Errorf("stream %d: %w", i, err)
+		}
+	}
+
+	return n, nil
+}
+
+// Dedupe reads the streams of protobuf encoded slog records from each of
+// the readers in turn, like Concat, but only writes the first of the
+// records with the same [RecordID], such as when merging overlapping
+// copies of a stream. It returns the number of records written and the
+// number of duplicates that were dropped. The IDs of the records written
+// are kept in memory.
+func Dedupe(ctx context.Context, w io.Writer, readers ...io.Reader) (n, duplicates int64, err error) {
+	fw := newFrameWriter(w)
+	if err := fw.writeHeader(); err != nil {
+		return 0, 0, fmt.Errorf("error writing stream header: %w", err)
+	}
+
+	seen := make(map[string]struct{})
+	for i, r := range readers {
+		fw.setFraming(Framing_FRAMING_UINT32_LE)
+
+		var fnErr error
+		err := readFrames(ctx, r, func(frame []byte, r *slog.Record) bool {
+			id, err := RecordID(*r)
+			if err != nil {
+				fnErr = fmt.Errorf("error identifying record: %w", err)
+				return false
+			}
+			if _, ok := seen[id]; ok {
+				duplicates++
+				return true
+			}
+			seen[id] = struct{}{}
+
+			if err := fw.write(frame); err != nil {
+				fnErr = fmt.Errorf("error writing record: %w", err)
+				return false
+			}
+			n++
+			return true
+		}, withFramingChanges(fw.setFraming))
+		if err == nil {
+			err = fnErr
+		}
+		if err != nil {
+			return n, duplicates, fmt.Errorf("stream %d: %w", i, err)
+		}
+	}
+
+	return n, duplicates, nil
+}
+
+// Splice writes the records of the archive to w, with the records of
+// insert inserted among them in time order, returning the number of
+// records written. The archive must be ordered by time, as written by a
+// single logger; the records of insert may be in any order, and are
+// buffered in memory.
+//
+// Inserted records are written before the first record of the archive
+// that is later than them, so they follow the archive's records with the
+// same time. As with Concat, the output starts with a stream header, and
+// records are copied exactly as they were encoded, in the framing of the
+// archive.
+func Splice(ctx context.Context, w io.Writer, archive, insert io.Reader) (int64, error) {
+	type timedFrame struct {
+		time  time.Time
+		frame []byte
+	}
+
+	var inserts []timedFrame
+	err := readFrames(ctx, insert, func(frame []byte, r *slog.Record) bool {
+		inserts = append(inserts, timedFrame{time: r.Time, frame: slices.Clone(frame)})
+		return true
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error reading records to insert: %w", err)
+	}
+	slices.SortStableFunc(inserts, func(a, b timedFrame) int {
+		return a.time.Compare(b.time)
+	})
+
+	fw := newFrameWriter(w)
+	if err := fw.writeHeader(); err != nil {
+		return 0, fmt.Errorf("error writing stream header: %w", err)
+	}
+
+	var (
+		n        int64
+		writeErr error
+	)
+	write := func(frame []byte) bool {
+		if writeErr = fw.write(frame); writeErr != nil {
+			return false
+		}
+		n++
+		return true
+	}
+
+	err = readFrames(ctx, archive, func(frame []byte, r *slog.Record) bool {
+		for len(inserts) > 0 && inserts[0].time.Before(r.Time) {
+			if !write(inserts[0].frame) {
+				return false
+			}
+			inserts = inserts[1:]
+		}
+		return write(frame)
+	}, withFramingChanges(fw.setFraming))
+	if err != nil {
+		return n, fmt.Errorf("error reading archive: %w", err)
+	}
+
+	for _, tf := range inserts {
+		if !write(tf.frame) {
+			break
+		}
+	}
+	if writeErr != nil {
+		return n, fmt.Errorf("error writing record: %w", writeErr)
+	}
+
+	return n, nil
+}
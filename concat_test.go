@@ -0,0 +1,148 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+// writeRecords writes a record for each of the messages, at the given
+// times, and returns the stream.
+func writeRecords(t *testing.T, times []time.Time, msgs ...string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader())
+	for i, msg := range msgs {
+		if err := h.Handle(context.Background(), slog.NewRecord(times[i], slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf
+}
+
+// readMessages returns the messages of the records of the stream.
+func readMessages(t *testing.T, buf *bytes.Buffer) string {
+	t.Helper()
+
+	var msgs []string
+	err := slogproto.Read(context.Background(), buf, func(r *slog.Record) bool {
+		msgs = append(msgs, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(msgs, ",")
+}
+
+func TestConcat(t *testing.T) {
+	now := time.Now()
+	times := []time.Time{now, now, now}
+
+	var out bytes.Buffer
+	n, err := slogproto.Concat(context.Background(), &out,
+		writeRecords(t, times, "a1", "a2"),
+		bytes.NewReader(nil),
+		writeRecords(t, times, "b1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 records, got %d", n)
+	}
+
+	if got := bytes.Count(out.Bytes(), []byte(slogproto.StreamMagic)); got != 1 {
+		t.Fatalf("expected a single stream header, got %d", got)
+	}
+	if got := readMessages(t, &out); got != "a1,a2,b1" {
+		t.Fatalf("unexpected records %q", got)
+	}
+}
+
+func TestConcat_framing(t *testing.T) {
+	var wide bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&wide, nil, slogproto.WithFraming(slogproto.Framing_FRAMING_UINT64_BE)))
+	logger.Info("w1")
+	logger.Info("w2")
+
+	now := time.Now()
+	times := []time.Time{now, now}
+
+	// The records of each stream keep its framing, declared again where it
+	// changes.
+	var out bytes.Buffer
+	n, err := slogproto.Concat(context.Background(), &out,
+		writeRecords(t, times, "a1"),
+		&wide,
+		writeRecords(t, times, "b1", "b2"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 records, got %d", n)
+	}
+
+	if got := bytes.Count(out.Bytes(), []byte(slogproto.StreamMagic)); got != 3 {
+		t.Fatalf("expected 3 stream headers, got %d", got)
+	}
+	if got, want := readMessages(t, &out), "a1,w1,w2,b1,b2"; got != want {
+		t.Fatalf("expected records %q, got %q", want, got)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	base := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{base, base.Add(time.Second), base.Add(2 * time.Second)}
+
+	// The second copy overlaps the first, and has a record with the same
+	// message at a different time.
+	var out bytes.Buffer
+	n, duplicates, err := slogproto.Dedupe(context.Background(), &out,
+		writeRecords(t, times, "a", "b", "c"),
+		writeRecords(t, times, "b", "b", "c"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || duplicates != 2 {
+		t.Fatalf("expected 4 records and 2 duplicates, got %d and %d", n, duplicates)
+	}
+	if got, want := readMessages(t, &out), "a,b,c,b"; got != want {
+		t.Fatalf("expected records %q, got %q", want, got)
+	}
+}
+
+func TestSplice(t *testing.T) {
+	base := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds ...int) []time.Time {
+		var times []time.Time
+		for _, s := range seconds {
+			times = append(times, base.Add(time.Duration(s)*time.Second))
+		}
+		return times
+	}
+
+	var out bytes.Buffer
+	n, err := slogproto.Splice(context.Background(), &out,
+		writeRecords(t, at(1, 3, 5), "a1", "a3", "a5"),
+		writeRecords(t, at(6, 4, 2, 3, 0), "i6", "i4", "i2", "i3", "i0"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 {
+		t.Fatalf("expected 8 records, got %d", n)
+	}
+
+	if got, want := readMessages(t, &out), "i0,a1,i2,a3,i3,i4,a5,i6"; got != want {
+		t.Fatalf("expected records %q, got %q", want, got)
+	}
+}
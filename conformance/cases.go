@@ -0,0 +1,368 @@
+package conformance
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// epoch is the time of the records of the corpus, unless a case covers
+// times.
+var epoch = time.Date(2024, 1, 2, 3, 4, 5, 6_000_000, time.UTC)
+
+// Cases returns the cases of the corpus.
+func Cases() []Case {
+	return []Case{
+		{
+			Name:        "empty",
+			Description: "A stream without records.",
+		},
+		{
+			Name:        "header",
+			Description: "A stream header followed by a record.",
+			Header:      true,
+			Records:     []*slogproto.Record{record(slog.LevelInfo, "hello")},
+		},
+		{
+			Name:        "trailer",
+			Description: "A stream header, records and a stream trailer, as written by a handler with WithStreamTrailer.",
+			Header:      true,
+			Trailer:     true,
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "first", "n", intValue(1)),
+				record(slog.LevelWarn, "second", "n", intValue(2)),
+				record(slog.LevelError, "third", "n", intValue(3)),
+			},
+		},
+		{
+			Name:        "levels",
+			Description: "Records of the levels of slog, and of custom levels between and beyond them.",
+			Records: []*slogproto.Record{
+				record(slog.LevelDebug, "debug"),
+				record(slog.LevelInfo, "info"),
+				record(slog.LevelWarn, "warn"),
+				record(slog.LevelError, "error"),
+				record(slog.LevelDebug-4, "trace"),
+				record(slog.LevelInfo+2, "notice"),
+				record(slog.LevelError+4, "fatal"),
+				record(slog.Level(math.MinInt32), "min"),
+				record(slog.Level(math.MaxInt32), "max"),
+			},
+		},
+		{
+			Name:        "legacy-levels",
+			Description: "Records with only the level enum, without slog_level, which must be read as the matching slog level, and as INFO if unspecified.",
+			Records: []*slogproto.Record{
+				{Time: timestamppb.New(epoch), Message: "debug", Level: slogproto.Level_LEVEL_DEBUG},
+				{Time: timestamppb.New(epoch), Message: "info", Level: slogproto.Level_LEVEL_INFO},
+				{Time: timestamppb.New(epoch), Message: "warn", Level: slogproto.Level_LEVEL_WARN},
+				{Time: timestamppb.New(epoch), Message: "error", Level: slogproto.Level_LEVEL_ERROR},
+				{Time: timestamppb.New(epoch), Message: "unspecified"},
+			},
+		},
+		{
+			Name:        "messages",
+			Description: "Records with empty, multi-line, non-ASCII and large messages.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, ""),
+				record(slog.LevelInfo, "line one\nline two\ttabbed\r\n"),
+				record(slog.LevelInfo, "héllo, 世界 🌍 \u0000 nul"),
+				record(slog.LevelInfo, strings.Repeat("0123456789abcdef", 1<<12)),
+			},
+		},
+		{
+			Name:        "times",
+			Description: "Records without a time, at the Unix epoch, before it, with nanoseconds, and at the limits of the Timestamp message.",
+			Records: []*slogproto.Record{
+				{Message: "no time", Level: slogproto.Level_LEVEL_INFO},
+				{Time: timestamppb.New(time.Unix(0, 0)), Message: "epoch", Level: slogproto.Level_LEVEL_INFO},
+				{Time: timestamppb.New(time.Date(1969, 7, 20, 20, 17, 40, 0, time.UTC)), Message: "before epoch", Level: slogproto.Level_LEVEL_INFO},
+				{Time: timestamppb.New(time.Unix(1, 999_999_999)), Message: "nanoseconds", Level: slogproto.Level_LEVEL_INFO},
+				{Time: timestamppb.New(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)), Message: "min", Level: slogproto.Level_LEVEL_INFO},
+				{Time: timestamppb.New(time.Date(9999, 12, 31, 23, 59, 59, 999_999_999, time.UTC)), Message: "max", Level: slogproto.Level_LEVEL_INFO},
+			},
+		},
+		{
+			Name:        "kind-bool",
+			Description: "Bool values.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "bool", "true", &slogproto.Value{Kind: &slogproto.Value_Bool{Bool: true}}, "false", &slogproto.Value{Kind: &slogproto.Value_Bool{Bool: false}}),
+			},
+		},
+		{
+			Name:        "kind-int",
+			Description: "Int values at the limits of int64.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "int", "zero", intValue(0), "negative", intValue(-1), "min", intValue(math.MinInt64), "max", intValue(math.MaxInt64)),
+			},
+		},
+		{
+			Name:        "kind-uint",
+			Description: "Uint values at the limits of uint64.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "uint", "zero", uintValue(0), "max", uintValue(math.MaxUint64), "above int64", uintValue(math.MaxInt64+1)),
+			},
+		},
+		{
+			Name:        "kind-float",
+			Description: "Float values, including negative zero, infinities, NaN and the limits of float64.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "float",
+					"zero", floatValue(0),
+					"negative zero", floatValue(math.Copysign(0, -1)),
+					"pi", floatValue(math.Pi),
+					"max", floatValue(math.MaxFloat64),
+					"smallest", floatValue(math.SmallestNonzeroFloat64),
+					"inf", floatValue(math.Inf(1)),
+					"negative inf", floatValue(math.Inf(-1)),
+					"nan", floatValue(math.NaN()),
+				),
+			},
+		},
+		{
+			Name:        "kind-string",
+			Description: "String values, including empty, non-ASCII and large strings.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "string",
+					"empty", stringValue(""),
+					"unicode", stringValue("héllo, 世界 🌍"),
+					"escapes", stringValue("\"quoted\"\n\\"),
+					"large", stringValue(strings.Repeat("x", 1<<16)),
+				),
+			},
+		},
+		{
+			Name:        "kind-time",
+			Description: "Time values.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "time",
+					"epoch", &slogproto.Value{Kind: &slogproto.Value_Time{Time: timestamppb.New(time.Unix(0, 0))}},
+					"nanoseconds", &slogproto.Value{Kind: &slogproto.Value_Time{Time: timestamppb.New(epoch.Add(123))}},
+					"before epoch", &slogproto.Value{Kind: &slogproto.Value_Time{Time: timestamppb.New(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC))}},
+				),
+			},
+		},
+		{
+			Name:        "kind-duration",
+			Description: "Duration values, including negative durations and the limits of time.Duration.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "duration",
+					"zero", durationValue(0),
+					"second", durationValue(time.Second),
+					"negative", durationValue(-1500*time.Millisecond),
+					"max", durationValue(math.MaxInt64),
+					"min", durationValue(math.MinInt64),
+				),
+			},
+		},
+		{
+			Name:        "kind-any",
+			Description: "Any values: a Go value encoded as JSON, with a type URL prefixed with go/slog/, and an embedded protobuf message.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "any",
+					"json", &slogproto.Value{Kind: &slogproto.Value_Any{Any: &anypb.Any{TypeUrl: "go/slog/map[string]interface {}", Value: []byte(`{"a":[1,2,3],"b":null}`)}}},
+					"proto", anyValue(wrapperspb.String("wrapped")),
+				),
+			},
+		},
+		{
+			Name:        "kind-group",
+			Description: "Group values, with and without the order of their keys.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "ordered", "http", groupValue(true, "method", stringValue("GET"), "status", intValue(200), "path", stringValue("/"))),
+				unordered(record(slog.LevelInfo, "unordered", "http", groupValue(false, "method", stringValue("GET"), "status", intValue(200), "path", stringValue("/")))),
+			},
+		},
+		{
+			Name:        "nesting",
+			Description: "Groups nested 32 deep.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "nested", "g", nested(32)),
+			},
+		},
+		{
+			Name:        "key-order",
+			Description: "Records recording the order of their keys, which isn't sorted, with keys that need quoting.",
+			Records: []*slogproto.Record{
+				record(slog.LevelInfo, "ordered", "z", intValue(1), "a", intValue(2), "with space", intValue(3), "dotted.key", intValue(4), "ünïcode", intValue(5)),
+			},
+		},
+		{
+			Name:        "many-attrs",
+			Description: "A record with 1000 attributes.",
+			Records: []*slogproto.Record{
+				manyAttrs(1000),
+			},
+		},
+		{
+			Name:        "streams",
+			Description: "Records of different streams interleaved in one file.",
+			Records: []*slogproto.Record{
+				withStream(record(slog.LevelInfo, "from a"), "a"),
+				withStream(record(slog.LevelInfo, "from b"), "b"),
+				record(slog.LevelInfo, "from the default stream"),
+				withStream(record(slog.LevelInfo, "from a again"), "a"),
+			},
+		},
+		{
+			Name:        "invalid-truncated-size",
+			Description: "A stream ending with part of the size of a record.",
+			Invalid:     true,
+			data:        append(frame(record(slog.LevelInfo, "complete")), 0x10, 0x00),
+		},
+		{
+			Name:        "invalid-truncated-record",
+			Description: "A stream ending with part of a record.",
+			Invalid:     true,
+			data: func() []byte {
+				b := frame(record(slog.LevelInfo, "truncated"))
+				return b[:len(b)-3]
+			}(),
+		},
+		{
+			Name:        "invalid-record",
+			Description: "A frame which doesn't hold a valid Record message.",
+			Invalid:     true,
+			data:        []byte{0x04, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			Name:        "invalid-oversized",
+			Description: "A size prefix larger than any record a reader should accept.",
+			Invalid:     true,
+			data:        []byte{0xff, 0xff, 0xff, 0x7f, 0x00},
+		},
+		{
+			Name:        "invalid-version",
+			Description: "A stream header declaring an unsupported schema version.",
+			Invalid:     true,
+			data: func() []byte {
+				b, _ := proto.Marshal(&slogproto.StreamHeader{SchemaVersion: slogproto.SchemaVersion + 1})
+				header := binary.LittleEndian.AppendUint32([]byte(slogproto.StreamMagic), uint32(len(b)))
+				return append(append(header, b...), frame(record(slog.LevelInfo, "future"))...)
+			}(),
+		},
+	}
+}
+
+// record returns a record as written by the Handler with WithKeyOrder,
+// with the attributes given as pairs of keys and values.
+func record(level slog.Level, msg string, kvs ...any) *slogproto.Record {
+	pbr := &slogproto.Record{
+		Time:    timestamppb.New(epoch),
+		Message: msg,
+		Level:   slogproto.Level_LEVEL_INFO,
+	}
+
+	// The level enum only holds the levels of slog, so other levels are
+	// held by slog_level.
+	switch level {
+	case slog.LevelDebug:
+		pbr.Level = slogproto.Level_LEVEL_DEBUG
+	case slog.LevelInfo:
+	case slog.LevelWarn:
+		pbr.Level = slogproto.Level_LEVEL_WARN
+	case slog.LevelError:
+		pbr.Level = slogproto.Level_LEVEL_ERROR
+	default:
+		pbr.SlogLevel = int64(level)
+	}
+
+	for i := 0; i < len(kvs); i += 2 {
+		if pbr.Attrs == nil {
+			pbr.Attrs = map[string]*slogproto.Value{}
+		}
+		key := kvs[i].(string)
+		pbr.Attrs[key] = kvs[i+1].(*slogproto.Value)
+		pbr.Keys = append(pbr.Keys, key)
+	}
+	return pbr
+}
+
+// unordered removes the order of the attributes of the record, as written
+// by the Handler without WithKeyOrder.
+func unordered(pbr *slogproto.Record) *slogproto.Record {
+	pbr.Keys = nil
+	return pbr
+}
+
+func withStream(pbr *slogproto.Record, stream string) *slogproto.Record {
+	pbr.Stream = stream
+	return pbr
+}
+
+// frame returns the record framed by its size.
+func frame(pbr *slogproto.Record) []byte {
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(pbr)
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(b))), b...)
+}
+
+func intValue(v int64) *slogproto.Value {
+	return &slogproto.Value{Kind: &slogproto.Value_Int{Int: v}}
+}
+
+func uintValue(v uint64) *slogproto.Value {
+	return &slogproto.Value{Kind: &slogproto.Value_Uint{Uint: v}}
+}
+
+func floatValue(v float64) *slogproto.Value {
+	return &slogproto.Value{Kind: &slogproto.Value_Float{Float: v}}
+}
+
+func stringValue(v string) *slogproto.Value {
+	return &slogproto.Value{Kind: &slogproto.Value_String_{String_: v}}
+}
+
+func durationValue(v time.Duration) *slogproto.Value {
+	return &slogproto.Value{Kind: &slogproto.Value_Duration{Duration: durationpb.New(v)}}
+}
+
+func anyValue(m proto.Message) *slogproto.Value {
+	a, err := anypb.New(m)
+	if err != nil {
+		panic(err)
+	}
+	return &slogproto.Value{Kind: &slogproto.Value_Any{Any: a}}
+}
+
+// groupValue returns a group of the attributes given as pairs of keys and
+// values, recording their order if ordered is set.
+func groupValue(ordered bool, kvs ...any) *slogproto.Value {
+	g := &slogproto.Value_Group{Attrs: map[string]*slogproto.Value{}}
+	for i := 0; i < len(kvs); i += 2 {
+		key := kvs[i].(string)
+		g.Attrs[key] = kvs[i+1].(*slogproto.Value)
+		if ordered {
+			g.Keys = append(g.Keys, key)
+		}
+	}
+	return &slogproto.Value{Kind: &slogproto.Value_Group_{Group: g}}
+}
+
+// nested returns a group nested depth groups deep.
+func nested(depth int) *slogproto.Value {
+	v := stringValue("bottom")
+	for i := 0; i < depth; i++ {
+		v = groupValue(true, "g", v)
+	}
+	return v
+}
+
+// manyAttrs returns a record with n attributes.
+func manyAttrs(n int) *slogproto.Record {
+	pbr := record(slog.LevelInfo, "many")
+	pbr.Attrs = make(map[string]*slogproto.Value, n)
+	for i := 0; i < n; i++ {
+		pbr.Attrs[strings.Repeat("k", i%8+1)+strconv.Itoa(i)] = intValue(int64(i))
+	}
+	return pbr
+}
@@ -0,0 +1,323 @@
+// Package conformance provides a corpus of slogproto streams covering every
+// kind of value, nesting, levels, framing and edge cases, so that
+// implementations of the format in other languages, or with other protobuf
+// code generators, can prove that they are wire compatible with this
+// package.
+//
+// [Generate] writes the corpus to a directory, with an index.json file
+// listing its cases. Each case is a stream, <name>.slp, and the records it
+// holds, <name>.txtpb, in the protobuf text format of the
+// slogproto.v1.Record message, one per line. The text format is used
+// rather than JSON since Any values holding Go values, with type URLs
+// prefixed with go/slog/, can't be resolved to a message type. A conforming reader decodes
+// each stream to the records listed for it, and rejects the streams of the
+// cases marked invalid. The level of a record is its slog_level if that
+// isn't zero, or else its level.
+//
+// A conforming writer writes each listed record to a stream of its own,
+// with the stream header or trailer if the case has one, which [Verify]
+// checks against the records listed for the case. Streams don't need to be
+// byte for byte identical to the corpus, since the encoding of protobuf
+// maps isn't deterministic.
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// IndexFile is the name of the file listing the cases of a corpus.
+const IndexFile = "index.json"
+
+// Case is a stream of the corpus.
+type Case struct {
+	// Name is the name of the files of the case.
+	Name string `json:"name"`
+
+	// Description describes what the case covers.
+	Description string `json:"description"`
+
+	// Header and Trailer are set if the stream has a stream header or a
+	// stream trailer.
+	Header  bool `json:"header,omitempty"`
+	Trailer bool `json:"trailer,omitempty"`
+
+	// Invalid is set if readers must reject the stream.
+	Invalid bool `json:"invalid,omitempty"`
+
+	// Records are the records of the stream.
+	Records []*slogproto.Record `json:"-"`
+
+	// data is the stream of an invalid case.
+	data []byte
+}
+
+// Encode returns the stream of the case.
+func (c *Case) Encode() ([]byte, error) {
+	if c.Invalid {
+		return c.data, nil
+	}
+
+	var buf bytes.Buffer
+	if c.Trailer {
+		// Trailers are only written by the Handler, which writes records
+		// converted with fidelity as they were converted.
+		h := slogproto.NewHandler(&buf, nil, slogproto.WithFidelity(), slogproto.WithDeterministic(), slogproto.WithStreamHeader(), slogproto.WithStreamTrailer())
+		for _, pbr := range c.Records {
+			r, err := slogproto.ProtoToRecord(pbr, &slogproto.ConvertOptions{Fidelity: true})
+			if err != nil {
+				return nil, fmt.Errorf("conformance: case %s: %w", c.Name, err)
+			}
+			if err := h.Handle(context.Background(), r); err != nil {
+				return nil, fmt.Errorf("conformance: case %s: %w", c.Name, err)
+			}
+		}
+		if err := h.Shutdown(context.Background()); err != nil {
+			return nil, fmt.Errorf("conformance: case %s: %w", c.Name, err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	if c.Header {
+		if err := slogproto.WriteStreamHeader(&buf); err != nil {
+			return nil, err
+		}
+	}
+	for _, pbr := range c.Records {
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(pbr)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: case %s: %w", c.Name, err)
+		}
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(b))))
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// Generate writes the corpus to the directory, creating it if needed.
+func Generate(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	cases := Cases()
+	for i := range cases {
+		c := &cases[i]
+
+		stream, err := c.Encode()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, c.Name+".slp"), stream, 0o644); err != nil {
+			return err
+		}
+
+		var records bytes.Buffer
+		for _, pbr := range c.Records {
+			b, err := prototext.Marshal(pbr)
+			if err != nil {
+				return fmt.Errorf("conformance: case %s: %w", c.Name, err)
+			}
+			records.Write(b)
+			records.WriteByte('\n')
+		}
+		if err := os.WriteFile(filepath.Join(dir, c.Name+".txtpb"), records.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+
+	index, err := json.MarshalIndent(cases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, IndexFile), append(index, '\n'), 0o644)
+}
+
+// Result is the result of verifying a case.
+type Result struct {
+	Name string
+
+	// Err describes why the stream of the case doesn't conform, or is nil
+	// if it does.
+	Err error
+}
+
+// Verify checks the streams of the cases of the corpus in the directory,
+// as written by Generate or by another implementation, returning the
+// result of each case. It returns an error if the corpus itself can't be
+// read.
+//
+// Each valid stream must hold the records listed for its case, with a
+// stream header or trailer if the case has one, and be readable by this
+// package. Each invalid stream must be rejected by this package.
+func Verify(ctx context.Context, dir string) ([]Result, error) {
+	index, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	if err := json.Unmarshal(index, &cases); err != nil {
+		return nil, fmt.Errorf("conformance: invalid index: %w", err)
+	}
+
+	results := make([]Result, 0, len(cases))
+	for i := range cases {
+		c := &cases[i]
+
+		records, err := readRecords(filepath.Join(dir, c.Name+".txtpb"))
+		if err != nil {
+			return nil, fmt.Errorf("conformance: case %s: %w", c.Name, err)
+		}
+		c.Records = records
+
+		stream, err := os.ReadFile(filepath.Join(dir, c.Name+".slp"))
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, Result{Name: c.Name, Err: verifyCase(ctx, c, stream)})
+	}
+	return results, nil
+}
+
+// readRecords reads the records listed in the named file.
+func readRecords(name string) ([]*slogproto.Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*slogproto.Record
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		pbr := &slogproto.Record{}
+		if err := prototext.Unmarshal(sc.Bytes(), pbr); err != nil {
+			return nil, fmt.Errorf("invalid record %d: %w", len(records), err)
+		}
+		records = append(records, pbr)
+	}
+	return records, sc.Err()
+}
+
+// verifyCase checks the stream of the case.
+func verifyCase(ctx context.Context, c *Case, stream []byte) error {
+	var n int
+	readErr := slogproto.Read(ctx, bytes.NewReader(stream), func(*slog.Record) bool {
+		n++
+		return true
+	})
+
+	if c.Invalid {
+		if readErr == nil {
+			return errors.New("invalid stream was read without error")
+		}
+		return nil
+	}
+
+	if readErr != nil {
+		return fmt.Errorf("error reading stream: %w", readErr)
+	}
+	if n != len(c.Records) {
+		return fmt.Errorf("read %d records, expected %d", n, len(c.Records))
+	}
+
+	// Check the framing of the stream independently of the reader.
+	header, frames, trailer, err := splitStream(stream)
+	if err != nil {
+		return err
+	}
+	if header != c.Header {
+		return fmt.Errorf("stream header present: %t, expected %t", header, c.Header)
+	}
+	if trailer != c.Trailer {
+		return fmt.Errorf("stream trailer present: %t, expected %t", trailer, c.Trailer)
+	}
+	if trailer {
+		if _, err := slogproto.VerifyStream(ctx, bytes.NewReader(stream)); err != nil {
+			return err
+		}
+	}
+
+	if len(frames) != len(c.Records) {
+		return fmt.Errorf("stream has %d records, expected %d", len(frames), len(c.Records))
+	}
+	for i, frame := range frames {
+		pbr := &slogproto.Record{}
+		if err := proto.Unmarshal(frame, pbr); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		if !proto.Equal(pbr, c.Records[i]) {
+			want, _ := prototext.Marshal(c.Records[i])
+			got, _ := prototext.Marshal(pbr)
+			return fmt.Errorf("record %d differs\n  want: %s\n  got:  %s", i, want, got)
+		}
+	}
+	return nil
+}
+
+// splitStream returns the frames of the records of the stream, and
+// whether it has a stream header and a stream trailer.
+func splitStream(stream []byte) (header bool, frames [][]byte, trailer bool, err error) {
+	for offset := 0; offset < len(stream); {
+		if len(stream)-offset < 4 {
+			return false, nil, false, fmt.Errorf("truncated size at offset %d", offset)
+		}
+		magic := string(stream[offset : offset+4])
+		if magic == slogproto.StreamMagic || magic == slogproto.TrailerMagic {
+			offset += 4
+			if len(stream)-offset < 4 {
+				return false, nil, false, fmt.Errorf("truncated size at offset %d", offset)
+			}
+		}
+
+		size := int(binary.LittleEndian.Uint32(stream[offset:]))
+		offset += 4
+		if size > len(stream)-offset {
+			return false, nil, false, fmt.Errorf("truncated frame at offset %d", offset-4)
+		}
+		frame := stream[offset : offset+size]
+		offset += size
+
+		switch magic {
+		case slogproto.StreamMagic:
+			if offset-size-8 != 0 {
+				return false, nil, false, fmt.Errorf("stream header at offset %d", offset-size-8)
+			}
+			sh := &slogproto.StreamHeader{}
+			if err := proto.Unmarshal(frame, sh); err != nil {
+				return false, nil, false, fmt.Errorf("invalid stream header: %w", err)
+			}
+			if sh.SchemaVersion != slogproto.SchemaVersion {
+				return false, nil, false, fmt.Errorf("stream header declares schema version %d", sh.SchemaVersion)
+			}
+			header = true
+		case slogproto.TrailerMagic:
+			// The trailer ends with its size again.
+			if len(stream)-offset != 4 || int(binary.LittleEndian.Uint32(stream[offset:])) != size {
+				return false, nil, false, fmt.Errorf("stream trailer at offset %d doesn't end the stream", offset-size-8)
+			}
+			return header, frames, true, nil
+		default:
+			frames = append(frames, frame)
+		}
+	}
+	return header, frames, false, nil
+}
@@ -0,0 +1,82 @@
+package conformance_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/picatz/slogproto/conformance"
+)
+
+func TestCorpus(t *testing.T) {
+	dir := t.TempDir()
+	if err := conformance.Generate(dir); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	results, err := conformance.Verify(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if len(results) != len(conformance.Cases()) {
+		t.Fatalf("expected %d results, got %d", len(conformance.Cases()), len(results))
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("case %s: %v", result.Name, result.Err)
+		}
+	}
+
+	// The corpus is generated deterministically.
+	again := t.TempDir()
+	if err := conformance.Generate(again); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	for _, c := range conformance.Cases() {
+		a, _ := os.ReadFile(filepath.Join(dir, c.Name+".slp"))
+		b, _ := os.ReadFile(filepath.Join(again, c.Name+".slp"))
+		if !bytes.Equal(a, b) {
+			t.Errorf("case %s: stream differs between runs", c.Name)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	if err := conformance.Generate(dir); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	// A writer which drops the level of custom levels doesn't conform.
+	name := filepath.Join(dir, "levels.slp")
+	stream, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	stream = bytes.Replace(stream, []byte("notice"), []byte("NOTICE"), 1)
+	if err := os.WriteFile(name, stream, 0o644); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	// An invalid stream which can be read doesn't conform either.
+	if err := os.WriteFile(filepath.Join(dir, "invalid-record.slp"), nil, 0o644); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	results, err := conformance.Verify(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	failed := map[string]bool{}
+	for _, result := range results {
+		if result.Err != nil {
+			failed[result.Name] = true
+		}
+	}
+	if len(failed) != 2 || !failed["levels"] || !failed["invalid-record"] {
+		t.Fatalf("expected the levels and invalid-record cases to fail, got %v", failed)
+	}
+}
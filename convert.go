@@ -0,0 +1,91 @@
+package slogproto
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// ErrLossy is returned when a record cannot be converted with fidelity,
+// because decoding it would not produce the record it was encoded from.
+var ErrLossy = errors.New("slogproto: lossy conversion")
+
+// anyTypeURLPrefix prefixes the type URL of Any values holding a Go value
+// marshaled as JSON.
+const anyTypeURLPrefix = "go/slog/"
+
+// ConvertOptions are options for [RecordToProto] and [ProtoToRecord]. A
+// nil *ConvertOptions is equivalent to the zero value.
+type ConvertOptions struct {
+	// Fidelity guarantees that ProtoToRecord(RecordToProto(r)) is
+	// semantically equal to r: it has the same time instant, level,
+	// message and attributes, in the same order, including custom levels
+	// and the distinction between signed and unsigned integers.
+	//
+	// Records that cannot be converted losslessly are rejected with
+	// ErrLossy instead of being silently coerced. These are records with
+	// attributes that have an empty key or a duplicate key within the same
+	// group, or that hold an Any value other than an [anypb.Any] or a
+	// type registered with [RegisterValueType], since arbitrary Go values
+	// are encoded as JSON. When decoding, records that
+	// were not encoded with fidelity are rejected in the same way.
+	//
+	// Attributes holding a [slog.LogValuer] are resolved, the location of
+	// times is not preserved, and the program counter of the record is
+	// not encoded.
+	Fidelity bool
+}
+
+// RecordToProto converts a slog record to a slogproto Record, following
+// the same rules as the [Handler].
+func RecordToProto(r slog.Record, opts *ConvertOptions) (*Record, error) {
+	fidelity := opts != nil && opts.Fidelity
+
+	h := &Handler{
+		opts: &slog.HandlerOptions{},
+		enc:  encoding{fidelity: fidelity, keyOrder: fidelity},
+	}
+
+	pbr := &Record{}
+	if err := h.fillProtobufRecord(pbr, &r); err != nil {
+		return nil, err
+	}
+	return pbr, nil
+}
+
+// ProtoToRecord converts a slogproto Record to a slog record, like [Read].
+// Attributes are ordered as they were encoded, if their order was
+// recorded, or else sorted by key.
+func ProtoToRecord(pbr *Record, opts *ConvertOptions) (slog.Record, error) {
+	return fromPBRecord(pbr, &decodeLimits{}, opts != nil && opts.Fidelity, nil)
+}
+
+// AttrToProto converts the value of a slog attribute to a slogproto Value,
+// following the same rules as the [Handler], so that it can be embedded in
+// other protocol buffer messages, such as the details of a gRPC error,
+// without encoding a whole record. The order of the attributes of groups
+// is recorded, and values holding an [anypb.Any] are embedded as they are.
+func AttrToProto(a slog.Attr) (*Value, error) {
+	v, err := getValue(a.Value, encoding{keyOrder: true, embedAny: true})
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		// Empty groups are omitted by the Handler, but still have a value.
+		v = &Value{Kind: &Value_Group_{Group: &Value_Group{}}}
+	}
+	return v, nil
+}
+
+// ProtoToAttr converts a slogproto Value to a slog attribute with the
+// given key, like [Read].
+func ProtoToAttr(key string, v *Value) (slog.Attr, error) {
+	if v == nil {
+		return slog.Attr{}, errors.New("slogproto: nil value")
+	}
+
+	value, err := fromPBValue(v, &decodeLimits{}, 0, false)
+	if err != nil {
+		return slog.Attr{}, err
+	}
+	return slog.Attr{Key: key, Value: value}, nil
+}
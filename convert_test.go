@@ -0,0 +1,279 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// randomRecord returns a record with a random time, level, message and
+// attributes, which can be converted with fidelity.
+func randomRecord(rng *rand.Rand) slog.Record {
+	locations := []*time.Location{time.UTC, time.Local, time.FixedZone("X", -7*60*60)}
+
+	var t time.Time
+	if rng.IntN(10) > 0 {
+		t = time.Unix(0, rng.Int64()).In(locations[rng.IntN(len(locations))])
+	}
+
+	r := slog.NewRecord(t, slog.Level(rng.IntN(41)-20), fmt.Sprintf("message %d", rng.Int()), 0)
+	r.AddAttrs(randomAttrs(rng, 0)...)
+	return r
+}
+
+// randomAttrs returns up to eight attributes with unique keys, including
+// groups nested up to three levels deep.
+func randomAttrs(rng *rand.Rand, depth int) []slog.Attr {
+	n := rng.IntN(8)
+	if depth > 0 {
+		n++ // Groups can't be empty.
+	}
+
+	attrs := make([]slog.Attr, 0, n)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("k%d-%d", i, rng.IntN(1000))
+
+		var v slog.Value
+		switch kind := rng.IntN(9); {
+		case kind == 8 && depth < 3:
+			v = slog.GroupValue(randomAttrs(rng, depth+1)...)
+		case kind == 7:
+			v = slog.AnyValue(&anypb.Any{
+				TypeUrl: "type.googleapis.com/example.Message",
+				Value:   []byte(fmt.Sprint(rng.Int())),
+			})
+		case kind == 6:
+			v = slog.DurationValue(time.Duration(rng.Int64()))
+		case kind == 5:
+			v = slog.TimeValue(time.Unix(rng.Int64N(1<<40), rng.Int64N(1e9)))
+		case kind == 4:
+			v = slog.Uint64Value(rng.Uint64())
+		case kind == 3:
+			v = slog.Int64Value(rng.Int64() - rng.Int64())
+		case kind == 2:
+			v = slog.Float64Value(rng.NormFloat64())
+		case kind == 1:
+			v = slog.BoolValue(rng.IntN(2) == 0)
+		default:
+			v = slog.StringValue(fmt.Sprint(rng.Int()))
+		}
+
+		attrs = append(attrs, slog.Attr{Key: key, Value: v})
+	}
+	return attrs
+}
+
+// recordsEqual reports whether two records are semantically equal.
+func recordsEqual(a, b slog.Record) bool {
+	if !a.Time.Equal(b.Time) || a.Level != b.Level || a.Message != b.Message {
+		return false
+	}
+
+	return attrsEqual(recordAttrs(a), recordAttrs(b))
+}
+
+func recordAttrs(r slog.Record) []slog.Attr {
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+func attrsEqual(a, b []slog.Attr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Key != b[i].Key || a[i].Value.Kind() != b[i].Value.Kind() {
+			return false
+		}
+
+		switch a[i].Value.Kind() {
+		case slog.KindGroup:
+			if !attrsEqual(a[i].Value.Group(), b[i].Value.Group()) {
+				return false
+			}
+		case slog.KindAny:
+			am, aok := a[i].Value.Any().(proto.Message)
+			bm, bok := b[i].Value.Any().(proto.Message)
+			if !aok || !bok || !proto.Equal(am, bm) {
+				return false
+			}
+		default:
+			if !a[i].Value.Equal(b[i].Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestRecordToProto_fidelity(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	opts := &slogproto.ConvertOptions{Fidelity: true}
+
+	for i := 0; i < 1000; i++ {
+		r := randomRecord(rng)
+
+		pbr, err := slogproto.RecordToProto(r, opts)
+		if err != nil {
+			t.Fatalf("record %d: unexpected error: %v", i, err)
+		}
+
+		// Round-trip through the wire format too.
+		b, err := proto.Marshal(pbr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbr = &slogproto.Record{}
+		if err := proto.Unmarshal(b, pbr); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := slogproto.ProtoToRecord(pbr, opts)
+		if err != nil {
+			t.Fatalf("record %d: unexpected error: %v", i, err)
+		}
+
+		if !recordsEqual(r, got) {
+			t.Fatalf("record %d: round trip changed the record\nwant: %v\ngot:  %v", i, recordAttrs(r), recordAttrs(got))
+		}
+	}
+}
+
+func TestRecordToProto_lossy(t *testing.T) {
+	opts := &slogproto.ConvertOptions{Fidelity: true}
+
+	tests := map[string][]slog.Attr{
+		"json any":      {slog.Any("err", errors.New("boom"))},
+		"empty key":     {slog.Int("", 1)},
+		"inline group":  {slog.Group("", slog.Int("a", 1))},
+		"duplicate key": {slog.Int("a", 1), slog.Int("a", 2)},
+		"nested duplicate key": {
+			slog.Group("g", slog.Int("a", 1), slog.Int("a", 2)),
+		},
+	}
+
+	for name, attrs := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+			r.AddAttrs(attrs...)
+
+			_, err := slogproto.RecordToProto(r, opts)
+			if !errors.Is(err, slogproto.ErrLossy) {
+				t.Fatalf("expected ErrLossy, got %v", err)
+			}
+
+			// Without fidelity, the record is coerced.
+			if _, err := slogproto.RecordToProto(r, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProtoToRecord_lossy(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+	r.AddAttrs(slog.Int("b", 1), slog.Int("a", 2))
+
+	// Records encoded without fidelity don't record the attribute order.
+	pbr, err := slogproto.RecordToProto(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = slogproto.ProtoToRecord(pbr, &slogproto.ConvertOptions{Fidelity: true})
+	if !errors.Is(err, slogproto.ErrLossy) {
+		t.Fatalf("expected ErrLossy, got %v", err)
+	}
+
+	// Without fidelity, the attributes are sorted by key.
+	got, err := slogproto.ProtoToRecord(pbr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := recordAttrs(got)
+	if len(attrs) != 2 || attrs[0].Key != "a" || attrs[1].Key != "b" {
+		t.Fatalf("expected attributes sorted by key, got %v", attrs)
+	}
+}
+
+func TestHandler_fidelity(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug - 4}, slogproto.WithFidelity()))
+
+	logger.With("z", 1).WithGroup("g").Log(context.Background(), slog.LevelDebug-2, "trace", "y", uint64(2), "x", 3)
+
+	var got *slog.Record
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		got = r
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got == nil {
+		t.Fatal("expected a record")
+	}
+
+	if got.Level != slog.LevelDebug-2 {
+		t.Errorf("expected level %v, got %v", slog.LevelDebug-2, got.Level)
+	}
+
+	want := []slog.Attr{
+		slog.Int("z", 1),
+		slog.Group("g", slog.Uint64("y", 2), slog.Int("x", 3)),
+	}
+	if attrs := recordAttrs(*got); !attrsEqual(want, attrs) {
+		t.Errorf("expected %v, got %v", want, attrs)
+	}
+}
+
+func TestAttrToProto(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 100; i++ {
+		for _, a := range randomAttrs(rng, 0) {
+			v, err := slogproto.AttrToProto(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The value can be embedded in other messages.
+			b, err := proto.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v = &slogproto.Value{}
+			if err := proto.Unmarshal(b, v); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := slogproto.ProtoToAttr(a.Key, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !attrsEqual([]slog.Attr{a}, []slog.Attr{got}) {
+				t.Fatalf("expected %v, got %v", a, got)
+			}
+		}
+	}
+
+	if _, err := slogproto.ProtoToAttr("nil", nil); err == nil {
+		t.Fatal("expected an error converting a nil value")
+	}
+}
@@ -0,0 +1,125 @@
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// crashFlushTimeout bounds how long FlushOnPanic and Exit wait for the
+// records being written by each handler.
+const crashFlushTimeout = 5 * time.Second
+
+// crashHandlers holds the handlers registered with FlushOnCrash.
+var crashHandlers struct {
+	mu       sync.Mutex
+	handlers map[*Handler]struct{}
+}
+
+// FlushOnCrash registers the handler to be shut down by [FlushOnPanic]
+// and [Exit] before the process dies, so that the last records, which are
+// often the most important, aren't lost in the buffers of the writer or
+// of a compressor. Once shut down, the writer is also committed to stable
+// storage if it implements a Sync() error method, such as [os.File] and
+// [File].
+//
+// It returns a function that unregisters the handler.
+//
+// # Example
+//
+//	func main() {
+//		h := slogproto.NewHandler(f, nil, slogproto.WithGzip())
+//		slogproto.FlushOnCrash(h)
+//		defer slogproto.FlushOnPanic()
+//
+//		if err := run(); err != nil {
+//			slog.New(h).Error("failed", "err", err)
+//			slogproto.Exit(1)
+//		}
+//	}
+func FlushOnCrash(h *Handler) (unregister func()) {
+	crashHandlers.mu.Lock()
+	defer crashHandlers.mu.Unlock()
+
+	if crashHandlers.handlers == nil {
+		crashHandlers.handlers = make(map[*Handler]struct{})
+	}
+	crashHandlers.handlers[h] = struct{}{}
+
+	return func() {
+		crashHandlers.mu.Lock()
+		defer crashHandlers.mu.Unlock()
+
+		delete(crashHandlers.handlers, h)
+	}
+}
+
+// FlushOnPanic shuts down the handlers registered with FlushOnCrash if the
+// goroutine is panicking, and then continues panicking. It must be called
+// directly by a deferred call, at the start of main and of any goroutine
+// whose panics should be flushed:
+//
+//	defer slogproto.FlushOnPanic()
+func FlushOnPanic() {
+	if r := recover(); r != nil {
+		flushCrashHandlers()
+		panic(r)
+	}
+}
+
+// Exit shuts down the handlers registered with FlushOnCrash, and then
+// exits the process with the given status code, like [os.Exit], which
+// would otherwise lose the records they haven't written.
+func Exit(code int) {
+	flushCrashHandlers()
+	os.Exit(code)
+}
+
+// flushCrashHandlers shuts down the registered handlers, and syncs their
+// writers, reporting errors to stderr since the process is dying.
+func flushCrashHandlers() {
+	crashHandlers.mu.Lock()
+	handlers := make([]*Handler, 0, len(crashHandlers.handlers))
+	for h := range crashHandlers.handlers {
+		handlers = append(handlers, h)
+	}
+	crashHandlers.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), crashFlushTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, h := range handlers {
+		errs = append(errs, h.shutdownAndSync(ctx))
+	}
+	if err := errors.Join(errs...); err != nil {
+		fmt.Fprintf(os.Stderr, "slogproto: error flushing logs: %v\n", err)
+	}
+}
+
+// shutdownAndSync shuts down the handler, and commits its writer to stable
+// storage if it can be synced.
+func (h *Handler) shutdownAndSync(ctx context.Context) error {
+	if err := h.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var w io.Writer = *h.w
+	if h.compressor != nil {
+		w = h.compressor.wrapped
+	}
+
+	if s, ok := w.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			return fmt.Errorf("slogproto: error syncing writer: %w", err)
+		}
+	}
+	return nil
+}
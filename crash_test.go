@@ -0,0 +1,65 @@
+package slogproto_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestFlushOnPanic(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log.gz")
+
+	f, err := slogproto.OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	h := slogproto.NewHandler(f, nil, slogproto.WithGzip())
+	defer slogproto.FlushOnCrash(h)()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("expected the panic to continue, got %v", r)
+			}
+		}()
+		defer slogproto.FlushOnPanic()
+
+		slog.New(h).Error("about to panic")
+		panic("boom")
+	}()
+
+	if err := h.Handle(context.Background(), slog.Record{}); !errors.Is(err, slogproto.ErrHandlerClosed) {
+		t.Fatalf("expected the handler to be shut down, got %v", err)
+	}
+
+	rf, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	gz, err := gzip.NewReader(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs []string
+	err = slogproto.Read(context.Background(), gz, func(r *slog.Record) bool {
+		msgs = append(msgs, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0] != "about to panic" {
+		t.Fatalf("expected the record to be flushed, got %v", msgs)
+	}
+}
@@ -0,0 +1,252 @@
+//go:build !slogproto_nozstd && !tinygo
+
+package slogproto
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultDictSize is the default maximum size, in bytes, of a dictionary
+// trained with [TrainDict], which is the default of the zstd command.
+const DefaultDictSize = 112640
+
+// dictSampleFactor is the size of the sample of records a dictionary is
+// trained on, relative to the size of the dictionary.
+const dictSampleFactor = 100
+
+// dictMatchLen is the length of the byte sequences counted when training
+// a dictionary, which are long enough to be worth a zstd match.
+const dictMatchLen = 8
+
+// TrainDict samples the records of the streams of protobuf encoded slog
+// records read from the readers, such as existing archives, and returns a
+// zstd dictionary of at most size bytes, or DefaultDictSize if size is
+// zero or less.
+//
+// Records are short and share most of their bytes with each other, such
+// as attribute keys, messages and common values, so compressing them with
+// a dictionary of these gives much better ratios than generic zstd,
+// especially for records that are flushed one at a time. See [WithZstd]
+// and [WithZstdDict].
+//
+// The dictionary holds the sampled records which share the most byte
+// sequences with the others, in raw form, so it can be used with zstd
+// implementations supporting raw content dictionaries under the ID given
+// by [DictID].
+func TrainDict(ctx context.Context, size int, readers ...io.Reader) ([]byte, error) {
+	if size <= 0 {
+		size = DefaultDictSize
+	}
+
+	// Keep a uniform sample of the records, once there are more than the
+	// sample size.
+	var (
+		samples    [][]byte
+		sampleSize int
+		seen       int64
+		full       bool
+	)
+	for i, r := range readers {
+		err := readFrames(ctx, r, func(frame []byte, _ *slog.Record) bool {
+			seen++
+			if !full {
+				samples = append(samples, slices.Clone(frame))
+				sampleSize += len(frame)
+				full = sampleSize >= size*dictSampleFactor
+				return true
+			}
+			if j := rand.Int64N(seen); j < int64(len(samples)) {
+				samples[j] = slices.Clone(frame)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("stream %d: %w", i, err)
+		}
+	}
+
+	// Count the number of records each byte sequence appears in.
+	matches := make([][]uint64, len(samples))
+	counts := make(map[uint64]int)
+	for i, sample := range samples {
+		matches[i] = sampleMatches(sample)
+		for _, m := range matches[i] {
+			counts[m]++
+		}
+	}
+
+	// gain returns the number of other records sharing the byte sequences
+	// of a record which aren't in the dictionary yet.
+	gain := func(i int) int {
+		var n int
+		for _, m := range matches[i] {
+			if c := counts[m]; c > 1 {
+				n += c - 1
+			}
+		}
+		return n
+	}
+
+	// Consider the records sharing the most per byte first.
+	order := make([]int, len(samples))
+	scores := make([]float64, len(samples))
+	for i := range order {
+		order[i] = i
+		scores[i] = float64(gain(i)) / float64(max(len(samples[i]), 1))
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case scores[a] > scores[b]:
+			return -1
+		case scores[a] < scores[b]:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// Add records to the dictionary while they still share sequences with
+	// others that it doesn't have.
+	var (
+		chosen []int
+		total  int
+	)
+	for _, i := range order {
+		if total+len(samples[i]) > size || gain(i) == 0 {
+			continue
+		}
+		chosen = append(chosen, i)
+		total += len(samples[i])
+		for _, m := range matches[i] {
+			counts[m] = 0
+		}
+	}
+	if len(chosen) == 0 {
+		return nil, errors.New("slogproto: not enough similar records to train a dictionary")
+	}
+
+	// The most useful records go last, as zstd finds matches closer to
+	// the data more cheaply.
+	dict := make([]byte, 0, total)
+	for j := len(chosen) - 1; j >= 0; j-- {
+		dict = append(dict, samples[chosen[j]]...)
+	}
+	return dict, nil
+}
+
+// sampleMatches returns the distinct byte sequences of the sample which
+// are counted when training a dictionary.
+func sampleMatches(sample []byte) []uint64 {
+	seen := make(map[uint64]struct{})
+	var matches []uint64
+	for i := 0; i+dictMatchLen <= len(sample); i++ {
+		m := binary.LittleEndian.Uint64(sample[i:])
+		if _, ok := seen[m]; !ok {
+			seen[m] = struct{}{}
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// DictID returns the ID of the dictionary, which is recorded in the
+// headers of zstd frames compressed with it. It is derived from the
+// content of the dictionary, within the range of IDs that aren't reserved
+// by zstd.
+func DictID(dict []byte) uint32 {
+	const minID, maxID = 1 << 15, 1<<31 - 1
+	return minID + crc32.ChecksumIEEE(dict)%(maxID-minID+1)
+}
+
+// zstdEncoderOptions returns the options of zstd encoders compressing
+// records with the dictionary, if it isn't empty.
+func zstdEncoderOptions(dict []byte) []zstd.EOption {
+	opts := []zstd.EOption{zstd.WithEncoderLevel(zstd.SpeedBetterCompression)}
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithEncoderDictRaw(DictID(dict), dict))
+	}
+	return opts
+}
+
+// zstdDecoderOptions returns the options of zstd decoders decompressing
+// records compressed with the dictionary, if it isn't empty.
+func zstdDecoderOptions(dict []byte) []zstd.DOption {
+	var opts []zstd.DOption
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithDecoderDictRaw(DictID(dict), dict))
+	}
+	return opts
+}
+
+// WithZstd configures the handler to compress the records it writes with
+// zstd, using the dictionary if it isn't empty, such as one trained with
+// [TrainDict]. As with WithGzip, the compressor is flushed and closed by
+// [Handler.Shutdown], which must be called to produce a complete stream.
+//
+// The records can be read with [WithZstdDict] and the same dictionary.
+// WithZstd panics if the dictionary is larger than zstd supports, or if
+// the handler already compresses its records, such as with WithGzip.
+func WithZstd(dict []byte) HandlerOption {
+	return func(h *Handler) {
+		err := h.compress(func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, zstdEncoderOptions(dict)...)
+		})
+		if errors.Is(err, errCompressed) {
+			panic(err)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("slogproto: invalid zstd dictionary: %v", err))
+		}
+	}
+}
+
+// WithZstdDict configures Read to decompress its input with zstd, using
+// the dictionary it was compressed with, such as by a handler configured
+// with [WithZstd]. An empty dictionary reads input compressed without
+// one.
+func WithZstdDict(dict []byte) ReadOption {
+	return func(c *readConfig) {
+		c.decompress = func(r io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(r, zstdDecoderOptions(dict)...)
+			if err != nil {
+				return nil, fmt.Errorf("slogproto: error creating zstd reader: %w", err)
+			}
+			return zr.IOReadCloser(), nil
+		}
+	}
+}
+
+func init() {
+	RegisterCodec(Codec{
+		Name:  "zstd",
+		Magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		NewWriter: func(w io.Writer, opts *CodecOptions) (io.WriteCloser, error) {
+			var dict []byte
+			if opts != nil {
+				dict = opts.Dict
+			}
+			eopts := []zstd.EOption{zstd.WithEncoderLevel(zstd.SpeedBestCompression)}
+			if len(dict) > 0 {
+				eopts = append(eopts, zstd.WithEncoderDictRaw(DictID(dict), dict))
+			}
+			return zstd.NewWriter(w, eopts...)
+		},
+		NewReader: func(r io.Reader, opts *CodecOptions) (io.Reader, error) {
+			var dict []byte
+			if opts != nil {
+				dict = opts.Dict
+			}
+			return zstd.NewReader(r, zstdDecoderOptions(dict)...)
+		},
+	})
+}
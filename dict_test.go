@@ -0,0 +1,93 @@
+//go:build !slogproto_nozstd && !tinygo
+
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestTrainDict(t *testing.T) {
+	logRequests := func(w *bytes.Buffer, n int, options ...slogproto.HandlerOption) *slogproto.Handler {
+		h := slogproto.NewHandler(w, nil, options...)
+		logger := slog.New(h)
+		for i := 0; i < n; i++ {
+			logger.Info("handled request",
+				slog.Group("http",
+					slog.String("method", []string{"GET", "POST"}[i%2]),
+					slog.String("path", fmt.Sprintf("/api/v1/users/%d", i%17)),
+					slog.Int("status", 200),
+				),
+				slog.String("request_id", fmt.Sprintf("req-%08d", i)),
+			)
+		}
+		return h
+	}
+
+	var archive bytes.Buffer
+	logRequests(&archive, 1000)
+
+	dict, err := slogproto.TrainDict(context.Background(), 4096, &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dict) == 0 || len(dict) > 4096 {
+		t.Fatalf("expected a dictionary of at most 4096 bytes, got %d", len(dict))
+	}
+
+	compress := func(dict []byte) *bytes.Buffer {
+		var buf bytes.Buffer
+		h := logRequests(&buf, 5, slogproto.WithZstd(dict))
+		if err := h.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return &buf
+	}
+
+	withDict, withoutDict := compress(dict), compress(nil)
+	if withDict.Len() >= withoutDict.Len() {
+		t.Errorf("expected the dictionary to improve compression, got %d bytes with it and %d without", withDict.Len(), withoutDict.Len())
+	}
+
+	var n int
+	err = slogproto.Read(context.Background(), bytes.NewReader(withDict.Bytes()), func(r *slog.Record) bool {
+		if v, ok := slogproto.GetAttr(r, "http.status"); !ok || v.Int64() != 200 {
+			t.Errorf("expected the status attribute, got %v", v)
+		}
+		n++
+		return true
+	}, slogproto.WithZstdDict(dict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 records, got %d", n)
+	}
+
+	err = slogproto.Read(context.Background(), withDict, func(r *slog.Record) bool {
+		return true
+	}, slogproto.WithZstdDict(nil))
+	if err == nil {
+		t.Fatal("expected an error reading without the dictionary")
+	}
+}
+
+func TestTrainDict_empty(t *testing.T) {
+	if _, err := slogproto.TrainDict(context.Background(), 0, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error without records")
+	}
+}
+
+func TestWithZstd_compressed(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected compressing records twice to panic")
+		}
+	}()
+	slogproto.NewHandler(&bytes.Buffer{}, nil, slogproto.WithGzip(), slogproto.WithZstd(nil))
+}
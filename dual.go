@@ -0,0 +1,115 @@
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// dualHandler writes each record to a [Handler] and a human-readable
+// handler.
+type dualHandler struct {
+	proto    slog.Handler
+	readable slog.Handler
+}
+
+// NewDualHandler returns a handler that writes each record both to the
+// given Handler, for machines, and to a human-readable handler, such as a
+// [slog.TextHandler] or [slog.JSONHandler]. Each handler only receives the
+// records enabled for it, so they can have different levels.
+//
+// Attributes holding a [slog.LogValuer] are resolved once, so both handlers
+// see the same values.
+//
+// # Example
+//
+//	f, err := slogproto.OpenFile("app.log")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+//	logger := slog.New(slogproto.NewDualHandler(
+//		slogproto.NewHandler(f, nil),
+//		slog.NewTextHandler(os.Stderr, nil),
+//	))
+func NewDualHandler(h *Handler, readable slog.Handler) slog.Handler {
+	return &dualHandler{proto: h, readable: readable}
+}
+
+// NewTeeHandler returns a handler that forwards records to an existing
+// handler, and also writes them to w with a new [Handler] configured by
+// opts and options, so that an application can keep its current handler
+// while gaining a binary archive of its logs.
+//
+// # Example
+//
+//	logger := slog.New(slogproto.NewTeeHandler(
+//		slog.Default().Handler(),
+//		archive, nil,
+//	))
+func NewTeeHandler(next slog.Handler, w io.Writer, opts *slog.HandlerOptions, options ...HandlerOption) slog.Handler {
+	return NewDualHandler(NewHandler(w, opts, options...), next)
+}
+
+// Enabled returns true if the level is enabled for either handler.
+func (h *dualHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.proto.Enabled(ctx, level) || h.readable.Enabled(ctx, level)
+}
+
+// Handle writes the record to the handlers it is enabled for, returning
+// the errors of both.
+func (h *dualHandler) Handle(ctx context.Context, r slog.Record) error {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(resolveAttr(a))
+		return true
+	})
+
+	var errs []error
+	if h.proto.Enabled(ctx, r.Level) {
+		errs = append(errs, h.proto.Handle(ctx, r2))
+	}
+	if h.readable.Enabled(ctx, r.Level) {
+		errs = append(errs, h.readable.Handle(ctx, r2))
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a new handler with the given attributes added to both
+// handlers.
+func (h *dualHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	resolved := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		resolved[i] = resolveAttr(a)
+	}
+	return &dualHandler{
+		proto:    h.proto.WithAttrs(resolved),
+		readable: h.readable.WithAttrs(resolved),
+	}
+}
+
+// WithGroup returns a new handler with the given group added to both
+// handlers.
+func (h *dualHandler) WithGroup(name string) slog.Handler {
+	return &dualHandler{
+		proto:    h.proto.WithGroup(name),
+		readable: h.readable.WithGroup(name),
+	}
+}
+
+// resolveAttr returns the attribute with its value, and the values of the
+// groups it holds, resolved.
+func resolveAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = resolveAttr(ga)
+		}
+		a.Value = slog.GroupValue(attrs...)
+	}
+	return a
+}
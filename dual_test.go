@@ -0,0 +1,85 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+type countingValuer struct {
+	n *int
+}
+
+func (v countingValuer) LogValue() slog.Value {
+	*v.n++
+	return slog.IntValue(*v.n)
+}
+
+func TestNewDualHandler(t *testing.T) {
+	var protoBuf, textBuf bytes.Buffer
+
+	var n int
+	logger := slog.New(slogproto.NewDualHandler(
+		slogproto.NewHandler(&protoBuf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewTextHandler(&textBuf, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	)).With("service", "api")
+
+	logger.Debug("debug only")
+	logger.Info("both", "n", countingValuer{&n})
+
+	if n != 1 {
+		t.Fatalf("expected the valuer to be resolved once, got %d", n)
+	}
+
+	var msgs []string
+	err := slogproto.Read(context.Background(), &protoBuf, func(r *slog.Record) bool {
+		msgs = append(msgs, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(msgs, ",") != "debug only,both" {
+		t.Fatalf("unexpected protobuf records: %v", msgs)
+	}
+
+	text := textBuf.String()
+	if strings.Contains(text, "debug only") {
+		t.Fatalf("unexpected debug record in text output: %s", text)
+	}
+	if !strings.Contains(text, "msg=both service=api n=1") {
+		t.Fatalf("unexpected text output: %s", text)
+	}
+}
+
+func TestNewTeeHandler(t *testing.T) {
+	var jsonBuf, archive bytes.Buffer
+
+	logger := slog.New(slogproto.NewTeeHandler(slog.NewJSONHandler(&jsonBuf, nil), &archive, nil))
+	logger.WithGroup("req").Info("hello", "id", 1)
+
+	if got := jsonBuf.String(); !strings.Contains(got, `"msg":"hello","req":{"id":1}`) {
+		t.Fatalf("unexpected JSON output: %s", got)
+	}
+
+	var records int
+	err := slogproto.Read(context.Background(), &archive, func(r *slog.Record) bool {
+		records++
+		if r.Message != "hello" {
+			t.Errorf("unexpected message %q", r.Message)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if records != 1 {
+		t.Fatalf("expected 1 archived record, got %d", records)
+	}
+}
@@ -0,0 +1,321 @@
+// Package encryption encrypts and decrypts streams, such as slogproto log
+// files, at rest using AES-256-GCM.
+//
+// A stream starts with a header, containing a magic number, a version and
+// a random nonce prefix, followed by a series of chunks of up to 64 KiB of
+// plaintext, each sealed independently and prefixed with the 32-bit
+// little-endian size of its ciphertext:
+//
+//	╭──────────────────────────────────────────────────────────────────╮
+//	│  Header  │  Chunk Size  │  Sealed Chunk  │  ...  │  Final Chunk  │
+//	╰──────────────────────────────────────────────────────────────────╯
+//
+// Each chunk's nonce is derived from the nonce prefix, the chunk's index and
+// whether it is the final chunk, so chunks cannot be reordered, removed or
+// truncated without detection.
+//
+// Importing the package registers the [CodecName] codec with
+// [slogproto.RegisterCodec], which encrypts with the Key of its options.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/picatz/slogproto"
+)
+
+// KeySize is the size of an encryption key, in bytes.
+const KeySize = 32
+
+// chunkSize is the maximum size of the plaintext of a single chunk.
+const chunkSize = 64 << 10
+
+// noncePrefixSize is the size of the random prefix of every chunk nonce.
+const noncePrefixSize = 7
+
+// version is the version of the stream format.
+const version = 1
+
+// magic identifies an encrypted stream.
+var magic = []byte("SLPE")
+
+// headerSize is the size of the stream header: magic, version and nonce
+// prefix.
+var headerSize = len(magic) + 1 + noncePrefixSize
+
+// ErrInvalidKey is returned when a key is not KeySize bytes long.
+var ErrInvalidKey = fmt.Errorf("encryption: key must be %d bytes", KeySize)
+
+// ErrTruncated is returned when an encrypted stream ends before its final
+// chunk.
+var ErrTruncated = errors.New("encryption: stream is truncated")
+
+// GenerateKey returns a new random key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("encryption: error generating key: %w", err)
+	}
+	return key, nil
+}
+
+// ParseKey parses a key given either as KeySize raw bytes or hex encoded,
+// ignoring surrounding whitespace, as commonly stored in key files.
+func ParseKey(b []byte) ([]byte, error) {
+	if len(b) == KeySize {
+		return b, nil
+	}
+
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == hex.EncodedLen(KeySize) {
+		key := make([]byte, KeySize)
+		if _, err := hex.Decode(key, trimmed); err == nil {
+			return key, nil
+		}
+	}
+
+	return nil, ErrInvalidKey
+}
+
+// newAEAD returns the AES-256-GCM cipher for the key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of the chunk with the given index.
+func chunkNonce(prefix []byte, index uint32, final bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// Writer encrypts the data written to it. Close must be called to write the
+// final chunk, otherwise the stream is considered truncated.
+type Writer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	index  uint32
+	buf    []byte
+	closed bool
+}
+
+// NewWriter returns a Writer which encrypts data with the key and writes it
+// to w, starting with the stream header.
+func NewWriter(w io.Writer, key []byte) (*Writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, version)
+
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("encryption: error generating nonce: %w", err)
+	}
+	header = append(header, prefix...)
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// Write encrypts p, writing complete chunks to the underlying writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("encryption: write to closed writer")
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		// The buffered chunk is only written once more data arrives, so
+		// that the last chunk can be marked as final by Close.
+		if len(w.buf) == chunkSize {
+			if err := w.writeChunk(false); err != nil {
+				return 0, err
+			}
+		}
+
+		m := min(chunkSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+// Close writes the final chunk. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.writeChunk(true)
+}
+
+// writeChunk seals and writes the buffered plaintext as a chunk.
+func (w *Writer) writeChunk(final bool) error {
+	sealed := make([]byte, 4, 4+len(w.buf)+w.aead.Overhead())
+	sealed = w.aead.Seal(sealed, chunkNonce(w.prefix, w.index, final), w.buf, w.header)
+	binary.LittleEndian.PutUint32(sealed, uint32(len(sealed)-4))
+
+	if _, err := w.w.Write(sealed); err != nil {
+		return err
+	}
+
+	w.index++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Reader decrypts a stream written by a [Writer].
+type Reader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	index  uint32
+	buf    []byte
+	final  bool
+}
+
+// NewReader returns a Reader which decrypts the stream read from r with
+// the key, after reading and validating the stream header.
+func NewReader(r io.Reader, key []byte) (*Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("encryption: error reading header: %w", err)
+	}
+
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, errors.New("encryption: not an encrypted stream")
+	}
+
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("encryption: unsupported version %d", header[len(magic)])
+	}
+
+	return &Reader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		prefix: header[len(magic)+1:],
+	}, nil
+}
+
+// Read reads decrypted data into p.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.final {
+			return 0, io.EOF
+		}
+
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// readChunk reads and opens the next chunk.
+func (r *Reader) readChunk() error {
+	var size [4]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return err
+	}
+
+	n := binary.LittleEndian.Uint32(size[:])
+	if n > chunkSize+uint32(r.aead.Overhead()) {
+		return fmt.Errorf("encryption: chunk %d is too large: %d bytes", r.index, n)
+	}
+
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return err
+	}
+
+	// A chunk is only known to be final once it is opened as such.
+	plaintext, err := r.aead.Open(nil, chunkNonce(r.prefix, r.index, false), sealed, r.header)
+	if err != nil {
+		plaintext, err = r.aead.Open(nil, chunkNonce(r.prefix, r.index, true), sealed, r.header)
+		if err != nil {
+			return fmt.Errorf("encryption: error decrypting chunk %d: message authentication failed", r.index)
+		}
+		r.final = true
+	}
+
+	r.index++
+	r.buf = plaintext
+	return nil
+}
+
+// CodecName is the name of the codec registered by this package with
+// [slogproto.RegisterCodec], which encrypts streams with the key of its
+// options.
+const CodecName = "aes-256-gcm"
+
+func init() {
+	slogproto.RegisterCodec(slogproto.Codec{
+		Name:  CodecName,
+		Magic: magic,
+		NewWriter: func(w io.Writer, opts *slogproto.CodecOptions) (io.WriteCloser, error) {
+			if opts == nil || len(opts.Key) == 0 {
+				return nil, errors.New("encryption: a key is required to encrypt a stream")
+			}
+			return NewWriter(w, opts.Key)
+		},
+		NewReader: func(r io.Reader, opts *slogproto.CodecOptions) (io.Reader, error) {
+			if opts == nil || len(opts.Key) == 0 {
+				return nil, errors.New("encryption: the stream is encrypted, and no key was given")
+			}
+			return NewReader(r, opts.Key)
+		},
+	})
+}
@@ -0,0 +1,170 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/encryption"
+)
+
+func encrypt(t *testing.T, key, plaintext []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := encryption.NewWriter(&buf, key)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	for _, size := range []int{0, 1, 64 << 10, 64<<10 + 1, 300 << 10} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		ciphertext := encrypt(t, key, plaintext)
+
+		r, err := encryption.NewReader(bytes.NewReader(ciphertext), key)
+		if err != nil {
+			t.Fatalf("size %d: expected no error, but got: %v", size, err)
+		}
+
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("size %d: expected no error, but got: %v", size, err)
+		}
+
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("size %d: decrypted data does not match", size)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	key, _ := encryption.GenerateKey()
+
+	plaintext := make([]byte, 200<<10)
+	ciphertext := encrypt(t, key, plaintext)
+
+	t.Run("truncated", func(t *testing.T) {
+		r, err := encryption.NewReader(bytes.NewReader(ciphertext[:len(ciphertext)/2]), key)
+		if err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+
+		_, err = io.ReadAll(r)
+		if !errors.Is(err, encryption.ErrTruncated) {
+			t.Fatalf("expected ErrTruncated, but got: %v", err)
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[len(modified)-1] ^= 1
+
+		r, err := encryption.NewReader(bytes.NewReader(modified), key)
+		if err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+
+		if _, err := io.ReadAll(r); err == nil {
+			t.Fatalf("expected an error")
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, _ := encryption.GenerateKey()
+
+		r, err := encryption.NewReader(bytes.NewReader(ciphertext), other)
+		if err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+
+		if _, err := io.ReadAll(r); err == nil {
+			t.Fatalf("expected an error")
+		}
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	key, _ := encryption.GenerateKey()
+
+	for _, encoded := range [][]byte{key, []byte(hex.EncodeToString(key) + "\n")} {
+		parsed, err := encryption.ParseKey(encoded)
+		if err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+
+		if !bytes.Equal(parsed, key) {
+			t.Fatalf("parsed key does not match")
+		}
+	}
+
+	if _, err := encryption.ParseKey([]byte("short")); !errors.Is(err, encryption.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, but got: %v", err)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := slogproto.NewCodecWriter(encryption.CodecName, &buf, &slogproto.CodecOptions{Key: key})
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if _, err := w.Write([]byte("secret")); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	codec, r, err := slogproto.DetectCodec(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if codec.Name != encryption.CodecName {
+		t.Fatalf("expected the stream to be detected as encrypted, got %q", codec.Name)
+	}
+
+	if _, err := codec.NewReader(r, nil); err == nil {
+		t.Fatal("expected an error without a key")
+	}
+
+	dr, err := codec.NewReader(bytes.NewReader(buf.Bytes()), &slogproto.CodecOptions{Key: key})
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	got, err := io.ReadAll(dr)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if string(got) != "secret" {
+		t.Fatalf("expected the stream to be decrypted, got %q", got)
+	}
+}
@@ -0,0 +1,124 @@
+package slogproto
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NewHandlerFromEnv returns a new Handler that writes to the writer,
+// configured by the following environment variables, so that deployments
+// can tune logging without code changes:
+//
+//   - SLOGPROTO_LEVEL: the minimum level, such as "DEBUG" or "WARN+2"
+//     (see [slog.Level.UnmarshalText]); INFO by default.
+//   - SLOGPROTO_ADD_SOURCE: whether to add the source location of records.
+//   - SLOGPROTO_SAMPLE_RATE: the fraction of records to write, between
+//     zero and one (see [WithSampleRate]).
+//   - SLOGPROTO_COMPRESSION: "gzip" to compress records (see [WithGzip]),
+//     or "none".
+//   - SLOGPROTO_FIDELITY: whether to encode records losslessly (see
+//     [WithFidelity]).
+//   - SLOGPROTO_KEY_ORDER: whether to record the order of attributes (see
+//     [WithKeyOrder]).
+//   - SLOGPROTO_DETERMINISTIC: whether to encode identical records as
+//     identical bytes (see [WithDeterministic]).
+//   - SLOGPROTO_STREAM_HEADER: whether to write a stream header (see
+//     [WithStreamHeader]).
+//
+// Boolean variables accept the values understood by [strconv.ParseBool].
+// Options given explicitly are applied after those from the environment.
+// An error is returned if a variable has an invalid value, or if
+// SLOGPROTO_COMPRESSION is set along with an option compressing records,
+// such as [WithZstd].
+func NewHandlerFromEnv(w io.Writer, options ...HandlerOption) (*Handler, error) {
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}
+
+	var envOptions []HandlerOption
+
+	if v, ok := os.LookupEnv("SLOGPROTO_LEVEL"); ok {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("slogproto: invalid SLOGPROTO_LEVEL: %w", err)
+		}
+		opts.Level = level
+	}
+
+	if v, ok := os.LookupEnv("SLOGPROTO_ADD_SOURCE"); ok {
+		addSource, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("slogproto: invalid SLOGPROTO_ADD_SOURCE: %w", err)
+		}
+		opts.AddSource = addSource
+	}
+
+	if v, ok := os.LookupEnv("SLOGPROTO_SAMPLE_RATE"); ok {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(rate >= 0 && rate <= 1) {
+			return nil, fmt.Errorf("slogproto: invalid SLOGPROTO_SAMPLE_RATE %q: must be between 0 and 1", v)
+		}
+		envOptions = append(envOptions, WithSampleRate(rate))
+	}
+
+	// Compression is configured after the explicit options, so that a
+	// conflict with one of them is reported instead of wrapping one
+	// compressor in another.
+	var (
+		compress    HandlerOption
+		compressErr error
+	)
+	if v, ok := os.LookupEnv("SLOGPROTO_COMPRESSION"); ok {
+		switch strings.ToLower(v) {
+		case "", "none":
+		case "gzip":
+			compress = func(h *Handler) {
+				if h.compressor != nil {
+					compressErr = fmt.Errorf("slogproto: SLOGPROTO_COMPRESSION %q conflicts with the handler's options: %w", v, errCompressed)
+					return
+				}
+				WithGzip()(h)
+			}
+		default:
+			return nil, fmt.Errorf("slogproto: invalid SLOGPROTO_COMPRESSION %q: must be gzip or none", v)
+		}
+	}
+
+	for _, env := range []struct {
+		name   string
+		option HandlerOption
+	}{
+		{"SLOGPROTO_FIDELITY", WithFidelity()},
+		{"SLOGPROTO_KEY_ORDER", WithKeyOrder()},
+		{"SLOGPROTO_DETERMINISTIC", WithDeterministic()},
+		{"SLOGPROTO_STREAM_HEADER", WithStreamHeader()},
+	} {
+		v, ok := os.LookupEnv(env.name)
+		if !ok {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("slogproto: invalid %s: %w", env.name, err)
+		}
+		if enabled {
+			envOptions = append(envOptions, env.option)
+		}
+	}
+
+	options = append(envOptions, options...)
+	if compress != nil {
+		options = append(options, compress)
+	}
+
+	h := NewHandler(w, opts, options...)
+	if compressErr != nil {
+		return nil, compressErr
+	}
+	return h, nil
+}
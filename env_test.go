@@ -0,0 +1,109 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestNewHandlerFromEnv(t *testing.T) {
+	t.Setenv("SLOGPROTO_LEVEL", "WARN")
+	t.Setenv("SLOGPROTO_ADD_SOURCE", "true")
+	t.Setenv("SLOGPROTO_COMPRESSION", "gzip")
+
+	var buf bytes.Buffer
+
+	h, err := slogproto.NewHandlerFromEnv(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger := slog.New(h)
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("expected gzip output: %v", err)
+	}
+
+	var records []*slog.Record
+	err = slogproto.Read(context.Background(), gz, func(r *slog.Record) bool {
+		records = append(records, r)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error reading records: %v", err)
+	}
+
+	if len(records) != 1 || records[0].Message != "kept" {
+		t.Fatalf("expected only the warning, got %v", records)
+	}
+
+	hasSource := false
+	records[0].Attrs(func(a slog.Attr) bool {
+		hasSource = hasSource || a.Key == slog.SourceKey
+		return true
+	})
+	if !hasSource {
+		t.Errorf("expected a source attribute")
+	}
+}
+
+func TestNewHandlerFromEnv_sampleRate(t *testing.T) {
+	t.Setenv("SLOGPROTO_SAMPLE_RATE", "0")
+
+	var buf bytes.Buffer
+
+	h, err := slogproto.NewHandlerFromEnv(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	slog.New(h).Info("dropped")
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no records to be sampled, got %d bytes", buf.Len())
+	}
+}
+
+func TestNewHandlerFromEnv_invalid(t *testing.T) {
+	for _, env := range [][2]string{
+		{"SLOGPROTO_LEVEL", "LOUD"},
+		{"SLOGPROTO_ADD_SOURCE", "maybe"},
+		{"SLOGPROTO_SAMPLE_RATE", "2"},
+		{"SLOGPROTO_SAMPLE_RATE", "NaN"},
+		{"SLOGPROTO_COMPRESSION", "lz4"},
+		{"SLOGPROTO_FIDELITY", "sometimes"},
+	} {
+		t.Run(env[0]+"="+env[1], func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+
+			if _, err := slogproto.NewHandlerFromEnv(&bytes.Buffer{}); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestNewHandlerFromEnv_compressionConflict(t *testing.T) {
+	t.Setenv("SLOGPROTO_COMPRESSION", "gzip")
+
+	// Compressing twice would leave the first compressor unclosed.
+	if _, err := slogproto.NewHandlerFromEnv(&bytes.Buffer{}, slogproto.WithGzip()); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	t.Setenv("SLOGPROTO_COMPRESSION", "none")
+	if _, err := slogproto.NewHandlerFromEnv(&bytes.Buffer{}, slogproto.WithGzip()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// File is a log file opened for appending, which can be reopened by name
+// once it has been moved aside, such as by logrotate, so that new records
+// are written to a new file without restarting the process.
+//
+// # Example
+//
+//	f, err := slogproto.OpenFile("app.log")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+//	go f.ReopenOnSignal(ctx)
+//
+//	logger := slog.New(slogproto.NewHandler(f, nil))
+type File struct {
+	name string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFile opens the named file for appending, creating it if it doesn't
+// exist.
+func OpenFile(name string) (*File, error) {
+	f, err := openAppend(name)
+	if err != nil {
+		return nil, err
+	}
+	return &File{name: name, f: f}, nil
+}
+
+// openAppend opens the named file for appending, creating it if it
+// doesn't exist.
+func openAppend(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
+
+// Name returns the name of the file.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Write writes to the currently open file.
+func (f *File) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.f.Write(b)
+}
+
+// Reopen opens the file by name again and closes the previously opened
+// file. If the file can't be opened, writes continue to the previously
+// opened file.
+func (f *File) Reopen() error {
+	nf, err := openAppend(f.name)
+	if err != nil {
+		return fmt.Errorf("slogproto: error reopening file: %w", err)
+	}
+
+	f.mu.Lock()
+	old := f.f
+	f.f = nf
+	f.mu.Unlock()
+
+	return old.Close()
+}
+
+// Sync commits the contents of the currently open file to stable storage.
+func (f *File) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.f.Sync()
+}
+
+// Close closes the currently open file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.f.Close()
+}
+
+// ReopenOnSignal reopens the file each time the process receives one of
+// the given signals, or SIGHUP if none are given, until the context is
+// done or the file can't be reopened. It returns the context's error or
+// the error reopening the file, or an error at once if no signals are
+// given where there is no SIGHUP, such as under js/wasm.
+//
+// Since reopening is safe between any two writes, it can run in its own
+// goroutine while the file is written to.
+func (f *File) ReopenOnSignal(ctx context.Context, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = reopenSignals
+	}
+	if len(sigs) == 0 {
+		return errors.New("slogproto: no signals to reopen the file on")
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	defer signal.Stop(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c:
+			if err := f.Reopen(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package slogproto
+
+import "os"
+
+// reopenSignals are the signals ReopenOnSignal reopens the file on by
+// default, of which there are none in the browser.
+var reopenSignals []os.Signal
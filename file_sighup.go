@@ -0,0 +1,12 @@
+//go:build !js
+
+package slogproto
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals ReopenOnSignal reopens the file on by
+// default.
+var reopenSignals = []os.Signal{syscall.SIGHUP}
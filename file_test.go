@@ -0,0 +1,155 @@
+package slogproto_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+// countRecords returns the number of records in the named file.
+func countRecords(t *testing.T, name string) int {
+	t.Helper()
+
+	fh, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+
+	count := 0
+	err = slogproto.Read(context.Background(), fh, func(r *slog.Record) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error reading records: %v", err)
+	}
+	return count
+}
+
+func TestHandler_Reopen(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+
+	f, err := slogproto.OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	h := slogproto.NewHandler(f, nil)
+	logger := slog.New(h)
+
+	logger.Info("before rotation")
+	logger.Info("before rotation")
+
+	// Rotate the file like logrotate does, then reopen it.
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("before reopen")
+
+	if err := h.Reopen(); err != nil {
+		t.Fatalf("error reopening: %v", err)
+	}
+
+	logger.Info("after reopen")
+
+	if n := countRecords(t, name+".1"); n != 3 {
+		t.Fatalf("expected 3 records in the rotated file, got %d", n)
+	}
+
+	if n := countRecords(t, name); n != 1 {
+		t.Fatalf("expected 1 record in the new file, got %d", n)
+	}
+
+	t.Run("not reopenable", func(t *testing.T) {
+		if err := slogproto.NewHandler(&bytes.Buffer{}, nil).Reopen(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestHandler_SetWriter(t *testing.T) {
+	var first, second bytes.Buffer
+
+	bw := bufio.NewWriter(&first)
+
+	h := slogproto.NewHandler(bw, nil)
+	logger := slog.New(h).With("derived", true)
+
+	logger.Info("first")
+
+	if err := h.SetWriter(&second); err != nil {
+		t.Fatalf("error setting writer: %v", err)
+	}
+
+	logger.Info("second")
+
+	// The buffered record was flushed to the first writer.
+	for _, buf := range []*bytes.Buffer{&first, &second} {
+		count := 0
+		err := slogproto.Read(context.Background(), buf, func(r *slog.Record) bool {
+			count++
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error reading records: %v", err)
+		}
+
+		if count != 1 {
+			t.Fatalf("expected 1 record, got %d", count)
+		}
+	}
+}
+
+func TestHandler_SetWriter_gzip(t *testing.T) {
+	var first, second bytes.Buffer
+
+	h := slogproto.NewHandler(&first, nil, slogproto.WithGzip())
+	logger := slog.New(h).With("derived", true)
+
+	logger.Info("first")
+
+	if err := h.SetWriter(&second); err != nil {
+		t.Fatalf("error setting writer: %v", err)
+	}
+
+	logger.Info("second")
+
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each writer holds a complete gzip stream of its records.
+	for i, buf := range []*bytes.Buffer{&first, &second} {
+		zr, err := gzip.NewReader(buf)
+		if err != nil {
+			t.Fatalf("writer %d: %v", i, err)
+		}
+
+		var msgs []string
+		err = slogproto.Read(context.Background(), zr, func(r *slog.Record) bool {
+			msgs = append(msgs, r.Message)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("writer %d: error reading records: %v", i, err)
+		}
+		if _, err := io.Copy(io.Discard, zr); err != nil {
+			t.Fatalf("writer %d: incomplete gzip stream: %v", i, err)
+		}
+
+		if want := []string{"first", "second"}[i]; len(msgs) != 1 || msgs[0] != want {
+			t.Fatalf("writer %d: expected record %q, got %v", i, want, msgs)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+//go:build unix
+
+package slogproto_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestFile_ReopenOnSignal(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+
+	f, err := slogproto.OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	logger := slog.New(slogproto.NewHandler(f, nil))
+	logger.Info("before rotation")
+
+	// Keep SIGHUP from terminating the test before ReopenOnSignal is
+	// listening for it.
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	defer signal.Stop(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- f.ReopenOnSignal(ctx)
+	}()
+
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signal until the file has been reopened, since the signal may
+	// arrive before ReopenOnSignal is listening for it.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if _, err := os.Stat(name); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file was not reopened")
+		}
+	}
+
+	logger.Info("after reopen")
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if n := countRecords(t, name); n != 1 {
+		t.Fatalf("expected 1 record in the new file, got %d", n)
+	}
+}
@@ -1,10 +1,17 @@
+//go:build !slogproto_nocel && !tinygo
+
 package slogproto
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/netip"
 
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
 	"github.com/google/cel-go/ext"
 )
 
@@ -28,23 +35,17 @@ import (
 //   - lists
 //   - bindings
 //
+// And the following functions for IP addresses, which may be given as
+// [netip.Addr] and [netip.Prefix] attributes or as strings:
+//
+//   - ip_in_cidr(ip, cidr): whether the IP address, such as
+//     attrs.client_ip, is within the CIDR prefix, such as "10.0.0.0/8".
+//     It is false if ip isn't an IP address.
+//
 // If the expression is invalid, an error is returned.
 func CompileFilter(expr string) (cel.Program, error) {
 	// Create a CEL environment.
-	env, err := cel.NewEnv(
-		cel.StdLib(),
-		ext.Strings(),
-		ext.Math(),
-		ext.Encoders(),
-		ext.Sets(),
-		ext.Lists(),
-		ext.Bindings(),
-		cel.OptionalTypes(cel.OptionalTypesVersion(2)),
-		cel.Variable("msg", cel.StringType),
-		cel.Variable("level", cel.StringType),
-		cel.Variable("time", cel.TimestampType),
-		cel.Variable("attrs", cel.MapType(cel.StringType, cel.DynType)),
-	)
+	env, err := newFilterEnv()
 	if err != nil {
 		return nil, fmt.Errorf("error creating CEL environment: %s", err)
 	}
@@ -71,8 +72,86 @@ func CompileFilter(expr string) (cel.Program, error) {
 		return nil, fmt.Errorf("program construction error: %s", err)
 	}
 
+	// Note whether the expression references the attributes, so that
+	// they don't need to be decoded to evaluate it otherwise.
+	ce, err := cel.AstToCheckedExpr(checked)
+	if err != nil {
+		return nil, fmt.Errorf("program construction error: %s", err)
+	}
+
+	var attrs bool
+	for _, ref := range ce.GetReferenceMap() {
+		if ref.GetName() == "attrs" {
+			attrs = true
+			break
+		}
+	}
+
 	// Return the program that can be evaluated against a slog record.
-	return prog, nil
+	return &filterProgram{Program: prog, attrs: attrs}, nil
+}
+
+// newFilterEnv returns the CEL environment of filter expressions, with
+// the additional options.
+func newFilterEnv(opts ...cel.EnvOption) (*cel.Env, error) {
+	return cel.NewEnv(append([]cel.EnvOption{
+		cel.StdLib(),
+		ext.Strings(),
+		ext.Math(),
+		ext.Encoders(),
+		ext.Sets(),
+		ext.Lists(),
+		ext.Bindings(),
+		cel.OptionalTypes(cel.OptionalTypesVersion(2)),
+		cel.Variable("msg", cel.StringType),
+		cel.Variable("level", cel.StringType),
+		cel.Variable("time", cel.TimestampType),
+		cel.Variable("attrs", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Function("ip_in_cidr",
+			cel.Overload("ip_in_cidr_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(ipInCIDR),
+			),
+		),
+	}, opts...)...)
+}
+
+// filterProgram is a program compiled by CompileFilter.
+type filterProgram struct {
+	cel.Program
+
+	// attrs is set if the expression references the attributes.
+	attrs bool
+}
+
+// filterUsesAttrs reports whether evaluating the program may reference
+// the attributes of a record, which is assumed for programs not compiled
+// by CompileFilter.
+func filterUsesAttrs(prog cel.Program) bool {
+	fp, ok := prog.(*filterProgram)
+	return !ok || fp.attrs
+}
+
+// WithFilter configures Read to only pass the records matching the filter
+// program, compiled with CompileFilter, to its function. Reading stops with
+// an error if the program can't be evaluated against a record.
+//
+// When the expression only references the time, level and message of
+// records, their attributes are only decoded for the records that match,
+// which makes selective filters much faster on records with many
+// attributes.
+func WithFilter(prog cel.Program) ReadOption {
+	return func(c *readConfig) {
+		if prog == nil {
+			c.filter = nil
+			return
+		}
+		c.filter = &recordFilter{
+			match: func(r *slog.Record) (bool, error) {
+				return EvalFilter(prog, r)
+			},
+			attrs: filterUsesAttrs(prog),
+		}
+	}
 }
 
 // EvalFilter evaluates a filter program against a slog record. The record
@@ -90,29 +169,124 @@ func EvalFilter(prog cel.Program, r *slog.Record) (bool, error) {
 		return true, nil
 	}
 
+	// Evaluate the program.
+	result, _, err := prog.Eval(filterVars(r))
+	if err != nil {
+		return false, fmt.Errorf("error evaluating program: %s", err)
+	}
+
+	val, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("invalid filter expression output type: %T", result.Value())
+	}
+
+	// Return the result.
+	return val, nil
+}
+
+// filterVars returns the variables filter expressions are evaluated with
+// for the record.
+func filterVars(r *slog.Record) map[string]any {
 	attrsMap := make(map[string]any, r.NumAttrs())
 
 	r.Attrs(func(a slog.Attr) bool {
-		attrsMap[a.Key] = a.Value.Any()
+		attrsMap[a.Key] = celValue(a.Value)
 		return true
 	})
 
-	// Evaluate the program.
-	result, _, err := prog.Eval(map[string]any{
+	return map[string]any{
 		"msg":   r.Message,
 		"level": r.Level.String(),
 		"time":  r.Time,
 		"attrs": attrsMap,
-	})
+	}
+}
+
+// CopyFiltered reads protobuf encoded slog records from src, as with Read,
+// and appends the records matching the filter program to dst, until src is
+// exhausted or the context is canceled. It returns the number of records
+// copied.
+//
+// Matching records are copied exactly as they were encoded in src, in its
+// framing, declared by a stream header written before them, so that dst
+// may be a file that records were already appended to. When src is a
+// stream that is still being written to, such as a pipe, matching records
+// are written to dst as they arrive, so dst can be used as a continuously
+// updated view of src, such as an errors-only log.
+func CopyFiltered(ctx context.Context, dst io.Writer, src io.Reader, prog cel.Program, opts ...ReadOption) (int64, error) {
+	var (
+		copied  int64
+		copyErr error
+	)
+
+	if prog != nil {
+		opts = append(opts, WithFilter(prog))
+	}
+
+	fw := newFrameWriter(dst)
+	opts = append(opts, withFramingChanges(fw.setFraming))
+
+	err := readFrames(ctx, src, func(frame []byte, r *slog.Record) bool {
+		if err := fw.write(frame); err != nil {
+			copyErr = fmt.Errorf("error writing record: %w", err)
+			return false
+		}
+
+		copied++
+		return true
+	}, opts...)
 	if err != nil {
-		return false, fmt.Errorf("error evaluating program: %s", err)
+		return copied, err
 	}
 
-	val, ok := result.Value().(bool)
-	if !ok {
-		return false, fmt.Errorf("invalid filter expression output type: %T", result.Value())
+	return copied, copyErr
+}
+
+// FilterExprStage returns a [Pipeline] stage keeping the records matching
+// the filter program, as compiled by CompileFilter.
+func FilterExprStage(prog cel.Program) Stage {
+	return func(r *slog.Record) (bool, error) {
+		return EvalFilter(prog, r)
 	}
+}
 
-	// Return the result.
-	return val, nil
+// celValue returns the value of an attribute as a value of the CEL type
+// it corresponds to. Unsigned integers are kept as uint64, so those above
+// math.MaxInt64 are compared exactly, and groups are maps, so that their
+// attributes can be selected, as in attrs.http.status.
+func celValue(v slog.Value) any {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindGroup:
+		group := v.Group()
+		m := make(map[string]any, len(group))
+		for _, a := range group {
+			m[a.Key] = celValue(a.Value)
+		}
+		return m
+	}
+
+	// Identifiers and network values are compared as strings.
+	switch x := v.Any().(type) {
+	case netip.Addr, netip.Prefix, UUID, ULID:
+		return x.(fmt.Stringer).String()
+	default:
+		return x
+	}
+}
+
+// ipInCIDR implements the ip_in_cidr function of filter expressions.
+func ipInCIDR(ip, cidr ref.Val) ref.Val {
+	prefix, err := netip.ParsePrefix(string(cidr.(types.String)))
+	if err != nil {
+		return types.NewErr("ip_in_cidr: invalid CIDR prefix: %v", err)
+	}
+
+	addr, err := netip.ParseAddr(string(ip.(types.String)))
+	if err != nil {
+		return types.False
+	}
+	return types.Bool(prefix.Contains(addr.Unmap()))
 }
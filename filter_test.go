@@ -1,13 +1,30 @@
+//go:build !slogproto_nocel && !tinygo
+
 package slogproto_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"log/slog"
+	"math"
+	"net/netip"
 	"testing"
 	"time"
 
 	"github.com/picatz/slogproto"
 )
 
+// Fuzz reading with a filter, which is evaluated before the attributes of
+// records are decoded.
+func init() {
+	prog, err := slogproto.CompileFilter(`level == "INFO"`)
+	if err != nil {
+		panic(err)
+	}
+	fuzzReadOptions = append(fuzzReadOptions, []slogproto.ReadOption{slogproto.WithFilter(prog), slogproto.WithStreams("", "a")})
+}
+
 func TestFilter(t *testing.T) {
 	record := slog.NewRecord(time.Now(), slog.LevelInfo, "this is a test", 1)
 	record.AddAttrs(slog.Bool("test", true))
@@ -63,3 +80,255 @@ func TestFilter(t *testing.T) {
 		}
 	})
 }
+
+func TestFilter_uint(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "ids", 0)
+	record.AddAttrs(
+		slog.Uint64("id", math.MaxUint64),
+		slog.Group("trace", slog.Uint64("span", math.MaxInt64+1)),
+	)
+
+	var buf bytes.Buffer
+	if err := slogproto.NewHandler(&buf, nil).Handle(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expr := range []string{
+		`attrs.id == 18446744073709551615u`,
+		`attrs.id > 9223372036854775807`,
+		`string(attrs.id) == "18446744073709551615"`,
+		`attrs.trace.span == 9223372036854775808u`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			prog, err := slogproto.CompileFilter(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var matched int
+			err = slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+				matched++
+				return true
+			}, slogproto.WithFilter(prog))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != 1 {
+				t.Fatalf("expected the record to match, got %d matches", matched)
+			}
+		})
+	}
+}
+
+func TestFilter_ipInCIDR(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "request", 0)
+	record.AddAttrs(
+		slog.Any("client_ip", netip.MustParseAddr("10.1.2.3")),
+		slog.String("forwarded_for", "::ffff:192.168.1.1"),
+		slog.String("peer", "unknown"),
+		slog.Any("network", netip.MustParsePrefix("10.0.0.0/8")),
+	)
+
+	for expr, want := range map[string]bool{
+		`ip_in_cidr(attrs.client_ip, "10.0.0.0/8")`:          true,
+		`ip_in_cidr(attrs.client_ip, "192.168.0.0/16")`:      false,
+		`ip_in_cidr(attrs.forwarded_for, "192.168.0.0/16")`:  true,
+		`ip_in_cidr(attrs.peer, "0.0.0.0/0")`:                false,
+		`attrs.client_ip == "10.1.2.3"`:                      true,
+		`ip_in_cidr(attrs.client_ip, string(attrs.network))`: true,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			prog, err := slogproto.CompileFilter(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			matched, err := slogproto.EvalFilter(prog, &record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != want {
+				t.Fatalf("expected %v, got %v", want, matched)
+			}
+		})
+	}
+
+	prog, err := slogproto.CompileFilter(`ip_in_cidr(attrs.client_ip, "10.0.0.0")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := slogproto.EvalFilter(prog, &record); err == nil {
+		t.Fatal("expected an error for an invalid CIDR prefix")
+	}
+}
+
+func TestCopyFiltered(t *testing.T) {
+	var src bytes.Buffer
+
+	logger := slog.New(slogproto.NewHandler(&src, nil))
+
+	for i := 0; i < 10; i++ {
+		if i%3 == 0 {
+			logger.Error("failure", "i", i)
+		} else {
+			logger.Info("success", "i", i)
+		}
+	}
+
+	prog, err := slogproto.CompileFilter(`level == "ERROR"`)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	var dst bytes.Buffer
+
+	copied, err := slogproto.CopyFiltered(context.Background(), &dst, &src, prog)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if copied != 4 {
+		t.Fatalf("expected 4 records to be copied, but got: %d", copied)
+	}
+
+	var count int
+
+	err = slogproto.Read(context.Background(), &dst, func(r *slog.Record) bool {
+		count++
+
+		if r.Level != slog.LevelError || r.Message != "failure" {
+			t.Errorf("unexpected record: %v %q", r.Level, r.Message)
+		}
+
+		return true
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if count != 4 {
+		t.Fatalf("expected 4 records, but got: %d", count)
+	}
+}
+
+func TestCopyFiltered_framing(t *testing.T) {
+	var src bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&src, nil, slogproto.WithFraming(slogproto.Framing_FRAMING_UINT64_LE)))
+	logger.Error("copied")
+	logger.Info("skipped")
+
+	// Append to a stream with another framing, as slp filter --output
+	// does with an existing file.
+	var dst bytes.Buffer
+	slog.New(slogproto.NewHandler(&dst, nil, slogproto.WithFraming(slogproto.Framing_FRAMING_UINT32_BE))).Info("existing")
+
+	prog, err := slogproto.CompileFilter(`level == "ERROR"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := slogproto.CopyFiltered(context.Background(), &dst, bytes.NewReader(src.Bytes()), prog); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var msgs []string
+	err = slogproto.Read(context.Background(), &dst, func(r *slog.Record) bool {
+		msgs = append(msgs, r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(msgs), "[existing copied copied]"; got != want {
+		t.Fatalf("expected records %s, got %s", want, got)
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	var src bytes.Buffer
+
+	logger := slog.New(slogproto.NewHandler(&src, nil))
+
+	for i := 0; i < 10; i++ {
+		if i%3 == 0 {
+			logger.Error("failure", "i", i, "a", 1, "b", 2)
+		} else {
+			logger.Info("success", "i", i, "a", 1, "b", 2)
+		}
+	}
+
+	tests := map[string]struct {
+		expr string
+		opts []slogproto.ReadOption
+		want []int64
+	}{
+		"level": {
+			expr: `level == "ERROR"`,
+			want: []int64{0, 3, 6, 9},
+		},
+		"attrs": {
+			expr: `attrs.i > 6`,
+			want: []int64{7, 8, 9},
+		},
+		"message and time": {
+			expr: `msg == "success" && time > timestamp("2000-01-01T00:00:00Z")`,
+			want: []int64{1, 2, 4, 5, 7, 8},
+		},
+		// The attributes of records not matching a filter that doesn't
+		// reference them are never decoded, so they can't exceed a limit.
+		"limits": {
+			expr: `msg == "none"`,
+			opts: []slogproto.ReadOption{slogproto.WithDecodeLimits(0, 1, 0)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			prog, err := slogproto.CompileFilter(test.expr)
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+
+			var got []int64
+			err = slogproto.Read(context.Background(), bytes.NewReader(src.Bytes()), func(r *slog.Record) bool {
+				r.Attrs(func(a slog.Attr) bool {
+					if a.Key == "i" {
+						got = append(got, a.Value.Int64())
+					}
+					return true
+				})
+				return true
+			}, append(test.opts, slogproto.WithFilter(prog))...)
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Fatalf("expected records %v, but got: %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterExprStage(t *testing.T) {
+	prog, err := slogproto.CompileFilter(`attrs.status >= 500`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := slogproto.NewPipeline(slogproto.FilterExprStage(prog))
+
+	for status, want := range map[int]bool{200: false, 503: true} {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "request", 0)
+		r.AddAttrs(slog.Int("status", status))
+
+		got, err := p.Apply(&r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("status %d: expected %t, got %t", status, want, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package slogproto
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Flatten returns the attributes with groups replaced by their attributes,
+// keyed by their dotted path, such as "http.request.method", in order.
+// Values are resolved, and groups with an empty key are inlined.
+func Flatten(attrs []slog.Attr) []slog.Attr {
+	flat := make([]slog.Attr, 0, len(attrs))
+
+	for _, a := range attrs {
+		walkAttr(nil, a, func(path []string, a slog.Attr) bool {
+			if a.Value.Kind() == slog.KindGroup {
+				return true
+			}
+
+			if len(path) > 0 {
+				a.Key = strings.Join(path, ".") + "." + a.Key
+			}
+			flat = append(flat, a)
+			return true
+		})
+	}
+
+	return flat
+}
+
+// Unflatten returns the attributes with dotted keys, such as
+// "http.request.method", replaced by nested groups, merging attributes
+// with the same prefix into the same group in the order they first
+// appear. It is the inverse of Flatten.
+//
+// Keys with empty segments, and keys whose prefix is the key of an
+// attribute that isn't a group, are kept as they are.
+func Unflatten(attrs []slog.Attr) []slog.Attr {
+	var root attrTree
+
+	for _, a := range attrs {
+		root.add(strings.Split(a.Key, "."), a)
+	}
+
+	return root.attrs()
+}
+
+// attrTree is a tree of attributes built by Unflatten, where each node with
+// children is a group.
+type attrTree struct {
+	nodes []*attrNode
+	index map[string]*attrNode
+}
+
+// attrNode is either an attribute, or a group of attributes.
+type attrNode struct {
+	attr  slog.Attr
+	group *attrTree
+}
+
+// add adds the attribute at the path of keys to the tree.
+func (t *attrTree) add(path []string, a slog.Attr) {
+	if len(path) > 1 && !emptySegment(path) {
+		n, ok := t.index[path[0]]
+		if !ok {
+			n = &attrNode{attr: slog.Attr{Key: path[0]}, group: &attrTree{}}
+			t.insert(path[0], n)
+		}
+
+		if n.group != nil {
+			n.group.add(path[1:], a)
+			return
+		}
+	}
+
+	a.Key = strings.Join(path, ".")
+	n := &attrNode{attr: a}
+	if _, ok := t.index[a.Key]; ok {
+		// Don't index duplicate keys, so that dotted keys are merged into
+		// the first group.
+		t.nodes = append(t.nodes, n)
+		return
+	}
+	t.insert(a.Key, n)
+}
+
+// insert appends the node to the tree, indexed by key.
+func (t *attrTree) insert(key string, n *attrNode) {
+	if t.index == nil {
+		t.index = make(map[string]*attrNode)
+	}
+	t.index[key] = n
+	t.nodes = append(t.nodes, n)
+}
+
+// attrs returns the attributes of the tree, converting nodes with children
+// to groups.
+func (t *attrTree) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(t.nodes))
+	for _, n := range t.nodes {
+		if n.group != nil {
+			attrs = append(attrs, slog.Attr{Key: n.attr.Key, Value: slog.GroupValue(n.group.attrs()...)})
+			continue
+		}
+		attrs = append(attrs, n.attr)
+	}
+	return attrs
+}
+
+// emptySegment reports whether any of the keys in the path are empty.
+func emptySegment(path []string) bool {
+	for _, key := range path {
+		if key == "" {
+			return true
+		}
+	}
+	return false
+}
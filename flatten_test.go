@@ -0,0 +1,60 @@
+package slogproto_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestFlatten(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.String("a", "1"),
+		slog.Group("http",
+			slog.Group("request", slog.String("method", "GET")),
+			slog.Int("status", 200),
+		),
+		slog.Group("", slog.Bool("inlined", true)),
+	}
+
+	want := []slog.Attr{
+		slog.String("a", "1"),
+		slog.String("http.request.method", "GET"),
+		slog.Int("http.status", 200),
+		slog.Bool("inlined", true),
+	}
+
+	flat := slogproto.Flatten(attrs)
+	if !attrsEqual(want, flat) {
+		t.Fatalf("expected %v, got %v", want, flat)
+	}
+
+	// Unflatten is the inverse of Flatten, without inlined groups.
+	if got := slogproto.Unflatten(flat); !attrsEqual(append(attrs[:2:2], slog.Bool("inlined", true)), got) {
+		t.Fatalf("expected %v, got %v", attrs, got)
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.String("http.request.method", "GET"),
+		slog.String("user", "alice"),
+		slog.Int("http.status", 200),
+		slog.String("user.id", "not a group"),
+		slog.String("a..b", "empty segment"),
+	}
+
+	want := []slog.Attr{
+		slog.Group("http",
+			slog.Group("request", slog.String("method", "GET")),
+			slog.Int("status", 200),
+		),
+		slog.String("user", "alice"),
+		slog.String("user.id", "not a group"),
+		slog.String("a..b", "empty segment"),
+	}
+
+	if got := slogproto.Unflatten(attrs); !attrsEqual(want, got) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
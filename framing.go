@@ -0,0 +1,134 @@
+package slogproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrUnsupportedFraming is returned by [Read] for streams whose header
+// declares a framing it doesn't support.
+var ErrUnsupportedFraming = errors.New("slogproto: unsupported framing")
+
+// checkFraming returns an error if records with the framing cannot be
+// read or written.
+func checkFraming(f Framing) error {
+	switch f {
+	case Framing_FRAMING_UINT32_LE, Framing_FRAMING_UINT32_BE, Framing_FRAMING_UINT64_LE, Framing_FRAMING_UINT64_BE:
+		return nil
+	default:
+		return fmt.Errorf("%w: %d", ErrUnsupportedFraming, f)
+	}
+}
+
+// frameSizeLen returns the length of the size prefixes of the framing.
+func frameSizeLen(f Framing) int {
+	if f == Framing_FRAMING_UINT64_LE || f == Framing_FRAMING_UINT64_BE {
+		return 8
+	}
+	return 4
+}
+
+// frameSize returns the size of the record framed at the start of b, which
+// must hold its size prefix.
+func frameSize(f Framing, b []byte) uint64 {
+	switch f {
+	case Framing_FRAMING_UINT32_BE:
+		return uint64(binary.BigEndian.Uint32(b))
+	case Framing_FRAMING_UINT64_LE:
+		return binary.LittleEndian.Uint64(b)
+	case Framing_FRAMING_UINT64_BE:
+		return binary.BigEndian.Uint64(b)
+	default:
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+}
+
+// putFrameSize writes the size prefix of a record of size n to the start
+// of b, which must have room for it.
+func putFrameSize(f Framing, b []byte, n int) {
+	switch f {
+	case Framing_FRAMING_UINT32_BE:
+		binary.BigEndian.PutUint32(b, uint32(n))
+	case Framing_FRAMING_UINT64_LE:
+		binary.LittleEndian.PutUint64(b, uint64(n))
+	case Framing_FRAMING_UINT64_BE:
+		binary.BigEndian.PutUint64(b, uint64(n))
+	default:
+		binary.LittleEndian.PutUint32(b, uint32(n))
+	}
+}
+
+// checkFrameSize returns an error if the size of a record of n bytes
+// doesn't fit in the size prefixes of the framing.
+func checkFrameSize(f Framing, n int) error {
+	if frameSizeLen(f) == 4 && uint64(n) > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes exceeds the maximum of %s framing", ErrRecordTooLarge, n, f)
+	}
+	return nil
+}
+
+// writeFrame writes the encoded record to the writer, prefixed with its
+// size in the framing so that the reader knows how much to read, in a
+// single write.
+func writeFrame(w io.Writer, f Framing, b []byte) error {
+	if err := checkFrameSize(f, len(b)); err != nil {
+		return err
+	}
+
+	n := frameSizeLen(f)
+	frame := make([]byte, n, n+len(b))
+	putFrameSize(f, frame, len(b))
+	frame = append(frame, b...)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// frameWriter copies records to a writer in the framing of the stream
+// they are read from, declared by a stream header written before the
+// first record and whenever the framing changes. The output is readable
+// on its own, and when appended to a stream with another framing.
+type frameWriter struct {
+	w        io.Writer
+	framing  Framing
+	declared bool
+}
+
+// newFrameWriter returns a frameWriter writing records to w with the
+// default framing, until another is set.
+func newFrameWriter(w io.Writer) *frameWriter {
+	return &frameWriter{w: w, framing: Framing_FRAMING_UINT32_LE}
+}
+
+// setFraming sets the framing of the records written next, as reported
+// by readFrames with withFramingChanges.
+func (fw *frameWriter) setFraming(f Framing) {
+	if f != fw.framing {
+		fw.framing = f
+		fw.declared = false
+	}
+}
+
+// writeHeader writes a stream header declaring the framing of the records
+// written next.
+func (fw *frameWriter) writeHeader() error {
+	if _, err := fw.w.Write(streamHeader(fw.framing)); err != nil {
+		return err
+	}
+	fw.declared = true
+	return nil
+}
+
+// write writes the encoded record, preceded by a stream header if its
+// framing hasn't been declared yet.
+func (fw *frameWriter) write(b []byte) error {
+	if !fw.declared {
+		if err := fw.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return writeFrame(fw.w, fw.framing, b)
+}
@@ -0,0 +1,108 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWithFraming(t *testing.T) {
+	for _, framing := range []slogproto.Framing{
+		slogproto.Framing_FRAMING_UINT32_LE,
+		slogproto.Framing_FRAMING_UINT32_BE,
+		slogproto.Framing_FRAMING_UINT64_LE,
+		slogproto.Framing_FRAMING_UINT64_BE,
+	} {
+		t.Run(framing.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			h := slogproto.NewHandler(&buf, nil, slogproto.WithFraming(framing), slogproto.WithStreamTrailer())
+			logger := slog.New(h)
+			logger.Info("a")
+			logger.Info("b", "n", 1)
+			tx := h.Begin()
+			slog.New(tx).Info("c")
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			// The first record follows the stream header.
+			headerSize := len(slogproto.StreamMagic) + 4 + int(binary.LittleEndian.Uint32(buf.Bytes()[len(slogproto.StreamMagic):]))
+			prefix := buf.Bytes()[headerSize:]
+			var size uint64
+			switch framing {
+			case slogproto.Framing_FRAMING_UINT32_LE:
+				size = uint64(binary.LittleEndian.Uint32(prefix))
+			case slogproto.Framing_FRAMING_UINT32_BE:
+				size = uint64(binary.BigEndian.Uint32(prefix))
+			case slogproto.Framing_FRAMING_UINT64_LE:
+				size = binary.LittleEndian.Uint64(prefix)
+			case slogproto.Framing_FRAMING_UINT64_BE:
+				size = binary.BigEndian.Uint64(prefix)
+			}
+			if size == 0 || size > 100 {
+				t.Fatalf("unexpected size prefix %x", prefix[:8])
+			}
+
+			if _, err := slogproto.VerifyStream(context.Background(), bytes.NewReader(buf.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			// Bytes inserted between records are skipped by Repair, which
+			// keeps the framing.
+			corrupted := bytes.Clone(buf.Bytes()[:headerSize])
+			corrupted = append(corrupted, 0xff, 0xff, 0xff)
+			corrupted = append(corrupted, buf.Bytes()[headerSize:]...)
+			var repaired bytes.Buffer
+			if _, err := slogproto.Repair(context.Background(), &repaired, bytes.NewReader(corrupted)); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, stream := range []*bytes.Buffer{&buf, &repaired} {
+				if got := readMessages(t, stream); got != "a,b,c" {
+					t.Fatalf("expected messages a,b,c, got %s", got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithFraming_concatenated(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogproto.NewHandler(&buf, nil)).Info("a")
+	slog.New(slogproto.NewHandler(&buf, nil, slogproto.WithFraming(slogproto.Framing_FRAMING_UINT64_BE))).Info("b")
+	slog.New(slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader())).Info("c")
+
+	if got := readMessages(t, &buf); got != "a,b,c" {
+		t.Fatalf("expected messages a,b,c, got %s", got)
+	}
+}
+
+func TestWithFraming_unsupported(t *testing.T) {
+	header, err := proto.Marshal(&slogproto.StreamHeader{SchemaVersion: slogproto.SchemaVersion, Framing: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := binary.LittleEndian.AppendUint32([]byte(slogproto.StreamMagic), uint32(len(header)))
+	stream = append(stream, header...)
+
+	err = slogproto.Read(context.Background(), bytes.NewReader(stream), func(*slog.Record) bool { return true })
+	if !errors.Is(err, slogproto.ErrUnsupportedFraming) {
+		t.Fatalf("expected ErrUnsupportedFraming, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected an unsupported framing to panic")
+		}
+	}()
+	slogproto.WithFraming(42)
+}
@@ -7,13 +7,13 @@ require (
 	github.com/google/cel-go v0.17.1
 	github.com/klauspost/compress v1.16.7
 	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
 	google.golang.org/protobuf v1.33.0
 )
 
 require (
 	github.com/antlr/antlr4/runtime/Go/antlr/v4 v4.0.0-20230305170008-8188dc5388df // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/stoewer/go-strcase v1.2.0 // indirect
 	golang.org/x/exp v0.0.0-20230811145659-89c5cff77bcb // indirect
 	golang.org/x/text v0.8.0 // indirect
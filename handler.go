@@ -5,14 +5,18 @@
 package slogproto
 
 import (
+	"bytes"
 	"context"
-	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -28,25 +32,134 @@ var recordPool = sync.Pool{
 	},
 }
 
+// ErrHandlerClosed is returned by [Handler.Handle] once the handler has
+// been shut down with [Handler.Shutdown].
+var ErrHandlerClosed = errors.New("slogproto: handler is shut down")
+
 // Handler implements the slog.Handler interface and writes the log record
 // to the writer as a protocol buffer encoded struct containing the log
 // record, including the levem, message and attributes.
+//
+// Each record, including its size prefix, is written with a single call to
+// the writer's Write method. Multiple processes can therefore append to the
+// same file, if it is opened with os.O_APPEND, without interleaving their
+// records.
 type Handler struct {
-	opts      *slog.HandlerOptions
-	attrs     []slog.Attr
-	parent    *Handler
-	group     *Value_Group
-	groupName string
-	mu        *sync.Mutex
-	w         io.Writer
+	opts *slog.HandlerOptions
+	goas []groupOrAttrs
+	mu   *sync.Mutex
+
+	// w points to the writer, which is shared by every handler derived
+	// from the same NewHandler call so that it can be swapped, guarded
+	// by mu.
+	w *io.Writer
+
+	// closed is set once the handler has been shut down.
+	closed *atomic.Bool
+
+	// paused is set while the handler is paused.
+	paused *atomic.Bool
+
+	// level overrides the minimum level of opts once set with SetLevel.
+	level *atomic.Pointer[slog.Level]
+
+	// deadLetter receives records that could not be encoded or written.
+	deadLetter slog.Handler
+
+	// enc controls how attributes are encoded.
+	enc encoding
+
+	// attrFilter selects the attributes that are written, if set.
+	attrFilter *attrFilter
+
+	// schema validates the attributes that are written, if set.
+	schema *attrSchema
+
+	// deterministic encodes identical records as identical bytes.
+	deterministic bool
+
+	// recordID adds the ID of each record as an attribute.
+	recordID bool
+
+	// ulid adds a generated ULID to each record as an attribute.
+	ulid bool
+
+	// stream is the name of the stream of the handler's records.
+	stream string
+
+	// header holds a stream header, which is written along with the next
+	// record, guarded by mu.
+	header *[]byte
+
+	// framing is the framing of the records, declared by the header.
+	framing Framing
+
+	// totals accumulates the totals of the stream for its trailer, if
+	// one is written, guarded by mu.
+	totals *streamTotals
+
+	// audit chains each record to the previous one, if set, guarded by
+	// mu.
+	audit *auditChain
+
+	// sampleRate is the fraction of records that are written.
+	sampleRate float64
+
+	// compressor compresses the records written to the caller's writer,
+	// if set, guarded by mu.
+	compressor *compressor
+}
+
+// compressor is the writer compressing the records of a handler, created
+// by WithGzip, WithZstd or WithBlockCompression, which wraps the caller's
+// writer.
+type compressor struct {
+	// newWriter returns a compressor writing to w, to replace the current
+	// one when the caller's writer is replaced with SetWriter.
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+
+	// wc is the current compressor, closed on Shutdown.
+	wc io.WriteCloser
+
+	// wrapped is the caller's writer wrapped by wc.
+	wrapped io.Writer
+}
+
+// errCompressed is returned by compress when the handler already
+// compresses its records, since wrapping one compressor in another would
+// leave the first unclosed, and its output truncated.
+var errCompressed = errors.New("slogproto: records are already compressed by another option")
+
+// compress configures the handler to write records through a compressor
+// returned by newWriter, which wraps the caller's writer. Only one
+// compressor can be configured.
+func (h *Handler) compress(newWriter func(w io.Writer) (io.WriteCloser, error)) error {
+	if h.compressor != nil {
+		return errCompressed
+	}
+	wc, err := newWriter(*h.w)
+	if err != nil {
+		return err
+	}
+	h.compressor = &compressor{newWriter: newWriter, wc: wc, wrapped: *h.w}
+	*h.w = wc
+	return nil
 }
 
-// NewHandler returns a new Handler that writes to the writer.
+// groupOrAttrs holds either a group name or a list of attributes added
+// to a handler with WithGroup or WithAttrs, in the order they were added.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewHandler returns a new Handler that writes to the writer, configured
+// with the given options.
 //
 // # Example
 //
-//	h := slogproto.NewHandler(os.Stdout)
-func NewHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
+//	h := slogproto.NewHandler(os.Stdout, nil)
+func NewHandler(w io.Writer, opts *slog.HandlerOptions, options ...HandlerOption) *Handler {
 	if opts == nil {
 		opts = &slog.HandlerOptions{
 			Level:     slog.LevelInfo,
@@ -54,16 +167,71 @@ func NewHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
 		}
 	}
 
-	return &Handler{
-		opts: opts,
-		mu:   &sync.Mutex{},
-		w:    w,
+	h := &Handler{
+		opts:       opts,
+		mu:         &sync.Mutex{},
+		w:          &w,
+		closed:     &atomic.Bool{},
+		paused:     &atomic.Bool{},
+		level:      &atomic.Pointer[slog.Level]{},
+		sampleRate: 1,
+	}
+
+	for _, option := range options {
+		option(h)
 	}
+
+	return h
 }
 
 // Enabled returns true if the level is enabled for the handler.
+//
+// Once the handler has been shut down, or while it is paused, no level is
+// enabled.
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level <= h.opts.Level.Level()
+	if h.closed.Load() || h.paused.Load() {
+		return false
+	}
+	return level >= h.Level()
+}
+
+// Level returns the minimum level of records written by the handler, which
+// is the level set with SetLevel, or else the level of the handler's
+// options, or INFO.
+func (h *Handler) Level() slog.Level {
+	if l := h.level.Load(); l != nil {
+		return *l
+	}
+	if h.opts.Level != nil {
+		return h.opts.Level.Level()
+	}
+	return slog.LevelInfo
+}
+
+// SetLevel changes the minimum level of records written by the handler at
+// runtime, overriding the level of its options. It applies to every
+// handler derived from the same [NewHandler] call using WithAttrs or
+// WithGroup.
+func (h *Handler) SetLevel(level slog.Level) {
+	h.level.Store(&level)
+}
+
+// Pause stops the handler from writing records until Resume is called,
+// such as to shed logging load during an incident. Records handled while
+// paused are dropped without error. It applies to every handler derived
+// from the same [NewHandler] call using WithAttrs or WithGroup.
+func (h *Handler) Pause() {
+	h.paused.Store(true)
+}
+
+// Resume resumes writing records after Pause.
+func (h *Handler) Resume() {
+	h.paused.Store(false)
+}
+
+// Paused reports whether the handler is paused.
+func (h *Handler) Paused() bool {
+	return h.paused.Load()
 }
 
 // Handle writes the log record to the writer as a protocol buffer encoded
@@ -85,14 +253,29 @@ func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 //   - If a group's key is empty, inline the group's Attrs.
 //   - If a group has no Attrs (even if it has a non-empty key),
 //     ignore it.
+//
+// If the handler has been shut down, ErrHandlerClosed is returned.
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
-	// If the r.PC is zero ignore the record.
-	if r.PC != 0 && h.opts.AddSource {
-		fs := runtime.CallersFrames([]uintptr{r.PC})
-		f, _ := fs.Next()
-		h.attrs = append(h.attrs, slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", f.File, f.Line)))
+	if h.closed.Load() {
+		return ErrHandlerClosed
+	}
+
+	if h.paused.Load() {
+		return nil
 	}
 
+	if h.sampleRate < 1 && rand.Float64() >= h.sampleRate {
+		return nil
+	}
+
+	if err := h.handle(r); err != nil {
+		return h.handleDeadLetter(ctx, r, err)
+	}
+	return nil
+}
+
+// handle encodes the record and writes it to the handler's writer.
+func (h *Handler) handle(r slog.Record) error {
 	// Get a protobuf record from the pool.
 	pbr := recordPool.Get().(*Record)
 	defer func() {
@@ -107,8 +290,19 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 		return err
 	}
 
-	// Marshal the protobuf record.
-	b, err := proto.Marshal(pbr)
+	// Chained records depend on the previous record, so they're encoded
+	// once it has been written.
+	if h.audit != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.closed.Load() {
+			return ErrHandlerClosed
+		}
+		return h.writeChained(pbr, r.Time, false)
+	}
+
+	b, err := h.encodeFrame(pbr)
 	if err != nil {
 		return err
 	}
@@ -116,52 +310,242 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	// Write the length of the struct to the writer
-	// so that the reader knows how much to read.
-	buf := make([]byte, 4)
-	binary.LittleEndian.PutUint32(buf, uint32(len(b)))
-	if _, err := h.w.Write(buf); err != nil {
+	// The handler may have been shut down while encoding the record.
+	if h.closed.Load() {
+		return ErrHandlerClosed
+	}
+
+	return h.writeFrameLocked(b, r.Time)
+}
+
+// encodeFrame marshals the protobuf record after space for its size, so
+// that the frame can be written at once.
+func (h *Handler) encodeFrame(pbr *Record) ([]byte, error) {
+	n := frameSizeLen(h.framing)
+	if err := checkFrameSize(h.framing, proto.Size(pbr)); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n, n+proto.Size(pbr))
+	b, err := proto.MarshalOptions{Deterministic: h.deterministic}.MarshalAppend(b, pbr)
+	if err != nil {
+		return nil, err
+	}
+	putFrameSize(h.framing, b, len(b)-n)
+	return b, nil
+}
+
+// writeFrameLocked writes the frame of a record with the given time, along
+// with the stream header if it hasn't been written yet. It must be called
+// with mu held.
+func (h *Handler) writeFrameLocked(b []byte, t time.Time) error {
+	return h.writeFramesLocked([]recordFrame{{b: b, t: t}})
+}
+
+// recordFrame is the frame of a record and the record's time.
+type recordFrame struct {
+	b []byte
+	t time.Time
+}
+
+// writeFramesLocked writes the frames of records with a single call to
+// Write, along with the stream header if it hasn't been written yet, and
+// adds them to the stream totals. It must be called with mu held.
+func (h *Handler) writeFramesLocked(frames []recordFrame) error {
+	header := h.header != nil && *h.header != nil
+
+	var b []byte
+	if !header && len(frames) == 1 {
+		b = frames[0].b
+	} else {
+		if header {
+			b = append(b, *h.header...)
+		}
+		for _, f := range frames {
+			b = append(b, f.b...)
+		}
+	}
+
+	if _, err := (*h.w).Write(b); err != nil {
 		return err
 	}
 
-	// Write the struct to the writer.
-	_, err = h.w.Write(b)
-	return err
+	if h.totals != nil {
+		if header {
+			h.totals.addBytes(*h.header)
+		}
+		for _, f := range frames {
+			h.totals.addRecord(f.b, f.t)
+		}
+	}
+	if header {
+		*h.header = nil
+	}
+	return nil
 }
 
-// WithAttrs returns a new Handler whose attributes consist of
-// both the receiver's attributes and the arguments.
+// Shutdown stops the handler from accepting new records, waits for any
+// in-flight write to complete and flushes the underlying writer if it
+// implements a Flush() error method, such as [bufio.Writer] or
+// [compress/gzip.Writer]. It applies to every handler derived from the
+// same [NewHandler] call using WithAttrs or WithGroup.
 //
-// The Handler owns the slice: it may retain, modify or discard it.
-func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	// New handler
-	newHandler := &Handler{
-		mu:     h.mu,
-		w:      h.w,
-		opts:   h.opts,
-		attrs:  h.attrs,
-		parent: h,
+// Records are written synchronously, so none are queued when Shutdown is
+// called; records handled afterwards are rejected with ErrHandlerClosed.
+// If the context is done before the in-flight write completes, the
+// context's error is returned and the writer is not flushed.
+//
+// A final audit checkpoint and a stream trailer are written first, if the
+// handler was configured with WithAuditChain and WithStreamTrailer. The
+// writer is not closed, as it is owned by the caller, but a compressor
+// created by the handler with WithGzip is closed.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	if !h.closed.CompareAndSwap(false, true) {
+		return nil
 	}
 
-	// If in a group, add the attributes to the group.
-	if h.group != nil {
-		for i := 0; i < len(attrs); i++ {
-			v, err := getValue(attrs[i].Key, attrs[i].Value)
-			if err != nil {
-				panic(err)
-			}
-			h.group.Attrs[attrs[i].Key] = v
+	locked := make(chan struct{})
+	go func() {
+		h.mu.Lock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		defer h.mu.Unlock()
+	case <-ctx.Done():
+		// Release the lock once the in-flight write completes.
+		go func() {
+			<-locked
+			h.mu.Unlock()
+		}()
+		return ctx.Err()
+	}
+
+	if h.audit != nil && h.audit.key != nil && h.audit.sinceCheckpoint > 0 {
+		if err := h.writeCheckpoint(); err != nil {
+			return err
 		}
+	}
 
-		// Set the new handler's group to the existing group.
-		newHandler.group = h.group
-		newHandler.groupName = h.groupName
-	} else {
-		// Otherwise, add the attributes to the handler.
-		newHandler.attrs = append(newHandler.attrs, attrs...)
+	if h.totals != nil {
+		if _, err := (*h.w).Write(encodeStreamTrailer(h.totals.trailer())); err != nil {
+			return fmt.Errorf("slogproto: error writing stream trailer: %w", err)
+		}
+	}
+
+	if err := flush(*h.w); err != nil {
+		return err
+	}
+
+	if h.compressor != nil {
+		if err := h.compressor.wc.Close(); err != nil {
+			return fmt.Errorf("slogproto: error closing writer: %w", err)
+		}
 	}
 
-	return newHandler
+	return nil
+}
+
+// flush flushes the writer if it implements a Flush() error method.
+func flush(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return fmt.Errorf("slogproto: error flushing writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetWriter flushes the current writer, if it implements a Flush() error
+// method, and replaces it with w between records. It applies to every
+// handler derived from the same [NewHandler] call using WithAttrs or
+// WithGroup.
+//
+// The previous writer is not closed, as it is owned by the caller. If it
+// can't be flushed, the writer is not replaced.
+//
+// Handlers compressing records, with WithGzip, WithZstd or
+// WithBlockCompression, close the compressor of the previous writer,
+// completing its stream, and compress the records written to w with a new
+// one.
+func (h *Handler) SetWriter(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := flush(*h.w); err != nil {
+		return err
+	}
+
+	if c := h.compressor; c != nil {
+		if err := c.wc.Close(); err != nil {
+			return fmt.Errorf("slogproto: error closing writer: %w", err)
+		}
+
+		wc, err := c.newWriter(w)
+		if err != nil {
+			return fmt.Errorf("slogproto: error compressing writer: %w", err)
+		}
+		c.wc, c.wrapped = wc, w
+		*h.w = wc
+		return nil
+	}
+
+	*h.w = w
+	return nil
+}
+
+// Reopen flushes the writer, if it implements a Flush() error method, and
+// reopens it between records if it implements a Reopen() error method,
+// such as [File]. Otherwise, it returns an error.
+func (h *Handler) Reopen() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := (*h.w).(interface{ Reopen() error })
+	if !ok {
+		return fmt.Errorf("slogproto: writer of type %T can't be reopened", *h.w)
+	}
+
+	if err := flush(*h.w); err != nil {
+		return err
+	}
+
+	return r.Reopen()
+}
+
+// handleDeadLetter writes a record that failed with the given error to the
+// dead-letter handler, if one is configured. Otherwise, the error is
+// returned unchanged.
+func (h *Handler) handleDeadLetter(ctx context.Context, r slog.Record, err error) error {
+	if h.deadLetter == nil {
+		return err
+	}
+
+	dh := h.deadLetter.WithAttrs([]slog.Attr{slog.String("slogproto.error", err.Error())})
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			dh = dh.WithGroup(goa.group)
+		} else {
+			dh = dh.WithAttrs(goa.attrs)
+		}
+	}
+
+	if dlErr := dh.Handle(ctx, r); dlErr != nil {
+		return errors.Join(err, fmt.Errorf("slogproto: error writing dead letter: %w", dlErr))
+	}
+
+	return nil
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+//
+// The Handler owns the slice: it may retain, modify or discard it.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
 }
 
 // WithGroup returns a new Handler with the given group appended to
@@ -188,56 +572,117 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 	if name == "" {
 		return h
 	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
 
-	// Create a copy of the attributes.
-	attrsCopy := make([]slog.Attr, len(h.attrs))
-	copy(attrsCopy, h.attrs)
+// WithStream returns a new Handler that writes its records to the same
+// writer as the receiver, marked as belonging to the named stream, so that
+// the records of several components of a program can share a file while
+// still being read separately with [WithStreams] or [Demux].
+//
+// # Example
+//
+//	h := slogproto.NewHandler(f, nil)
+//	db := slog.New(h.WithStream("db"))
+//	api := slog.New(h.WithStream("api"))
+func (h *Handler) WithStream(name string) *Handler {
+	h2 := *h
+	h2.stream = name
+	return &h2
+}
+
+// withGroupOrAttrs returns a copy of the handler with the given group or
+// attributes appended, sharing the writer and its mutex.
+func (h *Handler) withGroupOrAttrs(goa groupOrAttrs) *Handler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h2.goas)-1] = goa
+	return &h2
+}
 
-	// New handler
-	newHandler := &Handler{
-		mu:        h.mu,
-		w:         h.w,
-		attrs:     attrsCopy,
-		opts:      h.opts,
-		parent:    h,
-		groupName: name,
+// marshalJSON marshals a Go value as JSON the way [slog.JSONHandler] does:
+// errors that don't implement [json.Marshaler] are marshaled as their
+// message, and HTML characters are not escaped.
+func marshalJSON(v any) ([]byte, error) {
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			v = err.Error()
+		}
 	}
 
-	// New group
-	newGroup := &Value_Group{
-		Attrs: make(map[string]*Value),
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
 	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
 
-	// If there is already a group, embed the new group in the existing group.
-	if h.parent != nil && h.parent.group != nil {
-		h.parent.group.Attrs[name] = &Value{
-			Kind: &Value_Group_{
-				Group: newGroup,
-			},
-		}
+// encoding controls how attributes are encoded.
+type encoding struct {
+	// fidelity rejects attributes that cannot be encoded losslessly.
+	fidelity bool
 
-		// Set the new handler's group to the existing group.
-		newHandler.group = newGroup
-	} else {
-		// Otherwise, set the new group as the handler's group.
-		newHandler.group = newGroup
-	}
+	// keyOrder records the order of the keys of each group.
+	keyOrder bool
 
-	return newHandler
+	// embedAny encodes Any values holding an [anypb.Any] as they are,
+	// instead of as JSON.
+	embedAny bool
 }
 
-// getValue converts a slog.Value to a slogproto Value.
-func getValue(group string, value slog.Value) (*Value, error) {
+// getValue converts a slog.Value to a slogproto Value. It returns nil for
+// an empty group, unless encoding with fidelity.
+func getValue(value slog.Value, enc encoding) (*Value, error) {
 	switch value.Kind() {
 	case slog.KindAny:
-		b, err := json.Marshal(value.Any())
+		if a, ok := value.Any().(*anypb.Any); ok && enc.embedAny {
+			return &Value{
+				Kind: &Value_Any{
+					Any: a,
+				},
+			}, nil
+		}
+
+		if vt := lookupValueType(value.Any()); vt != nil {
+			b, err := vt.marshal(value.Any())
+			if err != nil {
+				return nil, fmt.Errorf("slogproto: error marshaling %T: %w", value.Any(), err)
+			}
+			return &Value{
+				Kind: &Value_Any{
+					Any: &anypb.Any{
+						TypeUrl: vt.typeURL,
+						Value:   b,
+					},
+				},
+			}, nil
+		}
+
+		if enc.fidelity {
+			// Only Any values can be decoded as the value they were
+			// encoded from.
+			a, ok := value.Any().(*anypb.Any)
+			if !ok {
+				return nil, fmt.Errorf("%w: value of type %T cannot be decoded", ErrLossy, value.Any())
+			}
+			return &Value{
+				Kind: &Value_Any{
+					Any: a,
+				},
+			}, nil
+		}
+
+		b, err := marshalJSON(value.Any())
 		if err != nil {
 			return nil, fmt.Errorf("slogproto: error marshaling slog.Value as JSON: %w", err)
 		}
 		return &Value{
 			Kind: &Value_Any{
 				Any: &anypb.Any{
-					TypeUrl: fmt.Sprintf("go/slog/%T", value.Any()),
+					TypeUrl: fmt.Sprintf("%s%T", anyTypeURLPrefix, value.Any()),
 					Value:   b,
 				},
 			},
@@ -291,16 +736,19 @@ func getValue(group string, value slog.Value) (*Value, error) {
 			Attrs: make(map[string]*Value, len(attrs)),
 		}
 
+		var keys *[]string
+		if enc.keyOrder {
+			keys = &g.Keys
+		}
+
 		for i := 0; i < len(attrs); i++ {
-			v, err := getValue(attrs[i].Key, attrs[i].Value)
-			if err != nil {
+			if err := addAttr(g.Attrs, keys, attrs[i], enc); err != nil {
 				return nil, err
 			}
-			g.Attrs[attrs[i].Key] = v
 		}
 
 		// Return nil if there are no attributes.
-		if len(g.Attrs) == 0 {
+		if len(g.Attrs) == 0 && !enc.fidelity {
 			return nil, nil
 		}
 
@@ -310,7 +758,7 @@ func getValue(group string, value slog.Value) (*Value, error) {
 			},
 		}, nil
 	case slog.KindLogValuer:
-		return getValue(group, value.LogValuer().LogValue())
+		return getValue(value.LogValuer().LogValue(), enc)
 	default:
 		return nil, fmt.Errorf("unknown value kind: %v", value.Kind())
 	}
@@ -323,6 +771,17 @@ const (
 	LevelDebug = Level_LEVEL_DEBUG
 )
 
+// isStandardLevel reports whether the level is one of the four levels
+// defined by the slog package, which are encoded by the Level enum alone.
+func isStandardLevel(level slog.Level) bool {
+	switch level {
+	case slog.LevelInfo, slog.LevelWarn, slog.LevelError, slog.LevelDebug:
+		return true
+	default:
+		return false
+	}
+}
+
 // convertLevel converts a slog.Level to a slogproto Level.
 func convertLevel(level slog.Level) Level {
 	switch level {
@@ -339,11 +798,73 @@ func convertLevel(level slog.Level) Level {
 	}
 }
 
+// addAttr resolves the attribute and adds it to the given map of attributes,
+// ignoring empty attributes and groups, and inlining groups with an empty key.
+//
+// When recording the key order, the key is also appended to keys, unless
+// it overwrites an attribute. When encoding with fidelity, attributes that
+// would be ignored, inlined or overwritten are rejected with ErrLossy
+// instead.
+func addAttr(attrs map[string]*Value, keys *[]string, attr slog.Attr, enc encoding) error {
+	attr.Value = attr.Value.Resolve()
+
+	if enc.fidelity {
+		if attr.Key == "" {
+			return fmt.Errorf("%w: attribute with an empty key", ErrLossy)
+		}
+		if _, ok := attrs[attr.Key]; ok {
+			return fmt.Errorf("%w: duplicate attribute %q", ErrLossy, attr.Key)
+		}
+	}
+
+	// If the attribute is empty, skip it.
+	if attr.Equal(slog.Attr{}) {
+		return nil
+	}
+
+	// If the key is empty, skip it, unless it is a group.
+	// If it is a group, we want to inline its attributes.
+	if attr.Key == "" {
+		if attr.Value.Kind() != slog.KindGroup {
+			return nil
+		}
+
+		group := attr.Value.Group()
+		for i := 0; i < len(group); i++ {
+			if err := addAttr(attrs, keys, group[i], enc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	v, err := getValue(attr.Value, enc)
+	if err != nil {
+		return err
+	}
+
+	// Skip the empty group.
+	if v == nil {
+		return nil
+	}
+
+	_, overwrite := attrs[attr.Key]
+	attrs[attr.Key] = v
+	if keys != nil && !overwrite {
+		*keys = append(*keys, attr.Key)
+	}
+	return nil
+}
+
 // fillProtobufRecord fills a slogproto Record with the values from a slog Record.
 func (h *Handler) fillProtobufRecord(pbr *Record, slr *slog.Record) error {
 	pbr.Level = convertLevel(slr.Level)
+	if !isStandardLevel(slr.Level) {
+		pbr.SlogLevel = int64(slr.Level)
+	}
 	pbr.Message = slr.Message
-	pbr.Attrs = make(map[string]*Value, slr.NumAttrs()+len(h.attrs))
+	pbr.Stream = h.stream
+	pbr.Attrs = make(map[string]*Value, slr.NumAttrs()+len(h.goas))
 
 	timeIsZero := slr.Time.IsZero()
 
@@ -351,94 +872,181 @@ func (h *Handler) fillProtobufRecord(pbr *Record, slr *slog.Record) error {
 		pbr.Time = timestamppb.New(slr.Time)
 	}
 
-	// Add the handler's attributes.
-	for i := 0; i < len(h.attrs); i++ {
-		// If the key is empty, skip it.
-		if h.attrs[i].Key == "" {
-			continue
+	// Each group opened with WithGroup gets its own map of attributes,
+	// which is only added to its parent once we know it isn't empty.
+	// The order of each group's keys may be recorded too.
+	type group struct {
+		name  string
+		attrs map[string]*Value
+		keys  *[]string
+	}
+
+	newGroup := func(name string, attrs map[string]*Value) group {
+		g := group{name: name, attrs: attrs}
+		if h.enc.keyOrder {
+			g.keys = new([]string)
 		}
+		return g
+	}
 
-		v, err := getValue(h.attrs[i].Key, h.attrs[i].Value)
-		if err != nil {
-			return err
+	groups := []group{newGroup("", pbr.Attrs)}
+
+	// If the r.PC is zero ignore the source.
+	if slr.PC != 0 && h.opts.AddSource {
+		fs := runtime.CallersFrames([]uintptr{slr.PC})
+		f, _ := fs.Next()
+		pbr.Attrs[slog.SourceKey] = &Value{
+			Kind: &Value_String_{
+				String_: fmt.Sprintf("%s:%d", f.File, f.Line),
+			},
+		}
+		if groups[0].keys != nil {
+			*groups[0].keys = append(*groups[0].keys, slog.SourceKey)
 		}
-		pbr.Attrs[h.attrs[i].Key] = v
 	}
 
-	// Add the record's attributes.
-	var err error
-	slr.Attrs(func(attr slog.Attr) bool {
-		// If the key is empty, skip it, unless it is a group.
-		// If it is a group, we want to add it to the record.
-		if attr.Key == "" {
-			if attr.Value.Kind() == slog.KindGroup {
-				var v *Value
-				v, err = getValue(attr.Key, attr.Value)
-				if err != nil {
-					return false
-				}
+	// The path of the innermost group, used to filter and check
+	// attributes.
+	var path []string
+	trackPath := h.attrFilter != nil || h.schema != nil
 
-				// Skip the empty group.
-				if v == nil {
-					return true
-				}
+	var check *schemaCheck
+	if h.schema != nil {
+		check = h.schema.newCheck()
+	}
 
-				for k, v := range v.GetGroup().Attrs {
-					pbr.Attrs[k] = v
-				}
-				return true
+	// Add the handler's groups and attributes.
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			groups = append(groups, newGroup(goa.group, make(map[string]*Value)))
+			if trackPath {
+				path = append(path, goa.group)
 			}
-			return true
+			continue
 		}
 
-		var v *Value
-		v, err = getValue(attr.Key, attr.Value)
-		if err != nil {
-			return false
-		}
+		g := groups[len(groups)-1]
+		for i := 0; i < len(goa.attrs); i++ {
+			attr := goa.attrs[i]
+			if h.attrFilter != nil {
+				var ok bool
+				if attr, ok = h.attrFilter.filter(path, attr); !ok {
+					continue
+				}
+			}
+			if check != nil {
+				var (
+					ok  bool
+					err error
+				)
+				if attr, ok, err = check.check(path, attr); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+			}
 
-		// Skip the empty group.
-		if v == nil {
-			return true
+			if err := addAttr(g.attrs, g.keys, attr, h.enc); err != nil {
+				return err
+			}
 		}
+	}
 
-		if h.group != nil {
-			h.group.Attrs[attr.Key] = v
-		} else {
-			pbr.Attrs[attr.Key] = v
+	// Add the record's attributes to the innermost group.
+	var err error
+	g := groups[len(groups)-1]
+	slr.Attrs(func(attr slog.Attr) bool {
+		if h.attrFilter != nil {
+			var ok bool
+			if attr, ok = h.attrFilter.filter(path, attr); !ok {
+				return true
+			}
+		}
+		if check != nil {
+			var ok bool
+			if attr, ok, err = check.check(path, attr); err != nil || !ok {
+				return err == nil
+			}
 		}
 
-		return true
+		err = addAttr(g.attrs, g.keys, attr, h.enc)
+		return err == nil
 	})
 	if err != nil {
 		return err
 	}
 
-	// Add the group to the record.
-	if h.group != nil {
-		// If there is a parent, add the group to the parent.
-		if h.parent != nil && h.parent.group != nil {
-			h.parent.group.Attrs[h.groupName] = &Value{
-				Kind: &Value_Group_{
-					Group: h.group,
-				},
-			}
-		} else {
-			pbr.Attrs[h.groupName] = &Value{
-				Kind: &Value_Group_{
-					Group: h.group,
-				},
-			}
+	if check != nil {
+		if err := check.missing(); err != nil {
+			return err
 		}
 	}
 
-	// Add the parent's group to the record.
-	if h.parent != nil && h.parent.group != nil {
-		pbr.Attrs[h.parent.groupName] = &Value{
+	// Add the non-empty groups to their parents, from the innermost
+	// group outwards.
+	for i := len(groups) - 1; i > 0; i-- {
+		if len(groups[i].attrs) == 0 {
+			continue
+		}
+
+		parent := groups[i-1]
+		_, overwrite := parent.attrs[groups[i].name]
+		if overwrite && h.enc.fidelity {
+			return fmt.Errorf("%w: duplicate attribute %q", ErrLossy, groups[i].name)
+		}
+
+		value := &Value_Group{
+			Attrs: groups[i].attrs,
+		}
+		if groups[i].keys != nil {
+			value.Keys = *groups[i].keys
+		}
+
+		parent.attrs[groups[i].name] = &Value{
 			Kind: &Value_Group_{
-				Group: h.parent.group,
+				Group: value,
+			},
+		}
+		if parent.keys != nil && !overwrite {
+			*parent.keys = append(*parent.keys, groups[i].name)
+		}
+	}
+
+	if groups[0].keys != nil {
+		pbr.Keys = *groups[0].keys
+	}
+
+	if h.ulid {
+		t := slr.Time
+		if timeIsZero {
+			t = time.Now()
+		}
+		id := NewULID(t)
+
+		_, overwrite := pbr.Attrs[ULIDKey]
+		pbr.Attrs[ULIDKey] = &Value{
+			Kind: &Value_Any{
+				Any: &anypb.Any{
+					TypeUrl: ULIDTypeURL,
+					Value:   id[:],
+				},
 			},
 		}
+		if h.enc.keyOrder && !overwrite {
+			pbr.Keys = append(pbr.Keys, ULIDKey)
+		}
+	}
+
+	if h.recordID {
+		_, overwrite := pbr.Attrs[RecordIDKey]
+		pbr.Attrs[RecordIDKey] = &Value{
+			Kind: &Value_String_{
+				String_: recordID(pbr),
+			},
+		}
+		if h.enc.keyOrder && !overwrite {
+			pbr.Keys = append(pbr.Keys, RecordIDKey)
+		}
 	}
 
 	return nil
@@ -1,13 +1,19 @@
 package slogproto_test
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"testing/slogtest"
 	"time"
@@ -113,6 +119,60 @@ func TestHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_Enabled(t *testing.T) {
+	for _, test := range []struct {
+		opts  *slog.HandlerOptions
+		level slog.Level
+		want  bool
+	}{
+		{nil, slog.LevelDebug, false},
+		{nil, slog.LevelInfo, true},
+		{nil, slog.LevelError, true},
+		{&slog.HandlerOptions{Level: slog.LevelWarn}, slog.LevelInfo, false},
+		{&slog.HandlerOptions{Level: slog.LevelWarn}, slog.LevelWarn, true},
+		{&slog.HandlerOptions{Level: slog.LevelWarn}, slog.LevelError, true},
+	} {
+		h := slogproto.NewHandler(io.Discard, test.opts)
+		if got := h.Enabled(context.Background(), test.level); got != test.want {
+			t.Errorf("level %s with options %+v: expected %t, got %t", test.level, test.opts, test.want, got)
+		}
+	}
+}
+
+func TestHandler_WithGroup_independent(t *testing.T) {
+	var buf bytes.Buffer
+
+	g := slog.New(slogproto.NewHandler(&buf, nil)).WithGroup("g")
+	a := g.With("a", 1)
+	b := g.With("b", 2)
+
+	// Handlers derived from the same group don't share its attributes, and
+	// empty groups are omitted.
+	a.Info("first")
+	b.Info("second")
+	b.Info("third")
+	g.Info("empty")
+
+	var got []string
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		var attrs []string
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a.String())
+			return true
+		})
+		got = append(got, fmt.Sprintf("%s%v", r.Message, attrs))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[first[g=[a=1]] second[g=[b=2]] third[g=[b=2]] empty[]]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("expected %s, got %v", want, got)
+	}
+}
+
 func TestHandler_Compression_Comparison(t *testing.T) {
 	const numRecords = 1024
 
@@ -775,7 +835,7 @@ func humanSize(v int) string {
 	return fmt.Sprintf("%.2f%s", size, unit)
 }
 
-func Example_WriteToFile() {
+func Example_writeToFile() {
 	fh, err := os.OpenFile("test.log", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		panic(err)
@@ -796,3 +856,202 @@ func Example_WriteToFile() {
 	// Output:
 	//
 }
+
+func TestHandler_deadLetter(t *testing.T) {
+	var (
+		logBuffer        bytes.Buffer
+		deadLetterBuffer bytes.Buffer
+	)
+
+	l := slog.New(slogproto.NewHandler(&logBuffer, nil, slogproto.WithDeadLetter(&deadLetterBuffer)))
+
+	l.WithGroup("g").Info("unencodable", slog.Any("ch", make(chan int)))
+	l.Info("encodable", slog.Int("i", 1))
+
+	records := parseLogEntriesForInteral(t, logBuffer.Bytes())
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	if records[0][slog.MessageKey] != "encodable" {
+		t.Fatalf("expected encodable record, got %v", records[0][slog.MessageKey])
+	}
+
+	deadLetter := deadLetterBuffer.String()
+
+	for _, want := range []string{"msg=unencodable", "slogproto.error=", "g.ch="} {
+		if !strings.Contains(deadLetter, want) {
+			t.Errorf("expected dead letter to contain %q, got %q", want, deadLetter)
+		}
+	}
+}
+
+func TestHandler_Shutdown(t *testing.T) {
+	var logBuffer bytes.Buffer
+
+	w := bufio.NewWriter(&logBuffer)
+
+	h := slogproto.NewHandler(w, nil)
+
+	l := slog.New(h.WithGroup("g"))
+
+	l.Info("before shutdown", slog.Int("i", 1))
+
+	if logBuffer.Len() != 0 {
+		t.Fatalf("expected buffered writer to not be flushed yet")
+	}
+
+	err := h.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	records := parseLogEntriesForInteral(t, logBuffer.Bytes())
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	if h.Enabled(context.Background(), slog.LevelError) {
+		t.Fatalf("expected no level to be enabled after shutdown")
+	}
+
+	err = l.Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "after shutdown", 0))
+	if !errors.Is(err, slogproto.ErrHandlerClosed) {
+		t.Fatalf("expected ErrHandlerClosed, but got: %v", err)
+	}
+
+	err = h.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error on second shutdown, but got: %v", err)
+	}
+}
+
+// writeCounter counts the calls to its Write method.
+type writeCounter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestHandler_singleWritePerRecord(t *testing.T) {
+	var w writeCounter
+
+	l := slog.New(slogproto.NewHandler(&w, nil))
+
+	for i := 0; i < 10; i++ {
+		l.Info("this is a test", "i", i)
+	}
+
+	if w.writes != 10 {
+		t.Fatalf("expected 10 writes, got %d", w.writes)
+	}
+
+	records := parseLogEntriesForInteral(t, w.Bytes())
+	if len(records) != 10 {
+		t.Fatalf("expected 10 records, got %d", len(records))
+	}
+}
+
+func TestHandler_concurrentAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+
+	const (
+		writers = 4
+		records = 250
+	)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		// Each writer opens the file separately, as separate processes do.
+		fh, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fh.Close()
+
+		l := slog.New(slogproto.NewHandler(fh, nil))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < records; j++ {
+				l.Info("this is a test", "writer", i, "j", j)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(parseLogEntriesForInteral(t, b)); got != writers*records {
+		t.Fatalf("expected %d records, got %d", writers*records, got)
+	}
+}
+
+func TestHandler_deterministic(t *testing.T) {
+	r := slog.NewRecord(time.Date(2023, 8, 1, 3, 12, 11, 0, time.UTC), slog.LevelInfo, "this is a test", 0)
+	for i := 0; i < 20; i++ {
+		r.AddAttrs(slog.Int(fmt.Sprintf("attr%d", i), i))
+	}
+	r.AddAttrs(slog.Group("group", "a", 1, "b", 2, "c", map[string]int{"x": 1, "y": 2, "z": 3}))
+
+	var want []byte
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		h := slogproto.NewHandler(&buf, nil, slogproto.WithDeterministic())
+		if err := h.WithAttrs([]slog.Attr{slog.String("service", "test"), slog.Bool("ok", true)}).Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 {
+			want = buf.Bytes()
+			continue
+		}
+
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Fatalf("encoding %d differs:\n got %x\nwant %x", i, buf.Bytes(), want)
+		}
+	}
+}
+
+func TestHandler_sampleRate(t *testing.T) {
+	for rate, want := range map[float64]int{0: 0, 1: 100} {
+		var buf bytes.Buffer
+		logger := slog.New(slogproto.NewHandler(&buf, nil, slogproto.WithSampleRate(rate)))
+		for range 100 {
+			logger.Info("sampled")
+		}
+
+		var n int
+		err := slogproto.Read(context.Background(), &buf, func(*slog.Record) bool {
+			n++
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Fatalf("rate %v: expected %d records, got %d", rate, want, n)
+		}
+	}
+
+	for _, rate := range []float64{-0.1, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected sample rate %v to panic", rate)
+				}
+			}()
+			slogproto.WithSampleRate(rate)
+		}()
+	}
+}
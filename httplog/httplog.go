@@ -0,0 +1,229 @@
+// Package httplog provides net/http middleware logging one record per
+// request, with a standardized group of attributes describing it, so
+// that the request logs of services can be queried the same way:
+//
+//	{"time":"...","level":"INFO","msg":"request","http":{"method":"GET","path":"/users","status":200,"duration":1520000,"bytes":512,"remote_addr":"10.0.0.7:52114"}}
+//
+// # Example
+//
+//	h := slogproto.NewHandler(f, nil)
+//	http.ListenAndServe(":8080", httplog.Middleware(h, mux, nil))
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Keys of the group of attributes describing a request, and of its
+// attributes.
+const (
+	GroupKey      = "http"
+	MethodKey     = "method"
+	PathKey       = "path"
+	StatusKey     = "status"
+	DurationKey   = "duration"
+	BytesKey      = "bytes"
+	RemoteAddrKey = "remote_addr"
+)
+
+// Options configure the Middleware. A nil *Options is equivalent to the
+// zero value.
+type Options struct {
+	// Message is the message of the records, which is "request" by
+	// default.
+	Message string
+
+	// Level returns the level of the record of a request given its
+	// status. By default, server errors are logged at the error level,
+	// client errors at the warn level, and other requests at the info
+	// level.
+	Level func(status int) slog.Level
+
+	// Attrs returns additional attributes of the record of a request,
+	// added after the group, such as a request ID from a header.
+	Attrs func(r *http.Request) []slog.Attr
+}
+
+// Middleware returns a handler serving requests with next, which logs a
+// record for each request to h once it has been served, describing it
+// with the attributes of a group named [GroupKey].
+//
+// Requests whose handler panics are logged with a status of 500, if no
+// status was written, before the panic continues. The writer passed to
+// next implements [http.Flusher] and [http.Hijacker] if w does, and
+// connections hijacked without a status written, such as to upgrade them
+// to websockets, are logged with a status of 101.
+func Middleware(h slog.Handler, next http.Handler, opts *Options) http.Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	msg := opts.Message
+	if msg == "" {
+		msg = "request"
+	}
+
+	level := opts.Level
+	if level == nil {
+		level = defaultLevel
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v != nil && rw.status == 0 {
+				rw.status = http.StatusInternalServerError
+			}
+
+			logRequest(r.Context(), h, msg, level, opts.Attrs, r, rw, start)
+
+			if v != nil {
+				panic(v)
+			}
+		}()
+
+		next.ServeHTTP(wrap(w, rw), r)
+	})
+}
+
+// logRequest logs the record of a served request.
+func logRequest(ctx context.Context, h slog.Handler, msg string, level func(int) slog.Level, attrs func(*http.Request) []slog.Attr, r *http.Request, rw *responseWriter, start time.Time) {
+	status := rw.status
+	if status == 0 {
+		// Nothing was written, which net/http responds to with a 200.
+		status = http.StatusOK
+	}
+
+	lvl := level(status)
+	if !h.Enabled(ctx, lvl) {
+		return
+	}
+
+	now := time.Now()
+
+	record := slog.NewRecord(now, lvl, msg, 0)
+	record.AddAttrs(slog.Group(GroupKey,
+		slog.String(MethodKey, r.Method),
+		slog.String(PathKey, r.URL.Path),
+		slog.Int(StatusKey, status),
+		slog.Duration(DurationKey, now.Sub(start)),
+		slog.Int64(BytesKey, rw.bytes),
+		slog.String(RemoteAddrKey, r.RemoteAddr),
+	))
+	if attrs != nil {
+		record.AddAttrs(attrs(r)...)
+	}
+
+	// Errors writing the log can't be reported to the client, whose
+	// response has already been written.
+	_ = h.Handle(ctx, record)
+}
+
+// defaultLevel is the default level of the record of a request.
+func defaultLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// responseWriter records the status and the number of bytes of the body
+// of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status, other than an informational one, and
+// writes it.
+func (w *responseWriter) WriteHeader(status int) {
+	informational := status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+	if w.status == 0 && !informational {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written, and writes them.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying writer, for [http.ResponseController].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush flushes the response, whose underlying writer must be an
+// [http.Flusher].
+func (w *responseWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack takes over the connection of the response, whose underlying
+// writer must be an [http.Hijacker].
+func (w *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// wrap returns the responseWriter of w as a writer implementing the
+// optional interfaces of w, so that handlers checking for them behave as
+// they would without the middleware.
+func wrap(w http.ResponseWriter, rw *responseWriter) http.ResponseWriter {
+	_, flusher := w.(http.Flusher)
+	_, hijacker := w.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return flushHijackWriter{rw}
+	case flusher:
+		return flushWriter{rw}
+	case hijacker:
+		return hijackWriter{rw}
+	default:
+		return rw
+	}
+}
+
+// flushWriter is a responseWriter implementing [http.Flusher].
+type flushWriter struct{ *responseWriter }
+
+func (w flushWriter) Flush() { w.flush() }
+
+// hijackWriter is a responseWriter implementing [http.Hijacker].
+type hijackWriter struct{ *responseWriter }
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+// flushHijackWriter is a responseWriter implementing [http.Flusher] and
+// [http.Hijacker].
+type flushHijackWriter struct{ *responseWriter }
+
+func (w flushHijackWriter) Flush() { w.flush() }
+
+func (w flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
@@ -0,0 +1,206 @@
+package httplog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/httplog"
+)
+
+func TestMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	var buf bytes.Buffer
+	h := httplog.Middleware(slogproto.NewHandler(&buf, nil), mux, &httplog.Options{
+		Attrs: func(r *http.Request) []slog.Attr {
+			return []slog.Attr{slog.String("request_id", r.Header.Get("X-Request-Id"))}
+		},
+	})
+
+	for _, path := range []string{"/ok", "/missing", "/empty", "/panic"} {
+		req := httptest.NewRequest(http.MethodGet, path+"?q=1", nil)
+		req.Header.Set("X-Request-Id", "req"+path)
+
+		func() {
+			defer func() {
+				if v := recover(); (v != nil) != (path == "/panic") {
+					t.Fatalf("unexpected panic %v for %s", v, path)
+				}
+			}()
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+
+	type request struct {
+		level     slog.Level
+		path      string
+		status    int64
+		bytes     int64
+		requestID string
+	}
+
+	var got []request
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		if r.Message != "request" {
+			t.Fatalf("unexpected message %q", r.Message)
+		}
+
+		req := request{level: r.Level}
+		r.Attrs(func(a slog.Attr) bool {
+			switch a.Key {
+			case httplog.GroupKey:
+				for _, ga := range a.Value.Group() {
+					switch ga.Key {
+					case httplog.PathKey:
+						req.path = ga.Value.String()
+					case httplog.StatusKey:
+						req.status = ga.Value.Int64()
+					case httplog.BytesKey:
+						req.bytes = ga.Value.Int64()
+					case httplog.MethodKey:
+						if ga.Value.String() != http.MethodGet {
+							t.Fatalf("unexpected method %s", ga.Value)
+						}
+					case httplog.DurationKey:
+						if ga.Value.Kind() != slog.KindDuration {
+							t.Fatalf("expected a duration, got %s", ga.Value.Kind())
+						}
+					case httplog.RemoteAddrKey:
+						if ga.Value.String() == "" {
+							t.Fatal("expected a remote address")
+						}
+					}
+				}
+			case "request_id":
+				req.requestID = a.Value.String()
+			}
+			return true
+		})
+		got = append(got, req)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []request{
+		{slog.LevelInfo, "/ok", 200, 5, "req/ok"},
+		{slog.LevelWarn, "/missing", 404, 10, "req/missing"},
+		{slog.LevelInfo, "/empty", 200, 0, "req/empty"},
+		{slog.LevelError, "/panic", 500, 0, "req/panic"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d records, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestMiddleware_flush(t *testing.T) {
+	h := httplog.Middleware(slogproto.NewHandler(io.Discard, nil), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+	}), nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !rec.Flushed {
+		t.Fatal("expected the response to be flushed")
+	}
+
+	// Writers that can't flush aren't flushers once wrapped either, and
+	// report it to response controllers.
+	h = httplog.Middleware(slogproto.NewHandler(io.Discard, nil), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); ok {
+			t.Error("expected a writer that can't flush")
+		}
+		if err := http.NewResponseController(w).Flush(); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("expected ErrNotSupported, got %v", err)
+		}
+	}), nil)
+	h.ServeHTTP(struct{ http.ResponseWriter }{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestMiddleware_hijack(t *testing.T) {
+	var buf bytes.Buffer
+	logs := slogproto.NewHandler(&buf, nil)
+
+	h := httplog.Middleware(logs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\nhello")
+		brw.Flush()
+	}), nil)
+
+	// The server doesn't wait for hijacked connections to be served.
+	served := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(served)
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: example\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), "\r\n\r\nhello") {
+		t.Fatalf("unexpected response %q", b)
+	}
+
+	<-served
+
+	var status int64
+	err = slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		r.Attrs(func(a slog.Attr) bool {
+			for _, ga := range a.Value.Group() {
+				if ga.Key == httplog.StatusKey {
+					status = ga.Value.Int64()
+				}
+			}
+			return true
+		})
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("expected a status of 101, got %d", status)
+	}
+}
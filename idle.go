@@ -0,0 +1,116 @@
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrIdleTimeout is returned by [Read] when no complete record has been
+// read for the duration given to [WithIdleTimeout].
+var ErrIdleTimeout = errors.New("slogproto: idle timeout")
+
+// WithIdleTimeout configures Read to detect a stalled input, such as a
+// pipe or socket whose writer has hung, when no complete record has been
+// read for the given duration.
+//
+// Each time the duration passes without a record, fn is called with the
+// time since the last record (or since reading started). If fn returns an
+// error, Read stops and returns it; otherwise Read keeps waiting, and fn is
+// called again after another period. A nil fn stops reading with
+// ErrIdleTimeout.
+//
+// A quiet writer that is alive can be told apart from a dead one by
+// logging a heartbeat record more often than the timeout. Once Read
+// returns because of the timeout, the reader is left with a pending read.
+func WithIdleTimeout(d time.Duration, fn func(idle time.Duration) error) ReadOption {
+	return func(c *readConfig) {
+		c.idleTimeout = d
+		c.idleFn = fn
+	}
+}
+
+// readResult is the result of a call to Read.
+type readResult struct {
+	n   int
+	err error
+}
+
+// idleReader is a reader that calls a function when no complete record
+// has been read for a timeout, while waiting for the underlying reader.
+type idleReader struct {
+	ctx     context.Context
+	r       io.Reader
+	timeout time.Duration
+	fn      func(time.Duration) error
+
+	// last is when the last complete record was read.
+	last time.Time
+
+	// buf holds the data of the pending read, whose result is sent to
+	// results.
+	buf     []byte
+	pending bool
+	results chan readResult
+}
+
+// newIdleReader returns an idleReader reading from r.
+func newIdleReader(ctx context.Context, r io.Reader, timeout time.Duration, fn func(time.Duration) error) *idleReader {
+	if fn == nil {
+		fn = func(idle time.Duration) error {
+			return fmt.Errorf("%w: no record read for %s", ErrIdleTimeout, idle.Round(time.Millisecond))
+		}
+	}
+
+	return &idleReader{
+		ctx:     ctx,
+		r:       r,
+		timeout: timeout,
+		fn:      fn,
+		last:    time.Now(),
+		results: make(chan readResult, 1),
+	}
+}
+
+// record notes that a complete record has been read.
+func (r *idleReader) record() {
+	r.last = time.Now()
+}
+
+// Read reads from the underlying reader in another goroutine, so that the
+// timeout can be checked while waiting for it.
+func (r *idleReader) Read(p []byte) (int, error) {
+	if !r.pending {
+		if cap(r.buf) < len(p) {
+			r.buf = make([]byte, len(p))
+		}
+		buf := r.buf[:len(p)]
+
+		r.pending = true
+		go func() {
+			n, err := r.r.Read(buf)
+			r.results <- readResult{n, err}
+		}()
+	}
+
+	timer := time.NewTimer(time.Until(r.last.Add(r.timeout)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case res := <-r.results:
+			r.pending = false
+			return copy(p, r.buf[:res.n]), res.err
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		case <-timer.C:
+			idle := time.Since(r.last)
+			if err := r.fn(idle); err != nil {
+				return 0, err
+			}
+			timer.Reset(r.timeout)
+		}
+	}
+}
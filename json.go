@@ -0,0 +1,90 @@
+package slogproto
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// jsonHandler is a [slog.JSONHandler] for records decoded by [Read].
+type jsonHandler struct {
+	h slog.Handler
+}
+
+// NewJSONHandler returns a handler that writes records decoded by [Read] as
+// the JSON that [slog.NewJSONHandler] would have written for the records
+// originally handled, given the same options, byte for byte. It can be used
+// to put slogproto between an application and a pipeline consuming its
+// JSON logs.
+//
+// This holds for records written by a handler created with
+// [WithKeyOrder] or [WithFidelity], without AddSource, in the same time
+// zone, since times are written in the local time zone. Otherwise,
+// attributes are sorted by key.
+//
+// Go values, which are encoded as JSON, are written as they were encoded,
+// rather than as the [anypb.Any] holding them.
+func NewJSONHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	return &jsonHandler{h: slog.NewJSONHandler(w, opts)}
+}
+
+// Enabled returns true if the level is enabled for the handler.
+func (h *jsonHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle writes the record as JSON.
+func (h *jsonHandler) Handle(ctx context.Context, r slog.Record) error {
+	t := r.Time
+	if !t.IsZero() {
+		t = t.Local()
+	}
+
+	r2 := slog.NewRecord(t, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(jsonAttr(a))
+		return true
+	})
+
+	return h.h.Handle(ctx, r2)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *jsonHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	converted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		converted[i] = jsonAttr(a)
+	}
+	return &jsonHandler{h: h.h.WithAttrs(converted)}
+}
+
+// WithGroup returns a new handler with the given group.
+func (h *jsonHandler) WithGroup(name string) slog.Handler {
+	return &jsonHandler{h: h.h.WithGroup(name)}
+}
+
+// jsonAttr returns the attribute with the Go values it holds, which were
+// encoded as JSON, replaced by that JSON, and times in the local time
+// zone.
+func jsonAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindTime:
+		a.Value = slog.TimeValue(a.Value.Time().Local())
+	case slog.KindAny:
+		if v, ok := a.Value.Any().(*anypb.Any); ok && strings.HasPrefix(v.GetTypeUrl(), anyTypeURLPrefix) {
+			a.Value = slog.AnyValue(json.RawMessage(v.GetValue()))
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = jsonAttr(ga)
+		}
+		a.Value = slog.GroupValue(attrs...)
+	}
+	return a
+}
@@ -0,0 +1,93 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestNewJSONHandler(t *testing.T) {
+	log := func(h slog.Handler) {
+		l := slog.New(h)
+		l.Info("hello", "i", 1, "s", "<b>&</b>", "f", 1.5, "u", uint64(1<<63), "b", true)
+		l.Warn("nested", slog.Group("http", "method", "GET", "status", 200, slog.Group("empty")), "z", "last")
+		l.Error("failed", "err", errors.New("boom <now>"), "m", map[string]int{"b": 2, "a": 1}, "list", []string{"x", "y"})
+		l.Log(context.Background(), slog.LevelInfo+2, "custom", "d", 3*time.Second, "at", time.Date(2023, 8, 1, 3, 12, 11, 5, time.Local))
+		l.With("service", "api").WithGroup("req").With("id", 7).Info("derived", "path", "/", "a", 1)
+		l.WithGroup("unused").Info("no attrs")
+	}
+
+	var want bytes.Buffer
+	log(slog.NewJSONHandler(&want, nil))
+
+	var encoded bytes.Buffer
+	log(slogproto.NewHandler(&encoded, nil, slogproto.WithKeyOrder()))
+
+	var got bytes.Buffer
+	h := slogproto.NewJSONHandler(&got, nil)
+	err := slogproto.Read(context.Background(), &encoded, func(r *slog.Record) bool {
+		if err := h.Handle(context.Background(), *r); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records are logged at different times, so compare everything after
+	// the time of each record.
+	wantLines := bytes.Split(want.Bytes(), []byte("\n"))
+	gotLines := bytes.Split(got.Bytes(), []byte("\n"))
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(wantLines), len(gotLines), got.String())
+	}
+
+	for i := range wantLines {
+		if !bytes.Equal(stripTime(gotLines[i]), stripTime(wantLines[i])) {
+			t.Errorf("line %d differs:\n got %s\nwant %s", i, gotLines[i], wantLines[i])
+		}
+	}
+}
+
+func TestNewJSONHandler_time(t *testing.T) {
+	r := slog.NewRecord(time.Date(2023, 8, 1, 3, 12, 11, 272826000, time.Local), slog.LevelInfo, "hello", 0)
+
+	var want bytes.Buffer
+	if err := slog.NewJSONHandler(&want, nil).Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	pbr, err := slogproto.RecordToProto(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := slogproto.ProtoToRecord(pbr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	if err := slogproto.NewJSONHandler(&got, nil).Handle(context.Background(), decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.String() != want.String() {
+		t.Fatalf("got %s, want %s", got.String(), want.String())
+	}
+}
+
+// stripTime removes the leading time field of a line written by
+// slog.JSONHandler.
+func stripTime(line []byte) []byte {
+	if i := bytes.Index(line, []byte(`,"level"`)); i >= 0 {
+		return line[i:]
+	}
+	return line
+}
@@ -0,0 +1,192 @@
+package slogproto
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// JSONSchemaID is the $id of the JSON Schema returned by [JSONSchema].
+const JSONSchemaID = "https://github.com/picatz/slogproto/record.schema.json"
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the JSON
+// written by [NewJSONHandler], and so by slp, for each record: an object
+// with the time, level and message of the record, and its attributes,
+// whose values are described by the $defs of the schema for each kind of
+// slog value. Groups are nested objects.
+//
+// The attributes of the schema, if any, are described as properties with
+// the definition of their kind, and required attributes as required, so
+// that consumers can generate types for the attributes they rely on.
+func JSONSchema(attrs Schema) ([]byte, error) {
+	root := jsonSchemaObject()
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = JSONSchemaID
+	root["title"] = "slogproto record"
+	root["description"] = "A record read from a slogproto stream, as written by slp."
+	root["required"] = []string{"level", "msg"}
+	root["properties"] = map[string]any{
+		slog.TimeKey: map[string]any{
+			"description": "The time of the record, omitted if it has none.",
+			"type":        "string",
+			"format":      "date-time",
+		},
+		slog.LevelKey: map[string]any{
+			"description": "The level of the record, with the offset from the nearest level below it, such as INFO or WARN+2.",
+			"type":        "string",
+			"pattern":     "^(DEBUG|INFO|WARN|ERROR)([+-][0-9]+)?$",
+		},
+		slog.MessageKey: map[string]any{
+			"description": "The message of the record.",
+			"type":        "string",
+		},
+	}
+	root["$defs"] = jsonSchemaDefs()
+
+	// Sort the paths, so that groups are described before the attributes
+	// they hold.
+	paths := make([]string, 0, len(attrs))
+	for path := range attrs {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		as := attrs[path]
+
+		obj := root
+		keys := strings.Split(path, ".")
+		for i, key := range keys {
+			if i == 0 && (key == slog.TimeKey || key == slog.LevelKey || key == slog.MessageKey) {
+				// Attributes can't replace the built-in keys.
+				break
+			}
+
+			props, _ := obj["properties"].(map[string]any)
+			if props == nil {
+				props = map[string]any{}
+				obj["properties"] = props
+			}
+
+			required := as.Required
+			if i < len(keys)-1 {
+				// The attribute is within a group.
+				child, ok := props[key].(map[string]any)
+				if !ok || child["type"] != "object" {
+					child = jsonSchemaObject()
+					props[key] = child
+				}
+				if required {
+					obj["required"] = appendRequired(obj["required"], key)
+				}
+				obj = child
+				continue
+			}
+
+			props[key] = map[string]any{"$ref": "#/$defs/" + jsonSchemaKinds[as.Kind]}
+			if required {
+				obj["required"] = appendRequired(obj["required"], key)
+			}
+		}
+	}
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// jsonSchemaObject returns the schema of a group: an object whose
+// attributes have values of any kind.
+func jsonSchemaObject() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"$ref": "#/$defs/value"},
+	}
+}
+
+// appendRequired appends the key to the required keys of an object, if it
+// isn't already there.
+func appendRequired(required any, key string) []string {
+	keys, _ := required.([]string)
+	if slices.Contains(keys, key) {
+		return keys
+	}
+	return append(keys, key)
+}
+
+// jsonSchemaKinds maps the kinds of slog values to their definitions in
+// the schema returned by [JSONSchema].
+var jsonSchemaKinds = map[slog.Kind]string{
+	slog.KindAny:       "value",
+	slog.KindBool:      "bool",
+	slog.KindDuration:  "duration",
+	slog.KindFloat64:   "float",
+	slog.KindInt64:     "int",
+	slog.KindString:    "string",
+	slog.KindTime:      "time",
+	slog.KindUint64:    "uint",
+	slog.KindGroup:     "group",
+	slog.KindLogValuer: "value",
+}
+
+// jsonSchemaDefs returns the definitions of the kinds of values.
+func jsonSchemaDefs() map[string]any {
+	return map[string]any{
+		"value": map[string]any{
+			"description": "An attribute value of any kind. The kind of a value isn't recorded in JSON, so values of different kinds can have the same JSON.",
+			"anyOf": []any{
+				map[string]any{"$ref": "#/$defs/string"},
+				map[string]any{"$ref": "#/$defs/int"},
+				map[string]any{"$ref": "#/$defs/uint"},
+				map[string]any{"$ref": "#/$defs/float"},
+				map[string]any{"$ref": "#/$defs/bool"},
+				map[string]any{"$ref": "#/$defs/time"},
+				map[string]any{"$ref": "#/$defs/duration"},
+				map[string]any{"$ref": "#/$defs/group"},
+				map[string]any{"$ref": "#/$defs/any"},
+			},
+		},
+		"string": map[string]any{
+			"description": "A string value.",
+			"type":        "string",
+		},
+		"int": map[string]any{
+			"description": "A signed 64-bit integer value.",
+			"type":        "integer",
+			"minimum":     -1 << 63,
+			"maximum":     1<<63 - 1,
+		},
+		"uint": map[string]any{
+			"description": "An unsigned 64-bit integer value.",
+			"type":        "integer",
+			"minimum":     0,
+			"maximum":     uint64(1<<64 - 1),
+		},
+		"float": map[string]any{
+			"description": "A 64-bit floating point value. NaN and infinities are written as error strings.",
+			"type":        []string{"number", "string"},
+		},
+		"bool": map[string]any{
+			"description": "A boolean value.",
+			"type":        "boolean",
+		},
+		"time": map[string]any{
+			"description": "A time value, in RFC 3339 format with nanoseconds.",
+			"type":        "string",
+			"format":      "date-time",
+		},
+		"duration": map[string]any{
+			"description": "A duration value, in nanoseconds.",
+			"type":        "integer",
+		},
+		"group": map[string]any{
+			"description": "A group of attributes, each a property of the object.",
+			"type":        "object",
+			"additionalProperties": map[string]any{
+				"$ref": "#/$defs/value",
+			},
+		},
+		"any": map[string]any{
+			"description": "A Go value, written as the JSON it was encoded as, or a protobuf Any value with its type URL and base64 encoded value.",
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package slogproto_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestJSONSchema(t *testing.T) {
+	b, err := slogproto.JSONSchema(slogproto.Schema{
+		"http.status": {Kind: slog.KindInt64, Required: true},
+		"http.path":   {Kind: slog.KindString},
+		"user_id":     {Kind: slog.KindString},
+		"msg":         {Kind: slog.KindInt64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		ID         string   `json:"$id"`
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Ref        string   `json:"$ref"`
+			Type       any      `json:"type"`
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				Ref string `json:"$ref"`
+			} `json:"properties"`
+		} `json:"properties"`
+		Defs map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.ID != slogproto.JSONSchemaID {
+		t.Errorf("unexpected $id %q", schema.ID)
+	}
+	if !slices.Equal(schema.Required, []string{"level", "msg", "http"}) {
+		t.Errorf("unexpected required keys %v", schema.Required)
+	}
+	if schema.Properties["msg"].Type != "string" {
+		t.Errorf("expected the message to remain a string, got %v", schema.Properties["msg"].Type)
+	}
+	if ref := schema.Properties["user_id"].Ref; ref != "#/$defs/string" {
+		t.Errorf("unexpected user_id definition %q", ref)
+	}
+
+	http := schema.Properties["http"]
+	if http.Type != "object" || !slices.Equal(http.Required, []string{"status"}) {
+		t.Errorf("unexpected http group %+v", http)
+	}
+	if ref := http.Properties["status"].Ref; ref != "#/$defs/int" {
+		t.Errorf("unexpected http.status definition %q", ref)
+	}
+
+	for _, def := range []string{"value", "string", "int", "uint", "float", "bool", "time", "duration", "group", "any"} {
+		if _, ok := schema.Defs[def]; !ok {
+			t.Errorf("missing definition %q", def)
+		}
+	}
+}
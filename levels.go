@@ -0,0 +1,152 @@
+package slogproto
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// LevelMapping maps a severity of a foreign scale, and its name, to a slog
+// level.
+type LevelMapping struct {
+	Severity int
+	Name     string
+	Level    slog.Level
+}
+
+// LevelMapper maps the severities of a foreign scale, such as those of
+// syslog or OpenTelemetry, to slog levels and back, so that records can be
+// converted between formats without each conversion hardcoding its own
+// mapping.
+//
+// Severities and levels that aren't in the mapping are mapped to those of
+// the nearest less severe mapping, or of the least severe one if there is
+// none.
+type LevelMapper struct {
+	// mappings is sorted by level, and by severity in the order of the
+	// scale, which is descending if lower severities are more severe.
+	mappings []LevelMapping
+
+	// descending is set if lower severities are more severe.
+	descending bool
+}
+
+// NewLevelMapper returns a mapper of the given severities. Higher levels
+// must map to more severe severities, which may be either higher or lower
+// numbers, as long as they are consistently so.
+func NewLevelMapper(mappings ...LevelMapping) (*LevelMapper, error) {
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("slogproto: no level mappings")
+	}
+
+	m := &LevelMapper{mappings: slices.Clone(mappings)}
+	slices.SortStableFunc(m.mappings, func(a, b LevelMapping) int {
+		return int(a.Level) - int(b.Level)
+	})
+
+	first, last := m.mappings[0], m.mappings[len(m.mappings)-1]
+	m.descending = last.Severity < first.Severity
+
+	for i := 1; i < len(m.mappings); i++ {
+		prev, cur := m.mappings[i-1], m.mappings[i]
+		if (cur.Severity < prev.Severity) != m.descending || cur.Severity == prev.Severity {
+			return nil, fmt.Errorf("slogproto: severities %d and %d aren't ordered like levels %s and %s", prev.Severity, cur.Severity, prev.Level, cur.Level)
+		}
+	}
+
+	return m, nil
+}
+
+// mustLevelMapper returns the mapper of the given severities, which must
+// be valid.
+func mustLevelMapper(mappings ...LevelMapping) *LevelMapper {
+	m, err := NewLevelMapper(mappings...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// SyslogLevels maps the severities of syslog, from 0 (emergency) to 7
+// (debug), as defined by RFC 5424. Its most severe levels are above
+// slog.LevelError, and notices are between info and warnings.
+var SyslogLevels = mustLevelMapper(
+	LevelMapping{Severity: 7, Name: "debug", Level: slog.LevelDebug},
+	LevelMapping{Severity: 6, Name: "info", Level: slog.LevelInfo},
+	LevelMapping{Severity: 5, Name: "notice", Level: slog.LevelInfo + 2},
+	LevelMapping{Severity: 4, Name: "warning", Level: slog.LevelWarn},
+	LevelMapping{Severity: 3, Name: "err", Level: slog.LevelError},
+	LevelMapping{Severity: 2, Name: "crit", Level: slog.LevelError + 4},
+	LevelMapping{Severity: 1, Name: "alert", Level: slog.LevelError + 8},
+	LevelMapping{Severity: 0, Name: "emerg", Level: slog.LevelError + 12},
+)
+
+// OTelLevels maps the severity numbers of OpenTelemetry, from 1 (TRACE)
+// to 24 (FATAL4), to the slog levels 9 below them, so that INFO is
+// slog.LevelInfo and each of the levels of slog has its own range of
+// four severities.
+var OTelLevels = func() *LevelMapper {
+	var mappings []LevelMapping
+	for i, base := range []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"} {
+		for j := 0; j < 4; j++ {
+			severity := i*4 + j + 1
+			name := base
+			if j > 0 {
+				name = fmt.Sprintf("%s%d", base, j+1)
+			}
+			mappings = append(mappings, LevelMapping{
+				Severity: severity,
+				Name:     name,
+				Level:    slog.Level(severity - 9),
+			})
+		}
+	}
+	return mustLevelMapper(mappings...)
+}()
+
+// Level returns the slog level of the severity.
+func (m *LevelMapper) Level(severity int) slog.Level {
+	i, ok := slices.BinarySearchFunc(m.mappings, severity, func(lm LevelMapping, severity int) int {
+		if m.descending {
+			return severity - lm.Severity
+		}
+		return lm.Severity - severity
+	})
+	if !ok {
+		i--
+	}
+	return m.mappings[max(i, 0)].Level
+}
+
+// Severity returns the severity of the slog level.
+func (m *LevelMapper) Severity(level slog.Level) int {
+	return m.mapping(level).Severity
+}
+
+// Name returns the name of the severity of the slog level.
+func (m *LevelMapper) Name(level slog.Level) string {
+	return m.mapping(level).Name
+}
+
+// ParseName returns the slog level of the severity with the given name,
+// ignoring case, and false if there is none.
+func (m *LevelMapper) ParseName(name string) (slog.Level, bool) {
+	for _, lm := range m.mappings {
+		if strings.EqualFold(lm.Name, name) {
+			return lm.Level, true
+		}
+	}
+	return 0, false
+}
+
+// mapping returns the mapping of the slog level.
+func (m *LevelMapper) mapping(level slog.Level) LevelMapping {
+	i, ok := slices.BinarySearchFunc(m.mappings, level, func(lm LevelMapping, level slog.Level) int {
+		return int(lm.Level) - int(level)
+	})
+	if !ok {
+		i--
+	}
+	return m.mappings[max(i, 0)]
+}
@@ -0,0 +1,75 @@
+package slogproto_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestLevelMapper(t *testing.T) {
+	tests := map[string]struct {
+		mapper   *slogproto.LevelMapper
+		severity int
+		level    slog.Level
+		name     string
+	}{
+		"syslog emergency": {slogproto.SyslogLevels, 0, slog.LevelError + 12, "emerg"},
+		"syslog notice":    {slogproto.SyslogLevels, 5, slog.LevelInfo + 2, "notice"},
+		"syslog debug":     {slogproto.SyslogLevels, 7, slog.LevelDebug, "debug"},
+		"otel trace":       {slogproto.OTelLevels, 1, slog.LevelDebug - 4, "TRACE"},
+		"otel info":        {slogproto.OTelLevels, 9, slog.LevelInfo, "INFO"},
+		"otel warn2":       {slogproto.OTelLevels, 14, slog.LevelWarn + 1, "WARN2"},
+		"otel fatal4":      {slogproto.OTelLevels, 24, slog.LevelError + 7, "FATAL4"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.mapper.Level(test.severity); got != test.level {
+				t.Errorf("expected severity %d to map to %s, got %s", test.severity, test.level, got)
+			}
+			if got := test.mapper.Severity(test.level); got != test.severity {
+				t.Errorf("expected level %s to map to %d, got %d", test.level, test.severity, got)
+			}
+			if got := test.mapper.Name(test.level); got != test.name {
+				t.Errorf("expected level %s to be named %q, got %q", test.level, test.name, got)
+			}
+			if got, ok := test.mapper.ParseName(test.name); !ok || got != test.level {
+				t.Errorf("expected %q to parse as %s, got %s", test.name, test.level, got)
+			}
+		})
+	}
+}
+
+func TestLevelMapper_nearest(t *testing.T) {
+	m, err := slogproto.NewLevelMapper(
+		slogproto.LevelMapping{Severity: 10, Name: "low", Level: slog.LevelDebug},
+		slogproto.LevelMapping{Severity: 20, Name: "mid", Level: slog.LevelInfo},
+		slogproto.LevelMapping{Severity: 30, Name: "high", Level: slog.LevelError},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.Level(25); got != slog.LevelInfo {
+		t.Errorf("expected an unmapped severity to map to the nearest less severe level, got %s", got)
+	}
+	if got := m.Level(5); got != slog.LevelDebug {
+		t.Errorf("expected a severity below the scale to map to the least severe level, got %s", got)
+	}
+	if got := m.Severity(slog.LevelWarn); got != 20 {
+		t.Errorf("expected an unmapped level to map to the nearest less severe severity, got %d", got)
+	}
+	if got := slogproto.SyslogLevels.Severity(slog.LevelError + 6); got != 2 {
+		t.Errorf("expected syslog severity 2, got %d", got)
+	}
+
+	_, err = slogproto.NewLevelMapper(
+		slogproto.LevelMapping{Severity: 1, Level: slog.LevelDebug},
+		slogproto.LevelMapping{Severity: 3, Level: slog.LevelInfo},
+		slogproto.LevelMapping{Severity: 2, Level: slog.LevelError},
+	)
+	if err == nil {
+		t.Fatal("expected an error for severities that aren't ordered like levels")
+	}
+}
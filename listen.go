@@ -0,0 +1,181 @@
+//go:build unix
+
+package slogproto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// SenderKey is the key of the group of attributes [ListenUnixgram] adds
+// to the records it receives, attributing them to the process that sent
+// them: its "pid", "uid" and "gid" on Linux, or the "addr" of its socket,
+// if bound, on other platforms.
+const SenderKey = "sender"
+
+// SyslogKey is the key of the group holding the "facility" and
+// "severity" of the records [ListenUnixgram] converts from syslog
+// messages.
+const SyslogKey = "syslog"
+
+// ListenOptions are options for [ListenUnixgram].
+type ListenOptions struct {
+	// Syslog accepts syslog messages, starting with a "<PRI>" priority,
+	// in addition to slogproto frames. Each is converted to a record at
+	// the time it is received, with the rest of the message as its
+	// message, the level of its severity, and a SyslogKey group.
+	Syslog bool
+
+	// Mode, if not zero, is the permissions of the socket file, such as
+	// 0o666 to accept records from the processes of every user.
+	Mode os.FileMode
+
+	// ReadOptions are the options the frames of each datagram are read
+	// with, such as WithMaxRecordSize or WithDecodeLimits.
+	ReadOptions []ReadOption
+
+	// OnError is called with the errors of the datagrams which couldn't
+	// be read, or whose records the handler failed to handle, if set.
+	// Such datagrams are dropped, so that a sender can't stop the
+	// listener.
+	OnError func(error)
+}
+
+// syslogLevels are the levels of the syslog severities, from emergency
+// to debug.
+var syslogLevels = [8]slog.Level{
+	slog.LevelError, slog.LevelError, slog.LevelError, slog.LevelError,
+	slog.LevelWarn, slog.LevelInfo, slog.LevelInfo, slog.LevelDebug,
+}
+
+// ListenUnixgram listens on a Unix datagram socket created at the path,
+// and passes the records of the datagrams local processes send to it to
+// the handler, until the context is done. It returns the context's error
+// once the context is done, removing the socket.
+//
+// Each datagram holds one or more frames, read like a stream of its own,
+// such as those written by a [Handler] to a connection dialed with
+// net.Dial("unixgram", path). Records are attributed to their sender
+// with a SenderKey group. On Linux, the sender's credentials are those
+// the kernel attaches to each datagram (SCM_CREDENTIALS), as SO_PEERCRED
+// only applies to connected sockets.
+func ListenUnixgram(ctx context.Context, path string, h slog.Handler, opts *ListenOptions) error {
+	if opts == nil {
+		opts = &ListenOptions{}
+	}
+
+	cfg := &readConfig{maxRecordSize: DefaultMaxRecordSize}
+	for _, opt := range opts.ReadOptions {
+		opt(cfg)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	defer conn.Close()
+
+	if opts.Mode != 0 {
+		if err := os.Chmod(path, opts.Mode); err != nil {
+			return err
+		}
+	}
+	if err := passCred(conn); err != nil {
+		return fmt.Errorf("failed to receive sender credentials: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	onError := func(err error) {
+		if opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+
+	// Room for the stream header and size of a record of the maximum
+	// size.
+	buf := make([]byte, cfg.maxRecordSize+64)
+	oob := make([]byte, oobSize)
+	for {
+		n, oobn, flags, addr, err := conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if flags&syscall.MSG_TRUNC != 0 {
+			onError(fmt.Errorf("%w: datagram of more than %d bytes", ErrTruncated, len(buf)))
+			continue
+		}
+
+		sender := senderAttrs(oob[:oobn], addr)
+		handle := func(r *slog.Record) error {
+			if len(sender) > 0 {
+				r.AddAttrs(slog.Group(SenderKey, sender...))
+			}
+			if !h.Enabled(ctx, r.Level) {
+				return nil
+			}
+			return h.Handle(ctx, *r)
+		}
+
+		if opts.Syslog {
+			if r, ok := syslogRecord(buf[:n], time.Now()); ok {
+				if err := handle(&r); err != nil {
+					onError(err)
+				}
+				continue
+			}
+		}
+
+		var handleErr error
+		err = Read(ctx, bytes.NewReader(buf[:n]), func(r *slog.Record) bool {
+			handleErr = handle(r)
+			return handleErr == nil
+		}, opts.ReadOptions...)
+		if err := errors.Join(err, handleErr); err != nil {
+			onError(err)
+		}
+	}
+}
+
+// syslogRecord converts a syslog message to a record, reporting whether
+// the message starts with a priority.
+func syslogRecord(msg []byte, now time.Time) (slog.Record, bool) {
+	if len(msg) < 3 || msg[0] != '<' {
+		return slog.Record{}, false
+	}
+
+	pri, i := 0, 1
+	for ; i < len(msg) && i <= 3 && '0' <= msg[i] && msg[i] <= '9'; i++ {
+		pri = pri*10 + int(msg[i]-'0')
+	}
+	if i == 1 || i == len(msg) || msg[i] != '>' || pri > 191 {
+		return slog.Record{}, false
+	}
+
+	r := slog.NewRecord(now, syslogLevels[pri%8], string(bytes.TrimRight(msg[i+1:], "\x00\r\n")), 0)
+	r.AddAttrs(slog.Group(SyslogKey, slog.Int("facility", pri/8), slog.Int("severity", pri%8)))
+	return r, true
+}
+
+// addrAttrs returns the attributes of a sender known by the address of
+// its socket, if bound.
+func addrAttrs(addr *net.UnixAddr) []any {
+	if addr == nil || addr.Name == "" {
+		return nil
+	}
+	return []any{slog.String("addr", addr.Name)}
+}
@@ -0,0 +1,50 @@
+package slogproto
+
+import (
+	"log/slog"
+	"net"
+	"syscall"
+)
+
+// oobSize is the size of the ancillary data holding the credentials of
+// the sender of a datagram.
+var oobSize = syscall.CmsgSpace(syscall.SizeofUcred)
+
+// passCred enables SO_PASSCRED on the socket, so that the credentials of
+// the process sending each datagram are received with it.
+func passCred(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_PASSCRED, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// senderAttrs returns the attributes of the sender of a datagram, from
+// the credentials in its ancillary data.
+func senderAttrs(oob []byte, addr *net.UnixAddr) []any {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return addrAttrs(addr)
+	}
+	for i := range msgs {
+		cred, err := syscall.ParseUnixCredentials(&msgs[i])
+		if err != nil {
+			continue
+		}
+		return append([]any{
+			slog.Int("pid", int(cred.Pid)),
+			slog.Int("uid", int(cred.Uid)),
+			slog.Int("gid", int(cred.Gid)),
+		}, addrAttrs(addr)...)
+	}
+	return addrAttrs(addr)
+}
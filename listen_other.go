@@ -0,0 +1,19 @@
+//go:build unix && !linux
+
+package slogproto
+
+import "net"
+
+// oobSize is zero, as senders' credentials aren't received with their
+// datagrams.
+const oobSize = 0
+
+// passCred does nothing, as senders are only known by their address.
+func passCred(*net.UnixConn) error {
+	return nil
+}
+
+// senderAttrs returns the attributes of the sender of a datagram.
+func senderAttrs(_ []byte, addr *net.UnixAddr) []any {
+	return addrAttrs(addr)
+}
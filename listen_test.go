@@ -0,0 +1,129 @@
+//go:build unix
+
+package slogproto_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestListenUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	h := slogproto.NewMemoryHandler(10, nil)
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- slogproto.ListenUnixgram(ctx, path, h, &slogproto.ListenOptions{
+			Syslog: true,
+			Mode:   0o666,
+			OnError: func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			},
+		})
+	}()
+
+	var conn net.Conn
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		var err error
+		if conn, err = net.Dial("unixgram", path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+	}
+	defer conn.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o666 {
+		t.Fatalf("expected a socket writable by every user, got %v, %v", info.Mode(), err)
+	}
+
+	// Each record written by a handler is a datagram of its own.
+	logger := slog.New(slogproto.NewHandler(conn, nil))
+	logger.Info("hello", "user", "ann")
+
+	// Invalid datagrams are reported and dropped.
+	for _, msg := range []string{"\xff\xff\xff\xff", "<192>out of range"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := conn.Write([]byte("<11>app[42]: failed\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); h.Len() < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 records, got %d", h.Len())
+		}
+	}
+
+	records, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if r := records[0]; r.Message != "hello" || r.Level != slog.LevelInfo {
+		t.Errorf("unexpected record %v %q", r.Level, r.Message)
+	} else if v, ok := slogproto.GetAttr(&r, "user"); !ok || v.String() != "ann" {
+		t.Errorf("expected the user attribute, got %v", v)
+	}
+
+	r := records[1]
+	if r.Message != "app[42]: failed" || r.Level != slog.LevelError {
+		t.Errorf("unexpected syslog record %v %q", r.Level, r.Message)
+	}
+	if v, ok := slogproto.GetAttr(&r, slogproto.SyslogKey+".facility"); !ok || v.Int64() != 1 {
+		t.Errorf("expected facility 1, got %v", v)
+	}
+	if v, ok := slogproto.GetAttr(&r, slogproto.SyslogKey+".severity"); !ok || v.Int64() != 3 {
+		t.Errorf("expected severity 3, got %v", v)
+	}
+
+	if runtime.GOOS == "linux" {
+		for _, r := range records {
+			if v, ok := slogproto.GetAttr(&r, slogproto.SenderKey+".pid"); !ok || v.Int64() != int64(os.Getpid()) {
+				t.Errorf("%q: expected the sender's pid %d, got %v", r.Message, os.Getpid(), v)
+			}
+			if v, ok := slogproto.GetAttr(&r, slogproto.SenderKey+".uid"); !ok || v.Int64() != int64(os.Getuid()) {
+				t.Errorf("%q: expected the sender's uid %d, got %v", r.Message, os.Getuid(), v)
+			}
+		}
+	}
+
+	mu.Lock()
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+	mu.Unlock()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context's error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the socket to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package slogproto
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// MemoryHandler is a [Handler] that keeps the most recent records it
+// handles in memory, instead of writing them to a writer, such as for
+// tests, for debug endpoints showing the latest log lines, or for crash
+// reports. Handlers derived from it using WithAttrs or WithGroup keep
+// their records in the same memory.
+//
+// Records are kept encoded, so they are as they would be read from a
+// file written by a Handler with the same options.
+//
+// # Example
+//
+//	mem := slogproto.NewMemoryHandler(200, nil)
+//	logger := slog.New(mem)
+//
+//	http.HandleFunc("/debug/logs", func(w http.ResponseWriter, r *http.Request) {
+//		mem.WriteTo(w)
+//	})
+type MemoryHandler struct {
+	*Handler
+	ring *ringWriter
+}
+
+// NewMemoryHandler returns a handler that keeps the most recent records,
+// up to maxRecords, configured with the given options.
+//
+// A stream header, such as one declaring the framing set with WithFraming,
+// is kept apart from the records, so that it is still written by WriteTo
+// once the first record is discarded. NewMemoryHandler panics if the
+// options compress records, as with WithGzip, since compressed records
+// can't be kept and discarded one at a time.
+func NewMemoryHandler(maxRecords int, opts *slog.HandlerOptions, options ...HandlerOption) *MemoryHandler {
+	ring := &ringWriter{frames: make([][]byte, max(maxRecords, 1))}
+
+	h := NewHandler(ring, opts, options...)
+	if h.compressor != nil {
+		panic("slogproto: NewMemoryHandler can't keep compressed records")
+	}
+	if h.header != nil && *h.header != nil {
+		ring.header = *h.header
+		*h.header = nil
+	}
+
+	return &MemoryHandler{
+		Handler: h,
+		ring:    ring,
+	}
+}
+
+// Snapshot returns the records kept by the handler, oldest first, or an
+// error if they can't be decoded.
+func (h *MemoryHandler) Snapshot() ([]slog.Record, error) {
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	records := make([]slog.Record, 0, h.Len())
+	err := Read(context.Background(), &buf, func(r *slog.Record) bool {
+		records = append(records, *r)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Len returns the number of records kept by the handler.
+func (h *MemoryHandler) Len() int {
+	h.ring.mu.Lock()
+	defer h.ring.mu.Unlock()
+
+	return h.ring.n
+}
+
+// Reset discards the records kept by the handler.
+func (h *MemoryHandler) Reset() {
+	h.ring.mu.Lock()
+	defer h.ring.mu.Unlock()
+
+	clear(h.ring.frames)
+	h.ring.start, h.ring.n = 0, 0
+}
+
+// WriteTo writes the records kept by the handler to w, oldest first, as a
+// stream that can be read with [Read], starting with the stream header of
+// the handler, if it has one.
+func (h *MemoryHandler) WriteTo(w io.Writer) (int64, error) {
+	h.ring.mu.Lock()
+	frames := make([][]byte, 0, h.ring.n+1)
+	if h.ring.header != nil {
+		frames = append(frames, h.ring.header)
+	}
+	for i := 0; i < h.ring.n; i++ {
+		frames = append(frames, h.ring.frames[(h.ring.start+i)%len(h.ring.frames)])
+	}
+	h.ring.mu.Unlock()
+
+	var n int64
+	for _, frame := range frames {
+		m, err := w.Write(frame)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ringWriter keeps the most recent frames written to it, relying on the
+// Handler writing each frame with a single call to Write.
+type ringWriter struct {
+	mu     sync.Mutex
+	frames [][]byte
+	start  int
+	n      int
+
+	// header is the stream header of the handler, which isn't discarded.
+	header []byte
+}
+
+// Write keeps a copy of the frame, replacing the oldest frame if the
+// writer is full.
+func (w *ringWriter) Write(p []byte) (int, error) {
+	frame := bytes.Clone(p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.n < len(w.frames) {
+		w.frames[(w.start+w.n)%len(w.frames)] = frame
+		w.n++
+	} else {
+		w.frames[w.start] = frame
+		w.start = (w.start + 1) % len(w.frames)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,75 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestMemoryHandler(t *testing.T) {
+	h := slogproto.NewMemoryHandler(3, nil)
+	logger := slog.New(h).With("service", "api")
+
+	for i := 0; i < 5; i++ {
+		logger.Info(fmt.Sprintf("record %d", i), "i", i)
+	}
+	logger.Debug("disabled")
+
+	records, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || h.Len() != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	for i, r := range records {
+		if want := fmt.Sprintf("record %d", i+2); r.Message != want {
+			t.Errorf("expected message %q, got %q", want, r.Message)
+		}
+		if v, ok := slogproto.GetAttr(&r, "service"); !ok || v.String() != "api" {
+			t.Errorf("expected the service attribute, got %v", v)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := readMessages(t, &buf); got != "record 2,record 3,record 4" {
+		t.Fatalf("unexpected records %q", got)
+	}
+
+	h.Reset()
+	if records, err := h.Snapshot(); err != nil || len(records) != 0 {
+		t.Fatalf("expected no records after a reset, got %d (%v)", len(records), err)
+	}
+}
+
+func TestMemoryHandler_framing(t *testing.T) {
+	h := slogproto.NewMemoryHandler(2, nil, slogproto.WithFraming(slogproto.Framing_FRAMING_UINT64_BE))
+	logger := slog.New(h)
+
+	// The record written with the stream header is discarded, but the
+	// header is kept.
+	for i := 0; i < 3; i++ {
+		logger.Info(fmt.Sprintf("record %d", i))
+	}
+
+	records, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Message != "record 1" || records[1].Message != "record 2" {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a compressing memory handler to panic")
+		}
+	}()
+	slogproto.NewMemoryHandler(2, nil, slogproto.WithGzip())
+}
@@ -0,0 +1,34 @@
+package slogproto
+
+import (
+	"net/netip"
+)
+
+// Type URLs of the values of IP addresses and prefixes, which are encoded
+// in the binary form of [netip.Addr.MarshalBinary] and
+// [netip.Prefix.MarshalBinary]: 4 or 16 bytes for the address, followed
+// by its zone, if any, and, for prefixes, a byte with the number of bits.
+const (
+	AddrTypeURL   = "slogproto/netip.Addr"
+	PrefixTypeURL = "slogproto/netip.Prefix"
+)
+
+func init() {
+	RegisterValueType(AddrTypeURL,
+		netip.Addr.MarshalBinary,
+		func(b []byte) (netip.Addr, error) {
+			var addr netip.Addr
+			err := addr.UnmarshalBinary(b)
+			return addr, err
+		},
+	)
+
+	RegisterValueType(PrefixTypeURL,
+		netip.Prefix.MarshalBinary,
+		func(b []byte) (netip.Prefix, error) {
+			var prefix netip.Prefix
+			err := prefix.UnmarshalBinary(b)
+			return prefix, err
+		},
+	)
+}
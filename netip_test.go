@@ -0,0 +1,51 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/netip"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestNetip(t *testing.T) {
+	addrs := []netip.Addr{
+		netip.MustParseAddr("10.1.2.3"),
+		netip.MustParseAddr("2001:db8::1"),
+		netip.MustParseAddr("fe80::1%eth0"),
+	}
+	prefix := netip.MustParsePrefix("10.0.0.0/8")
+
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, nil))
+	for _, addr := range addrs {
+		logger.Info("request", "client_ip", addr, "network", prefix)
+	}
+
+	var i int
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		r.Attrs(func(a slog.Attr) bool {
+			switch a.Key {
+			case "client_ip":
+				if got, ok := a.Value.Any().(netip.Addr); !ok || got != addrs[i] {
+					t.Fatalf("expected %v, got %#v", addrs[i], a.Value.Any())
+				}
+			case "network":
+				if got, ok := a.Value.Any().(netip.Prefix); !ok || got != prefix {
+					t.Fatalf("expected %v, got %#v", prefix, a.Value.Any())
+				}
+			}
+			return true
+		})
+		i++
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != len(addrs) {
+		t.Fatalf("expected %d records, got %d", len(addrs), i)
+	}
+}
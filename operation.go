@@ -0,0 +1,128 @@
+package slogproto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// Keys of the group of attributes stamped on records by the loggers of
+// operations started with [StartOperation].
+const (
+	OperationKey       = "op"
+	OperationIDKey     = "id"
+	OperationParentKey = "parent"
+	OperationNameKey   = "name"
+)
+
+// Operation is a unit of work, such as the handling of a request or a
+// step of it, started with [StartOperation].
+type Operation struct {
+	// ID identifies the operation: 16 random hexadecimal digits.
+	ID string
+
+	// ParentID is the ID of the operation the operation was started
+	// within, if any.
+	ParentID string
+
+	// Name describes the operation, such as "load user".
+	Name string
+}
+
+// operationContextKey is the key of the Operation of a context.
+type operationContextKey struct{}
+
+// OperationFromContext returns the operation of the context, started with
+// [StartOperation], if any.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationContextKey{}).(Operation)
+	return op, ok
+}
+
+// StartOperation starts a named operation, within the operation of the
+// context, if any, returning a context holding it and a logger stamping
+// its records with an [OperationKey] group of the operation's ID, the ID
+// of its parent and its name. The records of an operation and those of
+// the operations started within it can then be correlated, such as with
+// the tree command of slp, as lightweight tracing.
+//
+// The logger may be one returned by StartOperation, whose operation's
+// attributes are replaced by those of the new operation.
+//
+// # Example
+//
+//	func handle(ctx context.Context, logger *slog.Logger, req *Request) {
+//		ctx, logger = slogproto.StartOperation(ctx, logger, "handle")
+//		logger.Info("received", "path", req.Path)
+//
+//		user := loadUser(ctx, logger, req.UserID)
+//		...
+//	}
+//
+//	func loadUser(ctx context.Context, logger *slog.Logger, id string) *User {
+//		ctx, logger = slogproto.StartOperation(ctx, logger, "load user")
+//		logger.Info("querying", "id", id)
+//		...
+//	}
+func StartOperation(ctx context.Context, logger *slog.Logger, name string) (context.Context, *slog.Logger) {
+	op := Operation{ID: newOperationID(), Name: name}
+	if parent, ok := OperationFromContext(ctx); ok {
+		op.ParentID = parent.ID
+	}
+
+	base := logger.Handler()
+	if oh, ok := base.(*operationHandler); ok {
+		base = oh.base
+	}
+
+	attrs := []any{slog.String(OperationIDKey, op.ID)}
+	if op.ParentID != "" {
+		attrs = append(attrs, slog.String(OperationParentKey, op.ParentID))
+	}
+	attrs = append(attrs, slog.String(OperationNameKey, op.Name))
+
+	h := &operationHandler{
+		base: base,
+		h:    base.WithAttrs([]slog.Attr{slog.Group(OperationKey, attrs...)}),
+	}
+	return context.WithValue(ctx, operationContextKey{}, op), slog.New(h)
+}
+
+// newOperationID returns a new random operation ID.
+func newOperationID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("slogproto: error generating operation ID: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// operationHandler is the handler of the logger of an operation, which
+// keeps the handler it was derived from, so that the loggers of
+// operations started within it don't include its attributes.
+type operationHandler struct {
+	// base is the handler without the attributes of the operation, and h
+	// the handler with them.
+	base, h slog.Handler
+}
+
+// Enabled returns true if the level is enabled for the handler.
+func (oh *operationHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return oh.h.Enabled(ctx, level)
+}
+
+// Handle handles the record with the attributes of the operation.
+func (oh *operationHandler) Handle(ctx context.Context, r slog.Record) error {
+	return oh.h.Handle(ctx, r)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (oh *operationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &operationHandler{base: oh.base.WithAttrs(attrs), h: oh.h.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new handler with the given group.
+func (oh *operationHandler) WithGroup(name string) slog.Handler {
+	return &operationHandler{base: oh.base.WithGroup(name), h: oh.h.WithGroup(name)}
+}
@@ -0,0 +1,72 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestStartOperation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil)).With("service", "api")
+
+	ctx, reqLogger := slogproto.StartOperation(context.Background(), logger, "handle")
+	reqLogger.Info("received")
+
+	childCtx, childLogger := slogproto.StartOperation(ctx, reqLogger.With("user", "u1"), "load user")
+	childLogger.Info("querying")
+
+	reqLogger.Info("done")
+
+	parent, ok := slogproto.OperationFromContext(ctx)
+	if !ok || parent.Name != "handle" || parent.ParentID != "" || len(parent.ID) != 16 {
+		t.Fatalf("unexpected operation %+v", parent)
+	}
+	child, ok := slogproto.OperationFromContext(childCtx)
+	if !ok || child.Name != "load user" || child.ParentID != parent.ID || child.ID == parent.ID {
+		t.Fatalf("unexpected child operation %+v", child)
+	}
+
+	type line struct {
+		Msg     string
+		Service string
+		User    string
+		Op      struct {
+			ID     string
+			Parent string
+			Name   string
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+
+	for i, want := range []struct {
+		msg  string
+		user string
+		op   slogproto.Operation
+	}{
+		{"received", "", parent},
+		{"querying", "u1", child},
+		{"done", "", parent},
+	} {
+		if n := strings.Count(lines[i], `"op":`); n != 1 {
+			t.Fatalf("expected a single op group, got %d in %s", n, lines[i])
+		}
+
+		var got line
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Msg != want.msg || got.Service != "api" || got.User != want.user || got.Op.ID != want.op.ID || got.Op.Parent != want.op.ParentID || got.Op.Name != want.op.Name {
+			t.Fatalf("line %d: unexpected record %s", i, lines[i])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package slogproto
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// HandlerOption configures optional behavior of a [Handler] created with
+// [NewHandler].
+type HandlerOption func(*Handler)
+
+// WithDeadLetter configures a writer that receives records the handler
+// could not encode or write, such as records containing values that
+// cannot be marshaled. Each record is written in the text format used by
+// [slog.TextHandler], with the error that caused it to be rejected
+// recorded in the "slogproto.error" attribute.
+//
+// When a record is successfully written to the dead-letter writer, Handle
+// returns nil instead of the original error.
+func WithDeadLetter(w io.Writer) HandlerOption {
+	return func(h *Handler) {
+		h.deadLetter = slog.NewTextHandler(w, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		})
+	}
+}
+
+// WithFidelity configures the handler to encode records losslessly, so
+// that they are decoded exactly as they were handled, as described by
+// [ConvertOptions.Fidelity]. Records that cannot be encoded losslessly are
+// rejected with ErrLossy, and sent to the dead-letter writer if one is
+// configured.
+func WithFidelity() HandlerOption {
+	return func(h *Handler) {
+		h.enc.fidelity = true
+		h.enc.keyOrder = true
+	}
+}
+
+// WithKeyOrder configures the handler to record the order of attributes,
+// so that they are decoded in the order they were handled instead of
+// sorted by key. Unlike [WithFidelity], records are never rejected: Go
+// values are still encoded as JSON, and duplicate keys keep the position
+// of their first occurrence.
+func WithKeyOrder() HandlerOption {
+	return func(h *Handler) {
+		h.enc.keyOrder = true
+	}
+}
+
+// WithDeterministic configures the handler to encode identical records as
+// identical bytes, by writing attributes in a stable order, so that the
+// output can be hashed, deduplicated or compared with golden files.
+//
+// Deterministic output is only stable for a given version of this module
+// and of the protobuf runtime, and is slightly slower to encode.
+func WithDeterministic() HandlerOption {
+	return func(h *Handler) {
+		h.deterministic = true
+	}
+}
+
+// WithRecordID configures the handler to add the ID of each record, as
+// returned by [RecordID], to the record as the [RecordIDKey] attribute,
+// replacing any attribute with the same key, so that readers can identify
+// it without computing it.
+func WithRecordID() HandlerOption {
+	return func(h *Handler) {
+		h.recordID = true
+	}
+}
+
+// WithULID configures the handler to add a generated [ULID], with the
+// timestamp of each record, to the record as the [ULIDKey] attribute,
+// replacing any attribute with the same key, so that other systems can
+// refer to it by a compact, unique and sortable ID. Unlike the ID added
+// by [WithRecordID], it differs between identical records.
+func WithULID() HandlerOption {
+	return func(h *Handler) {
+		h.ulid = true
+	}
+}
+
+// WithStreamHeader configures the handler to write a stream header,
+// declaring the SchemaVersion of its records, along with the first record
+// it writes. See [SchemaVersion].
+func WithStreamHeader() HandlerOption {
+	return func(h *Handler) {
+		header := streamHeader(h.framing)
+		h.header = &header
+	}
+}
+
+// WithFraming configures the handler to frame the records it writes with
+// size prefixes of the given byte order and width, such as 64-bit sizes
+// for records of 4 GiB or more, or big-endian sizes expected by other
+// tooling, instead of 32-bit little-endian sizes. The framing is declared
+// by a stream header, written along with the first record, which readers
+// such as [Read] follow until the next stream header. Unsupported framings
+// panic.
+func WithFraming(f Framing) HandlerOption {
+	if err := checkFraming(f); err != nil {
+		panic(err)
+	}
+	return func(h *Handler) {
+		h.framing = f
+		header := streamHeader(f)
+		h.header = &header
+	}
+}
+
+// WithSampleRate configures the handler to write a random sample of the
+// records it handles, each with the given probability between zero and
+// one. Records that aren't sampled are dropped without error. Rates
+// outside of that range, or NaN, panic.
+func WithSampleRate(rate float64) HandlerOption {
+	if !(rate >= 0 && rate <= 1) {
+		panic(fmt.Sprintf("slogproto: sample rate %v is not between 0 and 1", rate))
+	}
+	return func(h *Handler) {
+		h.sampleRate = rate
+	}
+}
+
+// WithGzip configures the handler to compress the records it writes with
+// gzip. The compressor is flushed and closed by [Handler.Shutdown], which
+// must be called to produce a complete gzip stream.
+//
+// WithGzip panics if the handler already compresses its records, such as
+// with WithZstd.
+func WithGzip() HandlerOption {
+	return func(h *Handler) {
+		err := h.compress(func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The OTLP messages used by the file formats, with the fields slogproto
+// converts. Unknown fields are skipped when decoding.
+//
+// The JSON encoding follows the OTLP specification: field names are
+// lowerCamelCase, 64-bit integers are strings, and trace and span IDs
+// are hex strings.
+
+// logsData is an ExportLogsServiceRequest, or LogsData, message.
+type logsData struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs,omitempty"`
+}
+
+type resourceLogs struct {
+	Resource  *resource   `json:"resource,omitempty"`
+	ScopeLogs []scopeLogs `json:"scopeLogs,omitempty"`
+	SchemaURL string      `json:"schemaUrl,omitempty"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type scopeLogs struct {
+	Scope      *scope      `json:"scope,omitempty"`
+	LogRecords []logRecord `json:"logRecords,omitempty"`
+}
+
+type scope struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type logRecord struct {
+	TimeUnixNano         jsonUint   `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano jsonUint   `json:"observedTimeUnixNano,omitempty"`
+	SeverityNumber       int32      `json:"severityNumber,omitempty"`
+	SeverityText         string     `json:"severityText,omitempty"`
+	Body                 *anyValue  `json:"body,omitempty"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+	Flags                uint32     `json:"flags,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type keyValue struct {
+	Key   string    `json:"key"`
+	Value *anyValue `json:"value,omitempty"`
+}
+
+type anyValue struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+	IntValue    *jsonInt     `json:"intValue,omitempty"`
+	DoubleValue *float64     `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *kvlistValue `json:"kvlistValue,omitempty"`
+	BytesValue  []byte       `json:"bytesValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []*anyValue `json:"values,omitempty"`
+}
+
+type kvlistValue struct {
+	Values []keyValue `json:"values,omitempty"`
+}
+
+// jsonInt is a 64-bit integer, encoded in JSON as a string, and decoded
+// from either a string or a number.
+type jsonInt int64
+
+func (i jsonInt) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, strconv.FormatInt(int64(i), 10)), nil
+}
+
+func (i *jsonInt) UnmarshalJSON(b []byte) error {
+	s, err := unquoteNumber(b)
+	if err != nil {
+		return err
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", b)
+	}
+	*i = jsonInt(v)
+	return nil
+}
+
+// jsonUint is an unsigned 64-bit integer, encoded as a jsonInt.
+type jsonUint uint64
+
+func (u jsonUint) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, strconv.FormatUint(uint64(u), 10)), nil
+}
+
+func (u *jsonUint) UnmarshalJSON(b []byte) error {
+	s, err := unquoteNumber(b)
+	if err != nil {
+		return err
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid unsigned integer %s", b)
+	}
+	*u = jsonUint(v)
+	return nil
+}
+
+// unquoteNumber returns the JSON number or string as a string.
+func unquoteNumber(b []byte) (string, error) {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	return string(b), nil
+}
+
+// The field numbers of the OTLP messages.
+const (
+	fieldLogsDataResourceLogs = 1
+
+	fieldResourceLogsResource  = 1
+	fieldResourceLogsScopeLogs = 2
+	fieldResourceLogsSchemaURL = 3
+
+	fieldResourceAttributes = 1
+
+	fieldScopeLogsScope      = 1
+	fieldScopeLogsLogRecords = 2
+
+	fieldScopeName    = 1
+	fieldScopeVersion = 2
+
+	fieldLogRecordTimeUnixNano         = 1
+	fieldLogRecordSeverityNumber       = 2
+	fieldLogRecordSeverityText         = 3
+	fieldLogRecordBody                 = 5
+	fieldLogRecordAttributes           = 6
+	fieldLogRecordFlags                = 8
+	fieldLogRecordTraceID              = 9
+	fieldLogRecordSpanID               = 10
+	fieldLogRecordObservedTimeUnixNano = 11
+
+	fieldAnyValueString = 1
+	fieldAnyValueBool   = 2
+	fieldAnyValueInt    = 3
+	fieldAnyValueDouble = 4
+	fieldAnyValueArray  = 5
+	fieldAnyValueKvlist = 6
+	fieldAnyValueBytes  = 7
+
+	fieldKeyValueKey   = 1
+	fieldKeyValueValue = 2
+
+	fieldValues = 1
+)
+
+// appendMessage appends the field holding the message encoded by fn.
+func appendMessage(b []byte, num protowire.Number, fn func([]byte) []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, fn(nil))
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func (m *logsData) appendProto(b []byte) []byte {
+	for i := range m.ResourceLogs {
+		b = appendMessage(b, fieldLogsDataResourceLogs, m.ResourceLogs[i].appendProto)
+	}
+	return b
+}
+
+func (m *resourceLogs) appendProto(b []byte) []byte {
+	if m.Resource != nil {
+		b = appendMessage(b, fieldResourceLogsResource, m.Resource.appendProto)
+	}
+	for i := range m.ScopeLogs {
+		b = appendMessage(b, fieldResourceLogsScopeLogs, m.ScopeLogs[i].appendProto)
+	}
+	return appendString(b, fieldResourceLogsSchemaURL, m.SchemaURL)
+}
+
+func (m *resource) appendProto(b []byte) []byte {
+	for i := range m.Attributes {
+		b = appendMessage(b, fieldResourceAttributes, m.Attributes[i].appendProto)
+	}
+	return b
+}
+
+func (m *scopeLogs) appendProto(b []byte) []byte {
+	if m.Scope != nil {
+		b = appendMessage(b, fieldScopeLogsScope, m.Scope.appendProto)
+	}
+	for i := range m.LogRecords {
+		b = appendMessage(b, fieldScopeLogsLogRecords, m.LogRecords[i].appendProto)
+	}
+	return b
+}
+
+func (m *scope) appendProto(b []byte) []byte {
+	b = appendString(b, fieldScopeName, m.Name)
+	return appendString(b, fieldScopeVersion, m.Version)
+}
+
+func (m *logRecord) appendProto(b []byte) []byte {
+	if m.TimeUnixNano != 0 {
+		b = protowire.AppendTag(b, fieldLogRecordTimeUnixNano, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, uint64(m.TimeUnixNano))
+	}
+	if m.SeverityNumber != 0 {
+		b = protowire.AppendTag(b, fieldLogRecordSeverityNumber, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.SeverityNumber))
+	}
+	b = appendString(b, fieldLogRecordSeverityText, m.SeverityText)
+	if m.Body != nil {
+		b = appendMessage(b, fieldLogRecordBody, m.Body.appendProto)
+	}
+	for i := range m.Attributes {
+		b = appendMessage(b, fieldLogRecordAttributes, m.Attributes[i].appendProto)
+	}
+	if m.Flags != 0 {
+		b = protowire.AppendTag(b, fieldLogRecordFlags, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, m.Flags)
+	}
+	if id, err := hex.DecodeString(m.TraceID); err == nil && len(id) > 0 {
+		b = protowire.AppendTag(b, fieldLogRecordTraceID, protowire.BytesType)
+		b = protowire.AppendBytes(b, id)
+	}
+	if id, err := hex.DecodeString(m.SpanID); err == nil && len(id) > 0 {
+		b = protowire.AppendTag(b, fieldLogRecordSpanID, protowire.BytesType)
+		b = protowire.AppendBytes(b, id)
+	}
+	if m.ObservedTimeUnixNano != 0 {
+		b = protowire.AppendTag(b, fieldLogRecordObservedTimeUnixNano, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, uint64(m.ObservedTimeUnixNano))
+	}
+	return b
+}
+
+func (m *keyValue) appendProto(b []byte) []byte {
+	b = appendString(b, fieldKeyValueKey, m.Key)
+	if m.Value != nil {
+		b = appendMessage(b, fieldKeyValueValue, m.Value.appendProto)
+	}
+	return b
+}
+
+func (m *anyValue) appendProto(b []byte) []byte {
+	switch {
+	case m.StringValue != nil:
+		b = protowire.AppendTag(b, fieldAnyValueString, protowire.BytesType)
+		b = protowire.AppendString(b, *m.StringValue)
+	case m.BoolValue != nil:
+		b = protowire.AppendTag(b, fieldAnyValueBool, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(*m.BoolValue))
+	case m.IntValue != nil:
+		b = protowire.AppendTag(b, fieldAnyValueInt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*m.IntValue))
+	case m.DoubleValue != nil:
+		b = protowire.AppendTag(b, fieldAnyValueDouble, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(*m.DoubleValue))
+	case m.ArrayValue != nil:
+		b = appendMessage(b, fieldAnyValueArray, func(b []byte) []byte {
+			for _, v := range m.ArrayValue.Values {
+				b = appendMessage(b, fieldValues, v.appendProto)
+			}
+			return b
+		})
+	case m.KvlistValue != nil:
+		b = appendMessage(b, fieldAnyValueKvlist, func(b []byte) []byte {
+			for i := range m.KvlistValue.Values {
+				b = appendMessage(b, fieldValues, m.KvlistValue.Values[i].appendProto)
+			}
+			return b
+		})
+	case m.BytesValue != nil:
+		b = protowire.AppendTag(b, fieldAnyValueBytes, protowire.BytesType)
+		b = protowire.AppendBytes(b, m.BytesValue)
+	}
+	return b
+}
+
+// consumeFields calls fn with each field of the message, and its value:
+// the varint or fixed value as v, or the bytes as data.
+func consumeFields(b []byte, fn func(num protowire.Number, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var (
+			v    uint64
+			data []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *logsData) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		if num == fieldLogsDataResourceLogs {
+			m.ResourceLogs = append(m.ResourceLogs, resourceLogs{})
+			return m.ResourceLogs[len(m.ResourceLogs)-1].unmarshalProto(data)
+		}
+		return nil
+	})
+}
+
+func (m *resourceLogs) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		switch num {
+		case fieldResourceLogsResource:
+			m.Resource = &resource{}
+			return m.Resource.unmarshalProto(data)
+		case fieldResourceLogsScopeLogs:
+			m.ScopeLogs = append(m.ScopeLogs, scopeLogs{})
+			return m.ScopeLogs[len(m.ScopeLogs)-1].unmarshalProto(data)
+		case fieldResourceLogsSchemaURL:
+			m.SchemaURL = string(data)
+		}
+		return nil
+	})
+}
+
+func (m *resource) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		if num == fieldResourceAttributes {
+			m.Attributes = append(m.Attributes, keyValue{})
+			return m.Attributes[len(m.Attributes)-1].unmarshalProto(data)
+		}
+		return nil
+	})
+}
+
+func (m *scopeLogs) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		switch num {
+		case fieldScopeLogsScope:
+			m.Scope = &scope{}
+			return m.Scope.unmarshalProto(data)
+		case fieldScopeLogsLogRecords:
+			m.LogRecords = append(m.LogRecords, logRecord{})
+			return m.LogRecords[len(m.LogRecords)-1].unmarshalProto(data)
+		}
+		return nil
+	})
+}
+
+func (m *scope) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		switch num {
+		case fieldScopeName:
+			m.Name = string(data)
+		case fieldScopeVersion:
+			m.Version = string(data)
+		}
+		return nil
+	})
+}
+
+func (m *logRecord) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, v uint64, data []byte) error {
+		switch num {
+		case fieldLogRecordTimeUnixNano:
+			m.TimeUnixNano = jsonUint(v)
+		case fieldLogRecordObservedTimeUnixNano:
+			m.ObservedTimeUnixNano = jsonUint(v)
+		case fieldLogRecordSeverityNumber:
+			m.SeverityNumber = int32(v)
+		case fieldLogRecordSeverityText:
+			m.SeverityText = string(data)
+		case fieldLogRecordBody:
+			m.Body = &anyValue{}
+			return m.Body.unmarshalProto(data)
+		case fieldLogRecordAttributes:
+			m.Attributes = append(m.Attributes, keyValue{})
+			return m.Attributes[len(m.Attributes)-1].unmarshalProto(data)
+		case fieldLogRecordFlags:
+			m.Flags = uint32(v)
+		case fieldLogRecordTraceID:
+			m.TraceID = hex.EncodeToString(data)
+		case fieldLogRecordSpanID:
+			m.SpanID = hex.EncodeToString(data)
+		}
+		return nil
+	})
+}
+
+func (m *keyValue) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, _ uint64, data []byte) error {
+		switch num {
+		case fieldKeyValueKey:
+			m.Key = string(data)
+		case fieldKeyValueValue:
+			m.Value = &anyValue{}
+			return m.Value.unmarshalProto(data)
+		}
+		return nil
+	})
+}
+
+func (m *anyValue) unmarshalProto(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, v uint64, data []byte) error {
+		switch num {
+		case fieldAnyValueString:
+			s := string(data)
+			m.StringValue = &s
+		case fieldAnyValueBool:
+			b := protowire.DecodeBool(v)
+			m.BoolValue = &b
+		case fieldAnyValueInt:
+			i := jsonInt(v)
+			m.IntValue = &i
+		case fieldAnyValueDouble:
+			f := math.Float64frombits(v)
+			m.DoubleValue = &f
+		case fieldAnyValueArray:
+			m.ArrayValue = &arrayValue{}
+			return consumeFields(data, func(num protowire.Number, _ uint64, data []byte) error {
+				if num != fieldValues {
+					return nil
+				}
+				e := &anyValue{}
+				m.ArrayValue.Values = append(m.ArrayValue.Values, e)
+				return e.unmarshalProto(data)
+			})
+		case fieldAnyValueKvlist:
+			m.KvlistValue = &kvlistValue{}
+			return consumeFields(data, func(num protowire.Number, _ uint64, data []byte) error {
+				if num != fieldValues {
+					return nil
+				}
+				m.KvlistValue.Values = append(m.KvlistValue.Values, keyValue{})
+				return m.KvlistValue.Values[len(m.KvlistValue.Values)-1].unmarshalProto(data)
+			})
+		case fieldAnyValueBytes:
+			m.BytesValue = append([]byte{}, data...)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,437 @@
+// Package otlp converts between slogproto streams and the file formats of
+// the OpenTelemetry Collector's file exporter and receiver, so that logs
+// can move between OpenTelemetry tooling and slogproto tooling offline.
+//
+// Both formats hold a series of OTLP ExportLogsServiceRequest messages,
+// each a batch of log records:
+//
+//   - [JSON] files hold one message per line, in the OTLP JSON encoding.
+//   - [Protobuf] files hold binary messages, each prefixed with its size
+//     as a 32-bit big-endian integer.
+//
+// Levels are mapped with [slogproto.OTelLevels], and the body of a log
+// record is its message. Attributes of records imported from OTLP, along
+// with their resource attributes in a "resource" group, and their trace
+// and span IDs, become slog attributes.
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Format is an OTLP file format.
+type Format int
+
+const (
+	// JSON is the OTLP JSON encoding, one message per line, as in the
+	// .jsonl files of the file exporter.
+	JSON Format = iota
+
+	// Protobuf is the OTLP binary encoding, each message prefixed with
+	// its 32-bit big-endian size.
+	Protobuf
+)
+
+// ParseFormat returns the format with the given name: "json" or "proto".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "json":
+		return JSON, nil
+	case "proto":
+		return Protobuf, nil
+	default:
+		return 0, fmt.Errorf("otlp: unknown format %q: expected json or proto", name)
+	}
+}
+
+// ScopeName is the name of the instrumentation scope of exported records.
+const ScopeName = "github.com/picatz/slogproto"
+
+// goTypeURLPrefix prefixes the type URL of the Any values in which the
+// Handler encodes Go values as JSON.
+const goTypeURLPrefix = "go/slog/"
+
+// batchSize is the number of records in each exported message.
+const batchSize = 512
+
+// maxMessageSize is the maximum size, in bytes, of a message read by
+// Import, which bounds the memory used to buffer it.
+const maxMessageSize = 64 << 20
+
+// Export reads a stream of protobuf encoded slog records from r, as with
+// [slogproto.Read] and the given options, and writes them to w as OTLP
+// logs in the format, returning the number of records written.
+func Export(ctx context.Context, w io.Writer, r io.Reader, format Format, opts ...slogproto.ReadOption) (int64, error) {
+	var (
+		n        int64
+		batch    []logRecord
+		writeErr error
+	)
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		writeErr = writeMessage(w, format, &logsData{
+			ResourceLogs: []resourceLogs{{
+				ScopeLogs: []scopeLogs{{
+					Scope:      &scope{Name: ScopeName},
+					LogRecords: batch,
+				}},
+			}},
+		})
+		batch = batch[:0]
+		return writeErr == nil
+	}
+
+	err := slogproto.Read(ctx, r, func(r *slog.Record) bool {
+		batch = append(batch, fromRecord(r))
+		n++
+		if len(batch) == batchSize {
+			return flush()
+		}
+		return true
+	}, opts...)
+	if err == nil {
+		flush()
+	}
+	if err == nil && writeErr != nil {
+		err = fmt.Errorf("otlp: error writing logs: %w", writeErr)
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// writeMessage writes the message to w in the format.
+func writeMessage(w io.Writer, format Format, msg *logsData) error {
+	switch format {
+	case JSON:
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case Protobuf:
+		b := msg.appendProto(make([]byte, 4))
+		binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+		_, err := w.Write(b)
+		return err
+	default:
+		return fmt.Errorf("otlp: unknown format %d", format)
+	}
+}
+
+// fromRecord returns the OTLP log record of the slog record.
+func fromRecord(r *slog.Record) logRecord {
+	lr := logRecord{
+		SeverityNumber: int32(slogproto.OTelLevels.Severity(r.Level)),
+		SeverityText:   slogproto.OTelLevels.Name(r.Level),
+		Body:           &anyValue{StringValue: &r.Message},
+	}
+	if !r.Time.IsZero() {
+		lr.TimeUnixNano = jsonUint(r.Time.UnixNano())
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		lr.Attributes = appendAttr(lr.Attributes, a)
+		return true
+	})
+	return lr
+}
+
+// appendAttr appends the attribute to the key values, inlining the
+// attributes of groups with an empty key, and omitting empty attributes.
+func appendAttr(kvs []keyValue, a slog.Attr) []keyValue {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+	if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+		for _, ga := range a.Value.Group() {
+			kvs = appendAttr(kvs, ga)
+		}
+		return kvs
+	}
+	return append(kvs, keyValue{Key: a.Key, Value: fromValue(a.Value)})
+}
+
+// fromValue returns the OTLP value of the slog value. Times, durations and
+// values without an OTLP equivalent are recorded as strings.
+func fromValue(v slog.Value) *anyValue {
+	switch v.Kind() {
+	case slog.KindString:
+		s := v.String()
+		return &anyValue{StringValue: &s}
+	case slog.KindInt64:
+		i := jsonInt(v.Int64())
+		return &anyValue{IntValue: &i}
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= math.MaxInt64 {
+			i := jsonInt(u)
+			return &anyValue{IntValue: &i}
+		}
+		s := v.String()
+		return &anyValue{StringValue: &s}
+	case slog.KindFloat64:
+		f := v.Float64()
+		return &anyValue{DoubleValue: &f}
+	case slog.KindBool:
+		b := v.Bool()
+		return &anyValue{BoolValue: &b}
+	case slog.KindTime:
+		s := v.Time().Format(time.RFC3339Nano)
+		return &anyValue{StringValue: &s}
+	case slog.KindGroup:
+		var kvs []keyValue
+		for _, a := range v.Group() {
+			kvs = appendAttr(kvs, a)
+		}
+		return &anyValue{KvlistValue: &kvlistValue{Values: kvs}}
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case []byte:
+			return &anyValue{BytesValue: x}
+		case *anypb.Any:
+			// Go values are read back as the JSON they were encoded as.
+			var decoded any
+			if strings.HasPrefix(x.GetTypeUrl(), goTypeURLPrefix) && json.Unmarshal(x.GetValue(), &decoded) == nil {
+				return fromValue(slog.AnyValue(decoded))
+			}
+		case []any:
+			values := make([]*anyValue, 0, len(x))
+			for _, e := range x {
+				values = append(values, fromValue(slog.AnyValue(e)))
+			}
+			return &anyValue{ArrayValue: &arrayValue{Values: values}}
+		case map[string]any:
+			keys := make([]string, 0, len(x))
+			for k := range x {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			var kvs []keyValue
+			for _, k := range keys {
+				kvs = appendAttr(kvs, slog.Any(k, x[k]))
+			}
+			return &anyValue{KvlistValue: &kvlistValue{Values: kvs}}
+		}
+	}
+	s := v.String()
+	return &anyValue{StringValue: &s}
+}
+
+// Import reads OTLP logs in the format from r, and writes them to w as a
+// stream of protobuf encoded slog records, returning the number of records
+// written.
+func Import(ctx context.Context, w io.Writer, r io.Reader, format Format) (int64, error) {
+	var n int64
+	br := bufio.NewReader(r)
+	for ctx.Err() == nil {
+		msg, err := readMessage(br, format)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("otlp: error reading logs: %w", err)
+		}
+
+		for _, rl := range msg.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				for i := range sl.LogRecords {
+					pbr, err := toRecord(rl.Resource, &sl.LogRecords[i])
+					if err != nil {
+						return n, fmt.Errorf("otlp: error converting log record: %w", err)
+					}
+					if err := slogproto.WriteRecord(w, pbr); err != nil {
+						return n, fmt.Errorf("otlp: error writing record: %w", err)
+					}
+					n++
+				}
+			}
+		}
+	}
+	return n, ctx.Err()
+}
+
+// readMessage reads the next message in the format, or returns io.EOF
+// once there are none.
+func readMessage(br *bufio.Reader, format Format) (*logsData, error) {
+	msg := &logsData{}
+	switch format {
+	case JSON:
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) == 0 && err != nil {
+				return nil, err
+			}
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				if err == io.EOF {
+					return nil, err
+				}
+				continue
+			}
+			if err := json.Unmarshal(line, msg); err != nil {
+				return nil, err
+			}
+			return msg, nil
+		}
+	case Protobuf:
+		var size [4]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("truncated message size")
+			}
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n > maxMessageSize {
+			return nil, fmt.Errorf("message of %d bytes exceeds the maximum of %d bytes", n, maxMessageSize)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return nil, fmt.Errorf("truncated message: %w", err)
+		}
+		if err := msg.unmarshalProto(b); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+}
+
+// toRecord returns the slogproto record of the OTLP log record of the
+// resource.
+func toRecord(res *resource, lr *logRecord) (*slogproto.Record, error) {
+	b := slogproto.NewRecordBuilder()
+
+	switch {
+	case lr.TimeUnixNano != 0:
+		b.Time(time.Unix(0, int64(lr.TimeUnixNano)).UTC())
+	case lr.ObservedTimeUnixNano != 0:
+		b.Time(time.Unix(0, int64(lr.ObservedTimeUnixNano)).UTC())
+	}
+
+	switch {
+	case lr.SeverityNumber > 0:
+		b.Severity(slogproto.OTelLevels, int(lr.SeverityNumber))
+	case lr.SeverityText != "":
+		if level, ok := slogproto.OTelLevels.ParseName(lr.SeverityText); ok {
+			b.Level(level)
+		} else {
+			var level slog.Level
+			if level.UnmarshalText([]byte(lr.SeverityText)) == nil {
+				b.Level(level)
+			}
+		}
+	}
+
+	if lr.Body != nil {
+		if lr.Body.StringValue != nil {
+			b.Msg(*lr.Body.StringValue)
+		} else {
+			b.Attr(toAttr("body", lr.Body))
+		}
+	}
+
+	for _, kv := range lr.Attributes {
+		b.Attr(toAttr(kv.Key, kv.Value))
+	}
+	if lr.TraceID != "" {
+		b.Str("trace_id", lr.TraceID)
+	}
+	if lr.SpanID != "" {
+		b.Str("span_id", lr.SpanID)
+	}
+	if res != nil && len(res.Attributes) > 0 {
+		attrs := make([]any, 0, len(res.Attributes))
+		for _, kv := range res.Attributes {
+			attrs = append(attrs, toAttr(kv.Key, kv.Value))
+		}
+		b.Attr(slog.Group("resource", attrs...))
+	}
+
+	return b.Build()
+}
+
+// toAttr returns the slog attribute of the OTLP value. Arrays are recorded
+// as Go values, which are encoded as JSON.
+func toAttr(key string, v *anyValue) slog.Attr {
+	switch {
+	case v == nil:
+		return slog.Attr{Key: key}
+	case v.StringValue != nil:
+		return slog.String(key, *v.StringValue)
+	case v.BoolValue != nil:
+		return slog.Bool(key, *v.BoolValue)
+	case v.IntValue != nil:
+		return slog.Int64(key, int64(*v.IntValue))
+	case v.DoubleValue != nil:
+		return slog.Float64(key, *v.DoubleValue)
+	case v.KvlistValue != nil:
+		attrs := make([]any, 0, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			attrs = append(attrs, toAttr(kv.Key, kv.Value))
+		}
+		return slog.Group(key, attrs...)
+	case v.ArrayValue != nil:
+		return slog.Any(key, goValue(v))
+	case v.BytesValue != nil:
+		return slog.String(key, hex.EncodeToString(v.BytesValue))
+	default:
+		return slog.Attr{Key: key}
+	}
+}
+
+// goValue returns the OTLP value as a Go value which encodes as JSON,
+// for the elements of arrays.
+func goValue(v *anyValue) any {
+	switch {
+	case v == nil:
+		return nil
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, e := range v.ArrayValue.Values {
+			values = append(values, goValue(e))
+		}
+		return values
+	case v.KvlistValue != nil:
+		m := make(map[string]any, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			m[kv.Key] = goValue(kv.Value)
+		}
+		return m
+	case v.BytesValue != nil:
+		return hex.EncodeToString(v.BytesValue)
+	default:
+		return nil
+	}
+}
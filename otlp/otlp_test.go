@@ -0,0 +1,116 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/otlp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var stream bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&stream, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	for i := 0; i < 1000; i++ {
+		r := slog.NewRecord(start.Add(time.Duration(i)*time.Second), slog.LevelWarn, "tick", 0)
+		r.AddAttrs(
+			slog.Int("i", i),
+			slog.Group("req", slog.String("method", "GET"), slog.Bool("ok", true)),
+			slog.Any("tags", []string{"a", "b"}),
+		)
+		if err := logger.Handler().Handle(context.Background(), r); err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+	}
+
+	for _, format := range []otlp.Format{otlp.JSON, otlp.Protobuf} {
+		var exported bytes.Buffer
+		n, err := otlp.Export(context.Background(), &exported, bytes.NewReader(stream.Bytes()), format)
+		if err != nil {
+			t.Fatalf("format %d: expected no error, but got: %v", format, err)
+		}
+		if n != 1000 {
+			t.Fatalf("format %d: expected 1000 records exported, got %d", format, n)
+		}
+
+		if format == otlp.JSON {
+			if lines := strings.Count(exported.String(), "\n"); lines != 2 {
+				t.Fatalf("expected a line per batch, got %d lines", lines)
+			}
+			if !strings.Contains(exported.String(), `"severityNumber":13`) {
+				t.Fatalf("expected the WARN severity number, got %.200s", exported.String())
+			}
+		}
+
+		var imported bytes.Buffer
+		n, err = otlp.Import(context.Background(), &imported, &exported, format)
+		if err != nil {
+			t.Fatalf("format %d: expected no error, but got: %v", format, err)
+		}
+		if n != 1000 {
+			t.Fatalf("format %d: expected 1000 records imported, got %d", format, n)
+		}
+
+		var i int64
+		err = slogproto.Read(context.Background(), &imported, func(r *slog.Record) bool {
+			if r.Message != "tick" || r.Level != slog.LevelWarn || !r.Time.Equal(start.Add(time.Duration(i)*time.Second)) {
+				t.Fatalf("format %d: unexpected record %d: %v %v %q", format, i, r.Time, r.Level, r.Message)
+			}
+			if v, ok := slogproto.GetAttr(r, "i"); !ok || v.Int64() != i {
+				t.Fatalf("format %d: expected attribute i=%d, got %v", format, i, v)
+			}
+			if v, ok := slogproto.GetAttr(r, "req.method"); !ok || v.String() != "GET" {
+				t.Fatalf("format %d: expected attribute req.method=GET, got %v", format, v)
+			}
+			if _, ok := slogproto.GetAttr(r, "tags"); !ok {
+				t.Fatalf("format %d: expected attribute tags", format)
+			}
+			i++
+			return true
+		})
+		if err != nil {
+			t.Fatalf("format %d: expected no error, but got: %v", format, err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	// A line written by the OpenTelemetry Collector's file exporter.
+	const line = `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},"scopeLogs":[{"scope":{},"logRecords":[{"timeUnixNano":"1704067200000000000","severityNumber":17,"severityText":"ERROR","body":{"stringValue":"failed"},"attributes":[{"key":"attempt","value":{"intValue":"3"}},{"key":"ratio","value":{"doubleValue":0.5}}],"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174"}]}]}]}`
+
+	var buf bytes.Buffer
+	n, err := otlp.Import(context.Background(), &buf, strings.NewReader(line+"\n\n"), otlp.JSON)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 record, got %d", n)
+	}
+
+	err = slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		if r.Message != "failed" || r.Level != slog.LevelError || !r.Time.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected record: %v %v %q", r.Time, r.Level, r.Message)
+		}
+		for key, want := range map[string]string{
+			"attempt":               "3",
+			"ratio":                 "0.5",
+			"trace_id":              "5b8efff798038103d269b633813fc60c",
+			"span_id":               "eee19b7ec3c1b174",
+			"resource.service.name": "api",
+		} {
+			if v, ok := slogproto.GetAttr(r, key); !ok || v.String() != want {
+				t.Errorf("expected attribute %s=%s, got %v", key, want, v)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+}
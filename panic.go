@@ -0,0 +1,127 @@
+package slogproto
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// PanicKey is the key of the group describing a recovered panic, holding
+// its value, the type of its value, and its stack as a list of frames.
+const PanicKey = "panic"
+
+// StackFrame is a frame of the stack of a recovered panic.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// RecoverOptions configure [Recover] and [Go]. A nil *RecoverOptions is
+// equivalent to the zero value.
+type RecoverOptions struct {
+	// Message is the message of the records of panics, which is "panic"
+	// by default.
+	Message string
+
+	// Repanic continues panicking once the panic is logged, after
+	// shutting down the handlers registered with [FlushOnCrash], as
+	// [FlushOnPanic] does. Otherwise, the panic is recovered.
+	Repanic bool
+}
+
+// Recover recovers a panic of the goroutine, if it is panicking, and logs
+// it to the handler as an error record with a [PanicKey] group describing
+// it, so that crashes can be investigated from the log. It must be called
+// directly by a deferred call, at the start of a goroutine:
+//
+//	go func() {
+//		defer slogproto.Recover(h, nil)
+//		work()
+//	}()
+func Recover(h slog.Handler, opts *RecoverOptions) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	if opts == nil {
+		opts = &RecoverOptions{}
+	}
+
+	logPanic(h, v, opts.Message)
+
+	if opts.Repanic {
+		flushCrashHandlers()
+		panic(v)
+	}
+}
+
+// Go runs fn in a new goroutine whose panics are logged to the handler,
+// and recovered unless opts.Repanic is set, as with [Recover].
+func Go(h slog.Handler, fn func(), opts *RecoverOptions) {
+	go func() {
+		defer Recover(h, opts)
+		fn()
+	}()
+}
+
+// logPanic logs a recovered panic value, with the stack of the goroutine
+// that panicked.
+func logPanic(h slog.Handler, v any, msg string) {
+	if msg == "" {
+		msg = "panic"
+	}
+
+	ctx := context.Background()
+	if !h.Enabled(ctx, slog.LevelError) {
+		return
+	}
+
+	value := fmt.Sprint(v)
+	if err, ok := v.(error); ok {
+		value = err.Error()
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, msg, 0)
+	r.AddAttrs(slog.Group(PanicKey,
+		slog.String("value", value),
+		slog.String("type", fmt.Sprintf("%T", v)),
+		slog.Any("stack", panicStack()),
+	))
+
+	// The panic is the error being reported, so an error logging it has
+	// nowhere to go.
+	_ = h.Handle(ctx, r)
+}
+
+// panicStack returns the frames of the stack of the panicking goroutine,
+// from the function that panicked outwards, without the frames of the
+// runtime's panic machinery or of the recovery.
+func panicStack() []StackFrame {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+
+	var frames []StackFrame
+	panicking := false
+	fs := runtime.CallersFrames(pcs)
+	for {
+		f, more := fs.Next()
+		switch {
+		case f.Function == "runtime.gopanic":
+			// The frames so far are those of the recovery.
+			frames, panicking = frames[:0], true
+		case panicking && strings.HasPrefix(f.Function, "runtime.") && len(frames) == 0:
+			// Runtime errors are raised by the runtime's own frames.
+		default:
+			frames = append(frames, StackFrame{Function: f.Function, File: f.File, Line: f.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
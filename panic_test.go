@@ -0,0 +1,104 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// writerFunc is an io.Writer calling the function.
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) {
+	return fn(p)
+}
+
+// panicky panics with a runtime error.
+func panicky() {
+	var m map[string]int
+	m["boom"]++
+}
+
+func TestGo(t *testing.T) {
+	// The goroutine's deferred functions run before the panic is
+	// logged, so wait for the record to be written instead.
+	var buf bytes.Buffer
+	written := make(chan struct{}, 1)
+	h := slogproto.NewHandler(writerFunc(func(p []byte) (int, error) {
+		defer func() {
+			written <- struct{}{}
+		}()
+		return buf.Write(p)
+	}), nil)
+
+	slogproto.Go(h, panicky, nil)
+	<-written
+
+	var panics int
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		panics++
+		if r.Level != slog.LevelError || r.Message != "panic" {
+			t.Fatalf("expected an error record, got %s %q", r.Level, r.Message)
+		}
+
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key != slogproto.PanicKey {
+				t.Fatalf("unexpected attribute %s", a.Key)
+			}
+
+			attrs := map[string]slog.Value{}
+			for _, ga := range a.Value.Group() {
+				attrs[ga.Key] = ga.Value
+			}
+
+			if got := attrs["value"].String(); got != "assignment to entry in nil map" {
+				t.Fatalf("unexpected value %q", got)
+			}
+			if got := attrs["type"].String(); !strings.HasPrefix(got, "runtime.") {
+				t.Fatalf("unexpected type %q", got)
+			}
+
+			var stack []slogproto.StackFrame
+			if err := json.Unmarshal(attrs["stack"].Any().(*anypb.Any).GetValue(), &stack); err != nil {
+				t.Fatal(err)
+			}
+			if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, ".panicky") || !strings.HasSuffix(stack[0].File, "panic_test.go") {
+				t.Fatalf("expected the stack to start with the panicking function, got %+v", stack)
+			}
+			return true
+		})
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if panics != 1 {
+		t.Fatalf("expected 1 record, got %d", panics)
+	}
+}
+
+func TestRecover_repanic(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("expected the panic to continue, got %v", r)
+			}
+		}()
+		defer slogproto.Recover(h, &slogproto.RecoverOptions{Message: "crashed", Repanic: true})
+
+		panic("boom")
+	}()
+
+	if got := readMessages(t, &buf); got != "crashed" {
+		t.Fatalf("expected the panic to be logged, got %q", got)
+	}
+}
@@ -0,0 +1,265 @@
+package slogproto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// RedactedValue replaces the values of attributes redacted by
+// [RedactStage].
+const RedactedValue = "[REDACTED]"
+
+// Stage is a step of a [Pipeline], which may change the record, and
+// reports whether it is kept. Records that aren't kept skip the stages
+// that follow.
+type Stage func(r *slog.Record) (bool, error)
+
+// Pipeline is a series of stages applied in turn to records, such as
+// filtering them, rewriting their attributes, redacting secrets or
+// changing their levels, so that common rewrites of archives, or of the
+// records of a program as they are logged, don't need custom code.
+//
+// # Example
+//
+//	p := slogproto.NewPipeline(
+//		slogproto.FilterStage(func(r *slog.Record) bool {
+//			return r.Level >= slog.LevelInfo
+//		}),
+//		slogproto.RedactStage("user.email", "*.token"),
+//		slogproto.DropStage("debug"),
+//	)
+//
+//	n, err := p.Copy(ctx, dst, src)
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline returns a Pipeline applying the stages in order.
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Apply applies the stages of the pipeline to the record, and reports
+// whether it is kept, stopping at the first error.
+func (p *Pipeline) Apply(r *slog.Record) (bool, error) {
+	for _, stage := range p.stages {
+		keep, err := stage(r)
+		if err != nil || !keep {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Copy reads protobuf encoded slog records from src, as with Read, and
+// writes the records kept by the pipeline, as changed by it, to dst with
+// the order of their attributes, until src is exhausted or the context is
+// canceled. It returns the number of records written.
+func (p *Pipeline) Copy(ctx context.Context, dst io.Writer, src io.Reader, opts ...ReadOption) (int64, error) {
+	h := NewHandler(dst, nil, WithKeyOrder())
+
+	var (
+		copied  int64
+		copyErr error
+	)
+	err := Read(ctx, src, func(r *slog.Record) bool {
+		keep, err := p.Apply(r)
+		if err != nil {
+			copyErr = err
+			return false
+		}
+		if !keep {
+			return true
+		}
+
+		if err := h.handle(*r); err != nil {
+			copyErr = fmt.Errorf("error writing record: %w", err)
+			return false
+		}
+
+		copied++
+		return true
+	}, opts...)
+	if err != nil {
+		return copied, err
+	}
+
+	return copied, copyErr
+}
+
+// Handler returns a handler applying the pipeline to records before
+// passing those kept to the next handler, so that the stages apply to the
+// records of a program as they are logged. Stages see the attributes added
+// with WithAttrs and WithGroup, and the level set by them decides whether
+// the next handler is enabled for the record.
+func (p *Pipeline) Handler(next slog.Handler) slog.Handler {
+	return &pipelineHandler{p: p, next: next}
+}
+
+// pipelineHandler is the handler returned by Pipeline.Handler.
+type pipelineHandler struct {
+	p    *Pipeline
+	next slog.Handler
+	goas []groupOrAttrs
+}
+
+// Enabled returns true, since the stages of the pipeline may change the
+// level of records.
+func (h *pipelineHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle applies the pipeline to the record, with the attributes and
+// groups added to the handler, and passes it to the next handler if it is
+// kept and enabled for its level.
+func (h *pipelineHandler) Handle(ctx context.Context, r slog.Record) error {
+	r = recordWithGroups(r, h.goas)
+
+	keep, err := h.p.Apply(&r)
+	if err != nil || !keep || !h.next.Enabled(ctx, r.Level) {
+		return err
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a new handler with the attributes added to the
+// records the pipeline is applied to.
+func (h *pipelineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &pipelineHandler{p: h.p, next: h.next, goas: append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{attrs: attrs})}
+}
+
+// WithGroup returns a new handler with the group added to the records the
+// pipeline is applied to.
+func (h *pipelineHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &pipelineHandler{p: h.p, next: h.next, goas: append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{group: name})}
+}
+
+// recordWithGroups returns the record with the attributes and groups added
+// to a handler with WithAttrs and WithGroup.
+func recordWithGroups(r slog.Record, goas []groupOrAttrs) slog.Record {
+	if len(goas) == 0 {
+		return r
+	}
+
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	// Nest the attributes in the groups, from the innermost outwards.
+	for i := len(goas) - 1; i >= 0; i-- {
+		goa := goas[i]
+		if goa.group != "" {
+			attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+		} else {
+			attrs = append(goa.attrs[:len(goa.attrs):len(goa.attrs)], attrs...)
+		}
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	return nr
+}
+
+// FilterStage returns a stage keeping the records for which keep returns
+// true.
+func FilterStage(keep func(r *slog.Record) bool) Stage {
+	return func(r *slog.Record) (bool, error) {
+		return keep(r), nil
+	}
+}
+
+// TransformStage returns a stage replacing the attributes of records with
+// those returned by fn, such as [Flatten].
+func TransformStage(fn func(attrs []slog.Attr) []slog.Attr) Stage {
+	return func(r *slog.Record) (bool, error) {
+		attrs := make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+
+		nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		nr.AddAttrs(fn(attrs)...)
+		*r = nr
+		return true, nil
+	}
+}
+
+// RedactStage returns a stage replacing the values of the attributes
+// whose dotted paths match one of the patterns, as described by
+// [WithAllowAttrs], with RedactedValue. Groups matching a pattern are
+// redacted as a whole.
+func RedactStage(patterns ...string) Stage {
+	return attrPathStage(splitPatterns(patterns), func(a slog.Attr) (slog.Attr, bool) {
+		return slog.String(a.Key, RedactedValue), true
+	})
+}
+
+// DropStage returns a stage dropping the attributes whose dotted paths
+// match one of the patterns, as described by [WithAllowAttrs].
+func DropStage(patterns ...string) Stage {
+	return attrPathStage(splitPatterns(patterns), func(a slog.Attr) (slog.Attr, bool) {
+		return a, false
+	})
+}
+
+// attrPathStage returns a stage replacing the attributes whose paths match
+// one of the patterns with the result of fn, which drops them if false.
+func attrPathStage(patterns [][]string, fn func(a slog.Attr) (slog.Attr, bool)) Stage {
+	var replace func(groups []string, attrs []slog.Attr) []slog.Attr
+	replace = func(groups []string, attrs []slog.Attr) []slog.Attr {
+		out := make([]slog.Attr, 0, len(attrs))
+		for _, a := range attrs {
+			a.Value = a.Value.Resolve()
+
+			// Attributes of groups with an empty key are inlined.
+			p := groups
+			if a.Key != "" {
+				p = append(groups[:len(groups):len(groups)], a.Key)
+			}
+
+			matched := false
+			for _, pattern := range patterns {
+				if len(pattern) == len(p) && matchPrefix(pattern, p) {
+					matched = true
+					break
+				}
+			}
+
+			switch {
+			case matched:
+				var keep bool
+				if a, keep = fn(a); !keep {
+					continue
+				}
+			case a.Value.Kind() == slog.KindGroup:
+				a.Value = slog.GroupValue(replace(p, a.Value.Group())...)
+			}
+			out = append(out, a)
+		}
+		return out
+	}
+
+	return TransformStage(func(attrs []slog.Attr) []slog.Attr {
+		return replace(nil, attrs)
+	})
+}
+
+// RelevelStage returns a stage changing the level of records to the one
+// returned by fn, such as to demote noisy errors to warnings.
+func RelevelStage(fn func(r *slog.Record) slog.Level) Stage {
+	return func(r *slog.Record) (bool, error) {
+		r.Level = fn(r)
+		return true, nil
+	}
+}
@@ -0,0 +1,100 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestPipeline_Copy(t *testing.T) {
+	var src bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&src, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger.Debug("noise")
+	logger.Info("login", "user", slog.GroupValue(slog.String("name", "ann"), slog.String("password", "hunter2")), "debug", true)
+	logger.Error("timeout", "retry", 1)
+
+	p := slogproto.NewPipeline(
+		slogproto.FilterStage(func(r *slog.Record) bool {
+			return r.Level >= slog.LevelInfo
+		}),
+		slogproto.RedactStage("*.password"),
+		slogproto.DropStage("debug"),
+		slogproto.RelevelStage(func(r *slog.Record) slog.Level {
+			if r.Message == "timeout" {
+				return slog.LevelWarn
+			}
+			return r.Level
+		}),
+	)
+
+	var dst bytes.Buffer
+	n, err := p.Copy(context.Background(), &dst, &src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 records, got %d", n)
+	}
+
+	var got []string
+	err = slogproto.Read(context.Background(), &dst, func(r *slog.Record) bool {
+		got = append(got, r.Level.String()+" "+r.Message+" "+attrString(r))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"INFO login user=[name=ann password=[REDACTED]]",
+		"WARN timeout retry=1",
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("expected records %q, got %q", want, got)
+	}
+}
+
+func TestPipeline_Handler(t *testing.T) {
+	h := slogproto.NewMemoryHandler(10, nil)
+
+	p := slogproto.NewPipeline(
+		slogproto.RedactStage("svc.token"),
+		slogproto.RelevelStage(func(r *slog.Record) slog.Level {
+			if r.Message == "important" {
+				return slog.LevelError
+			}
+			return r.Level
+		}),
+	)
+
+	logger := slog.New(p.Handler(h)).WithGroup("svc").With("token", "secret")
+	logger.Debug("important", "id", 1)
+	logger.Debug("dropped")
+
+	records, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if r := records[0]; r.Level != slog.LevelError || attrString(&r) != "svc=[id=1 token=[REDACTED]]" {
+		t.Fatalf("unexpected record: %s %s", r.Level, attrString(&r))
+	}
+}
+
+// attrString returns the attributes of the record as key=value pairs.
+func attrString(r *slog.Record) string {
+	var buf bytes.Buffer
+	r.Attrs(func(a slog.Attr) bool {
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(a.String())
+		return true
+	})
+	return buf.String()
+}
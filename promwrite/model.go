@@ -0,0 +1,52 @@
+//go:build !slogproto_nocel && !tinygo
+
+package promwrite
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The field numbers of the remote write WriteRequest message and the
+// messages it contains, of which only time series are written.
+const (
+	fieldWriteRequestTimeseries = 1
+
+	fieldTimeSeriesLabels  = 1
+	fieldTimeSeriesSamples = 2
+
+	fieldLabelName  = 1
+	fieldLabelValue = 2
+
+	fieldSampleValue     = 1
+	fieldSampleTimestamp = 2
+)
+
+// appendTimeSeries appends a TimeSeries with the labels and a single
+// sample to the encoded WriteRequest.
+func appendTimeSeries(b []byte, labels []label, value float64, ts int64) []byte {
+	var m []byte
+	for _, l := range labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, fieldLabelName, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, fieldLabelValue, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+
+		m = protowire.AppendTag(m, fieldTimeSeriesLabels, protowire.BytesType)
+		m = protowire.AppendBytes(m, lb)
+	}
+
+	var sb []byte
+	sb = protowire.AppendTag(sb, fieldSampleValue, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(value))
+	sb = protowire.AppendTag(sb, fieldSampleTimestamp, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts))
+
+	m = protowire.AppendTag(m, fieldTimeSeriesSamples, protowire.BytesType)
+	m = protowire.AppendBytes(m, sb)
+
+	b = protowire.AppendTag(b, fieldWriteRequestTimeseries, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
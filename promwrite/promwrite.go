@@ -0,0 +1,433 @@
+//go:build !slogproto_nocel && !tinygo
+
+// Package promwrite derives Prometheus metrics from records, such as the
+// number of failed requests or a histogram of their durations, using rules
+// matching records with filter expressions, and pushes them to a server
+// accepting the Prometheus remote write protocol, so that structured logs
+// can be turned into metrics without a separate agent.
+//
+// # Example
+//
+//	exporter, err := promwrite.NewExporter(promwrite.Config{
+//		URL: "http://prometheus:9090/api/v1/write",
+//		Rules: []promwrite.Rule{
+//			{
+//				Name:   "http_requests_total",
+//				Type:   promwrite.Counter,
+//				Match:  `has(attrs.status)`,
+//				Labels: map[string]string{"status": "status"},
+//			},
+//			{
+//				Name:  "http_request_duration_seconds",
+//				Type:  promwrite.Histogram,
+//				Match: `has(attrs.duration)`,
+//				Value: "duration",
+//			},
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	go exporter.Run(ctx)
+//
+//	err = slogproto.Read(ctx, r, func(r *slog.Record) bool {
+//		exporter.Observe(r)
+//		return true
+//	})
+package promwrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/google/cel-go/cel"
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/aggregate"
+)
+
+// RuleType is the type of the metric derived by a [Rule].
+type RuleType string
+
+const (
+	// Counter is a metric counting the records matched by a rule, or
+	// summing the values of one of their attributes.
+	Counter RuleType = "counter"
+
+	// Histogram is a metric counting the values of an attribute of the
+	// records matched by a rule in buckets, along with their sum and
+	// count.
+	Histogram RuleType = "histogram"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of histograms without
+// any, which are those of the Prometheus client libraries.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultInterval is the default interval at which an [Exporter] pushes
+// metrics.
+const DefaultInterval = 15 * time.Second
+
+// Rule derives a metric from the records matching its filter expression.
+type Rule struct {
+	// Name is the name of the metric, such as "http_requests_total".
+	Name string
+
+	// Type is the type of the metric, Counter by default.
+	Type RuleType
+
+	// Match is a filter expression, as accepted by
+	// [slogproto.CompileFilter], selecting the records the metric is
+	// derived from. All records are selected if it isn't set.
+	Match string
+
+	// Labels are the labels of the metric, whose values are the values of
+	// fields of the records: "level", "msg" or the dotted path of an
+	// attribute, such as "http.method". There is a series of the metric
+	// for each distinct set of label values.
+	Labels map[string]string
+
+	// Value is the dotted path of the numeric attribute added to counters
+	// and observed by histograms. Durations are converted to seconds.
+	// Records without it aren't counted by histograms, but counters count
+	// each record as one if it isn't set.
+	Value string
+
+	// Buckets are the upper bounds of the buckets of histograms, or
+	// DefaultBuckets if empty.
+	Buckets []float64
+}
+
+// Config declares the metrics derived by an [Exporter], and where they
+// are pushed to.
+type Config struct {
+	// URL is the URL of the remote write endpoint, such as
+	// "http://prometheus:9090/api/v1/write".
+	URL string
+
+	// Rules are the rules deriving the metrics.
+	Rules []Rule
+
+	// Labels are added to every series, such as the job or instance.
+	Labels map[string]string
+
+	// Interval is the interval at which Run pushes metrics, or
+	// DefaultInterval if zero.
+	Interval time.Duration
+
+	// Client is the client metrics are pushed with, or
+	// http.DefaultClient if nil.
+	Client *http.Client
+
+	// Header is added to the push requests, such as for authorization.
+	Header http.Header
+
+	// OnError is called with the errors of the pushes made by Run, which
+	// are retried at the next interval, if set.
+	OnError func(error)
+}
+
+// Exporter derives metrics from the records it observes, as declared by
+// the rules of a [Config], and pushes them with the Prometheus remote
+// write protocol. It is safe for concurrent use.
+type Exporter struct {
+	cfg   Config
+	rules []*rule
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// rule is a rule of an Exporter and its compiled filter expression.
+type rule struct {
+	Rule
+	match  cel.Program
+	labels []string
+}
+
+// series is the state of one series of a metric, with its labels
+// sorted by name.
+type series struct {
+	rule    *rule
+	labels  []label
+	value   float64
+	buckets []uint64
+	count   uint64
+}
+
+// label is the name and value of a label of a series.
+type label struct {
+	name, value string
+}
+
+// metricName matches valid metric and label names.
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// NewExporter returns an Exporter deriving metrics with the rules of the
+// config. An error is returned if a rule is invalid.
+func NewExporter(cfg Config) (*Exporter, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("promwrite: no URL")
+	}
+
+	e := &Exporter{cfg: cfg, series: make(map[string]*series)}
+	for _, rc := range cfg.Rules {
+		if !metricName.MatchString(rc.Name) {
+			return nil, fmt.Errorf("promwrite: invalid metric name %q", rc.Name)
+		}
+
+		r := &rule{Rule: rc}
+		switch r.Type {
+		case "":
+			r.Type = Counter
+		case Counter:
+		case Histogram:
+			if r.Value == "" {
+				return nil, fmt.Errorf("promwrite: rule %q: histograms need a value", r.Name)
+			}
+			if len(r.Buckets) == 0 {
+				r.Buckets = DefaultBuckets
+			}
+			r.Buckets = slices.Clone(r.Buckets)
+			slices.Sort(r.Buckets)
+		default:
+			return nil, fmt.Errorf("promwrite: rule %q: unknown type %q: expected counter or histogram", r.Name, r.Type)
+		}
+
+		if r.Match != "" {
+			prog, err := slogproto.CompileFilter(r.Match)
+			if err != nil {
+				return nil, fmt.Errorf("promwrite: rule %q: invalid match: %w", r.Name, err)
+			}
+			r.match = prog
+		}
+
+		for name := range r.Labels {
+			if !metricName.MatchString(name) || name == "le" {
+				return nil, fmt.Errorf("promwrite: rule %q: invalid label name %q", r.Name, name)
+			}
+			r.labels = append(r.labels, name)
+		}
+		slices.Sort(r.labels)
+
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+// Observe updates the metrics of the rules matching the record, returning
+// the errors of evaluating their filter expressions.
+func (e *Exporter) Observe(r *slog.Record) error {
+	var errs []error
+	for _, rl := range e.rules {
+		if rl.match != nil {
+			ok, err := slogproto.EvalFilter(rl.match, r)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("promwrite: rule %q: %w", rl.Name, err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		value, hasValue := 1.0, true
+		if rl.Value != "" {
+			value, hasValue = numericValue(r, rl.Value)
+		}
+		if !hasValue {
+			continue
+		}
+
+		labels := make([]label, 0, len(rl.labels))
+		for _, name := range rl.labels {
+			labels = append(labels, label{name, fieldValue(r, rl.Labels[name])})
+		}
+
+		e.observe(rl, labels, value)
+	}
+	return errors.Join(errs...)
+}
+
+// observe updates the series of the rule with the labels.
+func (e *Exporter) observe(rl *rule, labels []label, value float64) {
+	var key strings.Builder
+	key.WriteString(rl.Name)
+	for _, l := range labels {
+		key.WriteString("\x00" + l.value)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, ok := e.series[key.String()]
+	if !ok {
+		s = &series{rule: rl, labels: labels}
+		if rl.Type == Histogram {
+			s.buckets = make([]uint64, len(rl.Buckets))
+		}
+		e.series[key.String()] = s
+	}
+
+	s.value += value
+	s.count++
+	for i, bound := range rl.Buckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// fieldValue returns the level, message or value of the attribute of the
+// record at the path, or empty if it has none.
+func fieldValue(r *slog.Record, path string) string {
+	switch path {
+	case "level":
+		return r.Level.String()
+	case "msg":
+		return r.Message
+	}
+	if v, ok := slogproto.GetAttr(r, path); ok {
+		return v.String()
+	}
+	return ""
+}
+
+// numericValue returns the numeric value of the attribute of the record at
+// the path, with durations in seconds.
+func numericValue(r *slog.Record, path string) (float64, bool) {
+	v, ok := slogproto.GetAttr(r, path)
+	if !ok {
+		return 0, false
+	}
+	if v.Kind() == slog.KindDuration {
+		return v.Duration().Seconds(), true
+	}
+	return aggregate.Float64(v)
+}
+
+// Run pushes the metrics at the interval of the config until the context
+// is done, and then pushes them a final time, returning its error.
+func (e *Exporter) Run(ctx context.Context) error {
+	interval := e.cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Push(ctx); err != nil && e.cfg.OnError != nil {
+				e.cfg.OnError(err)
+			}
+		case <-ctx.Done():
+			return e.Push(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+// Push pushes the current value of every series to the remote write
+// endpoint, timestamped with the current time. Counters and histograms are
+// cumulative since the Exporter was created.
+func (e *Exporter) Push(ctx context.Context) error {
+	body := snappy.Encode(nil, e.writeRequest(time.Now()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("promwrite: %w", err)
+	}
+	for key, values := range e.cfg.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	client := e.cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("promwrite: error pushing metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("promwrite: error pushing metrics: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// writeRequest returns the encoded remote write request of the current
+// value of every series, sorted by their keys.
+func (e *Exporter) writeRequest(now time.Time) []byte {
+	ts := now.UnixMilli()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]string, 0, len(e.series))
+	for key := range e.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var b []byte
+	for _, key := range keys {
+		s := e.series[key]
+		switch s.rule.Type {
+		case Counter:
+			b = e.appendSeries(b, s.rule.Name, s.labels, s.value, ts)
+		case Histogram:
+			for i, bound := range s.rule.Buckets {
+				le := label{"le", strconv.FormatFloat(bound, 'g', -1, 64)}
+				b = e.appendSeries(b, s.rule.Name+"_bucket", append(s.labels[:len(s.labels):len(s.labels)], le), float64(s.buckets[i]), ts)
+			}
+			inf := label{"le", "+Inf"}
+			b = e.appendSeries(b, s.rule.Name+"_bucket", append(s.labels[:len(s.labels):len(s.labels)], inf), float64(s.count), ts)
+			b = e.appendSeries(b, s.rule.Name+"_sum", s.labels, s.value, ts)
+			b = e.appendSeries(b, s.rule.Name+"_count", s.labels, float64(s.count), ts)
+		}
+	}
+	return b
+}
+
+// appendSeries appends a time series of the WriteRequest, with the
+// metric name, labels and labels of the config sorted by name, as remote
+// write requires.
+func (e *Exporter) appendSeries(b []byte, name string, labels []label, value float64, ts int64) []byte {
+	all := make([]label, 0, len(labels)+len(e.cfg.Labels)+1)
+	all = append(all, label{"__name__", name})
+	all = append(all, labels...)
+	for n, v := range e.cfg.Labels {
+		if !slices.ContainsFunc(labels, func(l label) bool { return l.name == n }) {
+			all = append(all, label{n, v})
+		}
+	}
+	slices.SortFunc(all, func(a, b label) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	if math.IsNaN(value) {
+		value = 0
+	}
+	return appendTimeSeries(b, all, value, ts)
+}
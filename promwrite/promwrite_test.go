@@ -0,0 +1,162 @@
+//go:build !slogproto_nocel && !tinygo
+
+package promwrite_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/picatz/slogproto/promwrite"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestExporter(t *testing.T) {
+	var (
+		got    []string
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := snappy.Decode(nil, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = decodeWriteRequest(t, b)
+	}))
+	defer srv.Close()
+
+	exporter, err := promwrite.NewExporter(promwrite.Config{
+		URL:    srv.URL,
+		Labels: map[string]string{"job": "api"},
+		Rules: []promwrite.Rule{
+			{
+				Name:   "requests_total",
+				Match:  `has(attrs.status)`,
+				Labels: map[string]string{"status": "status", "level": "level"},
+			},
+			{
+				Name:    "request_duration_seconds",
+				Type:    promwrite.Histogram,
+				Value:   "duration",
+				Buckets: []float64{1, 0.1},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, status := range []int{200, 200, 500} {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "request", 0)
+		r.AddAttrs(slog.Int("status", status), slog.Duration("duration", time.Duration(i+1)*400*time.Millisecond))
+		if err := exporter.Observe(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "started", 0)
+	if err := exporter.Observe(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exporter.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Get("Content-Encoding") != "snappy" || header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+		t.Fatalf("unexpected headers: %v", header)
+	}
+
+	want := []string{
+		`request_duration_seconds_bucket{job="api",le="0.1"} 0`,
+		`request_duration_seconds_bucket{job="api",le="1"} 2`,
+		`request_duration_seconds_bucket{job="api",le="+Inf"} 3`,
+		`request_duration_seconds_count{job="api"} 3`,
+		`request_duration_seconds_sum{job="api"} 2.4`,
+		`requests_total{job="api",level="INFO",status="200"} 2`,
+		`requests_total{job="api",level="INFO",status="500"} 1`,
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected series:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestNewExporter_invalid(t *testing.T) {
+	for name, rule := range map[string]promwrite.Rule{
+		"name":      {Name: "requests-total"},
+		"type":      {Name: "requests", Type: "gauge"},
+		"match":     {Name: "requests", Match: "attrs."},
+		"label":     {Name: "requests", Labels: map[string]string{"le": "status"}},
+		"histogram": {Name: "duration", Type: promwrite.Histogram},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := promwrite.NewExporter(promwrite.Config{URL: "http://localhost", Rules: []promwrite.Rule{rule}})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// decodeWriteRequest returns the series of the WriteRequest, formatted
+// in the Prometheus text format with their values rounded.
+func decodeWriteRequest(t *testing.T, b []byte) []string {
+	t.Helper()
+
+	var series []string
+	for len(b) > 0 {
+		_, _, n := protowire.ConsumeTag(b)
+		ts, m := protowire.ConsumeBytes(b[n:])
+		b = b[n+m:]
+
+		var (
+			name   string
+			labels []string
+			value  float64
+		)
+		for len(ts) > 0 {
+			num, _, n := protowire.ConsumeTag(ts)
+			msg, m := protowire.ConsumeBytes(ts[n:])
+			ts = ts[n+m:]
+
+			fields := map[protowire.Number][]byte{}
+			for len(msg) > 0 {
+				num, typ, n := protowire.ConsumeTag(msg)
+				m := protowire.ConsumeFieldValue(num, typ, msg[n:])
+				fields[num] = msg[n : n+m]
+				msg = msg[n+m:]
+			}
+
+			switch num {
+			case 1:
+				labelName, _ := protowire.ConsumeString(fields[1])
+				labelValue, _ := protowire.ConsumeString(fields[2])
+				if labelName == "__name__" {
+					name = labelValue
+				} else {
+					labels = append(labels, labelName+"="+`"`+labelValue+`"`)
+				}
+			case 2:
+				bits, _ := protowire.ConsumeFixed64(fields[1])
+				value = math.Round(math.Float64frombits(bits)*1000) / 1000
+			}
+		}
+		series = append(series, name+"{"+strings.Join(labels, ",")+"} "+strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	return series
+}
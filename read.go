@@ -4,22 +4,250 @@ import (
 	"bufio"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 )
 
+// ReadOption configures optional behavior of [Read].
+type ReadOption func(*readConfig)
+
+// DefaultMaxRecordSize is the default maximum size, in bytes, of a single
+// encoded record accepted by [Read].
+const DefaultMaxRecordSize = 4 << 20
+
+// ErrRecordTooLarge is returned by [Read] when a record's size exceeds
+// the configured maximum record size.
+var ErrRecordTooLarge = errors.New("slogproto: record too large")
+
+// ErrTruncated is returned by [Read] when the input ends within a record,
+// or within a stream header or trailer, such as when it was cut short
+// while being written or copied. [Repair] can recover the records before
+// the truncated one.
+var ErrTruncated = errors.New("slogproto: truncated input")
+
+// Default limits applied when decoding records with [Read], which protect
+// readers of untrusted input from records that expand into an excessive
+// number of values.
+const (
+	// DefaultMaxDepth is the default maximum nesting depth of groups.
+	DefaultMaxDepth = 32
+
+	// DefaultMaxAttrs is the default maximum number of attributes in a
+	// record, including those nested in groups.
+	DefaultMaxAttrs = 10_000
+
+	// DefaultMaxAnySize is the default maximum size, in bytes, of the
+	// payload of an Any value.
+	DefaultMaxAnySize = 1 << 20
+)
+
+// ErrDecodeLimit is returned by [Read] when a record exceeds one of the
+// configured decode limits.
+var ErrDecodeLimit = errors.New("slogproto: decode limit exceeded")
+
+// readConfig holds the options used by Read.
+type readConfig struct {
+	maxRecordSize    int
+	limits           decodeLimits
+	progressInterval time.Duration
+	progressFn       func(Progress)
+	idleTimeout      time.Duration
+	idleFn           func(time.Duration) error
+	zeroCopy         bool
+	filter           *recordFilter
+	streams          []string
+	decompress       func(io.Reader) (io.ReadCloser, error)
+	offsetFn         func(int64)
+	switchFn         func() (int64, Framing, bool)
+	framingChangeFn  func(Framing)
+}
+
+// recordFilter selects the records passed to the function given to Read,
+// as configured with WithFilter.
+type recordFilter struct {
+	// match reports whether the record is selected.
+	match func(r *slog.Record) (bool, error)
+
+	// attrs is set if match may reference the attributes of records.
+	attrs bool
+}
+
+// WithMaxRecordSize limits the size, in bytes, of a single encoded record
+// accepted by Read, which bounds the memory used to buffer frames. Reading
+// a larger record fails with ErrRecordTooLarge. The default limit is
+// DefaultMaxRecordSize.
+func WithMaxRecordSize(n int) ReadOption {
+	return func(c *readConfig) {
+		c.maxRecordSize = n
+	}
+}
+
+// WithRecordOffsets configures Read to call fn with the offset of each
+// record in the input, before passing the record to its function, so that
+// records can be referred to by their position, such as to annotate them.
+// The offset is that of the record's size prefix in the decompressed
+// stream, as reported by read errors.
+func WithRecordOffsets(fn func(offset int64)) ReadOption {
+	return func(c *readConfig) {
+		c.offsetFn = fn
+	}
+}
+
+// WithDecodeLimits limits the nesting depth of groups, the total number of
+// attributes in a record (including those nested in groups), and the size
+// in bytes of the payload of Any values when decoding records. Reading a
+// record exceeding a limit fails with ErrDecodeLimit. A limit of zero or
+// less disables it.
+//
+// The defaults are DefaultMaxDepth, DefaultMaxAttrs and DefaultMaxAnySize.
+func WithDecodeLimits(maxDepth, maxAttrs, maxAnySize int) ReadOption {
+	return func(c *readConfig) {
+		c.limits = decodeLimits{
+			maxDepth:   maxDepth,
+			maxAttrs:   maxAttrs,
+			maxAnySize: maxAnySize,
+		}
+	}
+}
+
+// Progress describes how much of the input has been processed by [Read].
+type Progress struct {
+	// BytesRead is the number of bytes of complete frames consumed so far.
+	BytesRead int64
+
+	// TotalBytes is the total size of the input, if known, or zero.
+	TotalBytes int64
+
+	// Records is the number of records decoded so far.
+	Records int64
+}
+
+// WithProgress configures Read to call fn with the current progress at most
+// once per interval while reading, and once more when reading stops.
+//
+// The total size of the input is known when the reader implements a
+// Stat() (fs.FileInfo, error) method, such as [os.File].
+func WithProgress(interval time.Duration, fn func(Progress)) ReadOption {
+	return func(c *readConfig) {
+		c.progressInterval = interval
+		c.progressFn = fn
+	}
+}
+
+// withInputSwitches sets a function reporting whether the reader switched
+// to other data since it was last called, such as another file when
+// tailing one, along with the offset in the input at which the data
+// starts, and its framing, which applies without a stream header being
+// read, as when reading resumes past the header of a file. What remains
+// of a frame left incomplete before the switch is skipped, so that it
+// isn't joined with the data that follows.
+func withInputSwitches(fn func() (start int64, framing Framing, ok bool)) ReadOption {
+	return func(c *readConfig) {
+		c.switchFn = fn
+	}
+}
+
+// withFramingChanges sets a function called with the framing of the
+// records that follow, whenever a stream header is read, so that they
+// can be copied in the same framing.
+func withFramingChanges(fn func(Framing)) ReadOption {
+	return func(c *readConfig) {
+		c.framingChangeFn = fn
+	}
+}
+
 // Read reads protobuf encoded slog records from the reader and calls the
 // provided function for each record. If the function returns false, the
 // iteration is stopped.
 //
 // If the context is canceled, the iteration is stopped and the error is
 // returned. If the reader returns an error, the error is returned.
-func Read(ctx context.Context, r io.Reader, fn func(r *slog.Record) bool) error {
+func Read(ctx context.Context, r io.Reader, fn func(r *slog.Record) bool, opts ...ReadOption) error {
+	return readFrames(ctx, r, func(_ []byte, record *slog.Record) bool {
+		return fn(record)
+	}, opts...)
+}
+
+// readFrames reads protobuf encoded slog records from the reader like Read,
+// but also passes the encoded record (without its size prefix) to the
+// function, which is only valid until the function returns.
+func readFrames(ctx context.Context, r io.Reader, fn func(frame []byte, r *slog.Record) bool, opts ...ReadOption) error {
+	cfg := readConfig{
+		maxRecordSize: DefaultMaxRecordSize,
+		limits: decodeLimits{
+			maxDepth:   DefaultMaxDepth,
+			maxAttrs:   DefaultMaxAttrs,
+			maxAnySize: DefaultMaxAnySize,
+		},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// Decompress the input, if requested.
+	if cfg.decompress != nil {
+		dr, err := cfg.decompress(r)
+		if err != nil {
+			return err
+		}
+		defer dr.Close()
+		r = dr
+	}
+
+	// Track progress through the input, if requested.
+	var progress Progress
+	if cfg.progressFn != nil {
+		if st, ok := r.(interface{ Stat() (fs.FileInfo, error) }); ok {
+			if fi, err := st.Stat(); err == nil && fi.Mode().IsRegular() {
+				progress.TotalBytes = fi.Size()
+			}
+		}
+
+		lastReport := time.Now()
+		reportProgress := func(force bool) {
+			if !force && time.Since(lastReport) < cfg.progressInterval {
+				return
+			}
+			lastReport = time.Now()
+			cfg.progressFn(progress)
+		}
+		defer reportProgress(true)
+
+		next := fn
+		fn = func(frame []byte, r *slog.Record) bool {
+			progress.Records++
+			reportProgress(false)
+			return next(frame, r)
+		}
+	}
+
+	// Detect a stalled input, if requested.
+	if cfg.idleTimeout > 0 {
+		ir := newIdleReader(ctx, r, cfg.idleTimeout, cfg.idleFn)
+		r = ir
+
+		next := fn
+		fn = func(frame []byte, r *slog.Record) bool {
+			ir.record()
+			return next(frame, r)
+		}
+	}
+
 	// Create a new scanner to read from the reader.
 	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, cfg.maxRecordSize+8)), cfg.maxRecordSize+8)
+
+	// The framing of the records, declared by the last stream header.
+	framing := Framing_FRAMING_UINT32_LE
 
 	// Iterate over content from the scanner, which contains
 	// protobuf encoded messages in binary format, which cannot be split
@@ -28,75 +256,208 @@ func Read(ctx context.Context, r io.Reader, fn func(r *slog.Record) bool) error
 	//
 	// The file format is a series of [delimited](https://developers.google.com/protocol-buffers/docs/techniques#streaming)
 	// [Protocol Buffer](https://developers.google.com/protocol-buffers) messages. Each message is prefixed
-	// with a 32-bit unsigned integer representing the size of the message. The message
+	// with a 32-bit little-endian unsigned integer representing the size of the message, unless a
+	// stream header declares another framing (see WithFraming). The message
 	// itself is a protobuf encoded [`slog.Record`](https://pkg.go.dev/golang.org/x/exp/slog#Record).
 	//
 	// ╭────────────────────────────────────────────────────────────╮
 	// │  Message Size  │  Protocol Buffer Message  │  ...  │  EOF  │
 	// ╰────────────────────────────────────────────────────────────╯
 	//
-	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	var split bufio.SplitFunc
+	split = func(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		// Check context.
 		if ctx.Err() != nil {
 			return 0, nil, ctx.Err()
 		}
 
-		// If we're at the end of the file, return 0, nil, nil.
-		if atEOF {
+		// Switch framing if the reader moved to other data, as when
+		// tailing a file, and skip the bytes buffered before it.
+		if cfg.switchFn != nil {
+			if start, f, ok := cfg.switchFn(); ok {
+				framing = f
+				if cfg.framingChangeFn != nil {
+					cfg.framingChangeFn(framing)
+				}
+				// The scanner only splits the rest of the data after
+				// reading more, so split it now, as with stream headers.
+				if skip := int(start - progress.BytesRead); skip > 0 {
+					progress.BytesRead += int64(skip)
+					advance, token, err := split(data[skip:], atEOF)
+					return skip + advance, token, err
+				}
+			}
+		}
+
+		// If we're at the end of the file, and there is no more data,
+		// return 0, nil, nil. Complete messages may still be buffered
+		// when a reader returns the last of its data along with EOF.
+		if atEOF && len(data) == 0 {
 			return 0, nil, nil
 		}
 
+		// The offset of data in the input, for errors.
+		offset := progress.BytesRead
+
 		// Check if we have enough data to read the message length.
 		if len(data) < 4 {
+			if atEOF {
+				return 0, nil, fmt.Errorf("%w: %d bytes of a record size at offset %d", ErrTruncated, len(data), offset)
+			}
+			return 0, nil, nil
+		}
+
+		// Check the schema version declared by a stream header, and skip
+		// over it to the record that follows, since the scanner stops at
+		// the end of the input unless a record is returned. Stream
+		// trailers are skipped in the same way.
+		if magic := string(data[:4]); magic == StreamMagic || magic == TrailerMagic {
+			var (
+				n   int
+				err error
+			)
+			if magic == StreamMagic {
+				n, framing, err = readStreamHeader(data, framing)
+				if n > 0 && err == nil && cfg.framingChangeFn != nil {
+					cfg.framingChangeFn(framing)
+				}
+			} else {
+				n, _, err = readStreamTrailer(data)
+			}
+			if err != nil {
+				return 0, nil, fmt.Errorf("%w at offset %d", err, offset)
+			}
+			if n == 0 {
+				if atEOF {
+					return 0, nil, fmt.Errorf("%w: %d bytes of a stream header or trailer at offset %d", ErrTruncated, len(data), offset)
+				}
+				return 0, nil, nil
+			}
+
+			progress.BytesRead += int64(n)
+			if atEOF && len(data) == n {
+				return n, nil, nil
+			}
+
+			advance, token, err := split(data[n:], atEOF)
+			if token == nil {
+				progress.BytesRead -= int64(n)
+				return 0, nil, err
+			}
+			return n + advance, token, err
+		}
+
+		// Check if we have enough data to read the message length, which
+		// is wider than 4 bytes with 64-bit framing.
+		n := frameSizeLen(framing)
+		if len(data) < n {
+			if atEOF {
+				return 0, nil, fmt.Errorf("%w: %d bytes of a record size at offset %d", ErrTruncated, len(data), offset)
+			}
 			return 0, nil, nil
 		}
 
-		// Get the length of the message (first 4 bytes).
-		size := binary.LittleEndian.Uint32(data[:4])
+		// Get the length of the message.
+		size := frameSize(framing, data)
+
+		// Refuse to buffer records larger than the configured maximum.
+		if size > uint64(cfg.maxRecordSize) {
+			return 0, nil, fmt.Errorf("%w: %d bytes at offset %d exceeds the maximum of %d bytes", ErrRecordTooLarge, size, offset, cfg.maxRecordSize)
+		}
 
 		// Check if we have enough data to read the message.
-		if len(data) < int(size)+4 {
+		if len(data) < int(size)+n {
+			if atEOF {
+				return 0, nil, fmt.Errorf("%w: %d of %d bytes of a record at offset %d", ErrTruncated, len(data)-n, size, offset)
+			}
 			return 0, nil, nil
 		}
 
 		// Return the length of the message and the message itself.
-		return int(size) + 4, data[4 : int(size)+4], nil
-	})
+		progress.BytesRead += int64(size) + int64(n)
+		return int(size) + n, data[n : int(size)+n], nil
+	}
+	scanner.Split(split)
+
+	// Reuse a protobuf record from the pool, and buffers for the
+	// attributes, for every record read.
+	pbRecord := recordPool.Get().(*Record)
+	defer recordPool.Put(pbRecord)
+	defer pbRecord.Reset()
+
+	var scratch decodeScratch
+
+	// When the filter doesn't reference attributes, it is evaluated before
+	// decoding them, as is the selection of streams.
+	filterHeader := cfg.filter != nil && !cfg.filter.attrs
+	checkHeader := filterHeader || cfg.streams != nil
 
 	for scanner.Scan() && ctx.Err() == nil {
-		// Create a new pbRecord.
-		pbRecord := &Record{}
+		pbRecord.Reset()
+
+		// The offset of the record in the input, for errors.
+		offset := progress.BytesRead - int64(len(scanner.Bytes())) - int64(frameSizeLen(framing))
+
+		if checkHeader {
+			if err := unmarshalRecordHeader(scanner.Bytes(), pbRecord); err != nil {
+				return fmt.Errorf("error unmarshaling record at offset %d: %w", offset, err)
+			}
+
+			if cfg.streams != nil && !slices.Contains(cfg.streams, pbRecord.Stream) {
+				continue
+			}
+
+			if filterHeader {
+				header, err := fromPBRecord(pbRecord, &decodeLimits{}, false, nil)
+				if err != nil {
+					return fmt.Errorf("error converting record at offset %d: %w", offset, err)
+				}
+
+				include, err := cfg.filter.match(&header)
+				if err != nil {
+					return fmt.Errorf("error evaluating filter expression at offset %d: %w", offset, err)
+				}
+				if !include {
+					continue
+				}
+			}
+
+			pbRecord.Reset()
+		}
 
 		// Unmarshal the line into the record.
-		err := proto.Unmarshal(scanner.Bytes(), pbRecord)
+		var err error
+		if cfg.zeroCopy {
+			err = unmarshalRecordZeroCopy(scanner.Bytes(), pbRecord)
+		} else {
+			err = proto.Unmarshal(scanner.Bytes(), pbRecord)
+		}
 		if err != nil {
-			return fmt.Errorf("error unmarshaling record: %w", err)
+			return fmt.Errorf("error unmarshaling record at offset %d: %w", offset, err)
 		}
 
-		attrs := make([]slog.Attr, 0, len(pbRecord.Attrs))
-		for k, v := range pbRecord.Attrs {
-			// Skip empty keys.
-			if k == "" {
-				continue
-			}
+		limits := cfg.limits
+
+		record, err := fromPBRecord(pbRecord, &limits, false, &scratch)
+		if err != nil {
+			return fmt.Errorf("error converting record at offset %d: %w", offset, err)
+		}
 
-			v, err := fromPBValue(v)
+		if cfg.filter != nil && !filterHeader {
+			include, err := cfg.filter.match(&record)
 			if err != nil {
-				return fmt.Errorf("error converting value: %w", err)
+				return fmt.Errorf("error evaluating filter expression at offset %d: %w", offset, err)
 			}
-
-			attr := slog.Attr{
-				Key:   k,
-				Value: v,
+			if !include {
+				continue
 			}
-
-			attrs = append(attrs, attr)
 		}
 
-		record := slog.NewRecord(pbRecord.Time.AsTime(), fromPBLevel(pbRecord.Level), pbRecord.Message, 1)
-		record.AddAttrs(attrs...)
+		if cfg.offsetFn != nil {
+			cfg.offsetFn(offset)
+		}
 
-		ok := fn(&record)
+		ok := fn(scanner.Bytes(), &record)
 		if !ok {
 			break
 		}
@@ -113,6 +474,68 @@ func Read(ctx context.Context, r io.Reader, fn func(r *slog.Record) bool) error
 	return nil
 }
 
+// maxStreamHeaderSize bounds the size of an encoded stream header.
+const maxStreamHeaderSize = 1 << 10
+
+// readStreamHeader returns the size of the stream header at the start of
+// data, or zero if more data is needed, and the framing it declares, or
+// the given framing if it isn't known yet. An error is returned if it is
+// invalid or its schema version or framing is not supported.
+func readStreamHeader(data []byte, framing Framing) (int, Framing, error) {
+	if len(data) < len(StreamMagic)+4 {
+		return 0, framing, nil
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[len(StreamMagic):]))
+	if size > maxStreamHeaderSize {
+		return 0, framing, fmt.Errorf("invalid stream header: %d bytes exceeds the maximum of %d bytes", size, maxStreamHeaderSize)
+	}
+
+	n := len(StreamMagic) + 4 + size
+	if len(data) < n {
+		return 0, framing, nil
+	}
+
+	var header StreamHeader
+	if err := proto.Unmarshal(data[len(StreamMagic)+4:n], &header); err != nil {
+		return 0, framing, fmt.Errorf("invalid stream header: %w", err)
+	}
+
+	if err := checkSchemaVersion(header.GetSchemaVersion()); err != nil {
+		return 0, framing, err
+	}
+
+	if err := checkFraming(header.GetFraming()); err != nil {
+		return 0, framing, err
+	}
+
+	return n, header.GetFraming(), nil
+}
+
+// fromPBRecord converts a slogproto Record to a slog.Record within the
+// limits. With fidelity, records that cannot be decoded exactly as they
+// were encoded are rejected with ErrLossy.
+func fromPBRecord(pbr *Record, limits *decodeLimits, fidelity bool, scratch *decodeScratch) (slog.Record, error) {
+	attrs, err := fromPBAttrs(pbr.Attrs, pbr.Keys, limits, 0, fidelity, scratch)
+	if err != nil {
+		return slog.Record{}, err
+	}
+
+	var t time.Time
+	if pbr.Time != nil {
+		t = pbr.Time.AsTime()
+	}
+
+	level := fromPBLevel(pbr.Level)
+	if pbr.SlogLevel != 0 {
+		level = slog.Level(pbr.SlogLevel)
+	}
+
+	record := slog.NewRecord(t, level, pbr.Message, 0)
+	record.AddAttrs(attrs...)
+	return record, nil
+}
+
 func fromPBLevel(l Level) slog.Level {
 	switch l {
 	case Level_LEVEL_INFO:
@@ -128,7 +551,126 @@ func fromPBLevel(l Level) slog.Level {
 	}
 }
 
-func fromPBValue(v *Value) (slog.Value, error) {
+// decodeLimits bounds the values decoded from a single record, counting
+// the attributes decoded so far.
+type decodeLimits struct {
+	maxDepth   int
+	maxAttrs   int
+	maxAnySize int
+
+	attrs int
+}
+
+// addAttr counts a decoded attribute, returning an error if there are too
+// many.
+func (l *decodeLimits) addAttr() error {
+	l.attrs++
+	if l.maxAttrs > 0 && l.attrs > l.maxAttrs {
+		return fmt.Errorf("%w: more than %d attributes", ErrDecodeLimit, l.maxAttrs)
+	}
+	return nil
+}
+
+// decodeScratch holds buffers reused between the records decoded by Read,
+// to reduce allocations. Only the attributes at the top level of a record
+// use them, since the attributes of a group are kept by its value.
+type decodeScratch struct {
+	keys  []string
+	attrs []slog.Attr
+}
+
+// fromPBAttrs converts the attributes of a record or group, nested in
+// groups to the given depth, to slog attributes within the limits. They
+// are ordered by the recorded order of their keys, if any, or else sorted
+// by key. The result is built in the scratch buffers, if given.
+func fromPBAttrs(attrs map[string]*Value, keys []string, limits *decodeLimits, depth int, fidelity bool, scratch *decodeScratch) ([]slog.Attr, error) {
+	if !validKeys(attrs, keys) {
+		if fidelity && len(attrs) > 1 {
+			return nil, fmt.Errorf("%w: attribute order was not recorded", ErrLossy)
+		}
+
+		if scratch != nil {
+			keys = scratch.keys[:0]
+		} else {
+			keys = make([]string, 0, len(attrs))
+		}
+		for k := range attrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if scratch != nil {
+			scratch.keys = keys
+		}
+	}
+
+	var result []slog.Attr
+	if scratch != nil {
+		clear(scratch.attrs)
+		result = scratch.attrs[:0]
+		defer func() { scratch.attrs = result }()
+	} else {
+		result = make([]slog.Attr, 0, len(keys))
+	}
+	for _, k := range keys {
+		// Skip empty keys.
+		if k == "" {
+			if fidelity {
+				return nil, fmt.Errorf("%w: attribute with an empty key", ErrLossy)
+			}
+			continue
+		}
+
+		if err := limits.addAttr(); err != nil {
+			return nil, err
+		}
+
+		v, err := fromPBValue(attrs[k], limits, depth, fidelity)
+		if err != nil {
+			return nil, fmt.Errorf("error converting value of %q: %w", k, err)
+		}
+
+		result = append(result, slog.Attr{
+			Key:   k,
+			Value: v,
+		})
+	}
+
+	return result, nil
+}
+
+// validKeys reports whether keys holds each key of attrs exactly once.
+func validKeys(attrs map[string]*Value, keys []string) bool {
+	if len(keys) != len(attrs) {
+		return false
+	}
+
+	// Avoid allocating a set for the common case of a few keys.
+	if len(keys) <= 16 {
+		for i, k := range keys {
+			if _, ok := attrs[k]; !ok || slices.Contains(keys[:i], k) {
+				return false
+			}
+		}
+		return true
+	}
+
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := attrs[k]; !ok {
+			return false
+		}
+		if _, ok := seen[k]; ok {
+			return false
+		}
+		seen[k] = struct{}{}
+	}
+	return true
+}
+
+// fromPBValue converts a slogproto Value, nested in groups to the given
+// depth, to a slog.Value within the limits.
+func fromPBValue(v *Value, limits *decodeLimits, depth int, fidelity bool) (slog.Value, error) {
 	switch v.Kind.(type) {
 	case *Value_Bool:
 		return slog.BoolValue(v.GetBool()), nil
@@ -145,22 +687,28 @@ func fromPBValue(v *Value) (slog.Value, error) {
 	case *Value_Uint:
 		return slog.Uint64Value(uint64(v.GetUint())), nil
 	case *Value_Any:
-		return slog.AnyValue(v.GetAny()), nil
-	case *Value_Group_:
-		attrs := make([]slog.Attr, 0, len(v.GetGroup().GetAttrs()))
-
-		for k, v := range v.GetGroup().GetAttrs() {
-			v, err := fromPBValue(v)
+		if limits.maxAnySize > 0 && len(v.GetAny().GetValue()) > limits.maxAnySize {
+			return slog.Value{}, fmt.Errorf("%w: any value of %d bytes exceeds the maximum of %d bytes", ErrDecodeLimit, len(v.GetAny().GetValue()), limits.maxAnySize)
+		}
+		if fidelity && strings.HasPrefix(v.GetAny().GetTypeUrl(), anyTypeURLPrefix) {
+			return slog.Value{}, fmt.Errorf("%w: value of type %s was encoded as JSON", ErrLossy, strings.TrimPrefix(v.GetAny().GetTypeUrl(), anyTypeURLPrefix))
+		}
+		if vt := lookupValueTypeURL(v.GetAny().GetTypeUrl()); vt != nil {
+			x, err := vt.unmarshal(v.GetAny().GetValue())
 			if err != nil {
-				return slog.Value{}, fmt.Errorf("error converting nested value: %w", err)
-			}
-
-			attr := slog.Attr{
-				Key:   k,
-				Value: v,
+				return slog.Value{}, fmt.Errorf("error unmarshaling value of type %s: %w", v.GetAny().GetTypeUrl(), err)
 			}
+			return slog.AnyValue(x), nil
+		}
+		return slog.AnyValue(v.GetAny()), nil
+	case *Value_Group_:
+		if limits.maxDepth > 0 && depth+1 > limits.maxDepth {
+			return slog.Value{}, fmt.Errorf("%w: groups nested deeper than %d", ErrDecodeLimit, limits.maxDepth)
+		}
 
-			attrs = append(attrs, attr)
+		attrs, err := fromPBAttrs(v.GetGroup().GetAttrs(), v.GetGroup().GetKeys(), limits, depth+1, fidelity, nil)
+		if err != nil {
+			return slog.Value{}, err
 		}
 
 		return slog.GroupValue(attrs...), nil
@@ -1,14 +1,24 @@
 package slogproto_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/proto"
 )
 
 func setupTestLog(t *testing.T, recordsCount int) *os.File {
@@ -77,3 +87,514 @@ func TestRead(t *testing.T) {
 		t.Fatalf("expected 100 records, but got: %d", count)
 	}
 }
+
+func TestRead_WithProgress(t *testing.T) {
+	numberOfRecords := 100
+
+	fh := setupTestLog(t, numberOfRecords)
+
+	fi, err := fh.Stat()
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	var last slogproto.Progress
+
+	err = slogproto.Read(context.Background(), fh, func(r *slog.Record) bool {
+		return true
+	}, slogproto.WithProgress(0, func(p slogproto.Progress) {
+		if p.BytesRead < last.BytesRead || p.Records < last.Records {
+			t.Fatalf("expected progress to be monotonic, got %+v after %+v", p, last)
+		}
+		last = p
+	}))
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+
+	if last.Records != int64(numberOfRecords) {
+		t.Fatalf("expected %d records, but got: %d", numberOfRecords, last.Records)
+	}
+
+	if last.TotalBytes != fi.Size() || last.BytesRead != fi.Size() {
+		t.Fatalf("expected to read %d bytes, but got: %+v", fi.Size(), last)
+	}
+}
+
+func TestRead_WithRecordOffsets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader()))
+	for i := range 5 {
+		logger.Info(fmt.Sprintf("record %d", i), "padding", strings.Repeat("x", i*10))
+	}
+	data := buf.Bytes()
+
+	var (
+		offsets  []int64
+		messages []string
+	)
+	err := slogproto.Read(context.Background(), bytes.NewReader(data), func(r *slog.Record) bool {
+		messages = append(messages, r.Message)
+		return true
+	}, slogproto.WithRecordOffsets(func(offset int64) {
+		offsets = append(offsets, offset)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != len(messages) {
+		t.Fatalf("expected an offset for each of %d records, got %d", len(messages), len(offsets))
+	}
+
+	// Reading from the offset of a record starts with that record.
+	for i, offset := range offsets {
+		var first string
+		err := slogproto.Read(context.Background(), bytes.NewReader(data[offset:]), func(r *slog.Record) bool {
+			first = r.Message
+			return false
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != messages[i] {
+			t.Fatalf("expected record %q at offset %d, got %q", messages[i], offset, first)
+		}
+	}
+}
+
+func TestRead_WithIdleTimeout(t *testing.T) {
+	t.Run("stalled", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		// Write a single record, then stall.
+		go slog.New(slogproto.NewHandler(pw, nil)).Info("hello")
+
+		var records int
+		err := slogproto.Read(context.Background(), pr, func(r *slog.Record) bool {
+			records++
+			return true
+		}, slogproto.WithIdleTimeout(50*time.Millisecond, nil))
+		if !errors.Is(err, slogproto.ErrIdleTimeout) {
+			t.Fatalf("expected idle timeout error, but got: %v", err)
+		}
+
+		if records != 1 {
+			t.Fatalf("expected 1 record, but got: %d", records)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		pr, pw := io.Pipe()
+
+		// Write a record after the timeout has been reported, then close.
+		var reports int
+		err := slogproto.Read(context.Background(), pr, func(r *slog.Record) bool {
+			return true
+		}, slogproto.WithIdleTimeout(20*time.Millisecond, func(idle time.Duration) error {
+			reports++
+			if idle < 20*time.Millisecond {
+				t.Errorf("expected an idle time of at least 20ms, got %s", idle)
+			}
+			if reports == 1 {
+				go func() {
+					slog.New(slogproto.NewHandler(pw, nil)).Info("hello")
+					pw.Close()
+				}()
+			}
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+
+		if reports == 0 {
+			t.Fatal("expected the idle time to be reported")
+		}
+	})
+}
+
+func TestRead_WithZeroCopy(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug - 20})
+	ordered := slogproto.NewHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug - 20}, slogproto.WithKeyOrder())
+	for i := 0; i < 500; i++ {
+		r := randomRecord(rng)
+		r.AddAttrs(slog.Any("json", map[string]any{"i": i, "s": "héllo"}), slog.String("empty", ""))
+
+		handler := h
+		if i%2 == 0 {
+			handler = ordered
+		}
+		if err := handler.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var want []slog.Record
+	err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+		want = append(want, *r)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var i int
+	err = slogproto.Read(context.Background(), iotest.OneByteReader(bytes.NewReader(buf.Bytes())), func(r *slog.Record) bool {
+		if !recordsEqual(*r, want[i]) {
+			t.Fatalf("record %d differs:\n got %v\nwant %v", i, *r, want[i])
+		}
+		i++
+		return true
+	}, slogproto.WithZeroCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i != len(want) {
+		t.Fatalf("expected %d records, got %d", len(want), i)
+	}
+}
+
+func TestRead_WithZeroCopy_invalid(t *testing.T) {
+	for name, frame := range map[string][]byte{
+		"truncated field": {0x12, 0x05, 'a'},
+		"invalid utf-8":   {0x12, 0x02, 0xff, 0xfe},
+		"truncated tag":   {0x80},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := binary.LittleEndian.AppendUint32(nil, uint32(len(frame)))
+			data = append(data, frame...)
+
+			err := slogproto.Read(context.Background(), bytes.NewReader(data), func(r *slog.Record) bool {
+				return true
+			}, slogproto.WithZeroCopy())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestRead_WithMaxRecordSize(t *testing.T) {
+	var logBuffer bytes.Buffer
+
+	logger := slog.New(slogproto.NewHandler(&logBuffer, nil))
+
+	// Larger than the default buffer size of a bufio.Scanner.
+	logger.Info("large", "payload", strings.Repeat("a", 128<<10))
+
+	t.Run("default", func(t *testing.T) {
+		count := 0
+
+		err := slogproto.Read(context.Background(), bytes.NewReader(logBuffer.Bytes()), func(r *slog.Record) bool {
+			count++
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error reading records: %v", err)
+		}
+
+		if count != 1 {
+			t.Fatalf("expected 1 record, but got: %d", count)
+		}
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		err := slogproto.Read(context.Background(), bytes.NewReader(logBuffer.Bytes()), func(r *slog.Record) bool {
+			t.Fatalf("expected no records")
+			return true
+		}, slogproto.WithMaxRecordSize(64<<10))
+		if !errors.Is(err, slogproto.ErrRecordTooLarge) {
+			t.Fatalf("expected ErrRecordTooLarge, but got: %v", err)
+		}
+	})
+}
+
+func TestRead_dataWithEOF(t *testing.T) {
+	var logBuffer bytes.Buffer
+
+	logger := slog.New(slogproto.NewHandler(&logBuffer, nil))
+
+	for i := 0; i < 10; i++ {
+		logger.Info("this is a test", "test", i)
+	}
+
+	count := 0
+
+	// Return the last of the data along with io.EOF, like some
+	// decompressors do.
+	err := slogproto.Read(context.Background(), iotest.DataErrReader(&logBuffer), func(r *slog.Record) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error reading records: %v", err)
+	}
+
+	if count != 10 {
+		t.Fatalf("expected 10 records, but got: %d", count)
+	}
+}
+
+func TestRead_truncated(t *testing.T) {
+	var logBuffer bytes.Buffer
+
+	logger := slog.New(slogproto.NewHandler(&logBuffer, nil))
+	logger.Info("first")
+	logger.Info("second", "n", 2)
+
+	data := logBuffer.Bytes()
+	first := 4 + int(binary.LittleEndian.Uint32(data))
+	for _, n := range []int{2, 7, first - 1} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			// End the stream with part of the first record again.
+			truncated := append(bytes.Clone(data), data[:n]...)
+
+			count := 0
+			err := slogproto.Read(context.Background(), bytes.NewReader(truncated), func(r *slog.Record) bool {
+				count++
+				return true
+			})
+			if !errors.Is(err, slogproto.ErrTruncated) {
+				t.Fatalf("expected ErrTruncated, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), fmt.Sprintf("offset %d", len(data))) {
+				t.Fatalf("expected the offset of the truncated record, got: %v", err)
+			}
+			if count != 2 {
+				t.Fatalf("expected 2 records, but got: %d", count)
+			}
+		})
+	}
+}
+
+func TestRead_decodeLimits(t *testing.T) {
+	// nested returns a group nested to the given depth.
+	nested := func(depth int) slog.Attr {
+		attr := slog.Int("leaf", 1)
+		for i := 0; i < depth; i++ {
+			attr = slog.Group("g", attr)
+		}
+		return attr
+	}
+
+	manyAttrs := make([]any, 0, 40)
+	for i := 0; i < 20; i++ {
+		manyAttrs = append(manyAttrs, fmt.Sprintf("k%d", i), i)
+	}
+
+	tests := []struct {
+		name    string
+		log     func(l *slog.Logger)
+		opts    []slogproto.ReadOption
+		wantErr bool
+	}{
+		{
+			name: "nesting within default limit",
+			log:  func(l *slog.Logger) { l.Info("msg", nested(slogproto.DefaultMaxDepth)) },
+		},
+		{
+			name:    "nesting beyond default limit",
+			log:     func(l *slog.Logger) { l.Info("msg", nested(slogproto.DefaultMaxDepth+1)) },
+			wantErr: true,
+		},
+		{
+			name:    "too many attributes",
+			log:     func(l *slog.Logger) { l.Info("msg", manyAttrs...) },
+			opts:    []slogproto.ReadOption{slogproto.WithDecodeLimits(0, 10, 0)},
+			wantErr: true,
+		},
+		{
+			name:    "too many nested attributes",
+			log:     func(l *slog.Logger) { l.Info("msg", nested(10)) },
+			opts:    []slogproto.ReadOption{slogproto.WithDecodeLimits(0, 5, 0)},
+			wantErr: true,
+		},
+		{
+			name:    "any value too large",
+			log:     func(l *slog.Logger) { l.Info("msg", slog.Any("any", []string{strings.Repeat("a", 1024)})) },
+			opts:    []slogproto.ReadOption{slogproto.WithDecodeLimits(0, 0, 1000)},
+			wantErr: true,
+		},
+		{
+			name: "limits disabled",
+			log:  func(l *slog.Logger) { l.Info("msg", nested(100)) },
+			opts: []slogproto.ReadOption{slogproto.WithDecodeLimits(0, 0, 0)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logBuffer bytes.Buffer
+
+			test.log(slog.New(slogproto.NewHandler(&logBuffer, nil)))
+
+			err := slogproto.Read(context.Background(), &logBuffer, func(r *slog.Record) bool {
+				return true
+			}, test.opts...)
+
+			if test.wantErr && !errors.Is(err, slogproto.ErrDecodeLimit) {
+				t.Fatalf("expected ErrDecodeLimit, but got: %v", err)
+			}
+
+			if !test.wantErr && err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestRead_streamHeader(t *testing.T) {
+	var logBuffer bytes.Buffer
+
+	// Two concatenated streams, each starting with a stream header.
+	for i := 0; i < 2; i++ {
+		logger := slog.New(slogproto.NewHandler(&logBuffer, nil, slogproto.WithStreamHeader()))
+		logger.Info("this is a test", "test", 2*i)
+		logger.Info("this is a test", "test", 2*i+1)
+	}
+
+	if !bytes.HasPrefix(logBuffer.Bytes(), []byte(slogproto.StreamMagic)) {
+		t.Fatalf("expected the stream to start with a stream header")
+	}
+
+	count := 0
+
+	err := slogproto.Read(context.Background(), bytes.NewReader(logBuffer.Bytes()), func(r *slog.Record) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error reading records: %v", err)
+	}
+
+	if count != 4 {
+		t.Fatalf("expected 4 records, but got: %d", count)
+	}
+
+	t.Run("unsupported version", func(t *testing.T) {
+		var buf bytes.Buffer
+		buf.WriteString(slogproto.StreamMagic)
+		buf.Write([]byte{2, 0, 0, 0, 0x08, slogproto.SchemaVersion + 1})
+		buf.Write(logBuffer.Bytes())
+
+		err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+			t.Fatalf("expected no records")
+			return true
+		})
+		if !errors.Is(err, slogproto.ErrUnsupportedVersion) {
+			t.Fatalf("expected ErrUnsupportedVersion, but got: %v", err)
+		}
+	})
+}
+
+func BenchmarkRead(b *testing.B) {
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, nil))
+	for i := 0; i < 1000; i++ {
+		logger.Info("this is a test", "i", i, "s", "value", slog.Group("http", "method", "GET", "status", 200))
+	}
+
+	for name, opts := range map[string][]slogproto.ReadOption{
+		"default":   nil,
+		"zero copy": {slogproto.WithZeroCopy()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+					return true
+				}, opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// fuzzStream returns a stream of records covering the kinds of values, to
+// seed the fuzz targets.
+func fuzzStream(f *testing.F) []byte {
+	f.Helper()
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader(), slogproto.WithKeyOrder(), slogproto.WithStreamTrailer())
+	logger := slog.New(h)
+	logger.Info("first", "s", "v", "i", -1, "u", uint64(1), "f", 1.5, "b", true, "d", time.Second, "t", time.Unix(0, 0))
+	logger.Warn("second", slog.Group("g", slog.Group("h", "k", "v")), "any", map[string]int{"a": 1})
+	if err := h.Shutdown(context.Background()); err != nil {
+		f.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fuzzReadOptions are the options FuzzRead reads its input with.
+var fuzzReadOptions = [][]slogproto.ReadOption{
+	nil,
+	{slogproto.WithZeroCopy()},
+	{slogproto.WithStreams("", "a")},
+}
+
+func FuzzRead(f *testing.F) {
+	stream := fuzzStream(f)
+	f.Add(stream)
+	f.Add(stream[:len(stream)-3])
+	f.Add([]byte(slogproto.StreamMagic))
+	f.Add([]byte{0xff, 0xff, 0xff, 0x7f})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, opts := range fuzzReadOptions {
+			var n int
+			err := slogproto.Read(context.Background(), bytes.NewReader(data), func(r *slog.Record) bool {
+				n++
+				return true
+			}, opts...)
+			if err != nil && !strings.Contains(err.Error(), "offset") {
+				t.Fatalf("expected the error to report an offset, got: %v", err)
+			}
+		}
+	})
+}
+
+func FuzzProtoToAttr(f *testing.F) {
+	for _, a := range []slog.Attr{
+		slog.String("s", "v"),
+		slog.Int("i", -1),
+		slog.Float64("f", 1.5),
+		slog.Time("t", time.Unix(0, 0)),
+		slog.Duration("d", time.Second),
+		slog.Group("g", slog.Group("h", "k", "v"), "n", 1),
+	} {
+		v, err := slogproto.AttrToProto(a)
+		if err != nil {
+			f.Fatal(err)
+		}
+		b, err := proto.Marshal(v)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v := &slogproto.Value{}
+		if err := proto.Unmarshal(data, v); err != nil {
+			return
+		}
+
+		a, err := slogproto.ProtoToAttr("key", v)
+		if err != nil {
+			return
+		}
+
+		// Values that can be decoded can be encoded again.
+		if _, err := slogproto.AttrToProto(a); err != nil && !errors.Is(err, slogproto.ErrLossy) {
+			t.Fatalf("error encoding decoded value: %v", err)
+		}
+	})
+}
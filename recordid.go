@@ -0,0 +1,140 @@
+package slogproto
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"slices"
+)
+
+// RecordIDKey is the key of the attribute holding the ID of a record,
+// written by handlers created with [WithRecordID].
+const RecordIDKey = "record_id"
+
+// RecordID returns the ID of the record: a hash of its time, level,
+// message and attributes, as 32 hexadecimal digits, which identifies
+// identical records across copies of a stream.
+//
+// The hash is computed over the record as the [Handler] would encode it,
+// so a record has the same ID when it is logged, and when it is read back
+// from any copy of the stream, however the copy was encoded: the order of
+// attributes, the location of times, the stream of the record and its
+// [RecordIDKey] attribute, if any, are ignored. Unlike the bytes written
+// with [WithDeterministic], IDs don't depend on the version of the
+// protobuf runtime.
+//
+// An error is returned if the record can't be encoded, such as when it
+// holds a Go value that can't be marshaled as JSON.
+func RecordID(r slog.Record) (string, error) {
+	h := &Handler{
+		opts: &slog.HandlerOptions{},
+		enc:  encoding{embedAny: true},
+	}
+
+	pbr := &Record{}
+	if err := h.fillProtobufRecord(pbr, &r); err != nil {
+		return "", err
+	}
+	return recordID(pbr), nil
+}
+
+// recordID returns the ID of the encoded record, by hashing a canonical
+// encoding of it: its time, level and message, followed by its attributes
+// sorted by key.
+func recordID(pbr *Record) string {
+	b := make([]byte, 0, 256)
+
+	if pbr.Time == nil {
+		b = append(b, 0)
+	} else {
+		t := pbr.Time.AsTime()
+		b = append(b, 1)
+		b = binary.AppendVarint(b, t.Unix())
+		b = binary.AppendUvarint(b, uint64(t.Nanosecond()))
+	}
+
+	level := fromPBLevel(pbr.Level)
+	if pbr.SlogLevel != 0 {
+		level = slog.Level(pbr.SlogLevel)
+	}
+	b = binary.AppendVarint(b, int64(level))
+	b = appendIDString(b, pbr.Message)
+	b = appendIDAttrs(b, pbr.Attrs, true)
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16])
+}
+
+// appendIDAttrs appends the canonical encoding of the attributes, sorted
+// by key, skipping empty groups, and the record ID if top is set.
+func appendIDAttrs(b []byte, attrs map[string]*Value, top bool) []byte {
+	keys := make([]string, 0, len(attrs))
+	for key, v := range attrs {
+		if top && key == RecordIDKey {
+			continue
+		}
+		if g, ok := v.GetKind().(*Value_Group_); ok && len(g.Group.GetAttrs()) == 0 {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	b = binary.AppendUvarint(b, uint64(len(keys)))
+	for _, key := range keys {
+		b = appendIDString(b, key)
+		b = appendIDValue(b, attrs[key])
+	}
+	return b
+}
+
+// appendIDValue appends the canonical encoding of the value: the field
+// number of its kind, followed by the value.
+func appendIDValue(b []byte, v *Value) []byte {
+	switch kind := v.GetKind().(type) {
+	case *Value_Bool:
+		b = append(b, 1)
+		if kind.Bool {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case *Value_Float:
+		b = append(b, 2)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(kind.Float))
+	case *Value_Int:
+		b = append(b, 3)
+		return binary.AppendVarint(b, kind.Int)
+	case *Value_String_:
+		b = append(b, 4)
+		return appendIDString(b, kind.String_)
+	case *Value_Time:
+		b = append(b, 5)
+		t := kind.Time.AsTime()
+		b = binary.AppendVarint(b, t.Unix())
+		return binary.AppendUvarint(b, uint64(t.Nanosecond()))
+	case *Value_Duration:
+		b = append(b, 6)
+		b = binary.AppendVarint(b, kind.Duration.GetSeconds())
+		return binary.AppendVarint(b, int64(kind.Duration.GetNanos()))
+	case *Value_Uint:
+		b = append(b, 7)
+		return binary.AppendUvarint(b, kind.Uint)
+	case *Value_Group_:
+		b = append(b, 8)
+		return appendIDAttrs(b, kind.Group.GetAttrs(), false)
+	case *Value_Any:
+		b = append(b, 9)
+		b = appendIDString(b, kind.Any.GetTypeUrl())
+		return appendIDString(b, string(kind.Any.GetValue()))
+	default:
+		return append(b, 0)
+	}
+}
+
+// appendIDString appends the string, prefixed with its length.
+func appendIDString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,103 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRecordID(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("test", 3600))
+
+	r := slog.NewRecord(now, slog.LevelWarn+1, "request", 0)
+	r.AddAttrs(
+		slog.String("method", "GET"),
+		slog.Int("status", 200),
+		slog.Uint64("bytes", 512),
+		slog.Duration("elapsed", time.Second),
+		slog.Time("start", now),
+		slog.Any("tags", []string{"a", "b"}),
+		slog.Group("user", slog.String("id", "u1"), slog.Bool("admin", false)),
+		slog.Group("empty"),
+	)
+
+	id, err := slogproto.RecordID(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 32 {
+		t.Fatalf("expected 32 hexadecimal digits, got %q", id)
+	}
+
+	for name, newHandler := range map[string]func(w *bytes.Buffer) slog.Handler{
+		"default": func(w *bytes.Buffer) slog.Handler {
+			return slogproto.NewHandler(w, nil)
+		},
+		"key order": func(w *bytes.Buffer) slog.Handler {
+			return slogproto.NewHandler(w, nil, slogproto.WithKeyOrder())
+		},
+		"deterministic": func(w *bytes.Buffer) slog.Handler {
+			return slogproto.NewHandler(w, nil, slogproto.WithDeterministic())
+		},
+		"stream": func(w *bytes.Buffer) slog.Handler {
+			return slogproto.NewHandler(w, nil).WithStream("api")
+		},
+		"record id": func(w *bytes.Buffer) slog.Handler {
+			return slogproto.NewHandler(w, nil, slogproto.WithRecordID(), slogproto.WithKeyOrder())
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := newHandler(&buf)
+			if err := h.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+
+			err := slogproto.Read(context.Background(), &buf, func(got *slog.Record) bool {
+				gotID, err := slogproto.RecordID(*got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if gotID != id {
+					t.Fatalf("expected ID %s, got %s", id, gotID)
+				}
+
+				got.Attrs(func(a slog.Attr) bool {
+					if a.Key == slogproto.RecordIDKey && a.Value.String() != id {
+						t.Fatalf("expected %s attribute %s, got %s", slogproto.RecordIDKey, id, a.Value)
+					}
+					return true
+				})
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	for name, other := range map[string]slog.Record{
+		"time":    slog.NewRecord(now.Add(1), slog.LevelWarn+1, "request", 0),
+		"level":   slog.NewRecord(now, slog.LevelWarn, "request", 0),
+		"message": slog.NewRecord(now, slog.LevelWarn+1, "response", 0),
+		"attrs": func() slog.Record {
+			other := r.Clone()
+			other.AddAttrs(slog.Int("retries", 1))
+			return other
+		}(),
+	} {
+		t.Run("different "+name, func(t *testing.T) {
+			otherID, err := slogproto.RecordID(other)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if otherID == id {
+				t.Fatalf("expected a different ID, got %s for both", id)
+			}
+		})
+	}
+}
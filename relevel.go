@@ -0,0 +1,65 @@
+//go:build !slogproto_nocel && !tinygo
+
+package slogproto
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/google/cel-go/cel"
+)
+
+// LevelRule changes the level of the records matching its filter
+// expression, such as to demote a known-noisy error to a warning, or to
+// promote specific warnings to errors for alerting, without changing the
+// code logging them.
+type LevelRule struct {
+	// Match is a filter expression, as accepted by [CompileFilter],
+	// selecting the records whose level is changed.
+	Match string
+
+	// Level is the new level of the matching records.
+	Level slog.Level
+}
+
+// LevelRulesStage returns a [Pipeline] stage changing the level of records
+// to that of the first rule they match, leaving the others unchanged. It
+// can be applied as records are written, with [Pipeline.Handler], or read,
+// with [Pipeline.Copy] or [Pipeline.Apply]. An error is returned if the
+// expression of a rule is invalid.
+//
+// # Example
+//
+//	stage, err := slogproto.LevelRulesStage(
+//		slogproto.LevelRule{Match: `level == "ERROR" && msg == "upstream timeout"`, Level: slog.LevelWarn},
+//		slogproto.LevelRule{Match: `level == "WARN" && attrs.tenant == "acme"`, Level: slog.LevelError},
+//	)
+//	if err != nil {
+//		return err
+//	}
+//
+//	logger := slog.New(slogproto.NewPipeline(stage).Handler(h))
+func LevelRulesStage(rules ...LevelRule) (Stage, error) {
+	progs := make([]cel.Program, len(rules))
+	for i, rule := range rules {
+		prog, err := CompileFilter(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("slogproto: level rule %d: invalid match: %w", i, err)
+		}
+		progs[i] = prog
+	}
+
+	return func(r *slog.Record) (bool, error) {
+		for i, prog := range progs {
+			ok, err := EvalFilter(prog, r)
+			if err != nil {
+				return false, fmt.Errorf("slogproto: level rule %d: %w", i, err)
+			}
+			if ok {
+				r.Level = rules[i].Level
+				break
+			}
+		}
+		return true, nil
+	}, nil
+}
@@ -0,0 +1,52 @@
+//go:build !slogproto_nocel && !tinygo
+
+package slogproto_test
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestLevelRulesStage(t *testing.T) {
+	stage, err := slogproto.LevelRulesStage(
+		slogproto.LevelRule{Match: `level == "ERROR" && msg == "upstream timeout"`, Level: slog.LevelWarn},
+		slogproto.LevelRule{Match: `level == "WARN" && attrs.tenant == "acme"`, Level: slog.LevelError},
+		slogproto.LevelRule{Match: `msg == "upstream timeout"`, Level: slog.LevelDebug},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mem := slogproto.NewMemoryHandler(10, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := slog.New(slogproto.NewPipeline(stage).Handler(mem)).With("tenant", "acme")
+
+	logger.Error("upstream timeout")
+	logger.Warn("quota exceeded")
+	logger.Info("upstream timeout")
+	logger.Debug("ignored")
+
+	records, err := mem.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, r := range records {
+		got = append(got, fmt.Sprintf("%s %s", r.Level, r.Message))
+	}
+
+	// The first rule matching a record applies, so the demoted error isn't
+	// promoted again by the second rule, and the info record demoted to
+	// debug isn't enabled.
+	want := "[WARN upstream timeout ERROR quota exceeded]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("expected %s, got %v", want, got)
+	}
+
+	if _, err := slogproto.LevelRulesStage(slogproto.LevelRule{Match: `msg`}); err == nil {
+		t.Fatal("expected an error for an invalid match")
+	}
+}
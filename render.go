@@ -0,0 +1,114 @@
+package slogproto
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RenderOptions control how times, durations and integers are rendered when records
+// are exported as text, such as JSON or CSV, since the systems consuming
+// exports are often particular about them. The zero value leaves them as
+// the handler writing them would.
+type RenderOptions struct {
+	// TimeFormat is the format of times, including the time of the record:
+	// "rfc3339", "rfc3339nano", "unix", "unixmilli", "unixmicro" or
+	// "unixnano", for whole numbers of seconds and fractions of seconds
+	// since the Unix epoch, or else a layout accepted by [time.Time.Format].
+	TimeFormat string
+
+	// DurationFormat is the format of durations: "ns", "us", "ms" or "s",
+	// for numbers of the unit, which are fractional for all but "ns", or
+	// "human", for strings like "1m30.5s".
+	DurationFormat string
+
+	// LargeUintStrings renders unsigned integers above math.MaxInt64 as
+	// decimal strings, since many consumers of JSON parse numbers as
+	// int64 or float64, which can't hold them.
+	LargeUintStrings bool
+}
+
+// durationUnits are the units of numeric duration formats.
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// Validate returns an error if the duration format is unknown. Any time
+// format is valid, since unknown formats are layouts.
+func (o RenderOptions) Validate() error {
+	if _, ok := durationUnits[o.DurationFormat]; !ok && o.DurationFormat != "" && o.DurationFormat != "human" {
+		return fmt.Errorf("slogproto: unknown duration format %q: expected ns, us, ms, s or human", o.DurationFormat)
+	}
+	return nil
+}
+
+// TimeValue returns the time rendered in the time format.
+func (o RenderOptions) TimeValue(t time.Time) slog.Value {
+	switch strings.ToLower(o.TimeFormat) {
+	case "":
+		return slog.TimeValue(t)
+	case "rfc3339":
+		return slog.StringValue(t.Format(time.RFC3339))
+	case "rfc3339nano":
+		return slog.StringValue(t.Format(time.RFC3339Nano))
+	case "unix":
+		return slog.Int64Value(t.Unix())
+	case "unixmilli":
+		return slog.Int64Value(t.UnixMilli())
+	case "unixmicro":
+		return slog.Int64Value(t.UnixMicro())
+	case "unixnano":
+		return slog.Int64Value(t.UnixNano())
+	default:
+		return slog.StringValue(t.Format(o.TimeFormat))
+	}
+}
+
+// DurationValue returns the duration rendered in the duration format, or
+// as it is if the format is unknown.
+func (o RenderOptions) DurationValue(d time.Duration) slog.Value {
+	switch o.DurationFormat {
+	case "human":
+		return slog.StringValue(d.String())
+	case "ns":
+		return slog.Int64Value(d.Nanoseconds())
+	}
+	if unit, ok := durationUnits[o.DurationFormat]; ok {
+		return slog.Float64Value(float64(d) / float64(unit))
+	}
+	return slog.DurationValue(d)
+}
+
+// ReplaceAttr returns a function for [slog.HandlerOptions.ReplaceAttr]
+// rendering the times, durations and unsigned integers of records,
+// including those in groups, after calling next, if not nil.
+//
+//	opts := slogproto.RenderOptions{TimeFormat: "unixmilli", DurationFormat: "ms"}
+//	h := slogproto.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+//		ReplaceAttr: opts.ReplaceAttr(nil),
+//	})
+func (o RenderOptions) ReplaceAttr(next func(groups []string, a slog.Attr) slog.Attr) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if next != nil {
+			a = next(groups, a)
+		}
+
+		switch a.Value.Kind() {
+		case slog.KindTime:
+			a.Value = o.TimeValue(a.Value.Time())
+		case slog.KindDuration:
+			a.Value = o.DurationValue(a.Value.Duration())
+		case slog.KindUint64:
+			if u := a.Value.Uint64(); o.LargeUintStrings && u > math.MaxInt64 {
+				a.Value = slog.StringValue(strconv.FormatUint(u, 10))
+			}
+		}
+		return a
+	}
+}
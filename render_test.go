@@ -0,0 +1,86 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRenderOptions(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600_000_000, time.UTC)
+
+	r := slog.NewRecord(at, slog.LevelInfo, "done", 0)
+	r.AddAttrs(
+		slog.Duration("elapsed", 1500*time.Millisecond),
+		slog.Group("req", slog.Time("start", at), slog.Duration("wait", 90*time.Second)),
+	)
+
+	for _, tc := range []struct {
+		opts slogproto.RenderOptions
+		want string
+	}{
+		{
+			opts: slogproto.RenderOptions{},
+			want: `{"time":"2024-01-02T03:04:05.6Z","level":"INFO","msg":"done","elapsed":1500000000,"req":{"start":"2024-01-02T03:04:05.6Z","wait":90000000000}}`,
+		},
+		{
+			opts: slogproto.RenderOptions{TimeFormat: "unixmilli", DurationFormat: "ms"},
+			want: `{"time":1704164645600,"level":"INFO","msg":"done","elapsed":1500,"req":{"start":1704164645600,"wait":90000}}`,
+		},
+		{
+			opts: slogproto.RenderOptions{TimeFormat: "unix", DurationFormat: "s"},
+			want: `{"time":1704164645,"level":"INFO","msg":"done","elapsed":1.5,"req":{"start":1704164645,"wait":90}}`,
+		},
+		{
+			opts: slogproto.RenderOptions{TimeFormat: "2006-01-02 15:04:05", DurationFormat: "human"},
+			want: `{"time":"2024-01-02 03:04:05","level":"INFO","msg":"done","elapsed":"1.5s","req":{"start":"2024-01-02 03:04:05","wait":"1m30s"}}`,
+		},
+	} {
+		t.Run(tc.opts.TimeFormat+"/"+tc.opts.DurationFormat, func(t *testing.T) {
+			if err := tc.opts.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: tc.opts.ReplaceAttr(nil)})
+			if err := h.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.TrimSpace(buf.String()); got != tc.want {
+				t.Fatalf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	if err := (slogproto.RenderOptions{DurationFormat: "minutes"}).Validate(); err == nil {
+		t.Fatal("expected an error for an unknown duration format")
+	}
+}
+
+func TestRenderOptions_largeUintStrings(t *testing.T) {
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "ids", 0)
+	r.AddAttrs(
+		slog.Uint64("small", math.MaxInt64),
+		slog.Group("trace", slog.Uint64("id", math.MaxUint64)),
+	)
+
+	opts := slogproto.RenderOptions{LargeUintStrings: true}
+
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: opts.ReplaceAttr(nil)})
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"level":"INFO","msg":"ids","small":9223372036854775807,"trace":{"id":"18446744073709551615"}}`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
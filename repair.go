@@ -0,0 +1,218 @@
+package slogproto
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ByteRange is a range of bytes in a stream.
+type ByteRange struct {
+	// Offset is the offset of the first byte of the range.
+	Offset int64
+
+	// Length is the number of bytes in the range.
+	Length int64
+}
+
+// RepairReport describes the result of [Repair].
+type RepairReport struct {
+	// Records is the number of records recovered.
+	Records int64
+
+	// Skipped contains the ranges of bytes that could not be decoded as
+	// records, in the order they appear in the input.
+	Skipped []ByteRange
+}
+
+// SkippedBytes returns the total number of bytes skipped.
+func (r *RepairReport) SkippedBytes() int64 {
+	var n int64
+	for _, s := range r.Skipped {
+		n += s.Length
+	}
+	return n
+}
+
+// Repair scans a possibly corrupted stream of protobuf encoded slog records
+// from src and writes every record that can be decoded to dst, unchanged,
+// skipping over corrupted bytes until the start of the next decodable
+// record is found.
+//
+// Since frames have no checksum, a frame is only considered decodable if
+// it unmarshals as a record without unknown fields and with a known level,
+// so it is possible, although unlikely, for corrupted bytes to be recovered
+// as a record.
+//
+// The maximum record size of the given read options (see
+// [WithMaxRecordSize]) is used to bound how many bytes are buffered.
+func Repair(ctx context.Context, dst io.Writer, src io.Reader, opts ...ReadOption) (*RepairReport, error) {
+	cfg := readConfig{
+		maxRecordSize: DefaultMaxRecordSize,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var (
+		br      = bufio.NewReaderSize(src, cfg.maxRecordSize+8)
+		report  = &RepairReport{}
+		offset  int64
+		pbr     = &Record{}
+		framing = Framing_FRAMING_UINT32_LE
+	)
+
+	// skip discards a byte, extending the last skipped range if it ends at
+	// the current offset.
+	skip := func() error {
+		if _, err := br.Discard(1); err != nil {
+			return err
+		}
+
+		if n := len(report.Skipped); n > 0 && report.Skipped[n-1].Offset+report.Skipped[n-1].Length == offset {
+			report.Skipped[n-1].Length++
+		} else {
+			report.Skipped = append(report.Skipped, ByteRange{Offset: offset, Length: 1})
+		}
+
+		offset++
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		header, err := br.Peek(4)
+		if len(header) == 0 && errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return report, fmt.Errorf("error reading input at offset %d: %w", offset, err)
+		}
+
+		// A trailing partial size prefix can't be a record.
+		if len(header) < 4 {
+			if err := skip(); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		// Keep stream headers of supported schema versions.
+		if string(header) == StreamMagic {
+			if n, f := peekStreamHeader(br, framing); n > 0 {
+				if _, err := io.CopyN(dst, br, int64(n)); err != nil {
+					return report, fmt.Errorf("error writing stream header: %w", err)
+				}
+				offset += int64(n)
+				framing = f
+				continue
+			}
+		}
+
+		// Drop stream trailers, which won't match the repaired stream.
+		if string(header) == TrailerMagic {
+			b, _ := br.Peek(len(TrailerMagic) + 8 + maxStreamTrailerSize)
+			if n, _, err := readStreamTrailer(b); n > 0 && err == nil {
+				br.Discard(n)
+				offset += int64(n)
+				continue
+			}
+		}
+
+		n := frameSizeLen(framing)
+		prefix, _ := br.Peek(n)
+
+		var size uint64
+		if len(prefix) == n {
+			size = frameSize(framing, prefix)
+		}
+		if len(prefix) < n || size > uint64(cfg.maxRecordSize) {
+			if err := skip(); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		frame, err := br.Peek(int(size) + n)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return report, fmt.Errorf("error reading input at offset %d: %w", offset, err)
+		}
+
+		if len(frame) < int(size)+n || !validFrame(frame[n:], pbr) {
+			if err := skip(); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		if _, err := dst.Write(frame); err != nil {
+			return report, fmt.Errorf("error writing record: %w", err)
+		}
+
+		if _, err := br.Discard(len(frame)); err != nil {
+			return report, err
+		}
+
+		offset += int64(len(frame))
+		report.Records++
+	}
+}
+
+// peekStreamHeader returns the size of the stream header at the start of
+// the buffered reader, or zero if it isn't a valid header of a supported
+// schema version and framing, and the framing it declares, or the given
+// framing if it isn't valid.
+func peekStreamHeader(br *bufio.Reader, framing Framing) (int, Framing) {
+	b, _ := br.Peek(len(StreamMagic) + 4)
+	if len(b) < len(StreamMagic)+4 {
+		return 0, framing
+	}
+
+	size := int(binary.LittleEndian.Uint32(b[len(StreamMagic):]))
+	if size > maxStreamHeaderSize {
+		return 0, framing
+	}
+
+	b, _ = br.Peek(len(StreamMagic) + 4 + size)
+	n, f, err := readStreamHeader(b, framing)
+	if err != nil {
+		return 0, framing
+	}
+	return n, f
+}
+
+// validFrame reports whether the encoded record unmarshals without unknown
+// fields and with a known level, using pbr as scratch space.
+func validFrame(b []byte, pbr *Record) bool {
+	pbr.Reset()
+
+	if err := proto.Unmarshal(b, pbr); err != nil {
+		return false
+	}
+
+	if len(pbr.ProtoReflect().GetUnknown()) > 0 {
+		return false
+	}
+
+	switch pbr.Level {
+	case Level_LEVEL_INFO, Level_LEVEL_WARN, Level_LEVEL_ERROR, Level_LEVEL_DEBUG:
+	default:
+		return false
+	}
+
+	for _, v := range pbr.Attrs {
+		if v == nil || v.Kind == nil || len(v.ProtoReflect().GetUnknown()) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,85 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRepair(t *testing.T) {
+	var frames [][]byte
+
+	for i := 0; i < 5; i++ {
+		var buf bytes.Buffer
+		slog.New(slogproto.NewHandler(&buf, nil)).Info("this is a test", "test", i)
+		frames = append(frames, buf.Bytes())
+	}
+
+	// Corrupt the stream: garbage before the first record, a record with
+	// a truncated body in the middle, and a partial record at the end.
+	var corrupted bytes.Buffer
+	corrupted.Write([]byte{0xde, 0xad, 0xbe, 0xef, 0x00})
+	corrupted.Write(frames[0])
+	corrupted.Write(frames[1])
+	corrupted.Write(frames[2][:len(frames[2])/2])
+	corrupted.Write(frames[3])
+	corrupted.Write(frames[4][:len(frames[4])-1])
+
+	var repaired bytes.Buffer
+
+	report, err := slogproto.Repair(context.Background(), &repaired, &corrupted)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if report.Records != 3 {
+		t.Fatalf("expected 3 records to be recovered, but got: %d", report.Records)
+	}
+
+	if len(report.Skipped) != 3 {
+		t.Fatalf("expected 3 skipped ranges, but got: %v", report.Skipped)
+	}
+
+	if report.Skipped[0].Offset != 0 || report.Skipped[0].Length != 5 {
+		t.Fatalf("unexpected first skipped range: %+v", report.Skipped[0])
+	}
+
+	var want []int64
+	err = slogproto.Read(context.Background(), &repaired, func(r *slog.Record) bool {
+		r.Attrs(func(a slog.Attr) bool {
+			want = append(want, a.Value.Int64())
+			return true
+		})
+		return true
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if len(want) != 3 || want[0] != 0 || want[1] != 1 || want[2] != 3 {
+		t.Fatalf("unexpected recovered records: %v", want)
+	}
+}
+
+func TestRepair_streamHeader(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader())).Info("this is a test")
+
+	var repaired bytes.Buffer
+
+	report, err := slogproto.Repair(context.Background(), &repaired, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if report.Records != 1 || len(report.Skipped) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if !bytes.Equal(repaired.Bytes(), buf.Bytes()) {
+		t.Fatalf("expected the stream to be unchanged")
+	}
+}
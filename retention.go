@@ -0,0 +1,301 @@
+package slogproto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy bounds the age and total size of the log files in a
+// directory, such as the segments of rotated logs.
+type RetentionPolicy struct {
+	// MaxAge is the maximum age of a file, based on its modification time.
+	// Older files are removed. Zero means no limit.
+	MaxAge time.Duration
+
+	// MaxTotalSize is the maximum total size, in bytes, of the files. The
+	// oldest files are removed until the total size is within the limit.
+	// Zero means no limit.
+	MaxTotalSize int64
+
+	// Pattern is a glob pattern, as with [filepath.Match], selecting which
+	// files in the directory the policy applies to, such as "*.log". If
+	// empty, it applies to all regular files in the directory.
+	Pattern string
+
+	// Tiers downsample files as they age, before they expire, keeping
+	// fewer of their records, such as only errors once a file is a month
+	// old. See [RetentionPolicy.Tier].
+	Tiers []RetentionTier
+}
+
+// RetentionTier selects the records kept in files from a given age, so
+// that noisy records can be dropped long before the records that matter.
+type RetentionTier struct {
+	// MinAge is the age, based on the modification time of a file, from
+	// which the tier applies.
+	MinAge time.Duration
+
+	// Level is the minimum level of the records kept.
+	Level slog.Level
+
+	// Filter, if set, keeps the records it selects whatever their level,
+	// such as records matching a filter expression evaluated with
+	// [EvalFilter].
+	Filter func(r *slog.Record) (bool, error)
+}
+
+// Tier returns the tier of the policy applying to a file with the given
+// modification time at the given time: the tier with the greatest
+// MinAge that the file is older than. It returns false if no tier
+// applies.
+func (p RetentionPolicy) Tier(modTime, now time.Time) (RetentionTier, bool) {
+	var (
+		tier RetentionTier
+		ok   bool
+	)
+	age := now.Sub(modTime)
+	for _, t := range p.Tiers {
+		if age > t.MinAge && (!ok || t.MinAge > tier.MinAge) {
+			tier, ok = t, true
+		}
+	}
+	return tier, ok
+}
+
+// Expired returns the paths of the files in the directory that should be
+// removed according to the policy at the given time, from oldest to
+// newest, without removing them.
+func (p RetentionPolicy) Expired(dir string, now time.Time) ([]string, error) {
+	pattern := p.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var (
+		files     []file
+		totalSize int64
+	)
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		matched, err := filepath.Match(pattern, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if !matched {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("error reading file info: %w", err)
+		}
+
+		files = append(files, file{
+			path:    filepath.Join(dir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+		totalSize += info.Size()
+	}
+
+	// Oldest files first.
+	slices.SortFunc(files, func(a, b file) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	var expired []string
+	for _, f := range files {
+		tooOld := p.MaxAge > 0 && now.Sub(f.modTime) > p.MaxAge
+		tooLarge := p.MaxTotalSize > 0 && totalSize > p.MaxTotalSize
+
+		if !tooOld && !tooLarge {
+			continue
+		}
+
+		expired = append(expired, f.path)
+		totalSize -= f.size
+	}
+
+	return expired, nil
+}
+
+// Prune removes the files in the directory that have expired according to
+// the policy, returning the paths of the removed files.
+func (p RetentionPolicy) Prune(dir string) ([]string, error) {
+	expired, err := p.Expired(dir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]string, 0, len(expired))
+	for _, path := range expired {
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("error removing file: %w", err)
+		}
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
+
+// RetentionKey is the key of the attribute holding the retention class of
+// a record, as added by [Retention] and [WithRetention].
+const RetentionKey = "slogproto.retention"
+
+// Retention returns an attribute stamping a record with a retention class,
+// such as "30d" or "audit-7y", which tells [PruneRecords] how long to keep
+// it, so that records with different retention requirements can be
+// written to the same stream.
+//
+// The age of a class is its last dash-separated part, which is a duration
+// as accepted by [ParseRetention].
+func Retention(class string) slog.Attr {
+	return slog.String(RetentionKey, class)
+}
+
+// WithRetention configures the handler to stamp every record with the
+// retention class, as described by [Retention]. Records logged without a
+// group can override it with their own Retention attribute.
+func WithRetention(class string) HandlerOption {
+	return func(h *Handler) {
+		h.goas = append(h.goas, groupOrAttrs{attrs: []slog.Attr{Retention(class)}})
+	}
+}
+
+// ParseRetention returns the age of a retention class, which is its last
+// dash-separated part, such as "7y" in "audit-7y". In addition to the
+// units supported by [time.ParseDuration], ages may be given in days
+// ("d"), weeks ("w") or years of 365 days ("y").
+func ParseRetention(class string) (time.Duration, error) {
+	age := class
+	if i := strings.LastIndexByte(class, '-'); i >= 0 {
+		age = class[i+1:]
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(age, suffix); ok {
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil || f < 0 {
+				return 0, fmt.Errorf("slogproto: invalid retention class %q", class)
+			}
+			return time.Duration(f * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("slogproto: invalid retention class %q", class)
+	}
+	return d, nil
+}
+
+// PruneRecords reads protobuf encoded slog records from src, as with Read,
+// and writes those that haven't expired at the given time, according to
+// their retention class, to dst. Records without a retention class, or
+// without a time, are kept, as are records with an invalid class, so that
+// a typo never loses records. It returns the number of records kept and
+// removed.
+//
+// Kept records are copied exactly as they were encoded in src, in its
+// framing, declared by a stream header written before them.
+func PruneRecords(ctx context.Context, dst io.Writer, src io.Reader, now time.Time, opts ...ReadOption) (kept, removed int64, err error) {
+	fw := newFrameWriter(dst)
+
+	var writeErr error
+	err = readFrames(ctx, src, func(frame []byte, r *slog.Record) bool {
+		if recordExpired(r, now) {
+			removed++
+			return true
+		}
+
+		if writeErr = fw.write(frame); writeErr != nil {
+			return false
+		}
+		kept++
+		return true
+	}, append(opts, withFramingChanges(fw.setFraming))...)
+	if err == nil && writeErr != nil {
+		err = fmt.Errorf("error writing record: %w", writeErr)
+	}
+	return kept, removed, err
+}
+
+// DownsampleRecords reads protobuf encoded slog records from src, as with
+// Read, and writes those kept by the tier to dst: records at or above its
+// level, or matching its filter. It returns the number of records kept
+// and removed.
+//
+// Kept records are copied exactly as they were encoded in src, in its
+// framing, as with PruneRecords, so downsampling a stream again with the
+// same tier keeps every record.
+func DownsampleRecords(ctx context.Context, dst io.Writer, src io.Reader, tier RetentionTier, opts ...ReadOption) (kept, removed int64, err error) {
+	fw := newFrameWriter(dst)
+
+	var fnErr error
+	err = readFrames(ctx, src, func(frame []byte, r *slog.Record) bool {
+		keep := r.Level >= tier.Level
+		if !keep && tier.Filter != nil {
+			if keep, fnErr = tier.Filter(r); fnErr != nil {
+				fnErr = fmt.Errorf("error filtering record: %w", fnErr)
+				return false
+			}
+		}
+		if !keep {
+			removed++
+			return true
+		}
+
+		if fnErr = fw.write(frame); fnErr != nil {
+			fnErr = fmt.Errorf("error writing record: %w", fnErr)
+			return false
+		}
+		kept++
+		return true
+	}, append(opts, withFramingChanges(fw.setFraming))...)
+	if err == nil {
+		err = fnErr
+	}
+	return kept, removed, err
+}
+
+// recordExpired reports whether the record is older than the age of its
+// retention class.
+func recordExpired(r *slog.Record, now time.Time) bool {
+	if r.Time.IsZero() {
+		return false
+	}
+
+	class, ok := GetAttr(r, RetentionKey)
+	if !ok {
+		return false
+	}
+
+	age, err := ParseRetention(class.String())
+	if err != nil {
+		return false
+	}
+	return now.Sub(r.Time) > age
+}
@@ -0,0 +1,228 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	now := time.Now()
+
+	// Five 100 byte files, one per day, "0.log" being the oldest.
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, string(rune('0'+i))+".log")
+
+		if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+
+		modTime := now.Add(-time.Duration(5-i) * 24 * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("expected no error, but got: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), nil, 0644); err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		policy slogproto.RetentionPolicy
+		want   []string
+	}{
+		{
+			name:   "max age",
+			policy: slogproto.RetentionPolicy{MaxAge: 3*24*time.Hour + time.Hour, Pattern: "*.log"},
+			want:   []string{"0.log", "1.log"},
+		},
+		{
+			name:   "max total size",
+			policy: slogproto.RetentionPolicy{MaxTotalSize: 250, Pattern: "*.log"},
+			want:   []string{"0.log", "1.log", "2.log"},
+		},
+		{
+			name:   "both",
+			policy: slogproto.RetentionPolicy{MaxAge: 36 * time.Hour, MaxTotalSize: 450, Pattern: "*.log"},
+			want:   []string{"0.log", "1.log", "2.log", "3.log"},
+		},
+		{
+			name:   "no limits",
+			policy: slogproto.RetentionPolicy{},
+			want:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expired, err := test.policy.Expired(dir, now)
+			if err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+
+			var got []string
+			for _, path := range expired {
+				got = append(got, filepath.Base(path))
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Fatalf("expected %v, but got: %v", test.want, got)
+			}
+		})
+	}
+
+	removed, err := slogproto.RetentionPolicy{MaxTotalSize: 250, Pattern: "*.log"}.Prune(dir)
+	if err != nil {
+		t.Fatalf("expected no error, but got: %v", err)
+	}
+
+	if len(removed) != 3 {
+		t.Fatalf("expected 3 files to be removed, but got: %v", removed)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 files to remain, but got: %d", len(entries))
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":      30 * 24 * time.Hour,
+		"audit-7y": 7 * 365 * 24 * time.Hour,
+		"debug-2w": 14 * 24 * time.Hour,
+		"12h":      12 * time.Hour,
+	}
+	for class, want := range tests {
+		got, err := slogproto.ParseRetention(class)
+		if err != nil {
+			t.Fatalf("%s: %v", class, err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", class, want, got)
+		}
+	}
+
+	for _, class := range []string{"", "forever", "audit-xy", "audit-"} {
+		if _, err := slogproto.ParseRetention(class); err == nil {
+			t.Errorf("%q: expected an error", class)
+		}
+	}
+}
+
+func TestPruneRecords(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-40 * 24 * time.Hour)
+
+	var src bytes.Buffer
+	h := slogproto.NewHandler(&src, nil, slogproto.WithRetention("30d"))
+	for _, test := range []struct {
+		msg   string
+		time  time.Time
+		class string
+	}{
+		{"expired", old, ""},
+		{"audit", old, "audit-7y"},
+		{"recent", now, ""},
+		{"invalid", old, "forever"},
+		{"expired debug", old, "debug-1d"},
+	} {
+		r := slog.NewRecord(test.time, slog.LevelInfo, test.msg, 0)
+		if test.class != "" {
+			r.AddAttrs(slogproto.Retention(test.class))
+		}
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dst bytes.Buffer
+	kept, removed, err := slogproto.PruneRecords(context.Background(), &dst, &src, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept != 3 || removed != 2 {
+		t.Fatalf("expected 3 records kept and 2 removed, got %d and %d", kept, removed)
+	}
+
+	if got := readMessages(t, &dst); got != "audit,recent,invalid" {
+		t.Fatalf("unexpected records %q", got)
+	}
+}
+
+func TestRetentionPolicy_Tier(t *testing.T) {
+	now := time.Now()
+	policy := slogproto.RetentionPolicy{
+		Tiers: []slogproto.RetentionTier{
+			{MinAge: 365 * 24 * time.Hour, Level: slog.LevelError + 4},
+			{MinAge: 7 * 24 * time.Hour, Level: slog.LevelInfo},
+			{MinAge: 30 * 24 * time.Hour, Level: slog.LevelError},
+		},
+	}
+
+	for age, want := range map[time.Duration]slog.Level{
+		8 * 24 * time.Hour:   slog.LevelInfo,
+		40 * 24 * time.Hour:  slog.LevelError,
+		400 * 24 * time.Hour: slog.LevelError + 4,
+	} {
+		tier, ok := policy.Tier(now.Add(-age), now)
+		if !ok {
+			t.Fatalf("%s: expected a tier", age)
+		}
+		if tier.Level != want {
+			t.Errorf("%s: expected level %s, got %s", age, want, tier.Level)
+		}
+	}
+
+	if tier, ok := policy.Tier(now.Add(-time.Hour), now); ok {
+		t.Fatalf("expected no tier, got %+v", tier)
+	}
+}
+
+func TestDownsampleRecords(t *testing.T) {
+	var src bytes.Buffer
+	h := slogproto.NewHandler(&src, &slog.HandlerOptions{Level: slog.LevelDebug})
+	for _, r := range []slog.Record{
+		slog.NewRecord(time.Now(), slog.LevelDebug, "debug", 0),
+		slog.NewRecord(time.Now(), slog.LevelInfo, "info", 0),
+		slog.NewRecord(time.Now(), slog.LevelWarn, "warn", 0),
+		slog.NewRecord(time.Now(), slog.LevelError, "error", 0),
+		slog.NewRecord(time.Now(), slog.LevelInfo, "audit", 0),
+	} {
+		if r.Message == "audit" {
+			r.AddAttrs(slog.Bool("audit", true))
+		}
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit := func(r *slog.Record) (bool, error) {
+		_, ok := slogproto.GetAttr(r, "audit")
+		return ok, nil
+	}
+
+	var dst bytes.Buffer
+	tier := slogproto.RetentionTier{Level: slog.LevelWarn, Filter: audit}
+	kept, removed, err := slogproto.DownsampleRecords(context.Background(), &dst, &src, tier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept != 3 || removed != 2 {
+		t.Fatalf("expected 3 records kept and 2 removed, got %d and %d", kept, removed)
+	}
+
+	if got := readMessages(t, &dst); got != "warn,error,audit" {
+		t.Fatalf("unexpected records %q", got)
+	}
+}
@@ -0,0 +1,298 @@
+//go:build !slogproto_nocel && !tinygo
+
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Route declares the records written to a sink by a [Router]: those
+// within its levels that match its filter expression.
+type Route struct {
+	// MinLevel and MaxLevel are the lowest and highest levels of the
+	// records written to the sink, which may be changed while the Router
+	// is in use, such as with a [slog.LevelVar]. Nil means no bound.
+	MinLevel slog.Leveler
+	MaxLevel slog.Leveler
+
+	// Match is a filter expression, as accepted by [CompileFilter],
+	// selecting the records written to the sink, if set. It is evaluated
+	// with the attributes added to the Router with WithAttrs and
+	// WithGroup too.
+	Match string
+
+	// Sink is where records are written: "stderr", "stdout", the path of a
+	// file, which is opened for appending with OpenFile, or the address
+	// of a network listener, such as "tcp://collector:9000",
+	// "udp://collector:9000" or "unix:///run/collector.sock".
+	Sink string
+
+	// Format is the format records are written in: "proto" for a stream
+	// of protobuf encoded records, written by a [Handler], or "json" or
+	// "text" for lines written by [slog.JSONHandler] or
+	// [slog.TextHandler]. By default, records are written to stderr and
+	// stdout as text, and to other sinks as protobuf.
+	Format string
+}
+
+// RouterConfig declares the routes of a [Router].
+type RouterConfig struct {
+	// Routes are the routes records are written to. Each record is
+	// written to every route it matches.
+	Routes []Route
+
+	// HandlerOptions are the options of the handlers writing to sinks,
+	// whose levels are ignored in favor of those of the routes.
+	HandlerOptions *slog.HandlerOptions
+
+	// Options configure the handlers writing protobuf encoded records.
+	Options []HandlerOption
+}
+
+// Router is a handler writing records to the sinks of routes chosen by
+// their level and filter expressions, as declared by a [RouterConfig], so
+// that common topologies, such as errors to a file and everything to
+// stderr, don't need to be wired by hand.
+//
+// # Example
+//
+//	router, err := slogproto.NewRouter(slogproto.RouterConfig{
+//		Routes: []slogproto.Route{
+//			{MaxLevel: slog.LevelInfo, Sink: "stderr"},
+//			{MinLevel: slog.LevelWarn, Sink: "/var/log/app/errors.log"},
+//			{Match: `has(attrs.audit)`, Sink: "tcp://collector:9000"},
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer router.Close()
+//
+//	logger := slog.New(router)
+type Router struct {
+	routes []*route
+	goas   []groupOrAttrs
+
+	// sinks are the writers opened for the routes, closed by Close.
+	sinks []*routeSink
+}
+
+// route is a route of a Router and the handler of its sink.
+type route struct {
+	min, max slog.Leveler
+	match    cel.Program
+	h        slog.Handler
+}
+
+// accepts reports whether the level is within the levels of the route.
+func (r *route) accepts(level slog.Level) bool {
+	return (r.min == nil || level >= r.min.Level()) && (r.max == nil || level <= r.max.Level())
+}
+
+// routeSink is the writer of a sink opened by a Router, and its Handler,
+// if it writes protobuf encoded records.
+type routeSink struct {
+	w io.Writer
+	h *Handler
+}
+
+// NewRouter returns a Router writing to the sinks of the routes, opening
+// them, which must be closed with Close. An error is returned if a route
+// is invalid or its sink can't be opened.
+func NewRouter(cfg RouterConfig) (*Router, error) {
+	opts := slog.HandlerOptions{}
+	if cfg.HandlerOptions != nil {
+		opts = *cfg.HandlerOptions
+	}
+	opts.Level = slog.Level(math.MinInt)
+
+	router := &Router{}
+	for i, rc := range cfg.Routes {
+		r := &route{min: rc.MinLevel, max: rc.MaxLevel}
+
+		if rc.Match != "" {
+			prog, err := CompileFilter(rc.Match)
+			if err != nil {
+				router.Close()
+				return nil, fmt.Errorf("slogproto: route %d: invalid match: %w", i, err)
+			}
+			r.match = prog
+		}
+
+		h, err := router.openSink(rc, &opts, cfg.Options)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("slogproto: route %d: %w", i, err)
+		}
+		r.h = h
+
+		router.routes = append(router.routes, r)
+	}
+	return router, nil
+}
+
+// openSink opens the sink of the route, and returns the handler writing
+// to it in the route's format.
+func (router *Router) openSink(rc Route, opts *slog.HandlerOptions, options []HandlerOption) (slog.Handler, error) {
+	var (
+		w      io.Writer
+		format = "proto"
+	)
+	switch {
+	case rc.Sink == "stderr":
+		w, format = os.Stderr, "text"
+	case rc.Sink == "stdout":
+		w, format = os.Stdout, "text"
+	case strings.Contains(rc.Sink, "://"):
+		network, addr, _ := strings.Cut(rc.Sink, "://")
+		switch network {
+		case "tcp", "udp", "unix":
+		default:
+			return nil, fmt.Errorf("unsupported network %q of sink %q", network, rc.Sink)
+		}
+		conn, err := net.Dial(network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to sink: %w", err)
+		}
+		w = conn
+	case rc.Sink != "":
+		f, err := OpenFile(rc.Sink)
+		if err != nil {
+			return nil, fmt.Errorf("error opening sink: %w", err)
+		}
+		w = f
+	default:
+		return nil, errors.New("no sink")
+	}
+
+	if rc.Format != "" {
+		format = rc.Format
+	}
+
+	sink := &routeSink{w: w}
+	router.sinks = append(router.sinks, sink)
+
+	var h slog.Handler
+	switch format {
+	case "proto":
+		sink.h = NewHandler(w, opts, options...)
+		h = sink.h
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown format %q: expected proto, json or text", format)
+	}
+	return h, nil
+}
+
+// Enabled returns true if a route accepts records of the level.
+func (router *Router) Enabled(_ context.Context, level slog.Level) bool {
+	for _, r := range router.routes {
+		if r.accepts(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle writes the record to the sink of every route it matches,
+// returning the errors of each.
+func (router *Router) Handle(ctx context.Context, r slog.Record) error {
+	var (
+		matchRecord *slog.Record
+		errs        []error
+	)
+	for i, rt := range router.routes {
+		if !rt.accepts(r.Level) {
+			continue
+		}
+
+		if rt.match != nil {
+			if matchRecord == nil {
+				mr := router.matchRecord(r)
+				matchRecord = &mr
+			}
+			ok, err := EvalFilter(rt.match, matchRecord)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("slogproto: route %d: %w", i, err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		errs = append(errs, rt.h.Handle(ctx, r.Clone()))
+	}
+	return errors.Join(errs...)
+}
+
+// matchRecord returns the record with the attributes and groups added to
+// the router, which filter expressions are evaluated against.
+func (router *Router) matchRecord(r slog.Record) slog.Record {
+	return recordWithGroups(r, router.goas)
+}
+
+// WithAttrs returns a new handler with the given attributes added to the
+// handlers of every route.
+func (router *Router) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return router
+	}
+	return router.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a new handler with the given group added to the
+// handlers of every route.
+func (router *Router) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return router
+	}
+	return router.with(groupOrAttrs{group: name})
+}
+
+// with returns a copy of the router with the group or attributes added.
+func (router *Router) with(goa groupOrAttrs) *Router {
+	r2 := &Router{
+		goas:  append(router.goas[:len(router.goas):len(router.goas)], goa),
+		sinks: router.sinks,
+	}
+	for _, rt := range router.routes {
+		rt2 := *rt
+		if goa.group != "" {
+			rt2.h = rt.h.WithGroup(goa.group)
+		} else {
+			rt2.h = rt.h.WithAttrs(goa.attrs)
+		}
+		r2.routes = append(r2.routes, &rt2)
+	}
+	return r2
+}
+
+// Close shuts down the handlers writing protobuf encoded records, and
+// closes the files and connections opened for the routes, but not stderr
+// or stdout. It applies to every handler derived from the same Router.
+func (router *Router) Close() error {
+	var errs []error
+	for _, s := range router.sinks {
+		if s.h != nil {
+			errs = append(errs, s.h.Shutdown(context.Background()))
+		}
+		if c, ok := s.w.(io.Closer); ok && s.w != os.Stderr && s.w != os.Stdout {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,107 @@
+//go:build !slogproto_nocel && !tinygo
+
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestRouter(t *testing.T) {
+	dir := t.TempDir()
+	info := filepath.Join(dir, "info.log")
+	errs := filepath.Join(dir, "errors.log")
+	audit := filepath.Join(dir, "audit.log")
+
+	router, err := slogproto.NewRouter(slogproto.RouterConfig{
+		Routes: []slogproto.Route{
+			{MaxLevel: slog.LevelInfo, Sink: info},
+			{MinLevel: slog.LevelWarn, Sink: errs},
+			{Match: `attrs.user == "admin"`, Sink: audit},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !router.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("expected the router to be enabled for every level")
+	}
+
+	logger := slog.New(router)
+	logger.Info("started")
+	logger.Error("failed")
+
+	requestLogger := logger.With("user", "admin").WithGroup("request")
+	requestLogger.Debug("login")
+	requestLogger.Warn("denied")
+
+	if err := router.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		info:  "started,login",
+		errs:  "failed,denied",
+		audit: "login,denied",
+	} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := readMessages(t, bytes.NewBuffer(data)); got != want {
+			t.Fatalf("expected %s to have %q, got %q", filepath.Base(name), want, got)
+		}
+	}
+}
+
+func TestRouter_levelVar(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+
+	var level slog.LevelVar
+	router, err := slogproto.NewRouter(slogproto.RouterConfig{
+		Routes: []slogproto.Route{{MinLevel: &level, Sink: name, Format: "json"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer router.Close()
+
+	logger := slog.New(router)
+	logger.Info("kept")
+	level.Set(slog.LevelWarn)
+	logger.Info("dropped")
+
+	if err := router.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"msg":"kept"`)) || bytes.Contains(data, []byte("dropped")) {
+		t.Fatalf("expected only the first record, got %s", data)
+	}
+}
+
+func TestNewRouter_invalid(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+
+	for _, route := range []slogproto.Route{
+		{},
+		{Sink: name, Format: "xml"},
+		{Sink: name, Match: `attrs.`},
+		{Sink: "http://collector"},
+	} {
+		if _, err := slogproto.NewRouter(slogproto.RouterConfig{Routes: []slogproto.Route{route}}); err == nil {
+			t.Fatalf("expected an error for route %+v", route)
+		}
+	}
+}
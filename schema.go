@@ -0,0 +1,94 @@
+package slogproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+
+	slogprotov1 "github.com/picatz/slogproto/v1"
+)
+
+// The protocol buffer types of the current schema version, defined in
+// the versioned package [github.com/picatz/slogproto/v1].
+type (
+	Record        = slogprotov1.Record
+	Value         = slogprotov1.Value
+	Value_Group   = slogprotov1.Value_Group
+	Level         = slogprotov1.Level
+	Framing       = slogprotov1.Framing
+	StreamHeader  = slogprotov1.StreamHeader
+	StreamTrailer = slogprotov1.StreamTrailer
+
+	Value_Bool     = slogprotov1.Value_Bool
+	Value_Float    = slogprotov1.Value_Float
+	Value_Int      = slogprotov1.Value_Int
+	Value_String_  = slogprotov1.Value_String_
+	Value_Time     = slogprotov1.Value_Time
+	Value_Duration = slogprotov1.Value_Duration
+	Value_Uint     = slogprotov1.Value_Uint
+	Value_Group_   = slogprotov1.Value_Group_
+	Value_Any      = slogprotov1.Value_Any
+)
+
+const (
+	Level_LEVEL_UNSPECIFIED = slogprotov1.Level_LEVEL_UNSPECIFIED
+	Level_LEVEL_INFO        = slogprotov1.Level_LEVEL_INFO
+	Level_LEVEL_WARN        = slogprotov1.Level_LEVEL_WARN
+	Level_LEVEL_ERROR       = slogprotov1.Level_LEVEL_ERROR
+	Level_LEVEL_DEBUG       = slogprotov1.Level_LEVEL_DEBUG
+
+	Framing_FRAMING_UINT32_LE = slogprotov1.Framing_FRAMING_UINT32_LE
+	Framing_FRAMING_UINT32_BE = slogprotov1.Framing_FRAMING_UINT32_BE
+	Framing_FRAMING_UINT64_LE = slogprotov1.Framing_FRAMING_UINT64_LE
+	Framing_FRAMING_UINT64_BE = slogprotov1.Framing_FRAMING_UINT64_BE
+)
+
+// SchemaVersion is the schema version of the records written by the
+// [Handler].
+//
+// Streams declare the version of their records with a stream header,
+// which is the StreamMagic bytes followed by a size prefixed
+// [StreamHeader]. Records that aren't preceded by a header use schema
+// version 1, so archives written before stream headers existed remain
+// readable. A stream header may appear anywhere in a stream, such as at
+// the start of each of several concatenated streams, and applies to the
+// records that follow it.
+const SchemaVersion = 1
+
+// StreamMagic marks a stream header. Read as a size prefix, it would
+// describe a record of over 1 GiB, which no stream is expected to hold.
+const StreamMagic = "SLPV"
+
+// ErrUnsupportedVersion is returned by [Read] for streams with a schema
+// version it doesn't support.
+var ErrUnsupportedVersion = errors.New("slogproto: unsupported schema version")
+
+// WriteStreamHeader writes a stream header declaring SchemaVersion to the
+// writer.
+func WriteStreamHeader(w io.Writer) error {
+	_, err := w.Write(streamHeader(Framing_FRAMING_UINT32_LE))
+	return err
+}
+
+// streamHeader returns the encoded stream header declaring SchemaVersion
+// and the framing of the records that follow it.
+func streamHeader(framing Framing) []byte {
+	b, _ := proto.Marshal(&StreamHeader{SchemaVersion: SchemaVersion, Framing: framing})
+
+	header := make([]byte, 0, len(StreamMagic)+4+len(b))
+	header = append(header, StreamMagic...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(b)))
+	return append(header, b...)
+}
+
+// checkSchemaVersion returns an error if records of the given schema
+// version cannot be read.
+func checkSchemaVersion(version uint32) error {
+	if version != SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package slogproto
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxShards is the default number of writers a [ShardHandler] keeps
+// open.
+const DefaultMaxShards = 64
+
+// ShardOptions are options for [NewShardHandler].
+type ShardOptions struct {
+	// Open opens the writer of a shard, named by the value of the
+	// attribute, or by an empty string for records without it. It is
+	// required.
+	//
+	// Shard names come from logged values, so they must be sanitized
+	// before being used in file names.
+	Open func(shard string) (io.WriteCloser, error)
+
+	// MaxOpen is the number of writers kept open, after which the least
+	// recently used writer is closed to open another. The default is
+	// DefaultMaxShards.
+	MaxOpen int
+
+	// HandlerOptions configures the handlers writing each shard.
+	HandlerOptions *slog.HandlerOptions
+
+	// Options configures the handlers writing each shard.
+	Options []HandlerOption
+}
+
+// ShardHandler is a slog.Handler that routes records to a [Handler] for
+// each value of an attribute, such as a tenant ID, so that a single
+// logger can write separate archives for each of them.
+//
+// Writers are opened when their shard first receives a record, and closed
+// when they are the least recently used once too many are open, or when
+// the ShardHandler is closed. A shard whose writer was closed is opened
+// again when it receives another record, once its previous writer has
+// been closed, so writers should append to existing files, as [OpenFile]
+// does. Opening and closing the writer of a shard doesn't hold up the
+// records of other shards.
+//
+// # Example
+//
+//	h := slogproto.NewShardHandler("tenant", &slogproto.ShardOptions{
+//		Open: func(tenant string) (io.WriteCloser, error) {
+//			if tenant == "" {
+//				tenant = "default"
+//			}
+//			return slogproto.OpenFile(filepath.Join(dir, url.PathEscape(tenant)+".log"))
+//		},
+//	})
+//	defer h.Close()
+//
+//	logger := slog.New(h)
+//	logger.Info("signed in", "tenant", "acme")
+type ShardHandler struct {
+	key    string
+	opts   ShardOptions
+	goas   []groupOrAttrs
+	shards *shardSet
+}
+
+// NewShardHandler returns a handler that routes records by the value of
+// the attribute with the given dotted path, such as "tenant" or
+// "request.tenant". As with [WithAllowAttrs], the path includes the groups
+// opened with WithGroup.
+func NewShardHandler(key string, opts *ShardOptions) *ShardHandler {
+	if opts == nil || opts.Open == nil {
+		panic("slogproto: NewShardHandler requires an Open function")
+	}
+
+	o := *opts
+	if o.MaxOpen <= 0 {
+		o.MaxOpen = DefaultMaxShards
+	}
+	if o.HandlerOptions == nil {
+		o.HandlerOptions = &slog.HandlerOptions{Level: slog.LevelInfo}
+	}
+
+	return &ShardHandler{
+		key:  key,
+		opts: o,
+		shards: &shardSet{
+			open:    make(map[string]*list.Element),
+			lru:     list.New(),
+			closing: make(map[string]*shard),
+		},
+	}
+}
+
+// Enabled returns true if the level is enabled for the shards' handlers.
+func (h *ShardHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.HandlerOptions.Level != nil {
+		min = h.opts.HandlerOptions.Level.Level()
+	}
+	return level >= min
+}
+
+// Handle writes the record to the handler of its shard, opening its
+// writer if needed. Errors closing the writers of other shards to make
+// room for it are returned too.
+func (h *ShardHandler) Handle(ctx context.Context, r slog.Record) error {
+	s, err := h.shards.acquire(h.shardOf(r), &h.opts)
+	if s == nil {
+		return err
+	}
+
+	var sh slog.Handler = s.h
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			sh = sh.WithGroup(goa.group)
+		} else {
+			sh = sh.WithAttrs(goa.attrs)
+		}
+	}
+	return errors.Join(err, sh.Handle(ctx, r), h.shards.release(s))
+}
+
+// WithAttrs returns a new handler with the given attributes added to the
+// handlers of every shard.
+func (h *ShardHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goas = append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{attrs: attrs})
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group added to the
+// handlers of every shard.
+func (h *ShardHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goas = append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{group: name})
+	return &h2
+}
+
+// Close shuts down the handlers of every shard and closes their writers,
+// once the records they are writing have been written. It applies to
+// every handler derived from the same [NewShardHandler] call, which can
+// still be used afterwards to open shards again.
+func (h *ShardHandler) Close() error {
+	return h.shards.closeAll()
+}
+
+// shardOf returns the value of the handler's attribute in the record, or
+// an empty string if it doesn't have one. Like the Handler, the last
+// attribute with the path wins.
+func (h *ShardHandler) shardOf(r slog.Record) string {
+	var shard string
+	lookup := func(a slog.Attr, path string) {
+		if v, ok := lookupAttr(a, path); ok && v.Kind() != slog.KindGroup {
+			shard = v.String()
+		}
+	}
+
+	// The path of the attribute relative to the innermost group.
+	path := h.key
+	for _, goa := range h.goas {
+		if goa.group == "" {
+			for _, a := range goa.attrs {
+				lookup(a, path)
+			}
+			continue
+		}
+
+		var ok bool
+		if path, ok = strings.CutPrefix(path, goa.group+"."); !ok {
+			return shard
+		}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		lookup(a, path)
+		return true
+	})
+
+	return shard
+}
+
+// shardSet holds the open shards of handlers derived from the same
+// NewShardHandler call, ordered by when they were last used. Writers are
+// opened and closed without holding mu, so that the records of other
+// shards aren't held up by them.
+type shardSet struct {
+	mu   sync.Mutex
+	open map[string]*list.Element
+	lru  *list.List
+
+	// closing holds the evicted shards whose writers aren't closed yet,
+	// by name, so that a shard isn't opened again until the writer it
+	// had is closed.
+	closing map[string]*shard
+}
+
+// shard is the handler writing a shard, and its writer.
+type shard struct {
+	name string
+	h    *Handler
+	w    io.WriteCloser
+
+	// err is the error opening the writer, if any. It, h and w are set
+	// before opened is closed. closed is closed once the writer has
+	// been closed.
+	err    error
+	opened chan struct{}
+	closed chan struct{}
+
+	// refs is the number of records being written to the shard, and
+	// evicted is set once the shard should be closed as soon as none
+	// are, both guarded by the shardSet's mu.
+	refs    int
+	evicted bool
+}
+
+// acquire returns the named shard, opening it if needed, which must be
+// released once the record has been written. It may return both a shard
+// and the errors closing the shards evicted for it.
+func (s *shardSet) acquire(name string, opts *ShardOptions) (*shard, error) {
+	s.mu.Lock()
+
+	if e, ok := s.open[name]; ok {
+		s.lru.MoveToFront(e)
+		sh := e.Value.(*shard)
+		sh.refs++
+		s.mu.Unlock()
+
+		// Another record may still be opening the shard.
+		<-sh.opened
+		if sh.err != nil {
+			return nil, errors.Join(sh.err, s.release(sh))
+		}
+		return sh, nil
+	}
+
+	// Reserve the shard, to be opened once mu is released.
+	sh := &shard{
+		name:   name,
+		opened: make(chan struct{}),
+		closed: make(chan struct{}),
+		refs:   1,
+	}
+	prev := s.closing[name]
+	s.open[name] = s.lru.PushFront(sh)
+
+	var evicted []*shard
+	for s.lru.Len() > opts.MaxOpen {
+		if e := s.evict(s.lru.Back()); e != nil {
+			evicted = append(evicted, e)
+		}
+	}
+	s.mu.Unlock()
+
+	if prev != nil {
+		<-prev.closed
+	}
+
+	w, err := opts.Open(name)
+	if err != nil {
+		sh.err = fmt.Errorf("slogproto: error opening shard %q: %w", name, err)
+
+		// Forget the shard, so that the next record opens it again.
+		s.mu.Lock()
+		if e, ok := s.open[name]; ok && e.Value.(*shard) == sh {
+			s.lru.Remove(e)
+			delete(s.open, name)
+		}
+		sh.evicted = true
+		s.mu.Unlock()
+	} else {
+		sh.h = NewHandler(w, opts.HandlerOptions, opts.Options...)
+		sh.w = w
+	}
+	close(sh.opened)
+
+	// Close the evicted shards once this one is open, so that other
+	// records written to it aren't held up either.
+	var errs []error
+	for _, e := range evicted {
+		errs = append(errs, s.close(e))
+	}
+
+	if sh.err != nil {
+		return nil, errors.Join(append(errs, sh.err, s.release(sh))...)
+	}
+	return sh, errors.Join(errs...)
+}
+
+// release marks a record written to the shard, closing it if it has been
+// evicted and no other records are being written to it.
+func (s *shardSet) release(sh *shard) error {
+	s.mu.Lock()
+	sh.refs--
+	done := sh.evicted && sh.refs == 0
+	s.mu.Unlock()
+
+	if done {
+		return s.close(sh)
+	}
+	return nil
+}
+
+// evict removes the shard from the set, returning it if it must be closed
+// now, or else it is closed once the records being written to it have
+// been. The set's mu must be held.
+func (s *shardSet) evict(e *list.Element) *shard {
+	sh := e.Value.(*shard)
+	s.lru.Remove(e)
+	delete(s.open, sh.name)
+
+	sh.evicted = true
+	s.closing[sh.name] = sh
+	if sh.refs == 0 {
+		return sh
+	}
+	return nil
+}
+
+// closeAll evicts and closes every shard.
+func (s *shardSet) closeAll() error {
+	s.mu.Lock()
+	var evicted []*shard
+	for s.lru.Len() > 0 {
+		if sh := s.evict(s.lru.Back()); sh != nil {
+			evicted = append(evicted, sh)
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, sh := range evicted {
+		errs = append(errs, s.close(sh))
+	}
+	return errors.Join(errs...)
+}
+
+// close shuts down the shard's handler and closes its writer, if it was
+// opened. The set's mu must not be held.
+func (s *shardSet) close(sh *shard) error {
+	var err error
+	if sh.err == nil {
+		err = sh.h.Shutdown(context.Background())
+		if cerr := sh.w.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("slogproto: error closing shard %q: %w", sh.name, cerr)
+		}
+	}
+
+	s.mu.Lock()
+	if s.closing[sh.name] == sh {
+		delete(s.closing, sh.name)
+	}
+	s.mu.Unlock()
+
+	close(sh.closed)
+	return err
+}
@@ -0,0 +1,178 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+type shardBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *shardBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestShardHandler(t *testing.T) {
+	var (
+		shards = map[string]*shardBuffer{}
+		opened []string
+	)
+
+	h := slogproto.NewShardHandler("req.tenant", &slogproto.ShardOptions{
+		Open: func(shard string) (io.WriteCloser, error) {
+			opened = append(opened, shard)
+			b, ok := shards[shard]
+			if !ok {
+				b = &shardBuffer{}
+				shards[shard] = b
+			}
+			b.closed = false
+			return b, nil
+		},
+		MaxOpen: 2,
+	})
+
+	logger := slog.New(h).With("service", "api").WithGroup("req")
+	for _, tenant := range []string{"a", "b", "a", "c", "b"} {
+		logger.Info("hello "+tenant, "tenant", tenant)
+	}
+	logger.Info("anonymous")
+	slog.New(h).With(slog.Group("req", "tenant", "c")).Info("hello c")
+
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.Join(opened, ","), "a,b,c,b,,c"; got != want {
+		t.Fatalf("expected shards to be opened in order %q, got %q", want, got)
+	}
+
+	want := map[string]string{
+		"a": "hello a,hello a",
+		"b": "hello b,hello b",
+		"c": "hello c,hello c",
+		"":  "anonymous",
+	}
+	for shard, b := range shards {
+		if !b.closed {
+			t.Errorf("expected shard %q to be closed", shard)
+		}
+
+		var msgs []string
+		err := slogproto.Read(context.Background(), &b.Buffer, func(r *slog.Record) bool {
+			msgs = append(msgs, r.Message)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(msgs)
+
+		if got := strings.Join(msgs, ","); got != want[shard] {
+			t.Errorf("expected shard %q to hold %q, got %q", shard, want[shard], got)
+		}
+	}
+	if len(shards) != len(want) {
+		t.Fatalf("expected %d shards, got %d", len(want), len(shards))
+	}
+}
+
+// blockingWriter is a shard writer whose Close blocks until unblocked.
+type blockingWriter struct {
+	bytes.Buffer
+	closing, unblock chan struct{}
+}
+
+func (w *blockingWriter) Close() error {
+	close(w.closing)
+	<-w.unblock
+	return nil
+}
+
+func TestShardHandler_slowWriters(t *testing.T) {
+	unblock := make(chan struct{})
+	writers := map[string]*blockingWriter{}
+	var mu sync.Mutex
+
+	h := slogproto.NewShardHandler("tenant", &slogproto.ShardOptions{
+		Open: func(shard string) (io.WriteCloser, error) {
+			if shard == "slow" {
+				<-unblock
+			}
+			w := &blockingWriter{closing: make(chan struct{}), unblock: unblock}
+			mu.Lock()
+			writers[shard] = w
+			mu.Unlock()
+			return w, nil
+		},
+		MaxOpen: 2,
+	})
+	logger := slog.New(h)
+
+	// logWithin logs a record to the shard, failing if it takes too long.
+	logWithin := func(tenant string) {
+		t.Helper()
+
+		done := make(chan struct{})
+		go func() {
+			logger.Info("hello", "tenant", tenant)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("logging to shard %q was held up by other shards", tenant)
+		}
+	}
+
+	logWithin("a")
+
+	// A shard being opened doesn't hold up the others.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logger.Info("hello", "tenant", "slow")
+	}()
+	time.Sleep(10 * time.Millisecond)
+	logWithin("a")
+
+	// Nor does a shard being closed once evicted, for the next one.
+	close(unblock)
+	wg.Wait()
+	logWithin("slow")
+
+	unblock = make(chan struct{})
+	mu.Lock()
+	writers["a"].unblock = unblock
+	closing := writers["a"].closing
+	mu.Unlock()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logger.Info("hello", "tenant", "b")
+	}()
+	<-closing
+	logWithin("b")
+	logWithin("slow")
+
+	close(unblock)
+	wg.Wait()
+
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,563 +0,0 @@
-// Code generated by protoc-gen-go. DO NOT EDIT.
-// versions:
-// 	protoc-gen-go v1.31.0
-// 	protoc        (unknown)
-// source: slog.proto
-
-package slogproto
-
-import (
-	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
-	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
-	anypb "google.golang.org/protobuf/types/known/anypb"
-	durationpb "google.golang.org/protobuf/types/known/durationpb"
-	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
-	reflect "reflect"
-	sync "sync"
-)
-
-const (
-	// Verify that this generated code is sufficiently up-to-date.
-	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
-	// Verify that runtime/protoimpl is sufficiently up-to-date.
-	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
-)
-
-type Level int32
-
-const (
-	Level_LEVEL_UNSPECIFIED Level = 0
-	Level_LEVEL_INFO        Level = 1
-	Level_LEVEL_WARN        Level = 2
-	Level_LEVEL_ERROR       Level = 3
-	Level_LEVEL_DEBUG       Level = 4
-)
-
-// Enum value maps for Level.
-var (
-	Level_name = map[int32]string{
-		0: "LEVEL_UNSPECIFIED",
-		1: "LEVEL_INFO",
-		2: "LEVEL_WARN",
-		3: "LEVEL_ERROR",
-		4: "LEVEL_DEBUG",
-	}
-	Level_value = map[string]int32{
-		"LEVEL_UNSPECIFIED": 0,
-		"LEVEL_INFO":        1,
-		"LEVEL_WARN":        2,
-		"LEVEL_ERROR":       3,
-		"LEVEL_DEBUG":       4,
-	}
-)
-
-func (x Level) Enum() *Level {
-	p := new(Level)
-	*p = x
-	return p
-}
-
-func (x Level) String() string {
-	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
-}
-
-func (Level) Descriptor() protoreflect.EnumDescriptor {
-	return file_slog_proto_enumTypes[0].Descriptor()
-}
-
-func (Level) Type() protoreflect.EnumType {
-	return &file_slog_proto_enumTypes[0]
-}
-
-func (x Level) Number() protoreflect.EnumNumber {
-	return protoreflect.EnumNumber(x)
-}
-
-// Deprecated: Use Level.Descriptor instead.
-func (Level) EnumDescriptor() ([]byte, []int) {
-	return file_slog_proto_rawDescGZIP(), []int{0}
-}
-
-type Value struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	// Types that are assignable to Kind:
-	//
-	//	*Value_Bool
-	//	*Value_Float
-	//	*Value_Int
-	//	*Value_String_
-	//	*Value_Time
-	//	*Value_Duration
-	//	*Value_Uint
-	//	*Value_Group_
-	//	*Value_Any
-	Kind isValue_Kind `protobuf_oneof:"kind"`
-}
-
-func (x *Value) Reset() {
-	*x = Value{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_slog_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
-}
-
-func (x *Value) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*Value) ProtoMessage() {}
-
-func (x *Value) ProtoReflect() protoreflect.Message {
-	mi := &file_slog_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Value.ProtoReflect.Descriptor instead.
-func (*Value) Descriptor() ([]byte, []int) {
-	return file_slog_proto_rawDescGZIP(), []int{0}
-}
-
-func (m *Value) GetKind() isValue_Kind {
-	if m != nil {
-		return m.Kind
-	}
-	return nil
-}
-
-func (x *Value) GetBool() bool {
-	if x, ok := x.GetKind().(*Value_Bool); ok {
-		return x.Bool
-	}
-	return false
-}
-
-func (x *Value) GetFloat() float64 {
-	if x, ok := x.GetKind().(*Value_Float); ok {
-		return x.Float
-	}
-	return 0
-}
-
-func (x *Value) GetInt() int64 {
-	if x, ok := x.GetKind().(*Value_Int); ok {
-		return x.Int
-	}
-	return 0
-}
-
-func (x *Value) GetString_() string {
-	if x, ok := x.GetKind().(*Value_String_); ok {
-		return x.String_
-	}
-	return ""
-}
-
-func (x *Value) GetTime() *timestamppb.Timestamp {
-	if x, ok := x.GetKind().(*Value_Time); ok {
-		return x.Time
-	}
-	return nil
-}
-
-func (x *Value) GetDuration() *durationpb.Duration {
-	if x, ok := x.GetKind().(*Value_Duration); ok {
-		return x.Duration
-	}
-	return nil
-}
-
-func (x *Value) GetUint() uint64 {
-	if x, ok := x.GetKind().(*Value_Uint); ok {
-		return x.Uint
-	}
-	return 0
-}
-
-func (x *Value) GetGroup() *Value_Group {
-	if x, ok := x.GetKind().(*Value_Group_); ok {
-		return x.Group
-	}
-	return nil
-}
-
-func (x *Value) GetAny() *anypb.Any {
-	if x, ok := x.GetKind().(*Value_Any); ok {
-		return x.Any
-	}
-	return nil
-}
-
-type isValue_Kind interface {
-	isValue_Kind()
-}
-
-type Value_Bool struct {
-	Bool bool `protobuf:"varint,1,opt,name=bool,proto3,oneof"`
-}
-
-type Value_Float struct {
-	Float float64 `protobuf:"fixed64,2,opt,name=float,proto3,oneof"`
-}
-
-type Value_Int struct {
-	Int int64 `protobuf:"varint,3,opt,name=int,proto3,oneof"`
-}
-
-type Value_String_ struct {
-	String_ string `protobuf:"bytes,4,opt,name=string,proto3,oneof"`
-}
-
-type Value_Time struct {
-	Time *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3,oneof"`
-}
-
-type Value_Duration struct {
-	Duration *durationpb.Duration `protobuf:"bytes,6,opt,name=duration,proto3,oneof"`
-}
-
-type Value_Uint struct {
-	Uint uint64 `protobuf:"varint,7,opt,name=uint,proto3,oneof"`
-}
-
-type Value_Group_ struct {
-	Group *Value_Group `protobuf:"bytes,8,opt,name=group,proto3,oneof"`
-}
-
-type Value_Any struct {
-	Any *anypb.Any `protobuf:"bytes,9,opt,name=any,proto3,oneof"`
-}
-
-func (*Value_Bool) isValue_Kind() {}
-
-func (*Value_Float) isValue_Kind() {}
-
-func (*Value_Int) isValue_Kind() {}
-
-func (*Value_String_) isValue_Kind() {}
-
-func (*Value_Time) isValue_Kind() {}
-
-func (*Value_Duration) isValue_Kind() {}
-
-func (*Value_Uint) isValue_Kind() {}
-
-func (*Value_Group_) isValue_Kind() {}
-
-func (*Value_Any) isValue_Kind() {}
-
-type Record struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Time    *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
-	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Level   Level                  `protobuf:"varint,3,opt,name=level,proto3,enum=slog.Level" json:"level,omitempty"`
-	Attrs   map[string]*Value      `protobuf:"bytes,4,rep,name=attrs,proto3" json:"attrs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-}
-
-func (x *Record) Reset() {
-	*x = Record{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_slog_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
-}
-
-func (x *Record) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*Record) ProtoMessage() {}
-
-func (x *Record) ProtoReflect() protoreflect.Message {
-	mi := &file_slog_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Record.ProtoReflect.Descriptor instead.
-func (*Record) Descriptor() ([]byte, []int) {
-	return file_slog_proto_rawDescGZIP(), []int{1}
-}
-
-func (x *Record) GetTime() *timestamppb.Timestamp {
-	if x != nil {
-		return x.Time
-	}
-	return nil
-}
-
-func (x *Record) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
-}
-
-func (x *Record) GetLevel() Level {
-	if x != nil {
-		return x.Level
-	}
-	return Level_LEVEL_UNSPECIFIED
-}
-
-func (x *Record) GetAttrs() map[string]*Value {
-	if x != nil {
-		return x.Attrs
-	}
-	return nil
-}
-
-type Value_Group struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Attrs map[string]*Value `protobuf:"bytes,1,rep,name=attrs,proto3" json:"attrs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-}
-
-func (x *Value_Group) Reset() {
-	*x = Value_Group{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_slog_proto_msgTypes[2]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
-}
-
-func (x *Value_Group) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*Value_Group) ProtoMessage() {}
-
-func (x *Value_Group) ProtoReflect() protoreflect.Message {
-	mi := &file_slog_proto_msgTypes[2]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Value_Group.ProtoReflect.Descriptor instead.
-func (*Value_Group) Descriptor() ([]byte, []int) {
-	return file_slog_proto_rawDescGZIP(), []int{0, 0}
-}
-
-func (x *Value_Group) GetAttrs() map[string]*Value {
-	if x != nil {
-		return x.Attrs
-	}
-	return nil
-}
-
-var File_slog_proto protoreflect.FileDescriptor
-
-var file_slog_proto_rawDesc = []byte{
-	0x0a, 0x0a, 0x73, 0x6c, 0x6f, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x73, 0x6c,
-	0x6f, 0x67, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64,
-	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc6,
-	0x03, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x04, 0x62, 0x6f, 0x6f, 0x6c,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x04, 0x62, 0x6f, 0x6f, 0x6c, 0x12, 0x16,
-	0x0a, 0x05, 0x66, 0x6c, 0x6f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52,
-	0x05, 0x66, 0x6c, 0x6f, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x03, 0x69, 0x6e, 0x74, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x03, 0x69, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x06, 0x73, 0x74,
-	0x72, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74,
-	0x72, 0x69, 0x6e, 0x67, 0x12, 0x30, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x48, 0x00,
-	0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x37, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x14, 0x0a, 0x04, 0x75, 0x69, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52,
-	0x04, 0x75, 0x69, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x08,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x6c, 0x6f, 0x67, 0x2e, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x48, 0x00, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70,
-	0x12, 0x28, 0x0a, 0x03, 0x61, 0x6e, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x41, 0x6e, 0x79, 0x48, 0x00, 0x52, 0x03, 0x61, 0x6e, 0x79, 0x1a, 0x82, 0x01, 0x0a, 0x05, 0x47,
-	0x72, 0x6f, 0x75, 0x70, 0x12, 0x32, 0x0a, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x6c, 0x6f, 0x67, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65,
-	0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x1a, 0x45, 0x0a, 0x0a, 0x41, 0x74, 0x74, 0x72,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x21, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x73, 0x6c, 0x6f, 0x67, 0x2e, 0x56,
-	0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42,
-	0x06, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0xeb, 0x01, 0x0a, 0x06, 0x52, 0x65, 0x63, 0x6f,
-	0x72, 0x64, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69,
-	0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x05,
-	0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x73, 0x6c,
-	0x6f, 0x67, 0x2e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12,
-	0x2d, 0x0a, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
-	0x2e, 0x73, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x2e, 0x41, 0x74, 0x74,
-	0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x1a, 0x45,
-	0x0a, 0x0a, 0x41, 0x74, 0x74, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
-	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x21,
-	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e,
-	0x73, 0x6c, 0x6f, 0x67, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0x60, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x15,
-	0x0a, 0x11, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
-	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x49,
-	0x4e, 0x46, 0x4f, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x57,
-	0x41, 0x52, 0x4e, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x45,
-	0x52, 0x52, 0x4f, 0x52, 0x10, 0x03, 0x12, 0x0f, 0x0a, 0x0b, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f,
-	0x44, 0x45, 0x42, 0x55, 0x47, 0x10, 0x04, 0x42, 0x62, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x2e, 0x73,
-	0x6c, 0x6f, 0x67, 0x42, 0x09, 0x53, 0x6c, 0x6f, 0x67, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01,
-	0x5a, 0x1b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x69, 0x63,
-	0x61, 0x74, 0x7a, 0x2f, 0x73, 0x6c, 0x6f, 0x67, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0xa2, 0x02, 0x03,
-	0x53, 0x58, 0x58, 0xaa, 0x02, 0x04, 0x53, 0x6c, 0x6f, 0x67, 0xca, 0x02, 0x04, 0x53, 0x6c, 0x6f,
-	0x67, 0xe2, 0x02, 0x10, 0x53, 0x6c, 0x6f, 0x67, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61,
-	0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x04, 0x53, 0x6c, 0x6f, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
-}
-
-var (
-	file_slog_proto_rawDescOnce sync.Once
-	file_slog_proto_rawDescData = file_slog_proto_rawDesc
-)
-
-func file_slog_proto_rawDescGZIP() []byte {
-	file_slog_proto_rawDescOnce.Do(func() {
-		file_slog_proto_rawDescData = protoimpl.X.CompressGZIP(file_slog_proto_rawDescData)
-	})
-	return file_slog_proto_rawDescData
-}
-
-var file_slog_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_slog_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
-var file_slog_proto_goTypes = []interface{}{
-	(Level)(0),                    // 0: slog.Level
-	(*Value)(nil),                 // 1: slog.Value
-	(*Record)(nil),                // 2: slog.Record
-	(*Value_Group)(nil),           // 3: slog.Value.Group
-	nil,                           // 4: slog.Value.Group.AttrsEntry
-	nil,                           // 5: slog.Record.AttrsEntry
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),   // 7: google.protobuf.Duration
-	(*anypb.Any)(nil),             // 8: google.protobuf.Any
-}
-var file_slog_proto_depIdxs = []int32{
-	6,  // 0: slog.Value.time:type_name -> google.protobuf.Timestamp
-	7,  // 1: slog.Value.duration:type_name -> google.protobuf.Duration
-	3,  // 2: slog.Value.group:type_name -> slog.Value.Group
-	8,  // 3: slog.Value.any:type_name -> google.protobuf.Any
-	6,  // 4: slog.Record.time:type_name -> google.protobuf.Timestamp
-	0,  // 5: slog.Record.level:type_name -> slog.Level
-	5,  // 6: slog.Record.attrs:type_name -> slog.Record.AttrsEntry
-	4,  // 7: slog.Value.Group.attrs:type_name -> slog.Value.Group.AttrsEntry
-	1,  // 8: slog.Value.Group.AttrsEntry.value:type_name -> slog.Value
-	1,  // 9: slog.Record.AttrsEntry.value:type_name -> slog.Value
-	10, // [10:10] is the sub-list for method output_type
-	10, // [10:10] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
-}
-
-func init() { file_slog_proto_init() }
-func file_slog_proto_init() {
-	if File_slog_proto != nil {
-		return
-	}
-	if !protoimpl.UnsafeEnabled {
-		file_slog_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Value); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_slog_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Record); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_slog_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Value_Group); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
-	file_slog_proto_msgTypes[0].OneofWrappers = []interface{}{
-		(*Value_Bool)(nil),
-		(*Value_Float)(nil),
-		(*Value_Int)(nil),
-		(*Value_String_)(nil),
-		(*Value_Time)(nil),
-		(*Value_Duration)(nil),
-		(*Value_Uint)(nil),
-		(*Value_Group_)(nil),
-		(*Value_Any)(nil),
-	}
-	type x struct{}
-	out := protoimpl.TypeBuilder{
-		File: protoimpl.DescBuilder{
-			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_slog_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   5,
-			NumExtensions: 0,
-			NumServices:   0,
-		},
-		GoTypes:           file_slog_proto_goTypes,
-		DependencyIndexes: file_slog_proto_depIdxs,
-		EnumInfos:         file_slog_proto_enumTypes,
-		MessageInfos:      file_slog_proto_msgTypes,
-	}.Build()
-	File_slog_proto = out.File
-	file_slog_proto_rawDesc = nil
-	file_slog_proto_goTypes = nil
-	file_slog_proto_depIdxs = nil
-}
@@ -0,0 +1,299 @@
+// Package slogprototest provides helpers for testing programs that write
+// slogproto records, such as comparing records and asserting on golden
+// files of expected records.
+//
+// # Example
+//
+//	func TestServer(t *testing.T) {
+//		var buf bytes.Buffer
+//		logger := slog.New(slogproto.NewHandler(&buf, nil))
+//
+//		runServer(logger)
+//
+//		records := slogprototest.ReadRecords(t, &buf)
+//		slogprototest.Golden(t, "testdata/server.slp", records, slogprototest.IgnoreTime())
+//	}
+//
+// Run the tests with the -slogprototest.update flag to write the golden
+// files from the records the tests produce.
+package slogprototest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/proto"
+)
+
+// update configures Golden to write golden files instead of comparing
+// records with them.
+var update = flag.Bool("slogprototest.update", false, "update slogproto golden files")
+
+// Option configures how records are normalized before they are compared.
+type Option func(*config)
+
+type config struct {
+	ignoreTime  bool
+	ignoreAttrs []string
+}
+
+// IgnoreTime ignores the time of records, and of time attributes.
+func IgnoreTime() Option {
+	return func(c *config) {
+		c.ignoreTime = true
+	}
+}
+
+// IgnoreAttrs ignores the attributes at the given dotted paths, such as
+// "request_id" or "http.latency".
+func IgnoreAttrs(paths ...string) Option {
+	return func(c *config) {
+		c.ignoreAttrs = append(c.ignoreAttrs, paths...)
+	}
+}
+
+// Normalize returns the record in canonical form, so that records can be
+// compared regardless of how they were produced: values are resolved,
+// groups with an empty key are inlined, empty groups are removed, and
+// attributes are sorted by key, including those of groups. The program
+// counter is zeroed, as it differs between builds.
+func Normalize(r slog.Record, opts ...Option) slog.Record {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return normalize(r, &cfg)
+}
+
+func normalize(r slog.Record, cfg *config) slog.Record {
+	t := r.Time
+	if cfg.ignoreTime {
+		t = time.Time{}
+	}
+
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	nr := slog.NewRecord(t, r.Level, r.Message, 0)
+	nr.AddAttrs(normalizeAttrs(attrs, "", cfg)...)
+	return nr
+}
+
+// normalizeAttrs returns the attributes of the group at the given path in
+// canonical form.
+func normalizeAttrs(attrs []slog.Attr, path string, cfg *config) []slog.Attr {
+	result := make([]slog.Attr, 0, len(attrs))
+
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+
+		if a.Key == "" && a.Value.Kind() == slog.KindGroup {
+			result = append(result, normalizeAttrs(a.Value.Group(), path, cfg)...)
+			continue
+		}
+
+		attrPath := a.Key
+		if path != "" {
+			attrPath = path + "." + a.Key
+		}
+
+		if slices.Contains(cfg.ignoreAttrs, attrPath) {
+			continue
+		}
+
+		switch a.Value.Kind() {
+		case slog.KindGroup:
+			group := normalizeAttrs(a.Value.Group(), attrPath, cfg)
+			if len(group) == 0 {
+				continue
+			}
+			a.Value = slog.GroupValue(group...)
+		case slog.KindTime:
+			if cfg.ignoreTime {
+				a.Value = slog.TimeValue(time.Time{})
+			}
+		}
+
+		result = append(result, a)
+	}
+
+	slices.SortStableFunc(result, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return result
+}
+
+// Diff returns a description of the differences between the records,
+// after normalizing them, or an empty string if they are equal.
+func Diff(want, got []slog.Record, opts ...Option) string {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var b strings.Builder
+
+	if len(want) != len(got) {
+		fmt.Fprintf(&b, "expected %d records, got %d\n", len(want), len(got))
+	}
+
+	for i := 0; i < max(len(want), len(got)); i++ {
+		switch {
+		case i >= len(got):
+			fmt.Fprintf(&b, "record %d: missing\n  want: %s\n", i, format(normalize(want[i], &cfg)))
+		case i >= len(want):
+			fmt.Fprintf(&b, "record %d: unexpected\n  got:  %s\n", i, format(normalize(got[i], &cfg)))
+		default:
+			w, g := normalize(want[i], &cfg), normalize(got[i], &cfg)
+			if !recordsEqual(w, g) {
+				fmt.Fprintf(&b, "record %d: differs\n  want: %s\n  got:  %s\n", i, format(w), format(g))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// AssertRecordsEqual fails the test if the records differ, after
+// normalizing them, reporting the differences.
+func AssertRecordsEqual(t testing.TB, want, got []slog.Record, opts ...Option) {
+	t.Helper()
+
+	if diff := Diff(want, got, opts...); diff != "" {
+		t.Errorf("records differ:\n%s", diff)
+	}
+}
+
+// recordsEqual reports whether the normalized records are equal.
+func recordsEqual(a, b slog.Record) bool {
+	if !a.Time.Equal(b.Time) || a.Level != b.Level || a.Message != b.Message || a.NumAttrs() != b.NumAttrs() {
+		return false
+	}
+
+	var aAttrs, bAttrs []slog.Attr
+	a.Attrs(func(a slog.Attr) bool {
+		aAttrs = append(aAttrs, a)
+		return true
+	})
+	b.Attrs(func(a slog.Attr) bool {
+		bAttrs = append(bAttrs, a)
+		return true
+	})
+
+	return slices.EqualFunc(aAttrs, bAttrs, attrsEqual)
+}
+
+// attrsEqual reports whether the normalized attributes are equal, comparing
+// Any values holding protocol buffer messages with proto.Equal, and other
+// Any values with reflect.DeepEqual.
+func attrsEqual(a, b slog.Attr) bool {
+	if a.Key != b.Key || a.Value.Kind() != b.Value.Kind() {
+		return false
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		return slices.EqualFunc(a.Value.Group(), b.Value.Group(), attrsEqual)
+	case slog.KindAny:
+		am, aok := a.Value.Any().(proto.Message)
+		bm, bok := b.Value.Any().(proto.Message)
+		if aok && bok {
+			return proto.Equal(am, bm)
+		}
+		return reflect.DeepEqual(a.Value.Any(), b.Value.Any())
+	default:
+		return a.Value.Equal(b.Value)
+	}
+}
+
+// format returns the record in the text format of slog.TextHandler.
+func format(r slog.Record) string {
+	var buf bytes.Buffer
+	slog.NewTextHandler(&buf, nil).Handle(context.Background(), r)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ReadRecords returns the records read from the reader, failing the test
+// if they can't be read.
+func ReadRecords(t testing.TB, r io.Reader) []slog.Record {
+	t.Helper()
+
+	var records []slog.Record
+	err := slogproto.Read(context.Background(), r, func(r *slog.Record) bool {
+		records = append(records, *r)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error reading records: %v", err)
+	}
+
+	return records
+}
+
+// ReadFile returns the records read from the named file, failing the test
+// if they can't be read.
+func ReadFile(t testing.TB, name string) []slog.Record {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("error opening records: %v", err)
+	}
+	defer f.Close()
+
+	return ReadRecords(t, f)
+}
+
+// WriteFile writes the records to the named file, creating its directory
+// if needed, failing the test if they can't be written.
+func WriteFile(t testing.TB, name string, records []slog.Record) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil)
+	for _, r := range records {
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatalf("error encoding record: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatalf("error creating directory: %v", err)
+	}
+
+	if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("error writing records: %v", err)
+	}
+}
+
+// Golden compares the records with those in the named golden file, such
+// as "testdata/output.slp", failing the test if they differ. When the
+// tests are run with the -slogprototest.update flag, the golden file is
+// written from the records instead.
+func Golden(t testing.TB, name string, records []slog.Record, opts ...Option) {
+	t.Helper()
+
+	if *update {
+		WriteFile(t, name, records)
+		return
+	}
+
+	AssertRecordsEqual(t, ReadFile(t, name), records, opts...)
+}
@@ -0,0 +1,61 @@
+package slogprototest_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"github.com/picatz/slogproto/slogprototest"
+)
+
+// logRequests logs records like a program under test might.
+func logRequests(logger *slog.Logger) {
+	for i, path := range []string{"/", "/health"} {
+		logger.Info("request",
+			slog.Group("http", "path", path, "status", 200),
+			"request_id", time.Now().UnixNano()+int64(i),
+		)
+	}
+	logger.Warn("slow request", "path", "/", "took", 2*time.Second)
+}
+
+func TestGolden(t *testing.T) {
+	var buf bytes.Buffer
+	logRequests(slog.New(slogproto.NewHandler(&buf, nil)))
+
+	records := slogprototest.ReadRecords(t, &buf)
+	slogprototest.Golden(t, "testdata/requests.slp", records,
+		slogprototest.IgnoreTime(),
+		slogprototest.IgnoreAttrs("request_id"),
+	)
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+
+	want := slog.NewRecord(now, slog.LevelInfo, "request", 0)
+	want.AddAttrs(slog.Int("status", 200), slog.Group("http", slog.String("method", "GET"), slog.String("path", "/")))
+
+	// The same record, with attributes in another order.
+	same := slog.NewRecord(now, slog.LevelInfo, "request", 0)
+	same.AddAttrs(slog.Group("http", slog.String("path", "/"), slog.String("method", "GET")), slog.Int("status", 200))
+
+	if diff := slogprototest.Diff([]slog.Record{want}, []slog.Record{same}); diff != "" {
+		t.Fatalf("expected no differences, got:\n%s", diff)
+	}
+
+	other := slog.NewRecord(now.Add(time.Second), slog.LevelInfo, "request", 0)
+	other.AddAttrs(slog.Int("status", 500), slog.Group("http", slog.String("method", "GET"), slog.String("path", "/")))
+
+	diff := slogprototest.Diff([]slog.Record{want}, []slog.Record{other, same})
+	if !strings.Contains(diff, "expected 1 records, got 2") || !strings.Contains(diff, "record 0: differs") || !strings.Contains(diff, "record 1: unexpected") {
+		t.Fatalf("unexpected differences:\n%s", diff)
+	}
+
+	if diff := slogprototest.Diff([]slog.Record{want}, []slog.Record{other}, slogprototest.IgnoreTime(), slogprototest.IgnoreAttrs("status")); diff != "" {
+		t.Fatalf("expected no differences when ignoring time and status, got:\n%s", diff)
+	}
+}
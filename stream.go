@@ -0,0 +1,44 @@
+package slogproto
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// WithStreams configures Read to only pass the records of the named
+// streams, written with [Handler.WithStream], to its function. Records
+// written without a stream belong to the stream with an empty name.
+func WithStreams(names ...string) ReadOption {
+	return func(c *readConfig) {
+		c.streams = append(make([]string, 0, len(names)), names...)
+	}
+}
+
+// Demux reads protobuf encoded slog records from r like [Read], passing
+// each record to fn along with the name of its stream, written with
+// [Handler.WithStream], so that the records of several streams sharing a
+// file can be separated. Records written without a stream belong to the
+// stream with an empty name.
+func Demux(ctx context.Context, r io.Reader, fn func(stream string, r *slog.Record) bool, opts ...ReadOption) error {
+	return readFrames(ctx, r, func(frame []byte, r *slog.Record) bool {
+		return fn(frameStream(frame), r)
+	}, opts...)
+}
+
+// frameStream returns the name of the stream of an encoded record, which
+// has already been decoded successfully.
+func frameStream(frame []byte) string {
+	var stream string
+	unmarshalFields(frame, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != 7 || typ != protowire.BytesType {
+			return -1, nil
+		}
+		v, n, err := consumeBytes(b)
+		stream = string(v)
+		return n, err
+	})
+	return stream
+}
@@ -0,0 +1,52 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestHandler_WithStream(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil)
+
+	slog.New(h).Info("main")
+	slog.New(h.WithStream("db")).Info("query", "rows", 3)
+	slog.New(h.WithStream("api")).With("route", "/").Info("request")
+	slog.New(h.WithStream("db")).Info("commit")
+
+	var got []string
+	err := slogproto.Demux(context.Background(), bytes.NewReader(buf.Bytes()), func(stream string, r *slog.Record) bool {
+		got = append(got, stream+":"+r.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ":main,db:query,api:request,db:commit"; strings.Join(got, ",") != want {
+		t.Fatalf("expected %q, got %q", want, strings.Join(got, ","))
+	}
+
+	for _, zeroCopy := range []bool{false, true} {
+		opts := []slogproto.ReadOption{slogproto.WithStreams("db", "")}
+		if zeroCopy {
+			opts = append(opts, slogproto.WithZeroCopy())
+		}
+
+		var msgs []string
+		err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+			msgs = append(msgs, r.Message)
+			return true
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "main,query,commit"; strings.Join(msgs, ",") != want {
+			t.Fatalf("expected %q, got %q", want, strings.Join(msgs, ","))
+		}
+	}
+}
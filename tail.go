@@ -0,0 +1,322 @@
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTailPollInterval is the default interval at which [Tail] checks a
+// file for new records.
+const DefaultTailPollInterval = 250 * time.Millisecond
+
+// TailOptions are options for [Tail]. A nil *TailOptions is equivalent to
+// the zero value.
+type TailOptions struct {
+	// FromEnd starts reading at the end of the file, so that only records
+	// written after Tail is called are read, unless reading resumes from a
+	// checkpoint.
+	FromEnd bool
+
+	// Checkpoint is the name of a file in which Tail saves the position
+	// of the last record read, so that a later call resumes where it left
+	// off. If the file is smaller than the saved position, it is assumed
+	// to have been rotated, and is read from the start.
+	Checkpoint string
+
+	// PollInterval is how often the file is checked for new records, and
+	// for rotation, once all of its records have been read. The default is
+	// DefaultTailPollInterval.
+	PollInterval time.Duration
+}
+
+// Tail reads the records of the named file like [Read], then keeps
+// following it as records are appended, until the context is done or fn
+// returns false. It returns the context's error once the context is done.
+//
+// Frames partially written at the end of the file are read once they
+// are complete. When the file is rotated, by being moved aside or removed
+// and created again, Tail finishes reading the old file and continues
+// with the new one from its start. When the file is truncated, it is read
+// again from the start. A frame left incomplete at the end of the old
+// file, or before the truncation, is skipped. When reading starts past
+// the start of the file, from its end or a checkpoint, records are read
+// with the framing declared by the stream header at its start, if any.
+func Tail(ctx context.Context, name string, fn func(r *slog.Record) bool, opts *TailOptions) error {
+	if opts == nil {
+		opts = &TailOptions{}
+	}
+
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultTailPollInterval
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	fr := &followReader{
+		ctx:        ctx,
+		name:       name,
+		f:          f,
+		interval:   interval,
+		checkpoint: opts.Checkpoint,
+		saved:      -1,
+	}
+	defer fr.close()
+
+	offset, err := fr.start(opts.FromEnd)
+	if err != nil {
+		return err
+	}
+	fr.offset = offset
+	fr.startOffset = offset
+
+	err = readFrames(ctx, fr, func(_ []byte, r *slog.Record) bool {
+		return fn(r)
+	}, WithProgress(0, func(p Progress) {
+		fr.consumed = p.BytesRead
+	}), withInputSwitches(fr.nextSwitch))
+	if cerr := fr.saveCheckpoint(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// followReader reads a file, waiting for more data at its end, and
+// switching to the file with the same name once it has been rotated.
+type followReader struct {
+	ctx        context.Context
+	name       string
+	f          *os.File
+	interval   time.Duration
+	checkpoint string
+
+	// offset is the offset of the next read in the current file.
+	offset int64
+
+	// delivered is the number of bytes read from previous files, and
+	// consumed the number of bytes of complete frames read from all
+	// files, as reported by readFrames.
+	delivered int64
+	consumed  int64
+
+	// startOffset is the offset in the current file at which reading
+	// started.
+	startOffset int64
+
+	// saved is the offset last saved to the checkpoint.
+	saved int64
+
+	// framing is the framing of the current file, to be used by
+	// readFrames from the start of its data if switched is set.
+	framing  Framing
+	switched bool
+}
+
+// start returns the offset at which to start reading the file, from the
+// checkpoint if there is one, and seeks to it.
+func (fr *followReader) start(fromEnd bool) (int64, error) {
+	fi, err := fr.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var offset int64
+	if fromEnd {
+		offset = fi.Size()
+	}
+
+	if fr.checkpoint != "" {
+		b, err := os.ReadFile(fr.checkpoint)
+		switch {
+		case err == nil:
+			saved, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+			if err != nil || saved < 0 {
+				return 0, fmt.Errorf("slogproto: invalid checkpoint %q", fr.checkpoint)
+			}
+
+			offset = saved
+			if saved > fi.Size() {
+				offset = 0
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return 0, fmt.Errorf("slogproto: error reading checkpoint: %w", err)
+		}
+	}
+
+	// Past the start of the file, its stream header isn't read again, so
+	// read the framing it declares beforehand.
+	if offset > 0 {
+		if err := fr.readFraming(); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := fr.f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// readFraming reads the framing declared by the stream header at the start
+// of the current file, which is the default framing if it has none, to be
+// used from the next frame read.
+func (fr *followReader) readFraming() error {
+	fr.framing = Framing_FRAMING_UINT32_LE
+	fr.switched = true
+
+	buf := make([]byte, len(StreamMagic)+4+maxStreamHeaderSize)
+	n, err := fr.f.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if n < len(StreamMagic) || string(buf[:len(StreamMagic)]) != StreamMagic {
+		return nil
+	}
+
+	_, framing, err := readStreamHeader(buf[:n], fr.framing)
+	if err != nil {
+		return fmt.Errorf("slogproto: %w", err)
+	}
+	fr.framing = framing
+	return nil
+}
+
+// nextSwitch returns the offset in the data read at which the current
+// file starts, and its framing, if the file was switched or read again
+// since it was last called.
+func (fr *followReader) nextSwitch() (int64, Framing, bool) {
+	if !fr.switched {
+		return 0, 0, false
+	}
+	fr.switched = false
+	return fr.delivered, fr.framing, true
+}
+
+// Read reads from the current file, waiting for more data at its end, and
+// switching files when it is rotated.
+func (fr *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := fr.f.Read(p)
+		fr.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		// Every complete frame has been read once more data is needed,
+		// so this is a good time to save the checkpoint.
+		if err := fr.saveCheckpoint(); err != nil {
+			return 0, err
+		}
+
+		if err := fr.checkRotation(); err != nil {
+			return 0, err
+		}
+
+		select {
+		case <-fr.ctx.Done():
+			return 0, fr.ctx.Err()
+		case <-time.After(fr.interval):
+		}
+	}
+}
+
+// checkRotation reopens the file if another file has taken its name, or
+// reads it from the start if it has been truncated.
+func (fr *followReader) checkRotation() error {
+	fi, err := os.Stat(fr.name)
+	if err != nil {
+		// The file may be between being moved aside and created again.
+		return nil
+	}
+
+	cur, err := fr.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !os.SameFile(fi, cur):
+		// Finish reading the old file before switching.
+		if cur.Size() > fr.offset {
+			return nil
+		}
+
+		f, err := os.Open(fr.name)
+		if err != nil {
+			return nil
+		}
+		fr.f.Close()
+		fr.f = f
+	case fi.Size() < fr.offset:
+		if _, err := fr.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	fr.delivered += fr.offset - fr.startOffset
+	fr.offset = 0
+	fr.startOffset = 0
+	fr.saved = -1
+
+	// The file is read again from its start, where a stream header may
+	// declare another framing, or none at all. A frame left incomplete at
+	// the end of the previous file is skipped.
+	fr.framing = Framing_FRAMING_UINT32_LE
+	fr.switched = true
+	return nil
+}
+
+// saveCheckpoint saves the offset of the last complete frame read from
+// the current file to the checkpoint file, if there is one.
+func (fr *followReader) saveCheckpoint() error {
+	if fr.checkpoint == "" {
+		return nil
+	}
+
+	offset := fr.startOffset + fr.consumed - fr.delivered
+	if offset == fr.saved {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fr.checkpoint), filepath.Base(fr.checkpoint)+".*")
+	if err != nil {
+		return fmt.Errorf("slogproto: error saving checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = fmt.Fprintf(tmp, "%d\n", offset)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), fr.checkpoint)
+	}
+	if err != nil {
+		return fmt.Errorf("slogproto: error saving checkpoint: %w", err)
+	}
+
+	fr.saved = offset
+	return nil
+}
+
+// close closes the current file.
+func (fr *followReader) close() error {
+	return fr.f.Close()
+}
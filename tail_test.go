@@ -0,0 +1,230 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+// appendRecord appends a record with the message to the named file, in
+// two writes, so that the file briefly ends with a partial frame.
+func appendRecord(t *testing.T, name, msg string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	slog.New(slogproto.NewHandler(&buf, nil)).Info(msg)
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	b := buf.Bytes()
+	if _, err := f.Write(b[:len(b)/2]); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := f.Write(b[len(b)/2:]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tail follows the named file in the background, returning the channel of
+// messages read, and a function stopping it and returning its error.
+func tail(t *testing.T, name string, opts *slogproto.TailOptions) (<-chan string, func() error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	msgs := make(chan string, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- slogproto.Tail(ctx, name, func(r *slog.Record) bool {
+			msgs <- r.Message
+			return true
+		}, opts)
+	}()
+
+	return msgs, func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func expectMessages(t *testing.T, msgs <-chan string, want ...string) {
+	t.Helper()
+
+	for _, w := range want {
+		select {
+		case got := <-msgs:
+			if got != w {
+				t.Fatalf("expected message %q, got %q", w, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for message %q", w)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	checkpoint := filepath.Join(dir, "app.checkpoint")
+
+	appendRecord(t, name, "before")
+
+	opts := &slogproto.TailOptions{
+		Checkpoint:   checkpoint,
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	msgs, stop := tail(t, name, opts)
+	expectMessages(t, msgs, "before")
+
+	appendRecord(t, name, "appended")
+	expectMessages(t, msgs, "appended")
+
+	// Rotate the file, writing to the old file after it was moved aside.
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendRecord(t, name+".1", "moved")
+	appendRecord(t, name, "rotated")
+	expectMessages(t, msgs, "moved", "rotated")
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got: %v", err)
+	}
+
+	// Resume from the checkpoint.
+	appendRecord(t, name, "resumed")
+
+	msgs, stop = tail(t, name, opts)
+	expectMessages(t, msgs, "resumed")
+	stop()
+
+	select {
+	case msg := <-msgs:
+		t.Fatalf("unexpected message %q", msg)
+	default:
+	}
+}
+
+func TestTail_fromEnd(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+
+	appendRecord(t, name, "before")
+
+	msgs, stop := tail(t, name, &slogproto.TailOptions{
+		FromEnd:      true,
+		PollInterval: 10 * time.Millisecond,
+	})
+	defer stop()
+
+	// Give Tail time to open the file before appending.
+	time.Sleep(50 * time.Millisecond)
+
+	appendRecord(t, name, "after")
+	expectMessages(t, msgs, "after")
+}
+
+func TestTail_framing(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	checkpoint := filepath.Join(dir, "app.checkpoint")
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	logger := slog.New(slogproto.NewHandler(f, nil, slogproto.WithFraming(slogproto.Framing_FRAMING_UINT64_BE)))
+	logger.Info("before")
+
+	// Reading from the end skips the stream header, whose framing must
+	// still be used for the records that follow.
+	msgs, stop := tail(t, name, &slogproto.TailOptions{
+		FromEnd:      true,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	logger.Info("after")
+	expectMessages(t, msgs, "after")
+	stop()
+
+	// So does resuming from a checkpoint.
+	opts := &slogproto.TailOptions{
+		Checkpoint:   checkpoint,
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	msgs, stop = tail(t, name, opts)
+	expectMessages(t, msgs, "before", "after")
+	stop()
+
+	logger.Info("resumed")
+
+	msgs, stop = tail(t, name, opts)
+	expectMessages(t, msgs, "resumed")
+
+	// A truncated file is read again from the start, with the default
+	// framing unless it declares another one.
+	if err := f.Truncate(0); err != nil {
+		t.Fatal(err)
+	}
+	appendRecord(t, name, "truncated")
+	expectMessages(t, msgs, "truncated")
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got: %v", err)
+	}
+}
+
+func TestTail_rotatedMidFrame(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	appendRecord(t, name, "first")
+
+	msgs, stop := tail(t, name, &slogproto.TailOptions{PollInterval: 10 * time.Millisecond})
+	expectMessages(t, msgs, "first")
+
+	// Leave half a record at the end of the file before rotating it.
+	var buf bytes.Buffer
+	slog.New(slogproto.NewHandler(&buf, nil)).Info("incomplete")
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(buf.Bytes()[:buf.Len()/2]); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The partial record isn't joined with the first of the new file.
+	appendRecord(t, name, "rotated")
+	appendRecord(t, name, "second")
+	expectMessages(t, msgs, "rotated", "second")
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, got: %v", err)
+	}
+}
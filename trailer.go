@@ -0,0 +1,271 @@
+package slogproto
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TrailerMagic marks a stream trailer, written by a handler configured with
+// [WithStreamTrailer]. Like StreamMagic, read as a size prefix it would
+// describe a record of over 1 GiB.
+const TrailerMagic = "SLPT"
+
+// maxStreamTrailerSize is the maximum size of an encoded stream trailer,
+// which is far larger than any valid trailer.
+const maxStreamTrailerSize = 1 << 10
+
+// ErrNoTrailer is returned when a stream doesn't end with a stream
+// trailer.
+var ErrNoTrailer = errors.New("slogproto: no stream trailer")
+
+// ErrTrailerMismatch is returned by [ReadStreamTrailer] and [VerifyStream]
+// when a stream doesn't match its trailer, such as when it was truncated
+// or modified after being written.
+var ErrTrailerMismatch = errors.New("slogproto: stream doesn't match its trailer")
+
+// crc32c is the table of the checksum of stream trailers.
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// WithStreamTrailer configures the handler to write a stream trailer when
+// it is shut down with [Handler.Shutdown], recording the number of records
+// and bytes it wrote, the times of its earliest and latest records, and a
+// checksum of the stream. The stream can then be checked for completeness
+// with [ReadStreamTrailer], which only reads the end of the stream, and
+// verified with [VerifyStream].
+//
+// The trailer describes the bytes written by the handler, before they are
+// compressed by an option such as WithGzip, so it only matches the stream
+// if nothing else writes to the same writer.
+func WithStreamTrailer() HandlerOption {
+	return func(h *Handler) {
+		h.totals = &streamTotals{}
+	}
+}
+
+// streamTotals accumulates the totals of a stream recorded in its trailer.
+type streamTotals struct {
+	records  uint64
+	bytes    uint64
+	min, max time.Time
+	checksum uint32
+}
+
+// addBytes adds bytes of the stream which aren't records, such as headers.
+func (st *streamTotals) addBytes(b []byte) {
+	st.bytes += uint64(len(b))
+	st.checksum = crc32.Update(st.checksum, crc32c, b)
+}
+
+// addRecord adds a record of the stream, with the given time, written as
+// b.
+func (st *streamTotals) addRecord(b []byte, t time.Time) {
+	st.addBytes(b)
+	st.records++
+	if t.IsZero() {
+		return
+	}
+	if st.min.IsZero() || t.Before(st.min) {
+		st.min = t
+	}
+	if st.max.IsZero() || t.After(st.max) {
+		st.max = t
+	}
+}
+
+// trailer returns the stream trailer of the totals.
+func (st *streamTotals) trailer() *StreamTrailer {
+	trailer := &StreamTrailer{
+		Records:  st.records,
+		Bytes:    st.bytes,
+		Checksum: st.checksum,
+	}
+	if !st.min.IsZero() {
+		trailer.MinTime = timestamppb.New(st.min)
+		trailer.MaxTime = timestamppb.New(st.max)
+	}
+	return trailer
+}
+
+// encodeStreamTrailer returns the encoded stream trailer: TrailerMagic, the
+// size prefixed trailer, and its size again.
+func encodeStreamTrailer(trailer *StreamTrailer) []byte {
+	b, _ := proto.Marshal(trailer)
+
+	out := make([]byte, 0, len(TrailerMagic)+8+len(b))
+	out = append(out, TrailerMagic...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(b)))
+	out = append(out, b...)
+	return binary.LittleEndian.AppendUint32(out, uint32(len(b)))
+}
+
+// readStreamTrailer returns the size of the stream trailer at the start of
+// data, along with the trailer, or zero if data doesn't hold all of it.
+func readStreamTrailer(data []byte) (int, *StreamTrailer, error) {
+	if len(data) < len(TrailerMagic)+4 {
+		return 0, nil, nil
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[len(TrailerMagic):]))
+	if size > maxStreamTrailerSize {
+		return 0, nil, fmt.Errorf("invalid stream trailer: %d bytes exceeds the maximum of %d bytes", size, maxStreamTrailerSize)
+	}
+
+	n := len(TrailerMagic) + 8 + size
+	if len(data) < n {
+		return 0, nil, nil
+	}
+
+	if int(binary.LittleEndian.Uint32(data[n-4:])) != size {
+		return 0, nil, errors.New("invalid stream trailer: mismatched sizes")
+	}
+
+	trailer := &StreamTrailer{}
+	if err := proto.Unmarshal(data[len(TrailerMagic)+4:n-4], trailer); err != nil {
+		return 0, nil, fmt.Errorf("invalid stream trailer: %w", err)
+	}
+	return n, trailer, nil
+}
+
+// ReadStreamTrailer returns the trailer at the end of the size bytes read
+// from r, such as a file, reading only the trailer, to check that the
+// stream is complete without reading its records. It returns ErrNoTrailer
+// if the stream doesn't end with one, such as when the handler writing it
+// wasn't shut down, or records were appended afterwards, and the trailer
+// along with ErrTrailerMismatch if the size of the stream doesn't match it.
+//
+// Streams modified in place are only detected by [VerifyStream].
+func ReadStreamTrailer(r io.ReaderAt, size int64) (*StreamTrailer, error) {
+	if size < int64(len(TrailerMagic))+8 {
+		return nil, ErrNoTrailer
+	}
+
+	var end [4]byte
+	if _, err := r.ReadAt(end[:], size-4); err != nil {
+		return nil, err
+	}
+	n := int64(len(TrailerMagic)) + 8 + int64(binary.LittleEndian.Uint32(end[:]))
+	if n > size || n > int64(len(TrailerMagic))+8+maxStreamTrailerSize {
+		return nil, ErrNoTrailer
+	}
+
+	data := make([]byte, n)
+	if _, err := r.ReadAt(data, size-n); err != nil {
+		return nil, err
+	}
+	if string(data[:len(TrailerMagic)]) != TrailerMagic {
+		return nil, ErrNoTrailer
+	}
+
+	_, trailer, err := readStreamTrailer(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoTrailer, err)
+	}
+	if size-n != int64(trailer.Bytes) {
+		return trailer, fmt.Errorf("%w: %d bytes, expected %d", ErrTrailerMismatch, size-n, trailer.Bytes)
+	}
+	return trailer, nil
+}
+
+// VerifyStream reads the whole stream, and returns its trailer if every
+// record can be read and the stream matches the trailer. It returns
+// ErrNoTrailer if the stream doesn't end with one, and ErrTrailerMismatch
+// if it doesn't match it.
+func VerifyStream(ctx context.Context, r io.Reader) (*StreamTrailer, error) {
+	br := bufio.NewReader(r)
+	totals := &streamTotals{}
+	pbr := &Record{}
+	framing := Framing_FRAMING_UINT32_LE
+
+	for ctx.Err() == nil {
+		prefix, err := br.Peek(4)
+		if len(prefix) == 0 && errors.Is(err, io.EOF) {
+			return nil, ErrNoTrailer
+		}
+		if len(prefix) < 4 {
+			return nil, fmt.Errorf("error reading stream at offset %d: %w", totals.bytes, io.ErrUnexpectedEOF)
+		}
+
+		switch string(prefix) {
+		case StreamMagic:
+			n, f := peekStreamHeader(br, framing)
+			if n == 0 {
+				return nil, fmt.Errorf("invalid stream header at offset %d", totals.bytes)
+			}
+			framing = f
+			b, _ := br.Peek(n)
+			totals.addBytes(b)
+			br.Discard(n)
+			continue
+
+		case TrailerMagic:
+			b, _ := br.Peek(len(TrailerMagic) + 8 + maxStreamTrailerSize)
+			n, trailer, err := readStreamTrailer(b)
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("error reading stream trailer: %w", io.ErrUnexpectedEOF)
+			}
+			if len(b) > n {
+				return nil, fmt.Errorf("%w: data follows the trailer", ErrTrailerMismatch)
+			}
+			return trailer, compareTrailer(trailer, totals.trailer())
+		}
+
+		n := frameSizeLen(framing)
+		prefix, _ = br.Peek(n)
+		if len(prefix) < n {
+			return nil, fmt.Errorf("error reading stream at offset %d: %w", totals.bytes, io.ErrUnexpectedEOF)
+		}
+
+		size := frameSize(framing, prefix)
+		if size > DefaultMaxRecordSize {
+			return nil, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrRecordTooLarge, size, DefaultMaxRecordSize)
+		}
+
+		frame := make([]byte, n+int(size))
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return nil, fmt.Errorf("error reading record at offset %d: %w", totals.bytes, err)
+		}
+
+		pbr.Reset()
+		if err := unmarshalRecordHeader(frame[n:], pbr); err != nil {
+			return nil, fmt.Errorf("error unmarshaling record at offset %d: %w", totals.bytes, err)
+		}
+
+		var t time.Time
+		if pbr.Time != nil {
+			t = pbr.Time.AsTime()
+		}
+		totals.addRecord(frame, t)
+	}
+
+	return nil, ctx.Err()
+}
+
+// compareTrailer returns an error describing how the totals of a stream
+// differ from its trailer, if they do.
+func compareTrailer(trailer, got *StreamTrailer) error {
+	switch {
+	case got.Records != trailer.Records:
+		return fmt.Errorf("%w: %d records, expected %d", ErrTrailerMismatch, got.Records, trailer.Records)
+	case got.Bytes != trailer.Bytes:
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrTrailerMismatch, got.Bytes, trailer.Bytes)
+	case !got.MinTime.AsTime().Equal(trailer.MinTime.AsTime()) || !got.MaxTime.AsTime().Equal(trailer.MaxTime.AsTime()):
+		return fmt.Errorf("%w: records from %s to %s, expected %s to %s", ErrTrailerMismatch,
+			got.MinTime.AsTime().Format(time.RFC3339Nano), got.MaxTime.AsTime().Format(time.RFC3339Nano),
+			trailer.MinTime.AsTime().Format(time.RFC3339Nano), trailer.MaxTime.AsTime().Format(time.RFC3339Nano))
+	case got.Checksum != trailer.Checksum:
+		return fmt.Errorf("%w: checksum %08x, expected %08x", ErrTrailerMismatch, got.Checksum, trailer.Checksum)
+	}
+	return nil
+}
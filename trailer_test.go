@@ -0,0 +1,88 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestStreamTrailer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader(), slogproto.WithStreamTrailer())
+	for i, msg := range []string{"first", "second", "third"} {
+		if err := h.Handle(context.Background(), slog.NewRecord(start.Add(time.Duration(i)*time.Minute), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stream := buf.Bytes()
+
+	trailer, err := slogproto.ReadStreamTrailer(bytes.NewReader(stream), int64(len(stream)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trailer.Records != 3 {
+		t.Errorf("expected 3 records, got %d", trailer.Records)
+	}
+	if trailer.Bytes == 0 || trailer.Bytes >= uint64(len(stream)) {
+		t.Errorf("expected the bytes before the trailer, got %d of %d", trailer.Bytes, len(stream))
+	}
+	if !trailer.MinTime.AsTime().Equal(start) || !trailer.MaxTime.AsTime().Equal(start.Add(2*time.Minute)) {
+		t.Errorf("unexpected time range %s to %s", trailer.MinTime.AsTime(), trailer.MaxTime.AsTime())
+	}
+
+	truncated := stream[1:]
+	if _, err := slogproto.ReadStreamTrailer(bytes.NewReader(truncated), int64(len(truncated))); !errors.Is(err, slogproto.ErrTrailerMismatch) {
+		t.Errorf("expected ErrTrailerMismatch for a truncated stream, got %v", err)
+	}
+
+	if _, err := slogproto.VerifyStream(context.Background(), bytes.NewReader(stream)); err != nil {
+		t.Fatalf("expected the stream to match its trailer: %v", err)
+	}
+
+	if got := readMessages(t, bytes.NewBuffer(stream)); got != "first,second,third" {
+		t.Fatalf("unexpected records %q", got)
+	}
+
+	t.Run("modified", func(t *testing.T) {
+		modified := bytes.Replace(stream, []byte("second"), []byte("SECOND"), 1)
+
+		_, err := slogproto.VerifyStream(context.Background(), bytes.NewReader(modified))
+		if !errors.Is(err, slogproto.ErrTrailerMismatch) {
+			t.Fatalf("expected ErrTrailerMismatch, got %v", err)
+		}
+	})
+
+	t.Run("appended", func(t *testing.T) {
+		appended := bytes.NewBuffer(bytes.Clone(stream))
+		slog.New(slogproto.NewHandler(appended, nil)).Info("fourth")
+
+		_, err := slogproto.ReadStreamTrailer(bytes.NewReader(appended.Bytes()), int64(appended.Len()))
+		if !errors.Is(err, slogproto.ErrNoTrailer) {
+			t.Fatalf("expected ErrNoTrailer, got %v", err)
+		}
+
+		_, err = slogproto.VerifyStream(context.Background(), appended)
+		if !errors.Is(err, slogproto.ErrTrailerMismatch) {
+			t.Fatalf("expected ErrTrailerMismatch, got %v", err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		missing := writeRecords(t, []time.Time{start}, "only")
+
+		_, err := slogproto.VerifyStream(context.Background(), missing)
+		if !errors.Is(err, slogproto.ErrNoTrailer) {
+			t.Fatalf("expected ErrNoTrailer, got %v", err)
+		}
+	})
+}
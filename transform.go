@@ -0,0 +1,205 @@
+//go:build !slogproto_nocel && !tinygo
+
+package slogproto
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// CompileTransform compiles a transform expression into a program that
+// can be evaluated against a slog record, as with [TransformExprStage].
+// The expression must evaluate to a map, which replaces the attributes of
+// the record. It may reference the same variables and functions as
+// filter expressions, as compiled by [CompileFilter], and the following
+// functions of maps, such as attrs:
+//
+//   - m.with(key, value): a copy of m with the key set to the value,
+//     such as attrs.with("slow", attrs.duration_ms > 1000).
+//   - m.without(key) or m.without([key, ...]): a copy of m without the
+//     keys, such as attrs.without(["password", "token"]).
+//
+// If the expression is invalid, an error is returned.
+func CompileTransform(expr string) (cel.Program, error) {
+	attrsType := cel.MapType(cel.StringType, cel.DynType)
+
+	env, err := newFilterEnv(
+		cel.Function("with",
+			cel.MemberOverload("map_with_string_dyn", []*cel.Type{attrsType, cel.StringType, cel.DynType}, attrsType,
+				cel.FunctionBinding(mapWith),
+			),
+		),
+		cel.Function("without",
+			cel.MemberOverload("map_without_string", []*cel.Type{attrsType, cel.StringType}, attrsType,
+				cel.BinaryBinding(mapWithout),
+			),
+			cel.MemberOverload("map_without_list", []*cel.Type{attrsType, cel.ListType(cel.StringType)}, attrsType,
+				cel.BinaryBinding(mapWithout),
+			),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CEL environment: %s", err)
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("parse error: %s", iss.Err())
+	}
+
+	checked, issues := env.Check(ast)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("type-check error: %s", issues.Err())
+	}
+
+	if checked.OutputType().DeclaredTypeName() != "map" {
+		return nil, fmt.Errorf("invalid transform expression output type: %s", checked.OutputType().DeclaredTypeName())
+	}
+
+	prog, err := env.Program(checked)
+	if err != nil {
+		return nil, fmt.Errorf("program construction error: %s", err)
+	}
+	return prog, nil
+}
+
+// EvalTransform evaluates a transform program, compiled with
+// CompileTransform, against a slog record, replacing its attributes with
+// those of the map it returns. Attributes that were already present keep
+// their order, and new ones are added after them, sorted by key.
+func EvalTransform(prog cel.Program, r *slog.Record) error {
+	if prog == nil {
+		return nil
+	}
+
+	result, _, err := prog.Eval(filterVars(r))
+	if err != nil {
+		return fmt.Errorf("error evaluating program: %s", err)
+	}
+
+	m, ok := result.(traits.Mapper)
+	if !ok {
+		return fmt.Errorf("invalid transform expression output type: %s", result.Type())
+	}
+
+	attrs := attrsFromCEL(m)
+
+	// Keep the order of the attributes of the record.
+	order := make(map[string]int, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if _, ok := order[a.Key]; !ok {
+			order[a.Key] = len(order)
+		}
+		return true
+	})
+	slices.SortStableFunc(attrs, func(a, b slog.Attr) int {
+		i, aok := order[a.Key]
+		j, bok := order[b.Key]
+		switch {
+		case aok && bok:
+			return i - j
+		case aok:
+			return -1
+		case bok:
+			return 1
+		}
+		return 0
+	})
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	*r = nr
+	return nil
+}
+
+// TransformExprStage returns a [Pipeline] stage replacing the attributes
+// of records with those returned by the transform program, as compiled by
+// CompileTransform.
+func TransformExprStage(prog cel.Program) Stage {
+	return func(r *slog.Record) (bool, error) {
+		return true, EvalTransform(prog, r)
+	}
+}
+
+// attrsFromCEL returns the entries of a CEL map as attributes, sorted by
+// key, with maps as groups.
+func attrsFromCEL(m traits.Mapper) []slog.Attr {
+	attrs := make([]slog.Attr, 0, int(m.Size().(types.Int)))
+	for it := m.Iterator(); it.HasNext() == types.True; {
+		k := it.Next()
+		key, ok := k.Value().(string)
+		if !ok {
+			key = fmt.Sprint(k.Value())
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: valueFromCEL(m.Get(k))})
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return attrs
+}
+
+// valueFromCEL returns the slog value of a CEL value, the reverse of
+// celValue.
+func valueFromCEL(v ref.Val) slog.Value {
+	switch v := v.(type) {
+	case types.Null:
+		return slog.AnyValue(nil)
+	case traits.Mapper:
+		return slog.GroupValue(attrsFromCEL(v)...)
+	case traits.Lister:
+		list := make([]any, 0, int(v.Size().(types.Int)))
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			list = append(list, valueFromCEL(it.Next()).Any())
+		}
+		return slog.AnyValue(list)
+	}
+
+	switch x := v.Value().(type) {
+	case time.Time:
+		return slog.TimeValue(x)
+	case time.Duration:
+		return slog.DurationValue(x)
+	default:
+		return slog.AnyValue(x)
+	}
+}
+
+// mapWith implements the with function of transform expressions.
+func mapWith(args ...ref.Val) ref.Val {
+	m := copyCELMap(args[0].(traits.Mapper))
+	m[args[1]] = args[2]
+	return types.NewRefValMap(types.DefaultTypeAdapter, m)
+}
+
+// mapWithout implements the without function of transform expressions.
+func mapWithout(attrs, keys ref.Val) ref.Val {
+	m := copyCELMap(attrs.(traits.Mapper))
+	if list, ok := keys.(traits.Lister); ok {
+		for it := list.Iterator(); it.HasNext() == types.True; {
+			delete(m, it.Next())
+		}
+	} else {
+		delete(m, keys)
+	}
+	return types.NewRefValMap(types.DefaultTypeAdapter, m)
+}
+
+// copyCELMap returns the entries of a CEL map, keyed by CEL strings so
+// that keys can be replaced and deleted.
+func copyCELMap(m traits.Mapper) map[ref.Val]ref.Val {
+	entries := make(map[ref.Val]ref.Val, int(m.Size().(types.Int)))
+	for it := m.Iterator(); it.HasNext() == types.True; {
+		k := it.Next()
+		entries[types.DefaultTypeAdapter.NativeToValue(k.Value())] = m.Get(k)
+	}
+	return entries
+}
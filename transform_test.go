@@ -0,0 +1,77 @@
+//go:build !slogproto_nocel && !tinygo
+
+package slogproto_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestCompileTransform(t *testing.T) {
+	tests := map[string]struct {
+		expr string
+		want string
+	}{
+		"with": {
+			expr: `attrs.with("latency_bucket", attrs.duration_ms > 1000 ? "slow" : "fast")`,
+			want: "path=/users duration_ms=1500 http=[status=200] latency_bucket=slow",
+		},
+		"with existing": {
+			expr: `attrs.with("path", "/redacted")`,
+			want: "path=/redacted duration_ms=1500 http=[status=200]",
+		},
+		"without": {
+			expr: `attrs.without("path")`,
+			want: "duration_ms=1500 http=[status=200]",
+		},
+		"without list": {
+			expr: `attrs.without(["path", "duration_ms"]).with("level", level)`,
+			want: "http=[status=200] level=INFO",
+		},
+		"group": {
+			expr: `attrs.with("http", attrs.http.with("ok", attrs.http.status < 400))`,
+			want: "path=/users duration_ms=1500 http=[ok=true status=200]",
+		},
+		"literal": {
+			expr: `{"msg": msg, "at": time}`,
+			want: "at=2024-01-02 03:04:05 +0000 UTC msg=request",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			prog, err := slogproto.CompileTransform(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := slog.NewRecord(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), slog.LevelInfo, "request", 0)
+			r.AddAttrs(
+				slog.String("path", "/users"),
+				slog.Int("duration_ms", 1500),
+				slog.Group("http", slog.Int("status", 200)),
+			)
+
+			keep, err := slogproto.NewPipeline(slogproto.TransformExprStage(prog)).Apply(&r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !keep {
+				t.Fatal("expected the record to be kept")
+			}
+
+			if got := attrString(&r); got != test.want {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	for _, expr := range []string{`attrs.path`, `attrs.with(1, 2)`, `attrs.with(`} {
+		if _, err := slogproto.CompileTransform(expr); err == nil {
+			t.Fatalf("expected an error compiling %q", expr)
+		}
+	}
+}
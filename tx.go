@@ -0,0 +1,171 @@
+package slogproto
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrTxDone is returned when a record is handled by, or a transaction is
+// committed with, a [Tx] that was already committed or discarded.
+var ErrTxDone = errors.New("slogproto: transaction already committed or discarded")
+
+// Tx is a handler collecting the records of a unit of work, such as a
+// request, begun with [Handler.Begin], which are only written once the
+// transaction is committed, or dropped if it is discarded, so that
+// aborted work doesn't leave partial traces in the log.
+//
+// Records are encoded as they are handled, and written by Commit with a
+// single write, after any record already being written, so that they are
+// contiguous in the stream, even when multiple processes append to the
+// same file. Derived handlers, created with WithAttrs and WithGroup, add
+// their records to the same transaction.
+//
+// # Example
+//
+//	tx := h.Begin()
+//	logger := slog.New(tx)
+//	if err := handle(logger, req); err != nil {
+//		tx.Discard()
+//		return err
+//	}
+//	return tx.Commit()
+type Tx struct {
+	h     *Handler
+	state *txState
+}
+
+// txState holds the records of a transaction, shared by the handlers
+// derived from it.
+type txState struct {
+	mu      sync.Mutex
+	done    bool
+	records []txRecord
+}
+
+// txRecord is a record of a transaction, and its encoded frame, unless it
+// is chained, which is encoded when it is committed.
+type txRecord struct {
+	pbr   *Record
+	frame []byte
+	time  time.Time
+}
+
+// Begin returns a new transaction, whose records are written to the
+// handler once committed. Records are filtered by the handler's level,
+// but not sampled, so that transactions are written whole.
+func (h *Handler) Begin() *Tx {
+	return &Tx{h: h, state: &txState{}}
+}
+
+// Enabled returns true if the level is enabled for the handler.
+func (tx *Tx) Enabled(ctx context.Context, level slog.Level) bool {
+	return tx.h.Enabled(ctx, level)
+}
+
+// Handle encodes the record, and adds it to the transaction.
+func (tx *Tx) Handle(ctx context.Context, r slog.Record) error {
+	if tx.h.closed.Load() {
+		return ErrHandlerClosed
+	}
+
+	pbr := &Record{}
+	if err := tx.h.fillProtobufRecord(pbr, &r); err != nil {
+		return tx.h.handleDeadLetter(ctx, r, err)
+	}
+
+	rec := txRecord{pbr: pbr, time: r.Time}
+	if tx.h.audit == nil {
+		frame, err := tx.h.encodeFrame(pbr)
+		if err != nil {
+			return tx.h.handleDeadLetter(ctx, r, err)
+		}
+		rec = txRecord{frame: frame, time: r.Time}
+	}
+
+	tx.state.mu.Lock()
+	defer tx.state.mu.Unlock()
+
+	if tx.state.done {
+		return ErrTxDone
+	}
+	tx.state.records = append(tx.state.records, rec)
+	return nil
+}
+
+// WithAttrs returns a handler adding records with the given attributes to
+// the same transaction.
+func (tx *Tx) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Tx{h: tx.h.WithAttrs(attrs).(*Handler), state: tx.state}
+}
+
+// WithGroup returns a handler adding records with the given group to the
+// same transaction.
+func (tx *Tx) WithGroup(name string) slog.Handler {
+	return &Tx{h: tx.h.WithGroup(name).(*Handler), state: tx.state}
+}
+
+// Commit writes the records of the transaction, in the order they were
+// handled. It returns ErrTxDone if the transaction was already committed
+// or discarded, and ErrHandlerClosed if the handler was shut down, in
+// which case the records are dropped.
+func (tx *Tx) Commit() error {
+	tx.state.mu.Lock()
+	defer tx.state.mu.Unlock()
+
+	if tx.state.done {
+		return ErrTxDone
+	}
+	tx.state.done = true
+
+	records := tx.state.records
+	tx.state.records = nil
+	if len(records) == 0 {
+		return nil
+	}
+
+	h := tx.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed.Load() {
+		return ErrHandlerClosed
+	}
+
+	// Chained records are written one by one, since each depends on the
+	// last, but still contiguously, since the lock is held.
+	if h.audit != nil {
+		for _, rec := range records {
+			if err := h.writeChained(rec.pbr, rec.time, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	frames := make([]recordFrame, len(records))
+	for i, rec := range records {
+		frames[i] = recordFrame{b: rec.frame, t: rec.time}
+	}
+	return h.writeFramesLocked(frames)
+}
+
+// Discard drops the records of the transaction. It does nothing if the
+// transaction was already committed or discarded.
+func (tx *Tx) Discard() {
+	tx.state.mu.Lock()
+	defer tx.state.mu.Unlock()
+
+	tx.state.done = true
+	tx.state.records = nil
+}
+
+// Len returns the number of records added to the transaction.
+func (tx *Tx) Len() int {
+	tx.state.mu.Lock()
+	defer tx.state.mu.Unlock()
+
+	return len(tx.state.records)
+}
@@ -0,0 +1,140 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestHandler_Begin(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader(), slogproto.WithStreamTrailer())
+	logger := slog.New(h)
+
+	committed := h.Begin()
+	slog.New(committed).Info("t1")
+	logger.Info("a")
+	slog.New(committed).With("user", "u1").WithGroup("req").Info("t2", "path", "/")
+
+	discarded := h.Begin()
+	slog.New(discarded).Info("d1")
+
+	logger.Info("b")
+
+	if n := committed.Len(); n != 2 {
+		t.Fatalf("expected 2 records in the transaction, got %d", n)
+	}
+	if err := committed.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	discarded.Discard()
+
+	if err := committed.Commit(); !errors.Is(err, slogproto.ErrTxDone) {
+		t.Fatalf("expected ErrTxDone committing twice, got %v", err)
+	}
+	if err := slog.New(discarded).Handler().Handle(context.Background(), slog.Record{}); !errors.Is(err, slogproto.ErrTxDone) {
+		t.Fatalf("expected ErrTxDone handling a record after discarding, got %v", err)
+	}
+
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := slogproto.VerifyStream(context.Background(), bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		got = append(got, r.Message)
+		if r.Message == "t2" && r.NumAttrs() != 2 {
+			t.Fatalf("expected the attributes of the derived handler, got %d attributes", r.NumAttrs())
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "b", "t1", "t2"}; !slices.Equal(got, want) {
+		t.Fatalf("expected records %v, got %v", want, got)
+	}
+}
+
+// A transaction committed before any other record writes the stream
+// header, which the stream trailer accounts for.
+func TestHandler_Begin_header(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithStreamHeader(), slogproto.WithStreamTrailer())
+
+	tx := h.Begin()
+	slog.New(tx).Info("t1")
+	slog.New(tx).Info("t2")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	slog.New(h).Info("a")
+
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	trailer, err := slogproto.VerifyStream(context.Background(), bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trailer.Records != 3 {
+		t.Fatalf("expected 3 records in the trailer, got %d", trailer.Records)
+	}
+	if got := readMessages(t, &buf); got != "t1,t2,a" {
+		t.Fatalf("unexpected records %q", got)
+	}
+}
+
+func TestHandler_Begin_auditChain(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	h := slogproto.NewHandler(&buf, nil, slogproto.WithAuditChain(key, 2))
+
+	tx := h.Begin()
+	slog.New(tx).Info("t1")
+	slog.New(h).Info("a")
+	slog.New(tx).Info("t2")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := slogproto.VerifyAuditLog(context.Background(), bytes.NewReader(buf.Bytes()), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 5 || report.Unsigned != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestTx_closed(t *testing.T) {
+	h := slogproto.NewHandler(&bytes.Buffer{}, nil)
+
+	tx := h.Begin()
+	slog.New(tx).Info("t1")
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); !errors.Is(err, slogproto.ErrHandlerClosed) {
+		t.Fatalf("expected ErrHandlerClosed, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package slogproto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Type URLs of the values of UUIDs and ULIDs, which are encoded as their
+// 16 bytes, rather than as strings of 36 or 26 characters.
+const (
+	UUIDTypeURL = "slogproto/UUID"
+	ULIDTypeURL = "slogproto/ULID"
+)
+
+// ULIDKey is the key of the attribute added to records by [WithULID].
+const ULIDKey = "ulid"
+
+func init() {
+	RegisterValueType(UUIDTypeURL,
+		func(u UUID) ([]byte, error) { return u[:], nil },
+		func(b []byte) (u UUID, err error) {
+			if len(b) != len(u) {
+				return u, fmt.Errorf("slogproto: invalid UUID of %d bytes", len(b))
+			}
+			copy(u[:], b)
+			return u, nil
+		},
+	)
+
+	RegisterValueType(ULIDTypeURL,
+		func(u ULID) ([]byte, error) { return u[:], nil },
+		func(b []byte) (u ULID, err error) {
+			if len(b) != len(u) {
+				return u, fmt.Errorf("slogproto: invalid ULID of %d bytes", len(b))
+			}
+			copy(u[:], b)
+			return u, nil
+		},
+	)
+}
+
+// UUID is a universally unique identifier, as defined by RFC 9562. Its
+// attribute values are encoded as its 16 bytes. The UUIDs of other
+// packages, such as github.com/google/uuid, which are also arrays of 16
+// bytes, can be converted to it, as in slogproto.UUID(id).
+type UUID [16]byte
+
+// NewUUID returns a random (version 4) UUID.
+func NewUUID() UUID {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		panic(fmt.Sprintf("slogproto: error generating UUID: %v", err))
+	}
+	u[6] = u[6]&0x0f | 0x40 // Version 4.
+	u[8] = u[8]&0x3f | 0x80 // Variant 10.
+	return u
+}
+
+// ParseUUID parses a UUID in its canonical form, such as
+// "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	err := u.UnmarshalText([]byte(s))
+	return u, err
+}
+
+// String returns the UUID in its canonical form.
+func (u UUID) String() string {
+	b, _ := u.MarshalText()
+	return string(b)
+}
+
+// MarshalText returns the UUID in its canonical form.
+func (u UUID) MarshalText() ([]byte, error) {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return b, nil
+}
+
+// UnmarshalText parses a UUID in its canonical form.
+func (u *UUID) UnmarshalText(b []byte) error {
+	if len(b) != 36 || b[8] != '-' || b[13] != '-' || b[18] != '-' || b[23] != '-' {
+		return fmt.Errorf("slogproto: invalid UUID %q", b)
+	}
+
+	var v UUID
+	for _, part := range [...]struct{ dst, src []byte }{
+		{v[0:4], b[0:8]},
+		{v[4:6], b[9:13]},
+		{v[6:8], b[14:18]},
+		{v[8:10], b[19:23]},
+		{v[10:], b[24:]},
+	} {
+		if _, err := hex.Decode(part.dst, part.src); err != nil {
+			return fmt.Errorf("slogproto: invalid UUID %q", b)
+		}
+	}
+	*u = v
+	return nil
+}
+
+// ULID is a universally unique lexicographically sortable identifier: a
+// 48-bit timestamp in milliseconds followed by 80 random bits, written as
+// 26 characters of Crockford's base32. Its attribute values are encoded
+// as its 16 bytes.
+type ULID [16]byte
+
+// crockford is the alphabet of Crockford's base32.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID with the timestamp of t and random bits.
+func NewULID(t time.Time) ULID {
+	var u ULID
+	if _, err := rand.Read(u[6:]); err != nil {
+		panic(fmt.Sprintf("slogproto: error generating ULID: %v", err))
+	}
+
+	ms := uint64(t.UnixMilli())
+	u[0], u[1], u[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	u[3], u[4], u[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	return u
+}
+
+// ParseULID parses a ULID written as 26 characters of Crockford's base32,
+// in either case.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	err := u.UnmarshalText([]byte(s))
+	return u, err
+}
+
+// Time returns the time of the timestamp of the ULID.
+func (u ULID) Time() time.Time {
+	ms := uint64(u[0])<<40 | uint64(u[1])<<32 | uint64(u[2])<<24 | uint64(u[3])<<16 | uint64(u[4])<<8 | uint64(u[5])
+	return time.UnixMilli(int64(ms))
+}
+
+// String returns the ULID as 26 characters of Crockford's base32.
+func (u ULID) String() string {
+	b, _ := u.MarshalText()
+	return string(b)
+}
+
+// MarshalText returns the ULID as 26 characters of Crockford's base32.
+func (u ULID) MarshalText() ([]byte, error) {
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+
+	b := make([]byte, 26)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return b, nil
+}
+
+// UnmarshalText parses a ULID written as 26 characters of Crockford's
+// base32, in either case.
+func (u *ULID) UnmarshalText(b []byte) error {
+	// The first character holds the top 3 of the 128 bits.
+	if len(b) != 26 || b[0] > '7' {
+		return fmt.Errorf("slogproto: invalid ULID %q", b)
+	}
+
+	var hi, lo uint64
+	for _, c := range b {
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		v := strings.IndexByte(crockford, c)
+		if v < 0 {
+			return fmt.Errorf("slogproto: invalid ULID %q", b)
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return nil
+}
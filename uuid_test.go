@@ -0,0 +1,120 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestUUID(t *testing.T) {
+	const s = "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+
+	u, err := slogproto.ParseUUID(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != s {
+		t.Fatalf("expected %s, got %s", s, u)
+	}
+
+	for _, invalid := range []string{"", "f81d4fae7dec11d0a76500a0c91e6bf6", "g81d4fae-7dec-11d0-a765-00a0c91e6bf6"} {
+		if _, err := slogproto.ParseUUID(invalid); err == nil {
+			t.Fatalf("expected an error parsing %q", invalid)
+		}
+	}
+
+	n := slogproto.NewUUID()
+	if n == u || n[6]>>4 != 4 || n[8]>>6 != 2 {
+		t.Fatalf("expected a random version 4 UUID, got %s", n)
+	}
+}
+
+func TestULID(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	u := slogproto.NewULID(now)
+	if !u.Time().Equal(now) {
+		t.Fatalf("expected time %v, got %v", now, u.Time())
+	}
+
+	s := u.String()
+	if len(s) != 26 {
+		t.Fatalf("expected 26 characters, got %q", s)
+	}
+
+	for _, text := range []string{s, string(bytes.ToLower([]byte(s)))} {
+		parsed, err := slogproto.ParseULID(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != u {
+			t.Fatalf("expected %s, got %s", u, parsed)
+		}
+	}
+
+	if later := slogproto.NewULID(now.Add(time.Millisecond)); later.String() <= s {
+		t.Fatalf("expected %s to sort after %s", later, s)
+	}
+
+	maxULID, err := slogproto.ParseULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxULID != slogproto.ULID(bytes.Repeat([]byte{0xff}, 16)) {
+		t.Fatalf("expected the maximum ULID, got %x", maxULID)
+	}
+
+	for _, invalid := range []string{"", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		if _, err := slogproto.ParseULID(invalid); err == nil {
+			t.Fatalf("expected an error parsing %q", invalid)
+		}
+	}
+}
+
+func TestWithULID(t *testing.T) {
+	id := slogproto.NewUUID()
+
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, nil, slogproto.WithULID(), slogproto.WithKeyOrder()))
+	logger.Info("created", "id", id)
+	logger.Info("created", "id", id)
+
+	var ulids []slogproto.ULID
+	err := slogproto.Read(context.Background(), &buf, func(r *slog.Record) bool {
+		var keys []string
+		r.Attrs(func(a slog.Attr) bool {
+			keys = append(keys, a.Key)
+			switch a.Key {
+			case "id":
+				if got, ok := a.Value.Any().(slogproto.UUID); !ok || got != id {
+					t.Fatalf("expected UUID %s, got %#v", id, a.Value.Any())
+				}
+			case slogproto.ULIDKey:
+				u, ok := a.Value.Any().(slogproto.ULID)
+				if !ok {
+					t.Fatalf("expected a ULID, got %#v", a.Value.Any())
+				}
+				if !u.Time().Equal(r.Time.Truncate(time.Millisecond)) {
+					t.Fatalf("expected the ULID to have the record's time %v, got %v", r.Time, u.Time())
+				}
+				ulids = append(ulids, u)
+			}
+			return true
+		})
+		if len(keys) != 2 || keys[1] != slogproto.ULIDKey {
+			t.Fatalf("expected the ULID to be the last attribute, got %v", keys)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ulids) != 2 || ulids[0] == ulids[1] {
+		t.Fatalf("expected 2 different ULIDs, got %v", ulids)
+	}
+}
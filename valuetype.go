@@ -0,0 +1,87 @@
+package slogproto
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// valueType is a Go type registered with RegisterValueType.
+type valueType struct {
+	typeURL   string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(b []byte) (any, error)
+}
+
+// valueTypes holds the registered value types by Go type and type URL.
+var valueTypes = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*valueType
+	byURL  map[string]*valueType
+}{
+	byType: make(map[reflect.Type]*valueType),
+	byURL:  make(map[string]*valueType),
+}
+
+// RegisterValueType registers a compact encoding for attribute values of
+// the Go type T, such as a UUID, an IP address or a decimal, which are
+// otherwise encoded as JSON. Values of the type are encoded by marshal as
+// an [anypb.Any] with the type URL, such as "example.com/uuid", which
+// readers in the same program decode with unmarshal back to a value of
+// the type, and other readers can recognize.
+//
+// The bytes passed to unmarshal must not be retained, since they may be
+// reused when reading with [WithZeroCopy].
+//
+// Types are usually registered in an init function, before any records
+// are written or read. RegisterValueType panics if the type or type URL
+// is already registered, or if the type URL is empty or starts with the
+// "go/slog/" prefix of values encoded as JSON.
+func RegisterValueType[T any](typeURL string, marshal func(v T) ([]byte, error), unmarshal func(b []byte) (T, error)) {
+	if typeURL == "" || strings.HasPrefix(typeURL, anyTypeURLPrefix) {
+		panic(fmt.Sprintf("slogproto: invalid type URL %q for RegisterValueType", typeURL))
+	}
+
+	t := reflect.TypeFor[T]()
+	vt := &valueType{
+		typeURL: typeURL,
+		marshal: func(v any) ([]byte, error) {
+			return marshal(v.(T))
+		},
+		unmarshal: func(b []byte) (any, error) {
+			return unmarshal(b)
+		},
+	}
+
+	valueTypes.Lock()
+	defer valueTypes.Unlock()
+
+	if _, dup := valueTypes.byType[t]; dup {
+		panic("slogproto: RegisterValueType called twice for type " + t.String())
+	}
+	if _, dup := valueTypes.byURL[typeURL]; dup {
+		panic("slogproto: RegisterValueType called twice for type URL " + typeURL)
+	}
+	valueTypes.byType[t] = vt
+	valueTypes.byURL[typeURL] = vt
+}
+
+// lookupValueType returns the registered type of the Go value, if any.
+func lookupValueType(v any) *valueType {
+	valueTypes.RLock()
+	defer valueTypes.RUnlock()
+
+	if len(valueTypes.byType) == 0 {
+		return nil
+	}
+	return valueTypes.byType[reflect.TypeOf(v)]
+}
+
+// lookupValueTypeURL returns the registered type with the type URL, if any.
+func lookupValueTypeURL(typeURL string) *valueType {
+	valueTypes.RLock()
+	defer valueTypes.RUnlock()
+
+	return valueTypes.byURL[typeURL]
+}
@@ -0,0 +1,116 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// testID is a value type with a compact encoding registered by the tests.
+type testID [4]byte
+
+func init() {
+	slogproto.RegisterValueType("slogproto.test/id",
+		func(id testID) ([]byte, error) {
+			return id[:], nil
+		},
+		func(b []byte) (testID, error) {
+			var id testID
+			if len(b) != len(id) {
+				return id, errors.New("invalid id")
+			}
+			copy(id[:], b)
+			return id, nil
+		},
+	)
+}
+
+func TestRegisterValueType(t *testing.T) {
+	id := testID{1, 2, 3, 4}
+
+	var buf bytes.Buffer
+	logger := slog.New(slogproto.NewHandler(&buf, nil))
+	logger.Info("created", "id", id, slog.Group("parent", "id", testID{5, 6, 7, 8}))
+
+	for name, opts := range map[string][]slogproto.ReadOption{
+		"default":   nil,
+		"zero copy": {slogproto.WithZeroCopy()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got []slog.Attr
+			err := slogproto.Read(context.Background(), bytes.NewReader(buf.Bytes()), func(r *slog.Record) bool {
+				r.Attrs(func(a slog.Attr) bool {
+					got = append(got, a)
+					return true
+				})
+				return true
+			}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != 2 {
+				t.Fatalf("expected 2 attributes, got %v", got)
+			}
+			if v, ok := got[0].Value.Any().(testID); !ok || v != id {
+				t.Fatalf("expected id %v, got %#v", id, got[0].Value.Any())
+			}
+			if v, ok := got[1].Value.Group()[0].Value.Any().(testID); !ok || v != (testID{5, 6, 7, 8}) {
+				t.Fatalf("expected parent id, got %#v", got[1].Value.Group()[0].Value.Any())
+			}
+		})
+	}
+
+	t.Run("fidelity", func(t *testing.T) {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "created", 0)
+		r.AddAttrs(slog.Any("id", id))
+
+		pbr, err := slogproto.RecordToProto(r, &slogproto.ConvertOptions{Fidelity: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pbr.GetAttrs()["id"].GetAny().GetTypeUrl(); got != "slogproto.test/id" {
+			t.Fatalf("expected the registered type URL, got %q", got)
+		}
+
+		decoded, err := slogproto.ProtoToRecord(pbr, &slogproto.ConvertOptions{Fidelity: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded.Attrs(func(a slog.Attr) bool {
+			if a.Value.Any() != id {
+				t.Fatalf("expected id %v, got %#v", id, a.Value.Any())
+			}
+			return true
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		pbr, err := slogproto.RecordToProto(slog.NewRecord(time.Now(), slog.LevelInfo, "created", 0), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pbr.Attrs = map[string]*slogproto.Value{
+			"id": {Kind: &slogproto.Value_Any{Any: &anypb.Any{TypeUrl: "slogproto.test/id", Value: []byte{1}}}},
+		}
+
+		if _, err := slogproto.ProtoToRecord(pbr, nil); err == nil {
+			t.Fatal("expected an error for an invalid encoding")
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected registering a type twice to panic")
+			}
+		}()
+		slogproto.RegisterValueType("slogproto.test/other", func(testID) ([]byte, error) { return nil, nil }, func([]byte) (testID, error) { return testID{}, nil })
+	})
+}
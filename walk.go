@@ -0,0 +1,52 @@
+package slogproto
+
+import "log/slog"
+
+// Walk calls fn for each attribute of the record in order, descending into
+// groups, with the path of keys of the groups containing the attribute.
+// A group's attributes are visited right after the group itself. The
+// attributes of groups with an empty key are visited as if they were
+// attributes of the enclosing group, as handlers inline them, and values
+// are resolved before they are visited.
+//
+// If fn returns false, the walk stops. The path is only valid until fn
+// returns, and must not be modified.
+//
+// # Example
+//
+//	slogproto.Walk(r, func(path []string, a slog.Attr) bool {
+//		fmt.Println(strings.Join(append(path, a.Key), "."), a.Value)
+//		return true
+//	})
+func Walk(r *slog.Record, fn func(path []string, a slog.Attr) bool) {
+	path := make([]string, 0, 8)
+
+	r.Attrs(func(a slog.Attr) bool {
+		return walkAttr(path, a, fn)
+	})
+}
+
+// walkAttr calls fn for the attribute, and its attributes if it's a group,
+// reporting whether the walk should continue.
+func walkAttr(path []string, a slog.Attr, fn func(path []string, a slog.Attr) bool) bool {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() != slog.KindGroup {
+		return fn(path, a)
+	}
+
+	if a.Key != "" {
+		if !fn(path, a) {
+			return false
+		}
+		path = append(path, a.Key)
+	}
+
+	for _, ga := range a.Value.Group() {
+		if !walkAttr(path, ga, fn) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,46 @@
+package slogproto_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/picatz/slogproto"
+)
+
+func TestWalk(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0)
+	r.AddAttrs(
+		slog.String("a", "1"),
+		slog.Group("http",
+			slog.Group("request", slog.String("method", "GET")),
+			slog.Int("status", 200),
+		),
+		slog.Group("", slog.Bool("inlined", true)),
+		slog.String("z", "2"),
+	)
+
+	var visited []string
+	slogproto.Walk(&r, func(path []string, a slog.Attr) bool {
+		visited = append(visited, strings.Join(append(path, a.Key), "."))
+		return true
+	})
+
+	want := []string{"a", "http", "http.request", "http.request.method", "http.status", "inlined", "z"}
+	if strings.Join(visited, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected %v, got %v", want, visited)
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		var visited []string
+		slogproto.Walk(&r, func(path []string, a slog.Attr) bool {
+			visited = append(visited, a.Key)
+			return a.Key != "method"
+		})
+
+		if len(visited) != 4 {
+			t.Fatalf("expected the walk to stop after 4 attributes, got %v", visited)
+		}
+	})
+}
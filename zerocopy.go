@@ -0,0 +1,370 @@
+package slogproto
+
+import (
+	"errors"
+	"math"
+	"unicode/utf8"
+	"unsafe"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WithZeroCopy configures Read to decode strings, and the payloads of Any
+// values, without copying them out of the buffer holding the encoded
+// record, which reduces allocations when scanning large files.
+//
+// The record passed to the function given to Read, and any string or
+// value taken from it, is then only valid until the function returns, and
+// must be copied to be kept, such as with [strings.Clone].
+func WithZeroCopy() ReadOption {
+	return func(c *readConfig) {
+		c.zeroCopy = true
+	}
+}
+
+// errInvalidWireFormat is returned when an encoded record is malformed.
+var errInvalidWireFormat = errors.New("invalid wire-format data")
+
+// errInvalidUTF8 is returned when a string of an encoded record isn't
+// valid UTF-8, as proto3 requires.
+var errInvalidUTF8 = errors.New("string field contains invalid UTF-8")
+
+// aliasString returns the bytes as a string sharing their memory.
+func aliasString(b []byte) (string, error) {
+	if !utf8.Valid(b) {
+		return "", errInvalidUTF8
+	}
+	if len(b) == 0 {
+		return "", nil
+	}
+	return unsafe.String(&b[0], len(b)), nil
+}
+
+// fieldFunc decodes a field of a message, returning the number of bytes
+// of b it consumed, or a negative number if the field should be skipped.
+type fieldFunc func(num protowire.Number, typ protowire.Type, b []byte) (int, error)
+
+// unmarshalFields decodes the fields of a message, skipping the fields
+// that fn doesn't decode, like unknown fields.
+func unmarshalFields(b []byte, fn fieldFunc) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errInvalidWireFormat
+		}
+		b = b[n:]
+
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return errInvalidWireFormat
+			}
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// consumeBytes returns the length delimited value at the start of b, and
+// the number of bytes consumed.
+func consumeBytes(b []byte) ([]byte, int, error) {
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, 0, errInvalidWireFormat
+	}
+	return v, n, nil
+}
+
+// consumeVarint returns the varint at the start of b, and the number of
+// bytes consumed.
+func consumeVarint(b []byte) (uint64, int, error) {
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, 0, errInvalidWireFormat
+	}
+	return v, n, nil
+}
+
+// unmarshalRecordZeroCopy decodes an encoded record like proto.Unmarshal,
+// but with strings and bytes referencing b instead of being copied.
+func unmarshalRecordZeroCopy(b []byte, pbr *Record) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			if pbr.Time == nil {
+				pbr.Time = &timestamppb.Timestamp{}
+			}
+			return n, unmarshalSecondsNanos(v, &pbr.Time.Seconds, &pbr.Time.Nanos)
+		case num == 2 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			pbr.Message, err = aliasString(v)
+			return n, err
+		case num == 3 && typ == protowire.VarintType:
+			v, n, err := consumeVarint(b)
+			pbr.Level = Level(int32(v))
+			return n, err
+		case num == 4 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			if pbr.Attrs == nil {
+				pbr.Attrs = make(map[string]*Value)
+			}
+			return n, unmarshalAttrZeroCopy(v, pbr.Attrs)
+		case num == 5 && typ == protowire.VarintType:
+			v, n, err := consumeVarint(b)
+			pbr.SlogLevel = protowire.DecodeZigZag(v)
+			return n, err
+		case num == 6 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			key, err := aliasString(v)
+			pbr.Keys = append(pbr.Keys, key)
+			return n, err
+		case num == 7 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			pbr.Stream, err = aliasString(v)
+			return n, err
+		default:
+			return -1, nil
+		}
+	})
+}
+
+// unmarshalAttrZeroCopy decodes an entry of a map of attributes into the
+// map.
+func unmarshalAttrZeroCopy(b []byte, attrs map[string]*Value) error {
+	var key string
+	value := &Value{}
+
+	err := unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.BytesType || (num != 1 && num != 2) {
+			return -1, nil
+		}
+
+		v, n, err := consumeBytes(b)
+		if err != nil {
+			return 0, err
+		}
+
+		if num == 1 {
+			key, err = aliasString(v)
+			return n, err
+		}
+		return n, unmarshalValueZeroCopy(v, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	attrs[key] = value
+	return nil
+}
+
+// unmarshalValueZeroCopy decodes an encoded Value into v.
+func unmarshalValueZeroCopy(b []byte, v *Value) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			x, n, err := consumeVarint(b)
+			v.Kind = &Value_Bool{Bool: x != 0}
+			return n, err
+		case num == 2 && typ == protowire.Fixed64Type:
+			x, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return 0, errInvalidWireFormat
+			}
+			v.Kind = &Value_Float{Float: math.Float64frombits(x)}
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			x, n, err := consumeVarint(b)
+			v.Kind = &Value_Int{Int: int64(x)}
+			return n, err
+		case num == 4 && typ == protowire.BytesType:
+			x, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			s, err := aliasString(x)
+			v.Kind = &Value_String_{String_: s}
+			return n, err
+		case num == 5 && typ == protowire.BytesType:
+			x, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			t, ok := v.Kind.(*Value_Time)
+			if !ok {
+				t = &Value_Time{Time: &timestamppb.Timestamp{}}
+				v.Kind = t
+			}
+			return n, unmarshalSecondsNanos(x, &t.Time.Seconds, &t.Time.Nanos)
+		case num == 6 && typ == protowire.BytesType:
+			x, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			d, ok := v.Kind.(*Value_Duration)
+			if !ok {
+				d = &Value_Duration{Duration: &durationpb.Duration{}}
+				v.Kind = d
+			}
+			return n, unmarshalSecondsNanos(x, &d.Duration.Seconds, &d.Duration.Nanos)
+		case num == 7 && typ == protowire.VarintType:
+			x, n, err := consumeVarint(b)
+			v.Kind = &Value_Uint{Uint: x}
+			return n, err
+		case num == 8 && typ == protowire.BytesType:
+			x, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			g, ok := v.Kind.(*Value_Group_)
+			if !ok {
+				g = &Value_Group_{Group: &Value_Group{}}
+				v.Kind = g
+			}
+			return n, unmarshalGroupZeroCopy(x, g.Group)
+		case num == 9 && typ == protowire.BytesType:
+			x, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			a, ok := v.Kind.(*Value_Any)
+			if !ok {
+				a = &Value_Any{Any: &anypb.Any{}}
+				v.Kind = a
+			}
+			return n, unmarshalAnyZeroCopy(x, a.Any)
+		default:
+			return -1, nil
+		}
+	})
+}
+
+// unmarshalGroupZeroCopy decodes an encoded group into g.
+func unmarshalGroupZeroCopy(b []byte, g *Value_Group) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.BytesType || (num != 1 && num != 2) {
+			return -1, nil
+		}
+
+		v, n, err := consumeBytes(b)
+		if err != nil {
+			return 0, err
+		}
+
+		if num == 1 {
+			if g.Attrs == nil {
+				g.Attrs = make(map[string]*Value)
+			}
+			return n, unmarshalAttrZeroCopy(v, g.Attrs)
+		}
+
+		key, err := aliasString(v)
+		g.Keys = append(g.Keys, key)
+		return n, err
+	})
+}
+
+// unmarshalAnyZeroCopy decodes an encoded Any into a.
+func unmarshalAnyZeroCopy(b []byte, a *anypb.Any) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.BytesType || (num != 1 && num != 2) {
+			return -1, nil
+		}
+
+		v, n, err := consumeBytes(b)
+		if err != nil {
+			return 0, err
+		}
+
+		if num == 1 {
+			a.TypeUrl, err = aliasString(v)
+			return n, err
+		}
+		a.Value = v
+		return n, nil
+	})
+}
+
+// unmarshalSecondsNanos decodes an encoded Timestamp or Duration, which
+// share the same fields.
+func unmarshalSecondsNanos(b []byte, seconds *int64, nanos *int32) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.VarintType || (num != 1 && num != 2) {
+			return -1, nil
+		}
+
+		v, n, err := consumeVarint(b)
+		if num == 1 {
+			*seconds = int64(v)
+		} else {
+			*nanos = int32(v)
+		}
+		return n, err
+	})
+}
+
+// unmarshalRecordHeader decodes the time, level, message and stream of an
+// encoded record, skipping its attributes. The message and stream
+// reference b.
+func unmarshalRecordHeader(b []byte, pbr *Record) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			if pbr.Time == nil {
+				pbr.Time = &timestamppb.Timestamp{}
+			}
+			return n, unmarshalSecondsNanos(v, &pbr.Time.Seconds, &pbr.Time.Nanos)
+		case num == 2 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			pbr.Message, err = aliasString(v)
+			return n, err
+		case num == 3 && typ == protowire.VarintType:
+			v, n, err := consumeVarint(b)
+			pbr.Level = Level(int32(v))
+			return n, err
+		case num == 5 && typ == protowire.VarintType:
+			v, n, err := consumeVarint(b)
+			pbr.SlogLevel = protowire.DecodeZigZag(v)
+			return n, err
+		case num == 7 && typ == protowire.BytesType:
+			v, n, err := consumeBytes(b)
+			if err != nil {
+				return 0, err
+			}
+			pbr.Stream, err = aliasString(v)
+			return n, err
+		default:
+			return -1, nil
+		}
+	})
+}